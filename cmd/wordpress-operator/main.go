@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"os"
 
 	logf "github.com/presslabs/controller-util/log"
@@ -31,6 +32,7 @@ import (
 	"github.com/bitpoke/wordpress-operator/pkg/apis"
 	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
 	"github.com/bitpoke/wordpress-operator/pkg/controller"
+	"github.com/bitpoke/wordpress-operator/pkg/tracing"
 )
 
 const genericErrorExitCode = 1
@@ -95,9 +97,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	ctx := signals.SetupSignalHandler()
+
+	shutdownTracing, err := tracing.Setup(ctx)
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(genericErrorExitCode)
+	}
+
 	// Start the Cmd
-	if err := mgr.Start(signals.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "unable to start the manager")
 		os.Exit(genericErrorExitCode)
 	}
+
+	// the context is canceled, the pending spans are flushed with a fresh one
+	if err := shutdownTracing(context.Background()); err != nil {
+		setupLog.Error(err, "unable to flush the spans")
+	}
 }
@@ -18,10 +18,17 @@ package v1alpha1
 
 import (
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// SiteLabel is set to the site's name on the objects the operator creates
+// for it, so that they can be selected together, e.g. by Velero backups.
+const SiteLabel = "wordpress.presslabs.org/site"
+
 // SecretRef represents a reference to a Secret.
 type SecretRef string
 
@@ -33,7 +40,7 @@ type RouteSpec struct {
 	// Domain for the route
 	// +kubebuilder:validation:MinLength=1
 	Domain string `json:"domain"`
-	// The path for the route. Defaults to /.
+	// The path for the route. Defaults to the site's path.
 	// +optional
 	Path string `json:"path"`
 }
@@ -57,6 +64,46 @@ type WordpressCondition struct {
 	Message string `json:"message"`
 }
 
+const (
+	// ReadyCondition signals whether the site is ready, i.e. all of its
+	// DeploymentReady, CodeSynced, MediaReady, DatabaseReady and
+	// BootstrapCompleted conditions which are set are true.
+	ReadyCondition WordpressConditionType = "Ready"
+	// DeploymentReadyCondition signals whether the web pods are rolled out.
+	DeploymentReadyCondition WordpressConditionType = "DeploymentReady"
+	// CodeSyncedCondition signals whether the web pods run the site's current
+	// code version.
+	CodeSyncedCondition WordpressConditionType = "CodeSynced"
+	// MediaReadyCondition signals whether the media persistent volume claim
+	// created by the operator is bound.
+	MediaReadyCondition WordpressConditionType = "MediaReady"
+	// BootstrapCompletedCondition signals whether the site was bootstrapped.
+	BootstrapCompletedCondition WordpressConditionType = "BootstrapCompleted"
+
+	// SiteReadyReason is the reason for a ready site.
+	SiteReadyReason = "SiteReady"
+	// SiteNotReadyReason is the reason for a site with conditions which are not true.
+	SiteNotReadyReason = "SiteNotReady"
+	// DeploymentAvailableReason is the reason for web pods which are updated and available.
+	DeploymentAvailableReason = "DeploymentAvailable"
+	// RolloutInProgressReason is the reason for web pods which are being rolled out.
+	RolloutInProgressReason = "RolloutInProgress"
+	// CodeSyncedReason is the reason for web pods running the current code version.
+	CodeSyncedReason = "CodeSynced"
+	// CodeSyncPendingReason is the reason for web pods not rolled out with the current code version yet.
+	CodeSyncPendingReason = "CodeSyncPending"
+	// MediaVolumeBoundReason is the reason for a bound media persistent volume claim.
+	MediaVolumeBoundReason = "MediaVolumeBound"
+	// MediaVolumePendingReason is the reason for a media persistent volume claim which is not bound yet.
+	MediaVolumePendingReason = "MediaVolumePending"
+	// BootstrapPendingReason is the reason for a site which is not bootstrapped yet.
+	BootstrapPendingReason = "BootstrapPending"
+)
+
+// ReconcileFailedReason is the reason of the events recorded for the
+// reconciles of a site which returned an error.
+const ReconcileFailedReason = "ReconcileFailed"
+
 const (
 	// WPCronTriggeringCondition signals that health of wp-cron trigger.
 	WPCronTriggeringCondition WordpressConditionType = "WPCronTriggering"
@@ -68,12 +115,309 @@ const (
 	WPCronTriggeringReason = "WPCronTriggering"
 )
 
+const (
+	// CertificateReadyCondition signals the readiness of the site's cert-manager Certificate.
+	CertificateReadyCondition WordpressConditionType = "CertificateReady"
+
+	// CertificateIssuedReason is the reason for an issued certificate.
+	CertificateIssuedReason = "CertificateIssued"
+	// CertificatePendingReason is the reason for a certificate not issued yet.
+	CertificatePendingReason = "CertificatePending"
+)
+
+const (
+	// TLSSecretValidCondition signals whether the secret of TLSSecretRef holds
+	// a certificate covering the site's domains.
+	TLSSecretValidCondition WordpressConditionType = "TLSSecretValid"
+
+	// TLSSecretValidReason is the reason for a secret holding a certificate covering the site's domains.
+	TLSSecretValidReason = "TLSSecretValid"
+	// TLSSecretNotFoundReason is the reason for a missing secret.
+	TLSSecretNotFoundReason = "TLSSecretNotFound"
+	// TLSSecretInvalidReason is the reason for a secret not holding a parsable certificate.
+	TLSSecretInvalidReason = "TLSSecretInvalid"
+	// TLSSecretDomainMismatchReason is the reason for a certificate not covering some of the site's domains.
+	TLSSecretDomainMismatchReason = "TLSSecretDomainMismatch"
+)
+
+const (
+	// CertificateExpiringSoonCondition signals whether the certificate of
+	// TLSSecretRef expires soon or already expired.
+	CertificateExpiringSoonCondition WordpressConditionType = "CertificateExpiringSoon"
+
+	// CertificateExpiringSoonReason is the reason for a certificate expiring soon.
+	CertificateExpiringSoonReason = "CertificateExpiringSoon"
+	// CertificateExpiredReason is the reason for an expired certificate.
+	CertificateExpiredReason = "CertificateExpired"
+	// CertificateNotExpiringReason is the reason for a certificate which doesn't expire soon.
+	CertificateNotExpiringReason = "CertificateNotExpiring"
+)
+
+const (
+	// ImagesVerifiedCondition signals whether the cosign signatures of the
+	// site's images were verified.
+	ImagesVerifiedCondition WordpressConditionType = "ImagesVerified"
+
+	// ImagesVerifiedReason is the reason for images whose signatures were verified.
+	ImagesVerifiedReason = "ImagesVerified"
+	// ImageVerificationPendingReason is the reason for images whose signatures are being verified.
+	ImageVerificationPendingReason = "ImageVerificationPending"
+	// ImageSignatureInvalidReason is the reason for an image without a valid signature.
+	ImageSignatureInvalidReason = "ImageSignatureInvalid"
+	// ImageVerificationMisconfiguredReason is the reason for a verification
+	// policy without a key nor a keyless identity.
+	ImageVerificationMisconfiguredReason = "ImageVerificationMisconfigured"
+)
+
+const (
+	// RestartDeferredCondition signals that the web pods' restart for changed
+	// Secrets and ConfigMaps is deferred to the maintenance window.
+	RestartDeferredCondition WordpressConditionType = "RestartDeferred"
+
+	// MaintenanceWindowClosedReason is the reason for a restart deferred
+	// until the maintenance window opens.
+	MaintenanceWindowClosedReason = "MaintenanceWindowClosed"
+)
+
+const (
+	// SMTPVerifiedCondition signals whether a test email was sent through
+	// the site's SMTP server.
+	SMTPVerifiedCondition WordpressConditionType = "SMTPVerified"
+
+	// SMTPVerifiedReason is the reason for a test email sent successfully.
+	SMTPVerifiedReason = "SMTPVerified"
+	// SMTPTestPendingReason is the reason for a test email being sent.
+	SMTPTestPendingReason = "SMTPTestPending"
+	// SMTPTestFailedReason is the reason for a test email which couldn't be sent.
+	SMTPTestFailedReason = "SMTPTestFailed"
+)
+
+const (
+	// DatabaseReadyCondition signals the readiness of the site's provisioned database.
+	DatabaseReadyCondition WordpressConditionType = "DatabaseReady"
+
+	// DatabaseProvisionedReason is the reason for a provisioned database.
+	DatabaseProvisionedReason = "DatabaseProvisioned"
+	// DatabasePendingReason is the reason for a database not provisioned yet.
+	DatabasePendingReason = "DatabasePending"
+	// DatabaseReachableReason is the reason for a reachable external database.
+	DatabaseReachableReason = "DatabaseReachable"
+	// DatabaseUnreachableReason is the reason for an unreachable external database.
+	DatabaseUnreachableReason = "DatabaseUnreachable"
+)
+
+const (
+	// DatabaseHealthyCondition signals whether the site's database currently accepts connections.
+	DatabaseHealthyCondition WordpressConditionType = "DatabaseHealthy"
+
+	// DatabaseHealthyReason is the reason for a database accepting connections.
+	DatabaseHealthyReason = "DatabaseHealthy"
+	// DatabaseUnhealthyReason is the reason for a database refusing connections.
+	DatabaseUnhealthyReason = "DatabaseUnhealthy"
+)
+
+const (
+	// DatabaseQuotaExceededCondition signals whether the site's database exceeds its soft quota.
+	DatabaseQuotaExceededCondition WordpressConditionType = "DatabaseQuotaExceeded"
+
+	// DatabaseQuotaExceededReason is the reason for a database exceeding its quota.
+	DatabaseQuotaExceededReason = "DatabaseQuotaExceeded"
+	// DatabaseWithinQuotaReason is the reason for a database within its quota.
+	DatabaseWithinQuotaReason = "DatabaseWithinQuota"
+)
+
+const (
+	// RuntimeSupportedCondition signals whether the site's runtime PHP version and variant are supported.
+	RuntimeSupportedCondition WordpressConditionType = "RuntimeSupported"
+
+	// RuntimeSupportedReason is the reason for a supported runtime.
+	RuntimeSupportedReason = "RuntimeSupported"
+	// RuntimeUnsupportedReason is the reason for a runtime without a known image, for which the default one is used.
+	RuntimeUnsupportedReason = "RuntimeUnsupported"
+)
+
+const (
+	// DiagnosticsPassedCondition signals whether the site passed the checks of its last diagnostics run.
+	DiagnosticsPassedCondition WordpressConditionType = "DiagnosticsPassed"
+
+	// DiagnosticsPassedReason is the reason for a site passing all the checks.
+	DiagnosticsPassedReason = "DiagnosticsPassed"
+	// DiagnosticsFailedReason is the reason for a site failing some of the checks.
+	DiagnosticsFailedReason = "DiagnosticsFailed"
+	// DiagnosticsErrorReason is the reason for a diagnostics Job which failed to run the checks.
+	DiagnosticsErrorReason = "DiagnosticsError"
+)
+
+const (
+	// IntegrityCompromisedCondition signals whether the last integrity check
+	// found core or plugin files which don't match their checksums.
+	IntegrityCompromisedCondition WordpressConditionType = "IntegrityCompromised"
+
+	// IntegrityVerifiedReason is the reason for core and plugin files matching their checksums.
+	IntegrityVerifiedReason = "IntegrityVerified"
+	// FilesModifiedReason is the reason for modified, added or missing core or plugin files.
+	FilesModifiedReason = "FilesModified"
+	// IntegrityCheckErrorReason is the reason for an integrity check Job which failed to verify the checksums.
+	IntegrityCheckErrorReason = "IntegrityCheckError"
+)
+
+const (
+	// BackupRestorableCondition signals whether the last verified backup was
+	// restored and passed the smoke checks.
+	BackupRestorableCondition WordpressConditionType = "BackupRestorable"
+
+	// BackupRestorableReason is the reason for a backup which was restored and passed the smoke checks.
+	BackupRestorableReason = "BackupRestorable"
+	// BackupNotRestorableReason is the reason for a backup which failed to be restored or the smoke checks.
+	BackupNotRestorableReason = "BackupNotRestorable"
+	// BackupVerificationErrorReason is the reason for a backup which can't be verified, e.g. as its
+	// verification Job failed to download or decrypt it.
+	BackupVerificationErrorReason = "BackupVerificationError"
+)
+
+const (
+	// UpgradeBackedUpCondition signals whether the site was backed up before
+	// its pending upgrade, which is held until then.
+	UpgradeBackedUpCondition WordpressConditionType = "UpgradeBackedUp"
+
+	// UpgradeBackedUpReason is the reason for an upgrade whose backup succeeded.
+	UpgradeBackedUpReason = "UpgradeBackedUp"
+	// UpgradeBackupPendingReason is the reason for an upgrade waiting for its backup.
+	UpgradeBackupPendingReason = "UpgradeBackupPending"
+	// UpgradeBackupFailedReason is the reason for an upgrade held as its backup failed.
+	UpgradeBackupFailedReason = "UpgradeBackupFailed"
+)
+
+const (
+	// CoreUpdateAvailableReason is the reason for a core update found, but not applied by the policy.
+	CoreUpdateAvailableReason = "CoreUpdateAvailable"
+	// CoreUpdatedReason is the reason for an applied core update.
+	CoreUpdatedReason = "CoreUpdated"
+	// CoreUpdateFailedReason is the reason for a core update which failed.
+	CoreUpdateFailedReason = "CoreUpdateFailed"
+	// CleanupCompletedReason is the reason for a completed content cleanup.
+	CleanupCompletedReason = "CleanupCompleted"
+	// CleanupFailedReason is the reason for a content cleanup which failed.
+	CleanupFailedReason = "CleanupFailed"
+	// InventoryFailedReason is the reason for an inventory which failed to collect the versions.
+	InventoryFailedReason = "InventoryFailed"
+)
+
+const (
+	// RolledOutReason is the reason for web pods rolled out with a new code version.
+	RolledOutReason = "RolledOut"
+	// HomeURLChangedReason is the reason for a changed home URL.
+	HomeURLChangedReason = "HomeURLChanged"
+	// BootstrappedReason is the reason for a bootstrapped site.
+	BootstrappedReason = "Bootstrapped"
+	// PluginsSyncedReason is the reason for plugins synced with the spec.
+	PluginsSyncedReason = "PluginsSynced"
+	// ThemesSyncedReason is the reason for themes synced with the spec.
+	ThemesSyncedReason = "ThemesSynced"
+	// OptionsSyncedReason is the reason for options synced with the spec.
+	OptionsSyncedReason = "OptionsSynced"
+	// UsersSyncedReason is the reason for users synced with the spec.
+	UsersSyncedReason = "UsersSynced"
+)
+
+// RuntimeVariant is the web server bundled with the runtime image.
+type RuntimeVariant string
+
+const (
+	// RuntimeVariantNginx bundles nginx with PHP-FPM.
+	RuntimeVariantNginx RuntimeVariant = "nginx"
+	// RuntimeVariantApache bundles Apache with mod_php.
+	RuntimeVariantApache RuntimeVariant = "apache"
+)
+
+// RuntimeSpec selects the runtime image. The supported combinations of PHP
+// versions and variants are mapped to tags of the operator's runtime image.
+type RuntimeSpec struct {
+	// PHPVersion is the PHP version of the runtime.
+	// +kubebuilder:validation:Enum="7.4";"8.0";"8.1"
+	PHPVersion string `json:"phpVersion"`
+	// Variant is the web server bundled with the runtime. Defaults to nginx.
+	// +kubebuilder:validation:Enum=nginx;apache
+	// +optional
+	Variant RuntimeVariant `json:"variant,omitempty"`
+}
+
+// PodAntiAffinityPreset defines the pod anti-affinity generated for web pods.
+type PodAntiAffinityPreset string
+
+const (
+	// PodAntiAffinityPresetNone doesn't generate any pod anti-affinity.
+	PodAntiAffinityPresetNone PodAntiAffinityPreset = "none"
+	// PodAntiAffinityPresetSoft prefers scheduling web pods on different nodes.
+	PodAntiAffinityPresetSoft PodAntiAffinityPreset = "soft"
+	// PodAntiAffinityPresetHard requires scheduling web pods on different nodes.
+	PodAntiAffinityPresetHard PodAntiAffinityPreset = "hard"
+)
+
+// SizePreset defines the defaults of the web pods' replicas, resources and
+// PHP-FPM pool.
+type SizePreset string
+
+const (
+	// SizePresetSmall runs a single web pod with 5 PHP-FPM workers.
+	SizePresetSmall SizePreset = "small"
+	// SizePresetMedium runs 2 web pods with 10 PHP-FPM workers each.
+	SizePresetMedium SizePreset = "medium"
+	// SizePresetLarge runs 3 web pods with 20 PHP-FPM workers each.
+	SizePresetLarge SizePreset = "large"
+)
+
+// WorkloadType defines the kind of workload running the web pods.
+type WorkloadType string
+
+const (
+	// WorkloadTypeDeployment runs the web pods in a Deployment.
+	WorkloadTypeDeployment WorkloadType = "Deployment"
+	// WorkloadTypeStatefulSet runs the web pods in a StatefulSet.
+	WorkloadTypeStatefulSet WorkloadType = "StatefulSet"
+)
+
+// WAFMode defines how the WAF handles the requests matching its rules.
+type WAFMode string
+
+const (
+	// WAFBlocking rejects the requests whose anomaly score reaches the threshold.
+	WAFBlocking WAFMode = "Blocking"
+	// WAFDetectionOnly only logs the requests matching the rules.
+	WAFDetectionOnly WAFMode = "DetectionOnly"
+)
+
+// ServiceMeshProvider is the service mesh the site runs in.
+type ServiceMeshProvider string
+
+const (
+	// ServiceMeshIstio is the Istio service mesh.
+	ServiceMeshIstio ServiceMeshProvider = "istio"
+	// ServiceMeshLinkerd is the Linkerd service mesh.
+	ServiceMeshLinkerd ServiceMeshProvider = "linkerd"
+)
+
 // WordpressSpec defines the desired state of Wordpress.
 type WordpressSpec struct {
 	// Number of desired web pods. This is a pointer to distinguish between
 	// explicit zero and not specified. Defaults to 1.
+	// It is ignored when Autoscaling is specified.
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
+	// SizePreset sets the defaults of Replicas, the CPU and memory of
+	// Resources, and the PHP-FPM workers and memory limit of PHP. Each of
+	// them can be overridden.
+	// +kubebuilder:validation:Enum=small;medium;large
+	// +optional
+	SizePreset SizePreset `json:"sizePreset,omitempty"`
+	// Autoscaling configures a HorizontalPodAutoscaler for the web pods.
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// ScaleToZero allows the site to be scaled down to zero replicas by KEDA
+	// outside of the configured schedules or when it receives no traffic.
+	// It takes precedence over Autoscaling.
+	// +optional
+	ScaleToZero *ScaleToZeroSpec `json:"scaleToZero,omitempty"`
 	// Domains for which this this site answers.
 	// The first item is set as the "main domain" (eg. WP_HOME and WP_SITEURL constants).
 	// Deprecated: use Routes instead. This field will be dropped in next release.
@@ -84,9 +428,33 @@ type WordpressSpec struct {
 	// If no routes are specified, ingress syncing is disabled and WP_HOME de defaults to NAME.NAMESPACE.svc.
 	// +optional
 	Routes []RouteSpec `json:"routes,omitempty"`
+	// Path is the path the site is installed under (eg. /blog), for routes
+	// which don't specify one. WP_HOME, WP_SITEURL, the bootstrap URL and the
+	// ingress rules are all derived from it. Defaults to /.
+	// +kubebuilder:validation:Pattern=^/
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Aliases are domains which permanently redirect to the site's main domain.
+	// The redirects are handled by an ingress using the ingress-nginx
+	// permanent-redirect annotation.
+	// +optional
+	Aliases []string `json:"aliases,omitempty"`
+	// SearchReplaceOnDomainChange runs a wp search-replace Job, replacing the
+	// previous home URL with the new one, when the site's main domain changes.
+	// The web pods are rolled out with the new home URL once the Job completes.
+	// +optional
+	SearchReplaceOnDomainChange bool `json:"searchReplaceOnDomainChange,omitempty"`
+	// UpgradeDatabaseOnCodeChange runs a wp core update-db Job when the
+	// site's image or git reference changes.
+	// +optional
+	UpgradeDatabaseOnCodeChange bool `json:"upgradeDatabaseOnCodeChange,omitempty"`
 	// WordPress runtime image to use. Defaults to docker.io/bitpoke/wordpress-runtime:<latest stable runtime tag>
 	// +optional
 	Image string `json:"image,omitempty"`
+	// Runtime selects the tag of the default runtime image by PHP version and
+	// variant. It is ignored if Image is set.
+	// +optional
+	Runtime *RuntimeSpec `json:"runtime,omitempty"`
 	// ImagePullPolicy overrides WordpressRuntime spec.imagePullPolicy
 	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
 	// +optional
@@ -98,11 +466,39 @@ type WordpressSpec struct {
 	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/configure-service-account/
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// ServiceAccount provisions a dedicated ServiceAccount for the site's
+	// pods, instead of running them with the namespace's default one.
+	// +optional
+	ServiceAccount *ServiceAccountSpec `json:"serviceAccount,omitempty"`
 	// TLSSecretRef a secret containing the TLS certificates for this site.
 	// +optional
 	TLSSecretRef SecretRef `json:"tlsSecretRef,omitempty"`
-	// DeploymentStrategy allows setting the deployment strategy for the WordPress site
+	// TLS configures TLS for the site.
+	// +optional
+	TLS *TLSSpec `json:"tls,omitempty"`
+	// WorkloadType is the kind of workload running the web pods, Deployment or
+	// StatefulSet. With StatefulSet, each replica gets its own code and media
+	// persistent volume claims. The operator doesn't sync their content, so
+	// multiple replicas should use git for code and a bucket for media.
+	// Defaults to Deployment.
+	// +kubebuilder:validation:Enum=Deployment;StatefulSet
+	// +optional
+	WorkloadType WorkloadType `json:"workloadType,omitempty"`
+	// DeploymentStrategy allows setting the deployment strategy for the WordPress site.
+	// Defaults to Recreate if the code or media volumes are ReadWriteOnce
+	// persistent volume claims and to RollingUpdate otherwise.
+	// +optional
 	DeploymentStrategy *appsv1.DeploymentStrategy `json:"deploymentStrategy,omitempty"`
+	// PodDisruptionBudget configures the PodDisruptionBudget created for sites
+	// running more than one replica. If not specified, minAvailable defaults to 1.
+	// +optional
+	PodDisruptionBudget *PodDisruptionBudgetSpec `json:"podDisruptionBudget,omitempty"`
+	// Canary runs a canary release of the site next to the stable one and
+	// routes a share of the traffic to it, once its pods are ready and the
+	// smoke test passed. The canary ingress uses the ingress-nginx canary
+	// annotations. It is ignored for StatefulSet workloads.
+	// +optional
+	Canary *CanarySpec `json:"canary,omitempty"`
 	// CodeVolumeSpec specifies how the site's code gets mounted into the
 	// container. If not specified, a code volume won't get mounted at all.
 	// +optional
@@ -111,6 +507,18 @@ type WordpressSpec struct {
 	// container. If not specified, a media volume won't be mounted at all.
 	// +optional
 	MediaVolumeSpec *MediaVolumeSpec `json:"media,omitempty"`
+	// Database configures the site's MySQL database.
+	// +optional
+	Database *DatabaseSpec `json:"database,omitempty"`
+	// Cache configures the site's object and page caches.
+	// +optional
+	Cache *CacheSpec `json:"cache,omitempty"`
+	// PHP tunes the runtime's PHP-FPM pool and PHP settings.
+	// +optional
+	PHP *PHPSpec `json:"php,omitempty"`
+	// CDN integrates the site with the CDN serving it.
+	// +optional
+	CDN *CDNSpec `json:"cdn,omitempty"`
 	// Volumes defines additional volumes to get injected into web and cli pods
 	// +optional
 	Volumes []corev1.Volume `json:"volumes,omitempty"`
@@ -144,6 +552,42 @@ type WordpressSpec struct {
 	// EnvFrom defines envFrom's which get passed into web and cli containers
 	// +optional
 	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// RollOnConfigChange rolls the web pods when the data of the Secrets and
+	// ConfigMaps referenced by their containers' environment changes.
+	// Defaults to true.
+	// +optional
+	RollOnConfigChange *bool `json:"rollOnConfigChange,omitempty"`
+	// RollOnConfigChangeExclusions are the names of the Secrets and
+	// ConfigMaps whose changes don't roll the web pods.
+	// +optional
+	RollOnConfigChangeExclusions []string `json:"rollOnConfigChangeExclusions,omitempty"`
+	// MaintenanceWindow defers the web pods' restarts caused by changes of
+	// the referenced Secrets and ConfigMaps and of the web server config, such
+	// as secret rotations, until the window opens. The changes of the site's
+	// spec and secret roll them immediately.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+	// RotateSalts regenerates the authentication keys and salts of the site's
+	// secret when set to a new value, which rolls the web pods and logs out
+	// the users. Clearing it keeps the current ones.
+	// +optional
+	RotateSalts string `json:"rotateSalts,omitempty"`
+	// SecretsProvider adds secrets provided by an external secrets store to
+	// the environment of the web and cli containers, e.g. the database's
+	// credentials.
+	// +optional
+	SecretsProvider *SecretsProviderSpec `json:"secretsProvider,omitempty"`
+	// Vault sets the annotations of the Vault Agent Injector on the web and
+	// wp-cli pods, rendering the given secrets as environment variables.
+	// +optional
+	Vault *VaultSpec `json:"vault,omitempty"`
+	// ImageVerification verifies the cosign signatures of the runtime, git
+	// clone and rclone images before the site's pods are rendered with them.
+	// The images are verified for all the sites when the operator runs with
+	// --verify-image-signatures, with the operator's key by default. The
+	// verified images must be pinned by digest.
+	// +optional
+	ImageVerification *ImageVerificationSpec `json:"imageVerification,omitempty"`
 	// If specified, the resources required by wordpress container.
 	// More info: https://kubernetes.io/docs/concepts/configuration/manage-compute-resources-container/
 	// +optional
@@ -157,12 +601,155 @@ type WordpressSpec struct {
 	// If specified, the pod's scheduling constraints
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PodAntiAffinityPreset generates a pod anti-affinity which spreads the
+	// web pods across nodes. It is ignored if Affinity specifies a pod anti-affinity.
+	// Defaults to none.
+	// +kubebuilder:validation:Enum=none;soft;hard
+	// +optional
+	PodAntiAffinityPreset PodAntiAffinityPreset `json:"podAntiAffinityPreset,omitempty"`
 	// If specified, indicates the pod's priority class
 	// +optional
 	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// HonorForwardedHeaders makes the runtime honor the X-Forwarded-Proto and
+	// X-Forwarded-For headers of requests coming from TrustedProxies (eg. when
+	// TLS is terminated by a load balancer in front of the site).
+	// +optional
+	HonorForwardedHeaders bool `json:"honorForwardedHeaders,omitempty"`
+	// TrustedProxies are the CIDRs of the proxies whose X-Forwarded-* headers
+	// are honored. They are passed to the runtime as STACK_TRUSTED_PROXIES.
+	// +optional
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+	// ProxyProtocol configures the site for running behind load balancers
+	// which pass the client address using the PROXY protocol, so that the
+	// real client IPs are logged and passed to WordPress.
+	// +optional
+	ProxyProtocol *ProxyProtocolSpec `json:"proxyProtocol,omitempty"`
+	// SecurityHeaders are response headers set on all the site's responses,
+	// through the ingress.
+	// +optional
+	SecurityHeaders *SecurityHeadersSpec `json:"securityHeaders,omitempty"`
+	// WebServerConfig mounts web server configuration snippets (eg. rewrite
+	// rules or upload limits) into the runtime container. The web pods are
+	// rolled when the config map changes.
+	// +optional
+	WebServerConfig *WebServerConfigSpec `json:"webServerConfig,omitempty"`
+	// Compression configures the compression of the site's responses by the
+	// web server. The rendered configuration is mounted along the
+	// WebServerConfig snippets.
+	// +optional
+	Compression *CompressionSpec `json:"compression,omitempty"`
+	// WAF runs a ModSecurity sidecar with the OWASP Core Rule Set in front of
+	// the runtime container, through which the site's requests are proxied.
+	// +optional
+	WAF *WAFSpec `json:"waf,omitempty"`
+	// Monitoring configures the collection of the site's metrics.
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+	// Logging configures the format of the runtime's access and error logs
+	// and their shipping by a fluent-bit sidecar.
+	// +optional
+	Logging *LoggingSpec `json:"logging,omitempty"`
+	// Cron runs the WordPress scheduled events from a CronJob, instead of
+	// the pseudo-cron triggered by the site's visits, which is unreliable on
+	// low traffic sites.
+	// +optional
+	Cron *CronSpec `json:"cron,omitempty"`
+	// SMTP configures the site to send its emails through an SMTP server,
+	// optionally verified by sending a test email.
+	// +optional
+	SMTP *SMTPSpec `json:"smtp,omitempty"`
+	// Plugins are installed and activated or deactivated by the operator,
+	// which corrects any drift once the web pods are rolled out with new code
+	// or a new plugins spec. The code volume must be writable.
+	// +optional
+	Plugins []PluginSpec `json:"plugins,omitempty"`
+	// Themes are installed by the operator, which activates the active one
+	// and corrects any drift once the web pods are rolled out with new code or
+	// a new themes spec. Themes shipped with the code, like child themes, are
+	// only activated. The code volume must be writable.
+	// +optional
+	Themes []ThemeSpec `json:"themes,omitempty"`
+	// Options are the site's options (eg. blogname, timezone_string or
+	// permalink_structure), keyed by name. They are updated by the operator,
+	// which corrects any drift once the web pods are rolled out with new code
+	// or a new options spec. Changes to the referenced secrets are applied
+	// along with the next rollout.
+	// +optional
+	Options map[string]OptionValue `json:"options,omitempty"`
+	// Users are created, and their roles corrected, by the operator once the
+	// web pods are rolled out with new code or a new users spec. The users
+	// not listed are left alone, unless DeleteUnmanagedAdministrators is set.
+	// +optional
+	Users []UserSpec `json:"users,omitempty"`
+	// DeleteUnmanagedAdministrators deletes the administrators not listed in
+	// Users, reassigning their content to the first listed administrator.
+	// It has no effect unless an administrator is listed.
+	// +optional
+	DeleteUnmanagedAdministrators bool `json:"deleteUnmanagedAdministrators,omitempty"`
+	// Updates is the site's automatic updates policy.
+	// +optional
+	Updates *UpdatesSpec `json:"updates,omitempty"`
+	// Multisite runs the site as a WordPress network. The network is
+	// installed by the bootstrap, which also creates the missing sub-sites.
+	// +optional
+	Multisite *MultisiteSpec `json:"multisite,omitempty"`
+	// JobPolicy is the execution policy of the Jobs and the CronJobs
+	// created by the operator for the site.
+	// +optional
+	JobPolicy *JobPolicySpec `json:"jobPolicy,omitempty"`
+	// JobPodOverrides schedules the Job pods apart from the web pods, e.g. on
+	// batch nodes.
+	// +optional
+	JobPodOverrides *JobPodOverridesSpec `json:"jobPodOverrides,omitempty"`
+	// Cleanup schedules the cleanup of the site's content, whose results are
+	// recorded in the status and in events.
+	// +optional
+	Cleanup *CleanupSpec `json:"cleanup,omitempty"`
+	// Diagnostics schedules health checks of the site, whose results are
+	// published in the status and in the DiagnosticsPassed condition.
+	// +optional
+	Diagnostics *DiagnosticsSpec `json:"diagnostics,omitempty"`
+	// IntegrityCheck schedules the verification of the core and plugin files
+	// against their checksums, whose results are published in the status and
+	// in the IntegrityCompromised condition.
+	// +optional
+	IntegrityCheck *IntegrityCheckSpec `json:"integrityCheck,omitempty"`
+	// Inventory schedules the collection of the core, plugin and theme
+	// versions, which are published in the status.
+	// +optional
+	Inventory *InventorySpec `json:"inventory,omitempty"`
+	// Backups schedules WordpressBackups of the site, pruning the old ones.
+	// +optional
+	Backups *BackupsSpec `json:"backups,omitempty"`
+	// Velero sets the hooks which make the Velero backups of the site
+	// consistent.
+	// +optional
+	Velero *VeleroSpec `json:"velero,omitempty"`
+	// Service allows customizing the site's Service.
+	// +optional
+	Service *ServiceSpec `json:"service,omitempty"`
 	// IngressAnnotations for this Wordpress site
 	// +optional
 	IngressAnnotations map[string]string `json:"ingressAnnotations,omitempty"`
+	// IngressClassName is the ingress class of the site's ingress.
+	// Defaults to the operator's --ingress-class flag.
+	// +optional
+	IngressClassName string `json:"ingressClassName,omitempty"`
+	// AdminAccess restricts the access to the WordPress admin and login page,
+	// using a dedicated ingress. admin-ajax.php remains public.
+	// +optional
+	AdminAccess *AdminAccessSpec `json:"adminAccess,omitempty"`
+	// LoginProtection rate limits the requests to the login page and
+	// xmlrpc.php per client, using a dedicated ingress, and can disable
+	// XML-RPC altogether.
+	// +optional
+	LoginProtection *LoginProtectionSpec `json:"loginProtection,omitempty"`
+	// DNS configures the DNS records published by external-dns for the site's domains.
+	// +optional
+	DNS *DNSSpec `json:"dns,omitempty"`
+	// ServiceMesh configures the site for running in a service mesh.
+	// +optional
+	ServiceMesh *ServiceMeshSpec `json:"serviceMesh,omitempty"`
 	// Additional init containers
 	// +optional
 	InitContainers []corev1.Container `json:"initContainers,omitempty"`
@@ -171,114 +758,1507 @@ type WordpressSpec struct {
 	Sidecars []corev1.Container `json:"sidecars,omitempty"`
 }
 
-// GitVolumeSource is the desired spec for git code source.
-type GitVolumeSource struct {
-	// Repository is the git repository for the code
-	Repository string `json:"repository"`
-	// GitRef to clone (can be a branch name, but it should point to a tag or a
-	// commit hash)
+// TLSSpec is the desired TLS spec for the site.
+type TLSSpec struct {
+	// IssuerRef is the cert-manager issuer used for creating a Certificate for
+	// the site's domains. Once the certificate is issued, its secret takes
+	// precedence over TLSSecretRef.
 	// +optional
-	GitRef string `json:"reference,omitempty"`
-	// Env defines env variables  which get passed to the git clone container
+	IssuerRef *CertificateIssuerReference `json:"issuerRef,omitempty"`
+	// Redirect permanently redirects plain HTTP requests to HTTPS and forces
+	// SSL for the WordPress admin (FORCE_SSL_ADMIN). Defaults to true.
 	// +optional
-	// +patchMergeKey=name
-	// +patchStrategy=merge
-	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
-	// EnvFrom defines envFrom which get passed to the git clone container
+	Redirect *bool `json:"redirect,omitempty"`
+	// HSTS sets the Strict-Transport-Security header on the site's responses.
 	// +optional
-	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
-	// EmptyDir volume to use for git cloning.
+	HSTS *HSTSSpec `json:"hsts,omitempty"`
+	// Secrets maps domains to their own TLS secrets. The other domains use
+	// the certificate issued for the site or TLSSecretRef.
 	// +optional
-	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	Secrets []DomainTLSSecret `json:"secrets,omitempty"`
 }
 
-// S3VolumeSource is the desired spec for accessing media files over S3
-// compatible object store.
-type S3VolumeSource struct {
-	// Bucket for storing media files
-	// +kubebuilder:validation:MinLength=1
-	Bucket string `json:"bucket"`
-	// PathPrefix is the prefix for media files in bucket
-	PathPrefix string `json:"prefix,omitempty"`
-	// Env variables for accessing S3 bucket. Taken into account are:
-	// ACCESS_KEY, SECRET_ACCESS_KEY
+// ImageVerificationSpec is the policy the cosign signatures of the site's
+// images are verified with. The fields which are not set default to the
+// operator's --image-signature-* flags.
+type ImageVerificationSpec struct {
+	// Key is the cosign key reference the signatures are verified with, e.g.
+	// a KMS URI, an https:// URL or k8s://namespace/secret.
 	// +optional
-	// +patchMergeKey=name
-	// +patchStrategy=merge
-	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	Key string `json:"key,omitempty"`
+	// PublicKeySecretRef selects the secret key holding the PEM-encoded public
+	// key the signatures are verified with. It takes precedence over Key.
+	// +optional
+	PublicKeySecretRef *corev1.SecretKeySelector `json:"publicKeySecretRef,omitempty"`
+	// CertificateIdentity is the regular expression the identity of the
+	// keyless signatures' certificates must match. It's used when no key is set.
+	// +optional
+	CertificateIdentity string `json:"certificateIdentity,omitempty"`
+	// CertificateOIDCIssuer is the OIDC issuer of the keyless signatures'
+	// certificates. It's used when no key is set.
+	// +optional
+	CertificateOIDCIssuer string `json:"certificateOIDCIssuer,omitempty"`
 }
 
-// GCSVolumeSource is the desired spec for accessing media files using google
-// cloud storage object store.
-type GCSVolumeSource struct {
-	// Bucket for storing media files
-	// +kubebuilder:validation:MinLength=1
-	Bucket string `json:"bucket"`
-	// PathPrefix is the prefix for media files in bucket
-	PathPrefix string `json:"prefix,omitempty"`
-	// Env variables for accessing gcs bucket. Taken into account are:
-	// GOOGLE_APPLICATION_CREDENTIALS_JSON
+// ServiceAccountSpec is the desired spec of the site's ServiceAccount.
+type ServiceAccountSpec struct {
+	// Create provisions a ServiceAccount named after the site, with the
+	// site's image pull secrets and without any roles bound to it. It's not
+	// used when ServiceAccountName is set.
 	// +optional
-	// +patchMergeKey=name
-	// +patchStrategy=merge
-	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	Create bool `json:"create,omitempty"`
+	// Annotations of the ServiceAccount, e.g. for binding it to a cloud
+	// identity with eks.amazonaws.com/role-arn or iam.gke.io/gcp-service-account.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// AutomountToken mounts the ServiceAccount's API token into the site's
+	// pods. Defaults to false, as they don't access the Kubernetes API.
+	// +optional
+	AutomountToken *bool `json:"automountToken,omitempty"`
 }
 
-// CodeVolumeSpec is the desired spec for mounting code into the wordpress
-// runtime container.
-type CodeVolumeSpec struct {
-	// Metadata for the media volume. Currently only labels and annotations are set if a PVC is specified
-	metav1.ObjectMeta `json:"metadata,omitempty"`
-	// ReadOnly specifies if the volume should be mounted read-only inside the
-	// wordpress runtime container
-	ReadOnly bool `json:"readOnly,omitempty"`
-	// MountPath specifies where should the code volume be mounted.
-	// Defaults to /app/web/wp-content
+// SecretsProviderSpec configures the secrets store the secrets added to the
+// environment of the site's containers are read from.
+type SecretsProviderSpec struct {
+	// CSI mounts a SecretProviderClass of the Secrets Store CSI driver into
+	// the site's pods.
 	// +optional
-	MountPath string `json:"mountPath,omitempty"`
-	// ContentSubPath specifies where within the code volumes, the WP_CONTENT_DIR is located.
-	// Defaults to: wp-content
+	CSI *CSISecretsProviderSpec `json:"csi,omitempty"`
+	// ExternalSecret creates an ExternalSecret of the External Secrets
+	// Operator, whose target secret is added to the environment.
 	// +optional
-	ContentSubPath string `json:"contentSubPath,omitempty"`
-	// ConfigSubPath specifies where within the code volumes the config directory is located.
-	// Defaults to: config
+	ExternalSecret *ExternalSecretSpec `json:"externalSecret,omitempty"`
+}
+
+// CSISecretsProviderSpec mounts a SecretProviderClass of the Secrets Store
+// CSI driver.
+type CSISecretsProviderSpec struct {
+	// SecretProviderClass is the name of the SecretProviderClass, in the
+	// site's namespace.
+	// +kubebuilder:validation:MinLength=1
+	SecretProviderClass string `json:"secretProviderClass"`
+	// SecretName is the secret the driver syncs the mounted objects into, as
+	// configured by the secretObjects of the SecretProviderClass. It's added
+	// to the environment once the volume is mounted.
 	// +optional
-	ConfigSubPath string `json:"configSubPath,omitempty"`
-	// GitDir specifies the git repo to use for code cloning. It has the highest
-	// level of precedence over EmptyDir, HostPath and PersistentVolumeClaim
+	SecretName string `json:"secretName,omitempty"`
+	// MountPath is the path the secrets are mounted at. Defaults to
+	// /var/run/secrets/wordpress.
 	// +optional
-	GitDir *GitVolumeSource `json:"git,omitempty"`
-	// PersistentVolumeClaim to use if no GitDir is specified
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// ExternalSecretSpec is the desired spec of the ExternalSecret created for
+// the site.
+type ExternalSecretSpec struct {
+	// SecretStoreRef is the store the secrets are read from.
+	SecretStoreRef ExternalSecretStoreRef `json:"secretStoreRef"`
+	// RefreshInterval is how often the secrets are read from the store.
+	// Defaults to 1h.
 	// +optional
-	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
-	// HostPath to use if no PersistentVolumeClaim is specified
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+	// Data maps the keys of the target secret to the store's secrets.
 	// +optional
-	HostPath *corev1.HostPathVolumeSource `json:"hostPath,omitempty"`
-	// EmptyDir to use if no HostPath is specified
+	Data []ExternalSecretData `json:"data,omitempty"`
+	// DataFrom are the store's secrets whose properties are all copied into
+	// the target secret.
 	// +optional
-	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	DataFrom []string `json:"dataFrom,omitempty"`
 }
 
-// MediaVolumeSpec is the desired spec for handling media files at runtime.
-type MediaVolumeSpec struct {
-	// Metadata for the media volume. Currently only labels and annotations are set if a PVC is specified
-	metav1.ObjectMeta `json:"metadata,omitempty"`
-	// ReadOnly specifies if the volume should be mounted read-only inside the
-	// wordpress runtime container
-	ReadOnly bool `json:"readOnly,omitempty"`
-	// MountPath specifies where should the media volume be mounted.
-	// Defaults to '/uploads' folder within the CodeVolumeSpec.MountPath
+// ExternalSecretStoreRef references a SecretStore or a ClusterSecretStore.
+type ExternalSecretStoreRef struct {
+	// Name of the store.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Kind of the store. Defaults to SecretStore.
+	// +kubebuilder:validation:Enum=SecretStore;ClusterSecretStore
 	// +optional
-	MountPath string `json:"mountPath,omitempty"`
-	// ContentSubPath specifies where within the media volume, the media files are located.
+	Kind string `json:"kind,omitempty"`
+}
+
+// ExternalSecretData maps a key of the target secret to a store's secret.
+type ExternalSecretData struct {
+	// SecretKey is the key of the target secret, which is the name of the
+	// environment variable.
+	// +kubebuilder:validation:MinLength=1
+	SecretKey string `json:"secretKey"`
+	// RemoteKey is the key of the store's secret.
+	// +kubebuilder:validation:MinLength=1
+	RemoteKey string `json:"remoteKey"`
+	// Property of the store's secret, for secrets holding structured data.
 	// +optional
-	ContentSubPath string `json:"contentSubPath,omitempty"`
-	// S3VolumeSource specifies the S3 object storage configuration for media
-	// files. It has the highest level of precedence over EmptyDir, HostPath
-	// and PersistentVolumeClaim
+	Property string `json:"property,omitempty"`
+}
+
+// VaultSpec configures the Vault Agent Injector for the site's pods.
+type VaultSpec struct {
+	// Role is the Vault role the agent authenticates as, with the pods'
+	// service account.
+	// +kubebuilder:validation:MinLength=1
+	Role string `json:"role"`
+	// Env maps environment variables to the keys of Vault secrets. They're
+	// rendered as export statements into /vault/secrets/wordpress.env.
+	// +kubebuilder:validation:MinItems=1
+	Env []VaultEnvVar `json:"env"`
+	// KVVersion is the version of the KV secrets engine holding the secrets.
+	// Defaults to 2.
+	// +kubebuilder:validation:Enum=1;2
 	// +optional
-	S3VolumeSource *S3VolumeSource `json:"s3,omitempty"`
-	// GCSVolumeSource specifies the google cloud storage object storage
+	KVVersion int32 `json:"kvVersion,omitempty"`
+	// Annotations are additional annotations of the injector, e.g.
+	// vault.hashicorp.com/namespace. They can't override the ones set by
+	// the operator.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// VaultEnvVar maps an environment variable to the key of a Vault secret.
+type VaultEnvVar struct {
+	// Name of the environment variable.
+	// +kubebuilder:validation:Pattern=^[A-Za-z_][A-Za-z0-9_]*$
+	Name string `json:"name"`
+	// Path of the secret, e.g. secret/data/mysite/db for the KV version 2.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// Key of the secret's data.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+}
+
+// DomainTLSSecret maps domains to the TLS secret holding their certificate.
+type DomainTLSSecret struct {
+	// Domains served with the certificate.
+	// +kubebuilder:validation:MinItems=1
+	Domains []string `json:"domains"`
+	// SecretName is the name of the TLS secret, in the site's namespace.
+	// +kubebuilder:validation:MinLength=1
+	SecretName string `json:"secretName"`
+}
+
+// HSTSSpec is the desired HTTP Strict Transport Security policy for the site.
+type HSTSSpec struct {
+	// MaxAge is the time, in seconds, browsers should only access the site
+	// using HTTPS. Defaults to one year.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxAge *int64 `json:"maxAge,omitempty"`
+	// IncludeSubdomains applies the policy to the subdomains of the site's domains.
+	// +optional
+	IncludeSubdomains bool `json:"includeSubdomains,omitempty"`
+	// Preload allows the site's domains to be included in browsers' HSTS preload lists.
+	// +optional
+	Preload bool `json:"preload,omitempty"`
+}
+
+// CertificateIssuerReference is a reference to a cert-manager issuer.
+type CertificateIssuerReference struct {
+	// Name of the issuer.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Kind of the issuer. Defaults to Issuer.
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer. Defaults to cert-manager.io.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// ProxyProtocolSpec configures where the PROXY protocol is accepted.
+type ProxyProtocolSpec struct {
+	// Ingress makes the ingress use the client address from the PROXY
+	// protocol header. The ingress controller must accept the PROXY protocol.
+	// +optional
+	Ingress bool `json:"ingress,omitempty"`
+	// Runtime makes the runtime accept the PROXY protocol on its http port,
+	// from TrustedProxies (eg. when the site's service is a LoadBalancer).
+	// +optional
+	Runtime bool `json:"runtime,omitempty"`
+}
+
+// SecurityHeadersSpec is the desired set of security response headers of the site.
+type SecurityHeadersSpec struct {
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header.
+	// +kubebuilder:validation:Pattern=`^[^"\\$\r\n]*$`
+	// +optional
+	ContentSecurityPolicy string `json:"contentSecurityPolicy,omitempty"`
+	// FrameOptions is the value of the X-Frame-Options header.
+	// +kubebuilder:validation:Enum=DENY;SAMEORIGIN
+	// +optional
+	FrameOptions string `json:"frameOptions,omitempty"`
+	// ReferrerPolicy is the value of the Referrer-Policy header (eg.
+	// strict-origin-when-cross-origin), or a comma separated list of policies.
+	// +kubebuilder:validation:Pattern=`^[a-z-]+(, *[a-z-]+)*$`
+	// +optional
+	ReferrerPolicy string `json:"referrerPolicy,omitempty"`
+	// ContentTypeNosniff sets the X-Content-Type-Options header to nosniff.
+	// +optional
+	ContentTypeNosniff bool `json:"contentTypeNosniff,omitempty"`
+	// PermissionsPolicy is the value of the Permissions-Policy header.
+	// +kubebuilder:validation:Pattern=`^[^"\\$\r\n]*$`
+	// +optional
+	PermissionsPolicy string `json:"permissionsPolicy,omitempty"`
+}
+
+// WebServerConfigSpec references the web server configuration snippets of the site.
+type WebServerConfigSpec struct {
+	// ConfigMapName is the name of the config map holding the configuration
+	// files, in the site's namespace.
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
+	// MountPath is the directory the configuration files are mounted into,
+	// which should be included by the web server's configuration.
+	// Defaults to /etc/nginx/conf.d.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// CronSpec is the desired spec of the CronJob running the site's scheduled events.
+type CronSpec struct {
+	// Enabled disables the pseudo-cron and runs the due events from a
+	// CronJob. Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// Schedule of the CronJob, in the cron format. Defaults to every 5 minutes.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// SMTPEncryption is the encryption of the connection to the SMTP server.
+// +kubebuilder:validation:Enum=none;starttls;tls
+type SMTPEncryption string
+
+const (
+	// SMTPEncryptionNone doesn't encrypt the connection.
+	SMTPEncryptionNone SMTPEncryption = "none"
+	// SMTPEncryptionSTARTTLS upgrades the connection using STARTTLS.
+	SMTPEncryptionSTARTTLS SMTPEncryption = "starttls"
+	// SMTPEncryptionTLS connects using implicit TLS.
+	SMTPEncryptionTLS SMTPEncryption = "tls"
+)
+
+// SMTPSpec is the desired spec of the SMTP server the site sends its emails through.
+type SMTPSpec struct {
+	// Host is the address of the SMTP server.
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+	// Port of the SMTP server. Defaults to 465 with implicit TLS, 587
+	// otherwise.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int32 `json:"port,omitempty"`
+	// Encryption of the connection to the SMTP server. Defaults to starttls.
+	// +optional
+	Encryption SMTPEncryption `json:"encryption,omitempty"`
+	// CredentialsSecretRef is a secret holding the USER and PASSWORD the
+	// site authenticates with. If not set, the emails are sent without
+	// authentication.
+	// +optional
+	CredentialsSecretRef SecretRef `json:"credentialsSecretRef,omitempty"`
+	// FromAddress is the sender address of the site's emails.
+	// +optional
+	FromAddress string `json:"fromAddress,omitempty"`
+	// FromName is the sender name of the site's emails.
+	// +optional
+	FromName string `json:"fromName,omitempty"`
+	// TestRecipient is the address a Job sends a test email to whenever the
+	// SMTP configuration changes, setting the SMTPVerified condition.
+	// +optional
+	TestRecipient string `json:"testRecipient,omitempty"`
+}
+
+// CleanupTask is a task of the content cleanup.
+// +kubebuilder:validation:Enum=spam-comments;expired-transients;orphaned-postmeta
+type CleanupTask string
+
+const (
+	// CleanupSpamComments deletes the comments marked as spam.
+	CleanupSpamComments CleanupTask = "spam-comments"
+	// CleanupExpiredTransients deletes the expired transients.
+	CleanupExpiredTransients CleanupTask = "expired-transients"
+	// CleanupOrphanedPostMeta deletes the post meta of the deleted posts.
+	CleanupOrphanedPostMeta CleanupTask = "orphaned-postmeta"
+)
+
+// CleanupSpec is the desired spec of the CronJob cleaning up the site's content.
+type CleanupSpec struct {
+	// Schedule of the CronJob, in the cron format. Defaults to daily, at 03:00.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// Tasks run by the cleanup. Defaults to all of them.
+	// +optional
+	Tasks []CleanupTask `json:"tasks,omitempty"`
+}
+
+// DiagnosticCheck is a health check of the site.
+// +kubebuilder:validation:Enum=core-checksums;plugin-updates;file-permissions
+type DiagnosticCheck string
+
+const (
+	// DiagnosticCoreChecksums verifies the core files against their checksums.
+	DiagnosticCoreChecksums DiagnosticCheck = "core-checksums"
+	// DiagnosticPluginUpdates checks for plugin updates.
+	DiagnosticPluginUpdates DiagnosticCheck = "plugin-updates"
+	// DiagnosticFilePermissions checks for world-writable files within wp-content.
+	DiagnosticFilePermissions DiagnosticCheck = "file-permissions"
+)
+
+// DiagnosticsSpec is the desired spec of the CronJob checking the site's health.
+type DiagnosticsSpec struct {
+	// Schedule of the CronJob, in the cron format. Defaults to every 6 hours.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// Checks run by the diagnostics. Defaults to all of them.
+	// +optional
+	Checks []DiagnosticCheck `json:"checks,omitempty"`
+}
+
+// IntegrityCheckSpec is the desired spec of the CronJob verifying the core
+// and plugin files against their checksums.
+type IntegrityCheckSpec struct {
+	// Schedule of the CronJob, in the cron format. Defaults to daily.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// SkipPlugins are the plugins which are not verified, e.g. the premium or
+	// the patched ones.
+	// +optional
+	SkipPlugins []string `json:"skipPlugins,omitempty"`
+}
+
+// InventorySpec is the desired spec of the inventory of the installed
+// versions.
+type InventorySpec struct {
+	// Schedule of the CronJob, in the cron format. Defaults to daily.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// BackupsSpec is the desired spec of the scheduled backups.
+type BackupsSpec struct {
+	// Schedule of the backups, in the cron format.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// Destination is the bucket the backups are uploaded to.
+	Destination BackupDestination `json:"destination"`
+	// IncludeDatabase backs up the site's database. Defaults to true.
+	// +optional
+	IncludeDatabase *bool `json:"includeDatabase,omitempty"`
+	// IncludeMedia backs up the site's media. Defaults to true.
+	// +optional
+	IncludeMedia *bool `json:"includeMedia,omitempty"`
+	// Retention prunes the finished backups, along with their artifacts.
+	// Without it, all the backups are kept.
+	// +optional
+	Retention *BackupRetentionSpec `json:"retention,omitempty"`
+	// Encryption encrypts the backups' artifacts before they're uploaded.
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+	// Verification periodically restores the database of the last succeeded
+	// backup into a throwaway database and smoke checks the restored site.
+	// +optional
+	Verification *BackupVerificationSpec `json:"verification,omitempty"`
+}
+
+// BackupVerificationSpec is the desired spec of the CronJob verifying that
+// the scheduled backups can be restored.
+type BackupVerificationSpec struct {
+	// Schedule of the verification, in the cron format.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// DatabaseImage is the MySQL or MariaDB image the throwaway database runs.
+	// Defaults to the operator's --backup-verification-database-image.
+	// +optional
+	DatabaseImage string `json:"databaseImage,omitempty"`
+	// DecryptionKeySecretRef selects the key of a secret holding the age
+	// identity or the GPG private key the backups are decrypted with. The
+	// backups encrypted with a passphrase are decrypted with it.
+	// +optional
+	DecryptionKeySecretRef *corev1.SecretKeySelector `json:"decryptionKeySecretRef,omitempty"`
+}
+
+// BackupRetentionSpec is the policy for pruning the scheduled backups.
+type BackupRetentionSpec struct {
+	// KeepLast is the number of the last succeeded backups which are kept,
+	// along with as many of the last failed ones.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	KeepLast *int32 `json:"keepLast,omitempty"`
+	// MaxAge is the age after which the finished backups are pruned. The
+	// last succeeded backup is kept regardless.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// VeleroSpec is the desired spec of the Velero backup hooks, run in the web
+// pods before and after their volumes are backed up.
+type VeleroSpec struct {
+	// LockDatabase holds a global read lock on the site's database from the
+	// pre-backup hook until the post-backup one. The site's database user
+	// needs the RELOAD privilege.
+	// +optional
+	LockDatabase bool `json:"lockDatabase,omitempty"`
+	// LockTimeout releases the database lock if the post-backup hook doesn't
+	// run. Defaults to 1h.
+	// +optional
+	LockTimeout *metav1.Duration `json:"lockTimeout,omitempty"`
+	// BackupVolumes opts the code and media persistent volumes of the web
+	// pods into Velero's file system backup.
+	// +optional
+	BackupVolumes bool `json:"backupVolumes,omitempty"`
+}
+
+// PluginState is the desired state of a plugin.
+type PluginState string
+
+const (
+	// PluginActive means the plugin is activated.
+	PluginActive PluginState = "active"
+	// PluginInactive means the plugin is installed, but deactivated.
+	PluginInactive PluginState = "inactive"
+)
+
+// PluginSpec is the desired spec of a plugin.
+type PluginSpec struct {
+	// Name is the plugin's slug, its directory within wp-content/plugins.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Version pins the plugin's version. If not set, the latest version is
+	// installed when the plugin is missing, and it's not updated afterwards.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Source is the URL of the plugin's zip, installed instead of the
+	// WordPress.org plugin directory one.
+	// +optional
+	Source string `json:"source,omitempty"`
+	// State of the plugin. Defaults to active.
+	// +kubebuilder:validation:Enum=active;inactive
+	// +optional
+	State PluginState `json:"state,omitempty"`
+}
+
+// ThemeSpec is the desired spec of a theme.
+type ThemeSpec struct {
+	// Name is the theme's slug, its directory within wp-content/themes.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Version pins the theme's version. If not set, the latest version is
+	// installed when the theme is missing, and it's not updated afterwards.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Source is the URL of the theme's zip, installed instead of the
+	// WordPress.org theme directory one.
+	// +optional
+	Source string `json:"source,omitempty"`
+	// Active marks the site's theme. Only the first theme marked active is
+	// activated. The parent of an active child theme should be listed too.
+	// +optional
+	Active bool `json:"active,omitempty"`
+}
+
+// OptionValue is the value of an option. Only one of its fields may be set.
+type OptionValue struct {
+	// Value of the option.
+	// +optional
+	Value string `json:"value,omitempty"`
+	// SecretKeyRef selects the key of a secret holding the option's value,
+	// for the sensitive ones (eg. API keys).
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+}
+
+// UserSpec is the desired spec of a user.
+type UserSpec struct {
+	// Username is the user's login.
+	// +kubebuilder:validation:MinLength=1
+	Username string `json:"username"`
+	// Email of the user.
+	// +kubebuilder:validation:MinLength=1
+	Email string `json:"email"`
+	// Role of the user. Defaults to subscriber.
+	// +optional
+	Role string `json:"role,omitempty"`
+	// PasswordSecretRef selects the key of a secret holding the user's
+	// password. If not set, a random password is generated when the user is
+	// created, and the password is not managed afterwards.
+	// +optional
+	PasswordSecretRef *corev1.SecretKeySelector `json:"passwordSecretRef,omitempty"`
+}
+
+// MultisiteMode is the way the network's sub-sites are addressed.
+// +kubebuilder:validation:Enum=subdomain;subdirectory
+type MultisiteMode string
+
+const (
+	// MultisiteSubdomain addresses the sub-sites by subdomains of the main domain.
+	MultisiteSubdomain MultisiteMode = "subdomain"
+	// MultisiteSubdirectory addresses the sub-sites by paths under the home URL.
+	MultisiteSubdirectory MultisiteMode = "subdirectory"
+)
+
+// MultisiteSpec is the desired spec of a WordPress network.
+type MultisiteSpec struct {
+	// Mode is the way the sub-sites are addressed. Defaults to subdirectory.
+	// +optional
+	Mode MultisiteMode `json:"mode,omitempty"`
+	// Sites are the network's sub-sites, besides the main one.
+	// +optional
+	Sites []SubsiteSpec `json:"sites,omitempty"`
+}
+
+// SubsiteSpec is the desired spec of a network's sub-site.
+type SubsiteSpec struct {
+	// Slug is the sub-site's subdomain or subdirectory, depending on the
+	// network's mode.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9-]+$`
+	Slug string `json:"slug"`
+	// Domains are mapped to the sub-site, using a sunrise.php drop-in
+	// rendered by the operator, and routed to the site.
+	// +optional
+	Domains []string `json:"domains,omitempty"`
+}
+
+// UpdatesSpec is the desired spec of the site's automatic updates.
+type UpdatesSpec struct {
+	// Core is the WordPress core updates policy.
+	// +optional
+	Core *CoreUpdatesSpec `json:"core,omitempty"`
+	// BackupBeforeUpgrade takes a WordpressBackup, and waits for it to
+	// succeed, before the web pods are rolled out with a new image or code
+	// reference and before a core update is applied. If the backup fails, the
+	// upgrade is held until the backup is deleted.
+	// +optional
+	BackupBeforeUpgrade *UpgradeBackupSpec `json:"backupBeforeUpgrade,omitempty"`
+}
+
+// UpgradeBackupSpec is the desired spec of the backups taken before upgrades.
+type UpgradeBackupSpec struct {
+	// Destination is the bucket the backups are uploaded to. Defaults to the
+	// scheduled backups' destination.
+	// +optional
+	Destination *BackupDestination `json:"destination,omitempty"`
+	// Encryption encrypts the backups' artifacts. Defaults to the scheduled
+	// backups' encryption.
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+}
+
+// CoreUpdatePolicy selects the WordPress core updates which are applied.
+// +kubebuilder:validation:Enum=none;minor;all
+type CoreUpdatePolicy string
+
+const (
+	// CoreUpdateNone only checks for updates, recording an event when one is found.
+	CoreUpdateNone CoreUpdatePolicy = "none"
+	// CoreUpdateMinor applies the minor (maintenance and security) updates.
+	CoreUpdateMinor CoreUpdatePolicy = "minor"
+	// CoreUpdateAll applies all the updates, including the major ones.
+	CoreUpdateAll CoreUpdatePolicy = "all"
+)
+
+// CoreUpdatesSpec is the desired spec of the WordPress core updates. The
+// updates are checked for, and applied, by wp-cli Jobs, so the core must be
+// within a writable code volume shared by the web pods.
+type CoreUpdatesSpec struct {
+	// Policy selects the updates which are applied. Defaults to none.
+	// +optional
+	Policy CoreUpdatePolicy `json:"policy,omitempty"`
+	// CheckIntervalSeconds is the interval between the update checks.
+	// Defaults to 43200.
+	// +kubebuilder:validation:Minimum=300
+	// +optional
+	CheckIntervalSeconds *int32 `json:"checkIntervalSeconds,omitempty"`
+	// Window is the maintenance window the updates are applied in. If not
+	// set, they're applied once found.
+	// +optional
+	Window *MaintenanceWindow `json:"window,omitempty"`
+}
+
+// Weekday is a day of the week.
+// +kubebuilder:validation:Enum=Monday;Tuesday;Wednesday;Thursday;Friday;Saturday;Sunday
+type Weekday string
+
+// MaintenanceWindow is a daily time window. A window ending before it
+// starts ends on the next day.
+type MaintenanceWindow struct {
+	// Days are the days the window starts on. If not set, it starts every day.
+	// +optional
+	Days []Weekday `json:"days,omitempty"`
+	// Start is the time the window starts at, as HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+	// End is the time the window ends at, as HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	End string `json:"end"`
+	// Timezone is the IANA timezone name in which the window is evaluated.
+	// Defaults to Etc/UTC.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// WAFSpec is the desired spec of the site's web application firewall.
+type WAFSpec struct {
+	// Mode is Blocking, which rejects the requests whose anomaly score reaches
+	// the threshold, or DetectionOnly, which only logs them. Defaults to Blocking.
+	// +kubebuilder:validation:Enum=Blocking;DetectionOnly
+	// +optional
+	Mode WAFMode `json:"mode,omitempty"`
+	// ParanoiaLevel of the Core Rule Set, from 1 to 4. The higher levels
+	// match more attacks, along with more false positives. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4
+	// +optional
+	ParanoiaLevel int32 `json:"paranoiaLevel,omitempty"`
+	// AnomalyThreshold is the inbound anomaly score from which the requests
+	// are blocked. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	AnomalyThreshold int32 `json:"anomalyThreshold,omitempty"`
+	// Rules mounts custom rules into the Core Rule Set's rules directory.
+	// +optional
+	Rules *WAFRulesSpec `json:"rules,omitempty"`
+	// Image is the ModSecurity image, which must be configurable as the
+	// OWASP CRS nginx images. Defaults to the operator's --waf-image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Resources of the WAF container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// WAFRulesSpec selects the config map keys holding the WAF's custom rules.
+type WAFRulesSpec struct {
+	// ConfigMapName is the name of the config map holding the rules, in the
+	// site's namespace. The web pods are rolled when it changes.
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
+	// Files are the config map's keys mounted into the rules directory, whose
+	// .conf files are loaded in lexical order. The
+	// REQUEST-900-EXCLUSION-RULES-BEFORE-CRS.conf and
+	// RESPONSE-999-EXCLUSION-RULES-AFTER-CRS.conf keys replace the Core Rule
+	// Set's exclusion rules.
+	// +kubebuilder:validation:MinItems=1
+	Files []string `json:"files"`
+}
+
+// ExporterType is the server whose metrics are exported.
+type ExporterType string
+
+const (
+	// PHPFPMExporter exports the metrics of the runtime's PHP-FPM pool.
+	PHPFPMExporter ExporterType = "php-fpm"
+	// ApacheExporter exports the metrics of the runtime's Apache web server.
+	ApacheExporter ExporterType = "apache"
+	// NginxExporter exports the metrics of the runtime's nginx web server.
+	NginxExporter ExporterType = "nginx"
+)
+
+// MonitoringSpec configures the collection of the site's metrics.
+type MonitoringSpec struct {
+	// Exporter runs a Prometheus exporter sidecar in the web pods, scraping
+	// the runtime's status page and serving its metrics on the pods' and the
+	// service's metrics port.
+	// +optional
+	Exporter *ExporterSpec `json:"exporter,omitempty"`
+	// Probe configures the Prometheus Operator Probe monitoring the
+	// availability of the site's domains through a blackbox exporter. The
+	// Probe is created when a prober is set, here or by the operator's
+	// --probe-prober-url.
+	// +optional
+	Probe *ProbeSpec `json:"probe,omitempty"`
+}
+
+// ProbeSpec is the desired spec of the Probe monitoring the site's domains.
+type ProbeSpec struct {
+	// ProberURL is the address of the blackbox exporter probing the domains,
+	// e.g. blackbox-exporter.monitoring:9115. Defaults to the operator's
+	// --probe-prober-url.
+	// +optional
+	ProberURL string `json:"proberURL,omitempty"`
+	// Module of the blackbox exporter the domains are probed with. Defaults
+	// to the operator's --probe-module.
+	// +optional
+	Module string `json:"module,omitempty"`
+	// Interval at which the domains are probed, e.g. 30s. Defaults to the
+	// Prometheus' scrape interval.
+	// +kubebuilder:validation:Pattern=^(0|(([0-9]+)h)?(([0-9]+)m)?(([0-9]+)s)?(([0-9]+)ms)?)$
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// Labels of the Probe, e.g. matching the Prometheus' probeSelector.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ExporterSpec is the desired spec of the Prometheus exporter sidecar.
+type ExporterSpec struct {
+	// Type of the exporter, php-fpm, apache or nginx. Defaults to php-fpm.
+	// +kubebuilder:validation:Enum=php-fpm;apache;nginx
+	// +optional
+	Type ExporterType `json:"type,omitempty"`
+	// StatusURI is the runtime's status page scraped by the exporter.
+	// Defaults to tcp://127.0.0.1:9000/status for php-fpm, to
+	// http://127.0.0.1:8080/server-status?auto for apache and to
+	// http://127.0.0.1:8080/stub_status for nginx.
+	// +optional
+	StatusURI string `json:"statusURI,omitempty"`
+	// Image of the exporter. Defaults to the operator's --php-fpm-exporter-image,
+	// --apache-exporter-image or --nginx-exporter-image, depending on its type.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Resources of the exporter container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// LogFormat is the format of the runtime's access and error logs.
+type LogFormat string
+
+const (
+	// TextLogFormat logs in the web server's and PHP's default formats.
+	TextLogFormat LogFormat = "text"
+	// JSONLogFormat logs a JSON object per line.
+	JSONLogFormat LogFormat = "json"
+)
+
+// LoggingSpec configures the site's logs.
+type LoggingSpec struct {
+	// Format of the runtime's access and error logs, text or json. It's
+	// passed to the runtime as STACK_LOG_FORMAT. Defaults to text.
+	// +kubebuilder:validation:Enum=text;json
+	// +optional
+	Format LogFormat `json:"format,omitempty"`
+	// Shipper runs a fluent-bit sidecar in the web pods, which tails the
+	// runtime's access and error logs and sends them to the configured outputs.
+	// +optional
+	Shipper *LogShipperSpec `json:"shipper,omitempty"`
+}
+
+// LogShipperSpec is the desired spec of the fluent-bit sidecar.
+type LogShipperSpec struct {
+	// OutputConfigMapName is the name of the config map holding the
+	// fluent-bit outputs, in the site's namespace. The web pods are rolled
+	// when it changes.
+	// +kubebuilder:validation:MinLength=1
+	OutputConfigMapName string `json:"outputConfigMapName"`
+	// OutputKey is the config map's key holding the fluent-bit [OUTPUT]
+	// sections. The records are tagged access or error and hold the site's
+	// name and namespace. Defaults to output.conf.
+	// +optional
+	OutputKey string `json:"outputKey,omitempty"`
+	// Image of fluent-bit. Defaults to the operator's --log-shipper-image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Resources of the log shipper container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// CompressionSpec is the desired compression of the site's responses.
+type CompressionSpec struct {
+	// Gzip enables gzip compression. Defaults to true.
+	// +optional
+	Gzip *bool `json:"gzip,omitempty"`
+	// GzipLevel is the gzip compression level. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=9
+	// +optional
+	GzipLevel int32 `json:"gzipLevel,omitempty"`
+	// Brotli enables brotli compression, for the clients supporting it. It
+	// requires a runtime image built with the ngx_brotli module.
+	// +optional
+	Brotli bool `json:"brotli,omitempty"`
+	// BrotliLevel is the brotli compression level. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=11
+	// +optional
+	BrotliLevel int32 `json:"brotliLevel,omitempty"`
+	// MinLength is the minimum length, in bytes, of the compressed responses.
+	// Defaults to 256.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinLength int32 `json:"minLength,omitempty"`
+	// Types are the MIME types compressed, besides text/html which is always
+	// compressed. Defaults to the common text, script, font and image/svg+xml types.
+	// +optional
+	Types []string `json:"types,omitempty"`
+}
+
+// ServiceSpec is the desired spec for the site's Service.
+type ServiceSpec struct {
+	// Type of the service. Defaults to ClusterIP.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Annotations to set on the service (eg. for provisioning internal load balancers).
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LoadBalancerSourceRanges restricts the client IPs allowed to access a
+	// LoadBalancer service.
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// AppProtocol is the application protocol of the http port.
+	// +optional
+	AppProtocol *string `json:"appProtocol,omitempty"`
+	// Ports are additional ports exposed by the service (eg. for sidecars).
+	// +optional
+	Ports []corev1.ServicePort `json:"ports,omitempty"`
+	// Headless also creates a headless service selecting the web pods, which
+	// get their own DNS records (eg. for reaching individual replicas).
+	// StatefulSet workloads always have one.
+	// +optional
+	Headless bool `json:"headless,omitempty"`
+	// IPFamilyPolicy of the service, for dual-stack clusters.
+	// +kubebuilder:validation:Enum=SingleStack;PreferDualStack;RequireDualStack
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicyType `json:"ipFamilyPolicy,omitempty"`
+	// IPFamilies of the service, in order of preference (eg. IPv4, IPv6).
+	// +kubebuilder:validation:MaxItems=2
+	// +optional
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+}
+
+// AdminAccessSpec restricts the access to the WordPress admin.
+type AdminAccessSpec struct {
+	// AllowedSourceRanges are the CIDRs of the clients allowed to access the admin.
+	// +optional
+	AllowedSourceRanges []string `json:"allowedSourceRanges,omitempty"`
+	// BasicAuthSecretRef is a secret holding an htpasswd file under the auth
+	// key, required for accessing the admin.
+	// +optional
+	BasicAuthSecretRef SecretRef `json:"basicAuthSecretRef,omitempty"`
+}
+
+// LoginProtectionSpec is the desired spec for protecting the site's login
+// page and xmlrpc.php from credential-stuffing attacks.
+type LoginProtectionSpec struct {
+	// RequestsPerMinute is the number of requests per minute each client can
+	// make to the login page and xmlrpc.php. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RequestsPerMinute int32 `json:"requestsPerMinute,omitempty"`
+	// BurstMultiplier multiplies RequestsPerMinute into the number of
+	// requests allowed in a burst. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	BurstMultiplier int32 `json:"burstMultiplier,omitempty"`
+	// DisableXMLRPC denies all the requests to xmlrpc.php.
+	// +optional
+	DisableXMLRPC bool `json:"disableXMLRPC,omitempty"`
+}
+
+// DNSSpec is the desired spec for the site's DNS records, managed by
+// external-dns (https://github.com/kubernetes-sigs/external-dns).
+type DNSSpec struct {
+	// Enabled adds the external-dns hostname annotations for the site's
+	// domains to its ingresses and, if it's a LoadBalancer, to its service.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// TTL of the DNS records, in seconds.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TTL *int64 `json:"ttl,omitempty"`
+	// Annotations to set alongside the hostname annotations (eg.
+	// external-dns.alpha.kubernetes.io/target).
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Endpoint creates a DNSEndpoint with records for the site's domains,
+	// to be used with the external-dns crd source.
+	// +optional
+	Endpoint *DNSEndpointSpec `json:"endpoint,omitempty"`
+}
+
+// DNSEndpointSpec is the desired spec for the records of the site's DNSEndpoint.
+type DNSEndpointSpec struct {
+	// RecordType is the type of the records. Defaults to CNAME.
+	// +kubebuilder:validation:Enum=A;AAAA;CNAME
+	// +optional
+	RecordType string `json:"recordType,omitempty"`
+	// Targets of the records (eg. the load balancer IPs or hostname).
+	// +kubebuilder:validation:MinItems=1
+	Targets []string `json:"targets"`
+}
+
+// DatabaseSpec is the desired spec of the site's MySQL database.
+type DatabaseSpec struct {
+	// Engine is the engine of the site's database. Defaults to mysql.
+	// +kubebuilder:validation:Enum=mysql;postgres
+	// +optional
+	Engine DatabaseEngine `json:"engine,omitempty"`
+	// Provision makes the operator provision a MysqlCluster, a database and a
+	// user for the site, using the mysql-operator
+	// (https://github.com/bitpoke/mysql-operator). The generated credentials
+	// are stored into the site's secret.
+	// +optional
+	Provision bool `json:"provision,omitempty"`
+	// Host is the host of an external database, used when the database is
+	// not provisioned.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// CloudSQL connects the site to a Cloud SQL instance through the Cloud
+	// SQL Auth Proxy, instead of the host.
+	// +optional
+	CloudSQL *CloudSQLSpec `json:"cloudSQL,omitempty"`
+	// Port is the port of the external database, or the one the Cloud SQL
+	// Auth Proxy listens on. Defaults to 3306.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+	// Name is the name of the site's database. Defaults to wordpress.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// TablePrefix is the prefix of the site's tables, used when installing
+	// WordPress and at runtime. Defaults to the runtime's prefix (wp_).
+	// +kubebuilder:validation:Pattern=^[A-Za-z0-9_]+$
+	// +optional
+	TablePrefix string `json:"tablePrefix,omitempty"`
+	// CredentialsSecretRef is a secret holding the USER and PASSWORD of the
+	// external database's user.
+	// +optional
+	CredentialsSecretRef SecretRef `json:"credentialsSecretRef,omitempty"`
+	// AdminCredentialsSecretRef is a secret holding the USER and PASSWORD of
+	// an external database's user allowed to create databases and users. If
+	// set, a Job creates the site's database and user and grants it access.
+	// +optional
+	AdminCredentialsSecretRef SecretRef `json:"adminCredentialsSecretRef,omitempty"`
+	// Pooling injects a ProxySQL sidecar into the web pods, which pools the
+	// connections to the database.
+	// +optional
+	Pooling *DatabasePoolingSpec `json:"pooling,omitempty"`
+	// TLS configures the TLS connections to the database.
+	// +optional
+	TLS *DatabaseTLSSpec `json:"tls,omitempty"`
+	// MysqlCluster configures the provisioned MysqlCluster.
+	// +optional
+	MysqlCluster *MysqlClusterSpec `json:"mysqlCluster,omitempty"`
+	// RotateCredentials rotates the provisioned database's credentials when
+	// changed: a new user is created, the web pods are rolled to use it and
+	// the previous user is revoked afterwards, once none of the site's Jobs
+	// is running.
+	// +optional
+	RotateCredentials string `json:"rotateCredentials,omitempty"`
+	// Usage measures the size of the site's database periodically.
+	// +optional
+	Usage *DatabaseUsageSpec `json:"usage,omitempty"`
+}
+
+// DatabaseUsageSpec is the desired spec of the database's size measurements.
+// The size is measured by a Job connecting with the site's database user, so
+// it's not measured for Cloud SQL and PostgreSQL databases.
+type DatabaseUsageSpec struct {
+	// IntervalSeconds is the interval between measurements. Defaults to 3600.
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	IntervalSeconds *int32 `json:"intervalSeconds,omitempty"`
+	// Quota is a soft limit of the database's size. When exceeded, the
+	// DatabaseQuotaExceeded condition is set and a warning event is recorded.
+	// +optional
+	Quota *resource.Quantity `json:"quota,omitempty"`
+}
+
+// DatabaseEngine is the engine of the site's database.
+type DatabaseEngine string
+
+const (
+	// DatabaseEngineMySQL is the MySQL database engine.
+	DatabaseEngineMySQL DatabaseEngine = "mysql"
+	// DatabaseEnginePostgres is the experimental PostgreSQL database engine,
+	// which requires the pg4wp compatibility layer within the runtime image.
+	// Only external databases are supported, without pooling, TLS,
+	// bootstrapping nor importing SQL dumps.
+	DatabaseEnginePostgres DatabaseEngine = "postgres"
+)
+
+// DatabaseTLSVerifyMode defines how the database server's certificate is verified.
+type DatabaseTLSVerifyMode string
+
+const (
+	// DatabaseTLSVerifyCA verifies the database server's certificate against the CA.
+	DatabaseTLSVerifyCA DatabaseTLSVerifyMode = "verify-ca"
+	// DatabaseTLSSkipVerify encrypts the connections without verifying the
+	// database server's certificate.
+	DatabaseTLSSkipVerify DatabaseTLSVerifyMode = "skip-verify"
+)
+
+// DatabaseTLSSpec is the desired spec of the TLS connections to the database.
+type DatabaseTLSSpec struct {
+	// CASecretRef is a secret holding the CA certificate of the database
+	// server under the ca.crt key.
+	// +optional
+	CASecretRef SecretRef `json:"caSecretRef,omitempty"`
+	// VerifyMode defines how the database server's certificate is verified.
+	// Defaults to verify-ca.
+	// +kubebuilder:validation:Enum=verify-ca;skip-verify
+	// +optional
+	VerifyMode DatabaseTLSVerifyMode `json:"verifyMode,omitempty"`
+}
+
+// CloudSQLSpec is the desired spec of the Cloud SQL Auth Proxy sidecar.
+// The sidecar runs within the web pods and the wp-cli Jobs, so the init
+// containers and the database bootstrap Job can't connect through it.
+type CloudSQLSpec struct {
+	// InstanceConnectionName is the connection name of the Cloud SQL
+	// instance, in the project:region:instance format.
+	InstanceConnectionName string `json:"instanceConnectionName"`
+	// CredentialsSecretRef is a secret holding the key of the Google service
+	// account used by the proxy under the credentials.json key. If not set,
+	// the proxy authenticates as the pods' service account, using Workload
+	// Identity.
+	// +optional
+	CredentialsSecretRef SecretRef `json:"credentialsSecretRef,omitempty"`
+	// PrivateIP connects to the instance's private IP address.
+	// +optional
+	PrivateIP bool `json:"privateIP,omitempty"`
+	// Image is the Cloud SQL Auth Proxy image. Defaults to the operator's
+	// Cloud SQL Auth Proxy image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Compute resources required by the sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// DatabasePoolingSpec is the desired spec of the ProxySQL sidecar.
+type DatabasePoolingSpec struct {
+	// Image is the ProxySQL image. Defaults to the operator's ProxySQL image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// MaxConnections is the maximum number of connections each web pod opens
+	// to the database. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxConnections *int32 `json:"maxConnections,omitempty"`
+	// Compute resources required by the sidecar.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// MysqlClusterSpec is the desired spec of the provisioned MysqlCluster.
+type MysqlClusterSpec struct {
+	// Number of MySQL nodes. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// MysqlVersion is the MySQL version of the cluster. Defaults to the
+	// mysql-operator's default version.
+	// +optional
+	MysqlVersion string `json:"mysqlVersion,omitempty"`
+	// PersistentVolumeClaim is the spec of the MySQL nodes' data volumes.
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
+}
+
+// CDNProvider is the provider of a CDN.
+type CDNProvider string
+
+const (
+	// CDNProviderCloudflare is the Cloudflare CDN.
+	CDNProviderCloudflare CDNProvider = "cloudflare"
+	// CDNProviderFastly is the Fastly CDN.
+	CDNProviderFastly CDNProvider = "fastly"
+	// CDNProviderCloudFront is the Amazon CloudFront CDN.
+	CDNProviderCloudFront CDNProvider = "cloudfront"
+)
+
+// CDNSpec is the desired spec of the site's CDN integration. The runtime
+// gets the CDN's settings through the CDN_PROVIDER, CDN_ZONE and CDN_HOST
+// env vars and its credentials prefixed with CDN_, to purge the content as it
+// gets updated. The operator purges the whole CDN once the web pods are
+// rolled out with a new image or git reference.
+type CDNSpec struct {
+	// Provider of the CDN.
+	// +kubebuilder:validation:Enum=cloudflare;fastly;cloudfront
+	Provider CDNProvider `json:"provider"`
+	// Zone is the Cloudflare zone ID, the Fastly service ID or the CloudFront
+	// distribution ID.
+	Zone string `json:"zone"`
+	// Host is the host the site's assets are rewritten to, if they're served
+	// from another domain than the site's.
+	// +optional
+	Host string `json:"host,omitempty"`
+	// CredentialsSecretRef is a secret holding the API_TOKEN key for
+	// Cloudflare and Fastly, or the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY keys for CloudFront. If not set for CloudFront, the
+	// purge Job authenticates as the pods' service account.
+	// +optional
+	CredentialsSecretRef SecretRef `json:"credentialsSecretRef,omitempty"`
+	// PurgeOnRollout purges the CDN once the web pods are rolled out with a
+	// new image or git reference. Defaults to true.
+	// +optional
+	PurgeOnRollout *bool `json:"purgeOnRollout,omitempty"`
+}
+
+// PHPProcessManager is the process manager of the PHP-FPM pool.
+type PHPProcessManager string
+
+const (
+	// PHPProcessManagerStatic keeps a fixed number of workers.
+	PHPProcessManagerStatic PHPProcessManager = "static"
+	// PHPProcessManagerDynamic adjusts the number of workers to the load.
+	PHPProcessManagerDynamic PHPProcessManager = "dynamic"
+	// PHPProcessManagerOnDemand spawns the workers as requests come in.
+	PHPProcessManagerOnDemand PHPProcessManager = "ondemand"
+)
+
+// PHPSpec is the desired spec of the runtime's PHP settings. The settings
+// are passed to the runtime image through the PHP_* env vars and default to
+// the image's own defaults.
+type PHPSpec struct {
+	// PM is the process manager of the PHP-FPM pool.
+	// +kubebuilder:validation:Enum=static;dynamic;ondemand
+	// +optional
+	PM PHPProcessManager `json:"pm,omitempty"`
+	// MaxChildren is the maximum number of PHP-FPM workers of each web pod.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxChildren *int32 `json:"maxChildren,omitempty"`
+	// MaxRequests is the number of requests each worker serves before being
+	// respawned. 0 means the workers are never respawned.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRequests *int32 `json:"maxRequests,omitempty"`
+	// MemoryLimit is the maximum memory a script may allocate.
+	// +optional
+	MemoryLimit *resource.Quantity `json:"memoryLimit,omitempty"`
+	// UploadMaxFilesize is the maximum size of an uploaded file.
+	// +optional
+	UploadMaxFilesize *resource.Quantity `json:"uploadMaxFilesize,omitempty"`
+	// PostMaxSize is the maximum size of a request's body.
+	// +optional
+	PostMaxSize *resource.Quantity `json:"postMaxSize,omitempty"`
+	// MaxExecutionTime is the maximum time in seconds a script may run.
+	// 0 means scripts may run indefinitely.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxExecutionTime *int32 `json:"maxExecutionTime,omitempty"`
+	// Opcache tunes the PHP OPcache.
+	// +optional
+	Opcache *PHPOpcacheSpec `json:"opcache,omitempty"`
+}
+
+// PHPOpcacheSpec is the desired spec of the PHP OPcache.
+type PHPOpcacheSpec struct {
+	// Memory is the size of the shared memory storing the compiled scripts.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// ValidateTimestamps checks the scripts for updates. Defaults to false
+	// when the code is cloned from git into a read-only volume, as it doesn't
+	// change during the pods' lifetime, and to the image's default otherwise.
+	// +optional
+	ValidateTimestamps *bool `json:"validateTimestamps,omitempty"`
+	// RevalidateFreq is the interval in seconds between the checks for
+	// updates of the scripts.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RevalidateFreq *int32 `json:"revalidateFreq,omitempty"`
+	// Preload is the path of a script preloaded when PHP-FPM starts.
+	// +optional
+	Preload string `json:"preload,omitempty"`
+}
+
+// CacheSpec is the desired spec of the site's object and page caches.
+type CacheSpec struct {
+	// Memcached runs memcached for the site and points the runtime to it
+	// through the MEMCACHED_HOST env var, used by the object cache drop-ins.
+	// +optional
+	Memcached *MemcachedSpec `json:"memcached,omitempty"`
+	// Page runs a caching reverse proxy in front of the web pods and points
+	// the runtime to its purge endpoint through the PAGE_CACHE_PURGE_URL and
+	// PAGE_CACHE_PURGE_TOKEN env vars.
+	// +optional
+	Page *PageCacheSpec `json:"page,omitempty"`
+	// FlushOnRollout flushes the object cache and purges the page cache once
+	// the web pods are rolled out with a new image or git reference, so that
+	// the cached content of the previous code version is not served.
+	// +optional
+	FlushOnRollout bool `json:"flushOnRollout,omitempty"`
+	// Warmup crawls the site's sitemap to populate the caches, on a schedule
+	// and after they are flushed.
+	// +optional
+	Warmup *CacheWarmupSpec `json:"warmup,omitempty"`
+}
+
+// CacheWarmupSpec is the desired spec of the site's cache warmup.
+type CacheWarmupSpec struct {
+	// Schedule of the warmup CronJob, in the cron format. If not set, the
+	// caches are only warmed up after being flushed.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+	// SitemapURL is the URL of the sitemap listing the pages to fetch. Sitemap
+	// indexes are followed one level deep. Defaults to the site's wp-sitemap.xml.
+	// +optional
+	SitemapURL string `json:"sitemapURL,omitempty"`
+	// Concurrency is the number of pages fetched in parallel. Defaults to 4.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Concurrency *int32 `json:"concurrency,omitempty"`
+}
+
+// PageCacheSpec is the desired spec of the site's full-page cache. Varnish
+// caches the anonymous GET and HEAD requests and passes the others, along
+// with the ones of logged in users, to the web pods. Pages are purged by
+// sending PURGE requests with the X-Purge-Token header to the purge URL.
+// The admin pages bypass the cache.
+type PageCacheSpec struct {
+	// Memory is the size of the cache storage. Defaults to 256Mi.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// TTLSeconds is the time pages are cached for, unless the response's
+	// Cache-Control header says otherwise. Defaults to 120.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSeconds *int32 `json:"ttlSeconds,omitempty"`
+	// Image is the Varnish image. Defaults to the operator's Varnish image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Compute resources required by Varnish.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// MemcachedMode defines where memcached runs.
+type MemcachedMode string
+
+const (
+	// MemcachedSidecar runs memcached within each web pod, so each pod has
+	// its own cache. The wp-cli Jobs don't use the cache.
+	MemcachedSidecar MemcachedMode = "sidecar"
+	// MemcachedShared runs memcached as a single pod Deployment shared by all
+	// the site's pods.
+	MemcachedShared MemcachedMode = "shared"
+)
+
+// MemcachedSpec is the desired spec of the site's memcached.
+type MemcachedSpec struct {
+	// Mode defines where memcached runs. Defaults to sidecar.
+	// +kubebuilder:validation:Enum=sidecar;shared
+	// +optional
+	Mode MemcachedMode `json:"mode,omitempty"`
+	// Memory is the memory used for storing items. Defaults to 64Mi.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// Image is the memcached image. Defaults to the operator's memcached image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Compute resources required by memcached.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ServiceMeshSpec is the desired spec for running the site in a service mesh.
+type ServiceMeshSpec struct {
+	// Provider of the service mesh, istio or linkerd.
+	// +kubebuilder:validation:Enum=istio;linkerd
+	Provider ServiceMeshProvider `json:"provider"`
+	// SidecarInjection annotates the web pods for sidecar injection. Job pods
+	// are always opted out, as the sidecar would keep them from completing.
+	// Defaults to true.
+	// +optional
+	SidecarInjection *bool `json:"sidecarInjection,omitempty"`
+	// VirtualService generates an Istio VirtualService and DestinationRule for
+	// the site's routes. Only supported by the istio provider.
+	// +optional
+	VirtualService *VirtualServiceSpec `json:"virtualService,omitempty"`
+}
+
+// VirtualServiceSpec is the desired spec for the site's Istio VirtualService.
+type VirtualServiceSpec struct {
+	// Gateways the VirtualService is bound to (eg. istio-system/ingressgateway).
+	// +kubebuilder:validation:MinItems=1
+	Gateways []string `json:"gateways"`
+}
+
+// AutoscalingSpec is the desired spec for the web pods horizontal autoscaler.
+type AutoscalingSpec struct {
+	// MinReplicas is the lower limit for the number of web pods. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit for the number of web pods.
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+	// TargetCPUUtilizationPercentage is the target average CPU utilization
+	// (represented as a percentage of requested CPU) over all the web pods.
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// TargetMemoryUtilizationPercentage is the target average memory utilization
+	// (represented as a percentage of requested memory) over all the web pods.
+	// +optional
+	TargetMemoryUtilizationPercentage *int32 `json:"targetMemoryUtilizationPercentage,omitempty"`
+	// Metrics contains additional metrics (eg. custom or external metrics)
+	// used to calculate the desired replica count.
+	// +optional
+	Metrics []autoscalingv2beta2.MetricSpec `json:"metrics,omitempty"`
+}
+
+// ScaleToZeroSpec is the desired spec for scaling the site to zero using KEDA.
+type ScaleToZeroSpec struct {
+	// MaxReplicas is the upper limit for the number of web pods. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// CooldownPeriod is the period (in seconds) to wait after the last trigger
+	// reported active before scaling the site to zero.
+	// +optional
+	CooldownPeriod *int32 `json:"cooldownPeriod,omitempty"`
+	// Schedules specifies the time windows in which the site is kept running
+	// (eg. business hours). A KEDA ScaledObject with cron triggers is created
+	// for them.
+	// +optional
+	Schedules []ScaleSchedule `json:"schedules,omitempty"`
+	// HTTP enables waking up the site on incoming traffic, using the KEDA HTTP
+	// add-on. When enabled, the ingress routes traffic through the add-on
+	// interceptor and Schedules are ignored. Only Deployment workloads are supported.
+	// +optional
+	HTTP *HTTPScaleSpec `json:"http,omitempty"`
+}
+
+// ScaleSchedule defines a time window in which the site is kept running.
+type ScaleSchedule struct {
+	// Timezone is the IANA timezone name in which the schedule is evaluated.
+	// Defaults to Etc/UTC.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+	// Start is the cron expression marking the start of the window.
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+	// End is the cron expression marking the end of the window.
+	// +kubebuilder:validation:MinLength=1
+	End string `json:"end"`
+	// Replicas is the number of web pods to run during the window. Defaults to 1.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// HTTPScaleSpec is the desired spec for scaling the site on HTTP traffic.
+type HTTPScaleSpec struct {
+	// TargetPendingRequests is the number of pending requests per web pod
+	// the add-on scales for. Defaults to 100.
+	// +optional
+	TargetPendingRequests *int32 `json:"targetPendingRequests,omitempty"`
+}
+
+// PodDisruptionBudgetSpec is the desired spec for the web pods disruption budget.
+// Only one of MinAvailable and MaxUnavailable can be set.
+type PodDisruptionBudgetSpec struct {
+	// MinAvailable is the number (or percentage) of web pods that must remain
+	// available during voluntary disruptions (eg. node drains).
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number (or percentage) of web pods that can be
+	// unavailable during voluntary disruptions. Takes precedence over MinAvailable.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// GitVolumeSource is the desired spec for git code source.
+type GitVolumeSource struct {
+	// Repository is the git repository for the code
+	Repository string `json:"repository"`
+	// GitRef to clone (can be a branch name, but it should point to a tag or a
+	// commit hash)
+	// +optional
+	GitRef string `json:"reference,omitempty"`
+	// Env defines env variables  which get passed to the git clone container
+	// +optional
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	// EnvFrom defines envFrom which get passed to the git clone container
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// EmptyDir volume to use for git cloning.
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+}
+
+// S3VolumeSource is the desired spec for accessing media files over S3
+// compatible object store.
+type S3VolumeSource struct {
+	// Bucket for storing media files
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+	// PathPrefix is the prefix for media files in bucket
+	PathPrefix string `json:"prefix,omitempty"`
+	// Env variables for accessing S3 bucket. Taken into account are:
+	// ACCESS_KEY, SECRET_ACCESS_KEY
+	// +optional
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+}
+
+// GCSVolumeSource is the desired spec for accessing media files using google
+// cloud storage object store.
+type GCSVolumeSource struct {
+	// Bucket for storing media files
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+	// PathPrefix is the prefix for media files in bucket
+	PathPrefix string `json:"prefix,omitempty"`
+	// Env variables for accessing gcs bucket. Taken into account are:
+	// GOOGLE_APPLICATION_CREDENTIALS_JSON
+	// +optional
+	// +patchMergeKey=name
+	// +patchStrategy=merge
+	Env []corev1.EnvVar `json:"env,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+}
+
+// CodeVolumeSpec is the desired spec for mounting code into the wordpress
+// runtime container.
+type CodeVolumeSpec struct {
+	// Metadata for the media volume. Currently only labels and annotations are set if a PVC is specified
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// ReadOnly specifies if the volume should be mounted read-only inside the
+	// wordpress runtime container
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// MountPath specifies where should the code volume be mounted.
+	// Defaults to /app/web/wp-content
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+	// ContentSubPath specifies where within the code volumes, the WP_CONTENT_DIR is located.
+	// Defaults to: wp-content
+	// +optional
+	ContentSubPath string `json:"contentSubPath,omitempty"`
+	// ConfigSubPath specifies where within the code volumes the config directory is located.
+	// Defaults to: config
+	// +optional
+	ConfigSubPath string `json:"configSubPath,omitempty"`
+	// GitDir specifies the git repo to use for code cloning. It has the highest
+	// level of precedence over EmptyDir, HostPath and PersistentVolumeClaim
+	// +optional
+	GitDir *GitVolumeSource `json:"git,omitempty"`
+	// PersistentVolumeClaim to use if no GitDir is specified
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"persistentVolumeClaim,omitempty"`
+	// HostPath to use if no PersistentVolumeClaim is specified
+	// +optional
+	HostPath *corev1.HostPathVolumeSource `json:"hostPath,omitempty"`
+	// EmptyDir to use if no HostPath is specified
+	// +optional
+	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+}
+
+// MediaVolumeSpec is the desired spec for handling media files at runtime.
+type MediaVolumeSpec struct {
+	// Metadata for the media volume. Currently only labels and annotations are set if a PVC is specified
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// ReadOnly specifies if the volume should be mounted read-only inside the
+	// wordpress runtime container
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// MountPath specifies where should the media volume be mounted.
+	// Defaults to '/uploads' folder within the CodeVolumeSpec.MountPath
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+	// ContentSubPath specifies where within the media volume, the media files are located.
+	// +optional
+	ContentSubPath string `json:"contentSubPath,omitempty"`
+	// S3VolumeSource specifies the S3 object storage configuration for media
+	// files. It has the highest level of precedence over EmptyDir, HostPath
+	// and PersistentVolumeClaim
+	// +optional
+	S3VolumeSource *S3VolumeSource `json:"s3,omitempty"`
+	// GCSVolumeSource specifies the google cloud storage object storage
 	// configuration for media files. It has the highest level of precedence
 	// over EmptyDir, HostPath and PersistentVolumeClaim
 	// +optional
@@ -293,6 +2273,69 @@ type MediaVolumeSpec struct {
 	// EmptyDir to use if no HostPath is specified
 	// +optional
 	EmptyDir *corev1.EmptyDirVolumeSource `json:"emptyDir,omitempty"`
+	// StaticAssets offloads the themes' and plugins' static assets to the S3
+	// or GCS bucket, so that all the replicas serve the same assets from the
+	// bucket or its CDN.
+	// +optional
+	StaticAssets *StaticAssetsSpec `json:"staticAssets,omitempty"`
+	// ImageOptimization losslessly recompresses the JPEG and PNG images of the
+	// media files on a schedule, to cut down their size and egress.
+	// +optional
+	ImageOptimization *ImageOptimizationSpec `json:"imageOptimization,omitempty"`
+}
+
+// ImageOptimizationSpec is the desired spec of the CronJob optimizing the
+// media images with jpegoptim and optipng. The images in S3 or GCS buckets
+// are downloaded, optimized and uploaded back with rclone, while the ones in
+// persistent volume claims or host paths are optimized in place.
+type ImageOptimizationSpec struct {
+	// Schedule of the optimization CronJob, in the cron format.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// MaxAge limits the optimization to the images modified within it, so
+	// that the images of the previous runs aren't processed again. Defaults to
+	// all the images.
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+	// MaxFileSize skips the images larger than it.
+	// +optional
+	MaxFileSize *resource.Quantity `json:"maxFileSize,omitempty"`
+	// Concurrency is the number of images optimized in parallel. Defaults to 2.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Concurrency *int32 `json:"concurrency,omitempty"`
+	// JPEGQuality is the maximum quality of the JPEG images. Images of a higher
+	// quality are recompressed lossily. Defaults to lossless optimization.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	JPEGQuality *int32 `json:"jpegQuality,omitempty"`
+	// Image providing jpegoptim and optipng. If they are missing, they are
+	// installed with apk. Defaults to the operator's --image-optimizer-image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Resources of the optimizer container.
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// StaticAssetsSpec is the desired spec for offloading the static assets. The
+// assets are copied under the static/ prefix of the media bucket, in a
+// directory named after the code version, along with a manifest.json listing
+// them. Once they are copied, their URL is passed to the runtime through the
+// STACK_STATIC_ASSETS_URL env var.
+type StaticAssetsSpec struct {
+	// URL the bucket's static/ prefix is publicly served at, usually by a CDN.
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+	// Paths within wp-content holding the assets. Defaults to themes, plugins
+	// and mu-plugins.
+	// +optional
+	Paths []string `json:"paths,omitempty"`
+	// Extensions of the assets' files. Defaults to the stylesheets, scripts,
+	// images and fonts.
+	// +optional
+	Extensions []string `json:"extensions,omitempty"`
 }
 
 // WordpressBootstrapSpec requires defining at least.
@@ -307,10 +2350,204 @@ type WordpressBootstrapSpec struct {
 	// EnvFrom defines envFrom's which get passed into wordpress bootstrapper
 	// +optional
 	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+	// DatabaseTimeoutSeconds is how long the bootstrap waits for the database
+	// to accept connections before failing. Defaults to 300.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	DatabaseTimeoutSeconds *int32 `json:"databaseTimeoutSeconds,omitempty"`
+	// ImportFrom imports a SQL dump, optionally gzipped, into the database
+	// instead of installing WordPress. The dump is only imported if WordPress
+	// is not installed yet.
+	// +optional
+	ImportFrom *DatabaseImportSource `json:"importFrom,omitempty"`
+	// ContentImport imports a WXR file, with wp import, once WordPress is
+	// installed. The file is only imported once.
+	// +optional
+	ContentImport *ContentImportSpec `json:"contentImport,omitempty"`
+	// RunOnce stops running the bootstrap init containers in the web and job
+	// pods once the site is bootstrapped, as recorded in status.bootstrapped,
+	// to speed up their start.
+	// +optional
+	RunOnce bool `json:"runOnce,omitempty"`
+}
+
+// DatabaseImportSource is the location of a SQL dump. Only one of its fields
+// may be set.
+type DatabaseImportSource struct {
+	// URL is the HTTP(S) URL the dump is downloaded from.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// SecretKeyRef selects the key of a secret holding the dump.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// PersistentVolumeClaim is the volume holding the dump.
+	// +optional
+	PersistentVolumeClaim *DatabaseImportVolumeSource `json:"persistentVolumeClaim,omitempty"`
+	// Backup is the database artifact of a WordpressBackup, downloaded from
+	// the bucket it was uploaded to, e.g. by a recovery bundle.
+	// +optional
+	Backup *BackupImportSource `json:"backup,omitempty"`
+}
+
+// BackupImportSource is the database artifact of a WordpressBackup within
+// the bucket it was uploaded to.
+type BackupImportSource struct {
+	// Destination is the bucket the backup was uploaded to.
+	Destination BackupDestination `json:"destination"`
+	// Path of the database artifact within the destination, e.g.
+	// default/mysite-backups-28000000/database.sql.gz.
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+	// Encryption is the method the artifact is encrypted with, if any.
+	// +kubebuilder:validation:Enum=age;gpg;passphrase
+	// +optional
+	Encryption BackupEncryptionMethod `json:"encryption,omitempty"`
+	// DecryptionKeySecretRef selects the key of a secret holding the age
+	// identity, the GPG private key or the passphrase the artifact is
+	// decrypted with.
+	// +optional
+	DecryptionKeySecretRef *corev1.SecretKeySelector `json:"decryptionKeySecretRef,omitempty"`
+}
+
+// ContentImportSpec is a WXR file imported into the site, along with the way
+// its authors and attachments are imported. Only one of URL and SecretKeyRef
+// may be set.
+type ContentImportSpec struct {
+	// URL is the HTTP(S) URL the WXR file is downloaded from.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// SecretKeyRef selects the key of a secret holding the WXR file.
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// AuthorMapping assigns the posts of the file's authors, by login, to the
+	// site's users, by login. Without it, the file's authors are created.
+	// +optional
+	AuthorMapping map[string]string `json:"authorMapping,omitempty"`
+	// FetchAttachments downloads the file's attachments into the site's media.
+	// Defaults to true.
+	// +optional
+	FetchAttachments *bool `json:"fetchAttachments,omitempty"`
+}
+
+// DatabaseImportVolumeSource is a SQL dump stored within a persistent volume claim.
+type DatabaseImportVolumeSource struct {
+	// ClaimName is the name of the persistent volume claim.
+	ClaimName string `json:"claimName"`
+	// Path is the path of the dump within the volume.
+	Path string `json:"path"`
+}
+
+// CanarySpec is the desired spec for a canary release of the site.
+// To promote the canary, update the site's image or code reference and remove it.
+type CanarySpec struct {
+	// Image is the WordPress runtime image used by the canary pods.
+	// Defaults to the site's image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// GitRef is the git reference cloned by the canary pods, when the code
+	// is deployed from git. Defaults to the site's code reference.
+	// +optional
+	GitRef string `json:"reference,omitempty"`
+	// Replicas is the number of canary pods. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Weight is the percentage of requests routed to the canary pods.
+	// Setting it to 100 switches all the traffic to the canary, blue-green style.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+	// SmokeTest specifies a Job which must succeed against the canary pods
+	// before they receive any traffic.
+	// +optional
+	SmokeTest *SmokeTestSpec `json:"smokeTest,omitempty"`
+}
+
+// SmokeTestSpec is the desired spec for the canary smoke test Job. The
+// CANARY_URL and SITE_URL environment variables are passed to the container.
+type SmokeTestSpec struct {
+	// Image of the smoke test container.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+	// Command of the smoke test container.
+	// +optional
+	Command []string `json:"command,omitempty"`
+	// Args of the smoke test container.
+	// +optional
+	Args []string `json:"args,omitempty"`
+	// BackoffLimit is the number of retries before marking the smoke test
+	// as failed. Defaults to 2.
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+}
+
+// JobPolicySpec overrides the execution policy of the Jobs and the CronJobs
+// created by the operator. The fields left unset keep the defaults of each Job.
+type JobPolicySpec struct {
+	// TTLSecondsAfterFinished is the time after which the finished Jobs are
+	// deleted. It leaves the operator the time to record their results.
+	// +kubebuilder:validation:Minimum=60
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// BackoffLimit is the number of retries before marking a Job as failed.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// ActiveDeadlineSeconds is the time a Job may run for before it's
+	// terminated and marked as failed.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// HistoryLimit is the number of the succeeded and of the failed Jobs kept
+	// by the CronJobs.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	HistoryLimit *int32 `json:"historyLimit,omitempty"`
+	// Lightweight skips the init containers of the Job pods which only the
+	// web pods need: the volumes preparation and the bootstrap. The code is
+	// still cloned when it's pulled from git.
+	// +optional
+	Lightweight bool `json:"lightweight,omitempty"`
+}
+
+// JobPodOverridesSpec overrides the scheduling and the resources of the Job
+// pods, which otherwise inherit the ones of the web pods.
+type JobPodOverridesSpec struct {
+	// If specified, the Job pods' node selector.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// If specified, the Job pods' tolerations.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// If specified, the Job pods' scheduling constraints.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// If specified, the resources required by the wp-cli container of the Job pods.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// CanaryStatus is the observed state of the canary release.
+type CanaryStatus struct {
+	// Revision identifies the canary image, code and smoke test.
+	Revision string `json:"revision"`
+	// AvailableReplicas is the number of available canary pods.
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+	// SmokeTestSucceeded is true when the smoke test Job completed successfully.
+	// +optional
+	SmokeTestSucceeded bool `json:"smokeTestSucceeded,omitempty"`
+	// Weight is the percentage of requests currently routed to the canary pods.
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
 }
 
 // WordpressStatus defines the observed state of Wordpress.
 type WordpressStatus struct {
+	// ObservedGeneration is the generation of the site last reconciled by
+	// the controller, whose conditions reflect it.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// Conditions represents the Wordpress resource conditions list.
 	// +optional
 	Conditions []WordpressCondition `json:"conditions,omitempty"`
@@ -318,6 +2555,334 @@ type WordpressStatus struct {
 	// This is copied over from the deployment object
 	// +optional
 	Replicas int32 `json:"replicas,omitempty"`
+	// Canary is the observed state of the canary release.
+	// +optional
+	Canary *CanaryStatus `json:"canary,omitempty"`
+	// HomeURL is the home URL the site's content refers to. When
+	// SearchReplaceOnDomainChange is set, it is updated once the search-replace
+	// Job completes.
+	// +optional
+	HomeURL string `json:"homeURL,omitempty"`
+	// DatabaseUpgradedFor is the code version (the image and the git
+	// reference) the database was last upgraded for.
+	// +optional
+	DatabaseUpgradedFor string `json:"databaseUpgradedFor,omitempty"`
+	// CDNPurgedFor is the code version (the image and the git reference) the
+	// CDN was last purged for.
+	// +optional
+	CDNPurgedFor string `json:"cdnPurgedFor,omitempty"`
+	// CacheFlushedFor is the code version (the image and the git reference)
+	// the caches were last flushed for.
+	// +optional
+	CacheFlushedFor string `json:"cacheFlushedFor,omitempty"`
+	// StaticAssetsSyncedFor is the code version (the image and the git
+	// reference) the static assets were last offloaded for.
+	// +optional
+	StaticAssetsSyncedFor string `json:"staticAssetsSyncedFor,omitempty"`
+	// PHPVersion is the PHP version of the runtime the web pods are rolled
+	// out with, when it's selected through spec.runtime.
+	// +optional
+	PHPVersion string `json:"phpVersion,omitempty"`
+	// Bootstrapped is set once the web pods are rolled out with the bootstrap
+	// init containers.
+	// +optional
+	Bootstrapped bool `json:"bootstrapped,omitempty"`
+	// PluginsSyncedFor identifies the plugins spec and the code version the
+	// plugins were last synced for.
+	// +optional
+	PluginsSyncedFor string `json:"pluginsSyncedFor,omitempty"`
+	// PluginsDrift lists the differences from the plugins spec found, and
+	// corrected, by the last sync.
+	// +optional
+	PluginsDrift []string `json:"pluginsDrift,omitempty"`
+	// ThemesSyncedFor identifies the themes spec and the code version the
+	// themes were last synced for.
+	// +optional
+	ThemesSyncedFor string `json:"themesSyncedFor,omitempty"`
+	// ThemesDrift lists the differences from the themes spec found, and
+	// corrected, by the last sync.
+	// +optional
+	ThemesDrift []string `json:"themesDrift,omitempty"`
+	// OptionsSyncedFor identifies the options spec and the code version the
+	// options were last synced for.
+	// +optional
+	OptionsSyncedFor string `json:"optionsSyncedFor,omitempty"`
+	// OptionsDrift lists the options found to differ from the spec, and
+	// updated, by the last sync. Their values are not reported.
+	// +optional
+	OptionsDrift []string `json:"optionsDrift,omitempty"`
+	// ImagesVerifiedFor identifies the images and the verification policy the
+	// images' signatures were last verified for.
+	// +optional
+	ImagesVerifiedFor string `json:"imagesVerifiedFor,omitempty"`
+	// UsersSyncedFor identifies the users spec and the code version the users
+	// were last synced for.
+	// +optional
+	UsersSyncedFor string `json:"usersSyncedFor,omitempty"`
+	// UsersDrift lists the differences from the users spec found, and
+	// corrected, by the last sync.
+	// +optional
+	UsersDrift []string `json:"usersDrift,omitempty"`
+	// SMTPTestedFor identifies the SMTP configuration the last test email
+	// was sent with.
+	// +optional
+	SMTPTestedFor string `json:"smtpTestedFor,omitempty"`
+	// DatabaseCredentials is the observed state of the provisioned database's credentials.
+	// +optional
+	DatabaseCredentials *DatabaseCredentialsStatus `json:"databaseCredentials,omitempty"`
+	// DatabaseUsage is the last measured size of the site's database.
+	// +optional
+	DatabaseUsage *DatabaseUsageStatus `json:"databaseUsage,omitempty"`
+	// CertificateNotAfter is the expiration time of the site's cert-manager Certificate.
+	// +optional
+	CertificateNotAfter *metav1.Time `json:"certificateNotAfter,omitempty"`
+	// TLSSecret is the validity of the certificate of TLSSecretRef.
+	// +optional
+	TLSSecret *TLSSecretStatus `json:"tlsSecret,omitempty"`
+	// CoreUpdate is the observed state of the WordPress core updates.
+	// +optional
+	CoreUpdate *CoreUpdateStatus `json:"coreUpdate,omitempty"`
+	// Cleanup is the observed state of the content cleanup.
+	// +optional
+	Cleanup *CleanupStatus `json:"cleanup,omitempty"`
+	// Diagnostics is the result of the last diagnostics run.
+	// +optional
+	Diagnostics *DiagnosticsStatus `json:"diagnostics,omitempty"`
+	// IntegrityCheck is the result of the last integrity check.
+	// +optional
+	IntegrityCheck *IntegrityCheckStatus `json:"integrityCheck,omitempty"`
+	// Inventory is the core, plugin and theme versions found by the last
+	// inventory run.
+	// +optional
+	Inventory *InventoryStatus `json:"inventory,omitempty"`
+	// Backups is the observed state of the scheduled backups.
+	// +optional
+	Backups *BackupsStatus `json:"backups,omitempty"`
+	// Upgrade is the code version the web pods are rolled out with, when the
+	// upgrades are backed up first.
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// CodeVersion is the code version (the image and the git reference) the
+	// web pods were last rolled out with.
+	// +optional
+	CodeVersion string `json:"codeVersion,omitempty"`
+	// History lists the last changes made by the operator to the site, oldest
+	// first, each also recorded as an event.
+	// +optional
+	History []ChangeRecord `json:"history,omitempty"`
+}
+
+// ChangeRecord is a change made by the operator to the site.
+type ChangeRecord struct {
+	// Time of the change.
+	Time metav1.Time `json:"time"`
+	// Reason of the event recorded for the change.
+	Reason string `json:"reason"`
+	// Message describes the change.
+	Message string `json:"message"`
+	// Generation of the site the change was made for, matching the spec
+	// changes found in its managed fields.
+	// +optional
+	Generation int64 `json:"generation,omitempty"`
+}
+
+// UpgradeStatus is the observed state of the upgrades backed up first.
+type UpgradeStatus struct {
+	// Image is the image the web pods are rolled out with.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// GitRef is the git reference the web pods are rolled out with.
+	// +optional
+	GitRef string `json:"reference,omitempty"`
+	// LastBackup is the name of the last backup taken before an upgrade.
+	// +optional
+	LastBackup string `json:"lastBackup,omitempty"`
+}
+
+// DatabaseCredentialsStatus is the observed state of the provisioned database's credentials.
+type DatabaseCredentialsStatus struct {
+	// Generation identifies the credentials in use. It is derived from the
+	// spec.database.rotateCredentials value they were created for.
+	// +optional
+	Generation string `json:"generation,omitempty"`
+	// PreviousGeneration identifies the credentials which are revoked once
+	// the web pods use the current ones.
+	// +optional
+	PreviousGeneration *string `json:"previousGeneration,omitempty"`
+}
+
+// DatabaseUsageStatus is the last measured size of the site's database.
+type DatabaseUsageStatus struct {
+	// Size is the size of the database's tables and indexes.
+	Size resource.Quantity `json:"size"`
+	// MeasuredAt is the time of the measurement.
+	MeasuredAt metav1.Time `json:"measuredAt"`
+}
+
+// TLSSecretStatus is the validity of the certificate of TLSSecretRef.
+type TLSSecretStatus struct {
+	// NotBefore is the time the certificate is valid from.
+	NotBefore metav1.Time `json:"notBefore"`
+	// NotAfter is the expiration time of the certificate.
+	NotAfter metav1.Time `json:"notAfter"`
+}
+
+// CoreUpdateStatus is the observed state of the WordPress core updates.
+type CoreUpdateStatus struct {
+	// Version is the core version found by the last check or update.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// AvailableVersion is the newest version allowed by the policy, or the
+	// newest one when no updates are applied. It's empty when up to date.
+	// +optional
+	AvailableVersion string `json:"availableVersion,omitempty"`
+	// CheckedAt is the time of the last check.
+	// +optional
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
+	// PreviousVersion is the core version before the last update.
+	// +optional
+	PreviousVersion string `json:"previousVersion,omitempty"`
+	// UpdatedAt is the time of the last update.
+	// +optional
+	UpdatedAt *metav1.Time `json:"updatedAt,omitempty"`
+}
+
+// CleanupStatus is the observed state of the content cleanup. The counters
+// add up the items deleted by all the recorded runs.
+type CleanupStatus struct {
+	// LastRunAt is the time the last recorded run finished at.
+	// +optional
+	LastRunAt *metav1.Time `json:"lastRunAt,omitempty"`
+	// LastRunSucceeded is true if the last recorded run succeeded.
+	// +optional
+	LastRunSucceeded bool `json:"lastRunSucceeded,omitempty"`
+	// SpamComments is the number of the deleted spam comments.
+	// +optional
+	SpamComments int64 `json:"spamComments,omitempty"`
+	// ExpiredTransients is the number of the deleted expired transients.
+	// +optional
+	ExpiredTransients int64 `json:"expiredTransients,omitempty"`
+	// OrphanedPostMeta is the number of the deleted orphaned post meta.
+	// +optional
+	OrphanedPostMeta int64 `json:"orphanedPostMeta,omitempty"`
+}
+
+// DiagnosticsStatus is the result of the last diagnostics run.
+type DiagnosticsStatus struct {
+	// CheckedAt is the time the last run finished at.
+	// +optional
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
+	// Checks are the results of the checks of the last run.
+	// +optional
+	Checks []DiagnosticCheckResult `json:"checks,omitempty"`
+}
+
+// IntegrityCheckStatus is the result of the last integrity check.
+type IntegrityCheckStatus struct {
+	// CheckedAt is the time the last check finished at.
+	// +optional
+	CheckedAt *metav1.Time `json:"checkedAt,omitempty"`
+	// ModifiedFiles are the core and plugin files which don't match their
+	// checksums, up to the ones the check could report.
+	// +optional
+	ModifiedFiles []ModifiedFile `json:"modifiedFiles,omitempty"`
+}
+
+// ModifiedFile is a core or plugin file which doesn't match its checksum.
+type ModifiedFile struct {
+	// Component the file belongs to, core or the plugin's name.
+	Component string `json:"component"`
+	// Path of the file, relative to the WordPress root for core files and to
+	// the plugin's directory for plugin files.
+	Path string `json:"path"`
+	// Message details the mismatch, e.g. the file was modified or should not
+	// exist.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// InventoryStatus is the inventory of the installed versions.
+type InventoryStatus struct {
+	// CollectedAt is the time the last inventory finished at.
+	// +optional
+	CollectedAt *metav1.Time `json:"collectedAt,omitempty"`
+	// WordpressVersion is the version of the WordPress core.
+	// +optional
+	WordpressVersion string `json:"wordpressVersion,omitempty"`
+	// Plugins are the installed plugins, including the must-use ones and the
+	// drop-ins.
+	// +optional
+	Plugins []InstalledExtension `json:"plugins,omitempty"`
+	// Themes are the installed themes.
+	// +optional
+	Themes []InstalledExtension `json:"themes,omitempty"`
+}
+
+// InstalledExtension is an installed plugin or theme.
+type InstalledExtension struct {
+	// Name of the plugin or theme, i.e. its slug.
+	Name string `json:"name"`
+	// Version of the plugin or theme, empty if it doesn't declare one.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// Status of the plugin or theme, e.g. active, inactive or must-use.
+	// +optional
+	Status string `json:"status,omitempty"`
+	// UpdateVersion is the version the plugin or theme can be updated to,
+	// empty if it's up to date or not published on wordpress.org.
+	// +optional
+	UpdateVersion string `json:"updateVersion,omitempty"`
+}
+
+// BackupsStatus is the observed state of the scheduled backups.
+type BackupsStatus struct {
+	// LastScheduleTime is the time the last backup was scheduled at.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastBackup is the name of the last scheduled WordpressBackup.
+	// +optional
+	LastBackup string `json:"lastBackup,omitempty"`
+	// Verification is the result of the last backup verification.
+	// +optional
+	Verification *BackupVerificationStatus `json:"verification,omitempty"`
+}
+
+// BackupVerificationStatus is the result of the last backup verification.
+type BackupVerificationStatus struct {
+	// VerifiedAt is the time the last verification finished at.
+	// +optional
+	VerifiedAt *metav1.Time `json:"verifiedAt,omitempty"`
+	// Backup is the name of the last verified WordpressBackup.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+	// Checks are the results of the smoke checks of the restored site.
+	// +optional
+	Checks []BackupVerificationCheckResult `json:"checks,omitempty"`
+}
+
+// BackupVerificationCheckResult is the result of a smoke check of a restored
+// backup: restore, homepage or core-checksums.
+type BackupVerificationCheckResult struct {
+	// Name of the check.
+	Name string `json:"name"`
+	// Passed is true if the restored site passed the check.
+	// +optional
+	Passed bool `json:"passed,omitempty"`
+	// Message details the problems found by the check.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DiagnosticCheckResult is the result of a health check.
+type DiagnosticCheckResult struct {
+	// Name of the check.
+	Name DiagnosticCheck `json:"name"`
+	// Passed is true if the site passed the check.
+	// +optional
+	Passed bool `json:"passed,omitempty"`
+	// Message details the problems found by the check.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +genclient
@@ -329,6 +2894,7 @@ type WordpressStatus struct {
 // +kubebuilder:subresource:status
 // +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas
 // +kubebuilder:printcolumn:name="image",type="string",JSONPath=".spec.image",description="wordpress image"
+// +kubebuilder:printcolumn:name="ready",type="string",JSONPath=".status.conditions[?(@.type == 'Ready')].status",description="whether the site is ready"
 // +kubebuilder:printcolumn:name="wp-cron",type="string",JSONPath=".status.conditions[?(@.type == 'WPCronTriggering')].status",description="wp-cron triggering status"
 type Wordpress struct {
 	metav1.TypeMeta   `json:",inline"`
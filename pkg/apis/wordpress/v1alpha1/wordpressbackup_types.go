@@ -0,0 +1,199 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupArtifactsFinalizer is the finalizer deleting the artifacts of the
+// backups which set deleteArtifacts.
+const BackupArtifactsFinalizer = "wordpress.presslabs.org/backup-artifacts"
+
+const (
+	// BackupSucceededReason is the reason for a backup whose artifacts were all uploaded.
+	BackupSucceededReason = "BackupSucceeded"
+	// BackupFailedReason is the reason for a backup whose Jobs failed.
+	BackupFailedReason = "BackupFailed"
+	// BackupScheduledReason is the reason for a WordpressBackup created by the schedule.
+	BackupScheduledReason = "BackupScheduled"
+	// BackupPrunedReason is the reason for a WordpressBackup pruned by the retention policy.
+	BackupPrunedReason = "BackupPruned"
+	// BackupPurgeFailedReason is the reason for a backup whose artifacts failed to be deleted.
+	BackupPurgeFailedReason = "BackupPurgeFailed"
+)
+
+// WordpressBackupSpec defines the desired state of WordpressBackup.
+type WordpressBackupSpec struct {
+	// SiteRef is the Wordpress, in the backup's namespace, which is backed up.
+	SiteRef corev1.LocalObjectReference `json:"siteRef"`
+	// Destination is the bucket the artifacts are uploaded to, under a prefix
+	// named after the backup. The backup is taken once, so changing it has no
+	// effect.
+	Destination BackupDestination `json:"destination"`
+	// IncludeDatabase backs up the site's database. Defaults to true.
+	// +optional
+	IncludeDatabase *bool `json:"includeDatabase,omitempty"`
+	// IncludeMedia backs up the site's media, from its persistent volume or
+	// bucket. Defaults to true.
+	// +optional
+	IncludeMedia *bool `json:"includeMedia,omitempty"`
+	// DeleteArtifacts deletes the uploaded artifacts from the destination
+	// when the backup is deleted.
+	// +optional
+	DeleteArtifacts bool `json:"deleteArtifacts,omitempty"`
+	// Encryption encrypts the artifacts before they're uploaded.
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+}
+
+// BackupDestination is the bucket the backups are uploaded to. Either S3 or
+// GCS must be set.
+type BackupDestination struct {
+	// S3 is an S3 bucket, along with the env variables holding its credentials.
+	// +optional
+	S3 *S3VolumeSource `json:"s3,omitempty"`
+	// GCS is a Google Cloud Storage bucket, along with the env variables
+	// holding its credentials.
+	// +optional
+	GCS *GCSVolumeSource `json:"gcs,omitempty"`
+}
+
+// BackupEncryption encrypts the backup's artifacts. Exactly one of
+// Recipients, GPGPublicKeySecretRef and PassphraseSecretRef must be set.
+type BackupEncryption struct {
+	// Recipients are the age public keys, or the SSH public keys, the
+	// artifacts are encrypted to, with age.
+	// +optional
+	Recipients []string `json:"recipients,omitempty"`
+	// GPGPublicKeySecretRef selects the armored GPG public key the artifacts
+	// are encrypted to.
+	// +optional
+	GPGPublicKeySecretRef *corev1.SecretKeySelector `json:"gpgPublicKeySecretRef,omitempty"`
+	// PassphraseSecretRef selects the passphrase the artifacts are
+	// symmetrically encrypted with, by GPG (AES-256).
+	// +optional
+	PassphraseSecretRef *corev1.SecretKeySelector `json:"passphraseSecretRef,omitempty"`
+}
+
+// BackupEncryptionMethod is the method an artifact is encrypted with.
+type BackupEncryptionMethod string
+
+const (
+	// BackupEncryptionAge means the artifact is encrypted to age recipients.
+	BackupEncryptionAge BackupEncryptionMethod = "age"
+	// BackupEncryptionGPG means the artifact is encrypted to a GPG public key.
+	BackupEncryptionGPG BackupEncryptionMethod = "gpg"
+	// BackupEncryptionPassphrase means the artifact is encrypted with a passphrase, by GPG.
+	BackupEncryptionPassphrase BackupEncryptionMethod = "passphrase"
+)
+
+// WordpressBackupPhase is the phase of a backup.
+type WordpressBackupPhase string
+
+const (
+	// BackupPending means the backup's Jobs are not created yet, eg. as the
+	// Wordpress doesn't exist.
+	BackupPending WordpressBackupPhase = "Pending"
+	// BackupRunning means the backup's Jobs are running.
+	BackupRunning WordpressBackupPhase = "Running"
+	// BackupSucceeded means all the artifacts were uploaded.
+	BackupSucceeded WordpressBackupPhase = "Succeeded"
+	// BackupFailed means one of the backup's Jobs failed.
+	BackupFailed WordpressBackupPhase = "Failed"
+)
+
+// BackupArtifact is an artifact uploaded by a backup.
+type BackupArtifact struct {
+	// Name of the artifact, database or media.
+	Name string `json:"name"`
+	// Location is the URL of the artifact (eg. s3://bucket/prefix/database.sql.gz).
+	Location string `json:"location"`
+	// Size of the artifact, in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+	// Objects is the number of the objects the artifact consists of.
+	// +optional
+	Objects int64 `json:"objects,omitempty"`
+	// Checksum is the SHA-256 checksum of a single object artifact.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+	// Encryption describes how the artifact is encrypted, so that a restore
+	// can check it holds a key to decrypt it before downloading it.
+	// +optional
+	Encryption *BackupArtifactEncryption `json:"encryption,omitempty"`
+}
+
+// BackupArtifactEncryption describes how an artifact is encrypted.
+type BackupArtifactEncryption struct {
+	// Method the artifact is encrypted with.
+	Method BackupEncryptionMethod `json:"method"`
+	// Keys are the age recipients or the fingerprint of the GPG public key
+	// the artifact is encrypted to. It's empty for a passphrase.
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+}
+
+// WordpressBackupStatus defines the observed state of WordpressBackup.
+type WordpressBackupStatus struct {
+	// Phase of the backup.
+	// +optional
+	Phase WordpressBackupPhase `json:"phase,omitempty"`
+	// Message is a human readable message about the backup's phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is the time the backup's Jobs were created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the backup finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Artifacts are the artifacts uploaded by the backup.
+	// +optional
+	Artifacts []BackupArtifact `json:"artifacts,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressBackup backs up the database and the media of a Wordpress site to a bucket.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=wpbackup
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="site",type="string",JSONPath=".spec.siteRef.name",description="wordpress site"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="backup phase"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type WordpressBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WordpressBackupSpec   `json:"spec,omitempty"`
+	Status WordpressBackupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressBackupList contains a list of WordpressBackup.
+type WordpressBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WordpressBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WordpressBackup{}, &WordpressBackupList{})
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CloneAnnotation is set on the Wordpress created by a clone, to the clone's name.
+const CloneAnnotation = "wordpress.presslabs.org/clone"
+
+const (
+	// CloneSucceededReason is the reason for a clone which is bootstrapped.
+	CloneSucceededReason = "CloneSucceeded"
+	// CloneFailedReason is the reason for a clone which can't be completed.
+	CloneFailedReason = "CloneFailed"
+)
+
+// WordpressCloneSpec defines the desired state of WordpressClone.
+type WordpressCloneSpec struct {
+	// SourceRef is the Wordpress, in the clone's namespace, which is cloned.
+	SourceRef corev1.LocalObjectReference `json:"sourceRef"`
+	// TargetName is the name of the Wordpress created by the clone.
+	// +kubebuilder:validation:MinLength=1
+	TargetName string `json:"targetName"`
+	// Routes of the target, replacing the source's. The source's home URL is
+	// replaced with the target's within the copied database.
+	// +kubebuilder:validation:MinItems=1
+	Routes []RouteSpec `json:"routes"`
+	// Database overrides the target's database spec. It's required when the
+	// source's database is external and it's not bootstrapped by the operator,
+	// as the target would share it otherwise.
+	// +optional
+	Database *DatabaseSpec `json:"database,omitempty"`
+	// MediaPrefix is the prefix of the target's media within the source's
+	// media bucket. Defaults to the target's name.
+	// +optional
+	MediaPrefix string `json:"mediaPrefix,omitempty"`
+	// DumpVolumeClaim is the spec of the claim holding the database dump until
+	// the target is bootstrapped. Defaults to a 10Gi ReadWriteOnce claim.
+	// +optional
+	DumpVolumeClaim *corev1.PersistentVolumeClaimSpec `json:"dumpVolumeClaim,omitempty"`
+}
+
+// WordpressClonePhase is the phase of a clone.
+type WordpressClonePhase string
+
+const (
+	// ClonePending means the source Wordpress doesn't exist.
+	ClonePending WordpressClonePhase = "Pending"
+	// CloneRunning means the source is being copied or the target is being
+	// bootstrapped.
+	CloneRunning WordpressClonePhase = "Running"
+	// CloneSucceeded means the target is bootstrapped.
+	CloneSucceeded WordpressClonePhase = "Succeeded"
+	// CloneFailed means the clone can't be completed.
+	CloneFailed WordpressClonePhase = "Failed"
+)
+
+// WordpressCloneStatus defines the observed state of WordpressClone.
+type WordpressCloneStatus struct {
+	// Phase of the clone.
+	// +optional
+	Phase WordpressClonePhase `json:"phase,omitempty"`
+	// Message is a human readable message about the clone's phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is the time the clone's Jobs were created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the clone finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressClone copies the code, the media and the database of a Wordpress
+// site into a new one, e.g. to stage changes.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=wpclone
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="source",type="string",JSONPath=".spec.sourceRef.name",description="source wordpress site"
+// +kubebuilder:printcolumn:name="target",type="string",JSONPath=".spec.targetName",description="target wordpress site"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="clone phase"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type WordpressClone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WordpressCloneSpec   `json:"spec,omitempty"`
+	Status WordpressCloneStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressCloneList contains a list of WordpressClone.
+type WordpressCloneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WordpressClone `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WordpressClone{}, &WordpressCloneList{})
+}
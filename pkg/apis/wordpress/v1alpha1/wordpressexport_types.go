@@ -0,0 +1,135 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ExportSucceededReason is the reason for an export whose files were all uploaded.
+	ExportSucceededReason = "ExportSucceeded"
+	// ExportFailedReason is the reason for an export whose Job failed.
+	ExportFailedReason = "ExportFailed"
+)
+
+// WordpressExportSpec defines the desired state of WordpressExport.
+type WordpressExportSpec struct {
+	// SiteRef is the Wordpress, in the export's namespace, which is exported.
+	SiteRef corev1.LocalObjectReference `json:"siteRef"`
+	// Destination is the bucket the WXR files are uploaded to, under a prefix
+	// named after the export. Defaults to the site's media bucket, under the
+	// exports prefix.
+	// +optional
+	Destination *BackupDestination `json:"destination,omitempty"`
+	// PostTypes limits the export to the given post types. Defaults to all.
+	// +optional
+	PostTypes []string `json:"postTypes,omitempty"`
+	// StartDate limits the export to the posts published on or after it, as
+	// YYYY-MM-DD.
+	// +kubebuilder:validation:Pattern=`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+	// +optional
+	StartDate string `json:"startDate,omitempty"`
+	// EndDate limits the export to the posts published on or before it, as
+	// YYYY-MM-DD.
+	// +kubebuilder:validation:Pattern=`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`
+	// +optional
+	EndDate string `json:"endDate,omitempty"`
+	// Author limits the export to the posts of an author, by its login, ID or email.
+	// +optional
+	Author string `json:"author,omitempty"`
+	// SkipComments leaves the comments out of the export.
+	// +optional
+	SkipComments bool `json:"skipComments,omitempty"`
+	// MaxFileSizeMB splits the export into files of at most this size, in MB.
+	// Defaults to 15.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxFileSizeMB *int32 `json:"maxFileSizeMB,omitempty"`
+}
+
+// WordpressExportPhase is the phase of an export.
+type WordpressExportPhase string
+
+const (
+	// ExportPending means the export's Job is not created yet, eg. as the
+	// Wordpress doesn't exist.
+	ExportPending WordpressExportPhase = "Pending"
+	// ExportRunning means the export's Job is running.
+	ExportRunning WordpressExportPhase = "Running"
+	// ExportSucceeded means the WXR files were uploaded.
+	ExportSucceeded WordpressExportPhase = "Succeeded"
+	// ExportFailed means the export's Job failed.
+	ExportFailed WordpressExportPhase = "Failed"
+)
+
+// WordpressExportStatus defines the observed state of WordpressExport.
+type WordpressExportStatus struct {
+	// Phase of the export.
+	// +optional
+	Phase WordpressExportPhase `json:"phase,omitempty"`
+	// Message is a human readable message about the export's phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is the time the export's Job was created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the export finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Location is the URL of the prefix the WXR files were uploaded to.
+	// +optional
+	Location string `json:"location,omitempty"`
+	// Files is the number of the uploaded WXR files.
+	// +optional
+	Files int64 `json:"files,omitempty"`
+	// Size of the uploaded WXR files, in bytes.
+	// +optional
+	Size int64 `json:"size,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressExport exports the content of a Wordpress site as WXR files, with
+// wp export, to a bucket.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=wpexport
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="site",type="string",JSONPath=".spec.siteRef.name",description="wordpress site"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="export phase"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type WordpressExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WordpressExportSpec   `json:"spec,omitempty"`
+	Status WordpressExportStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressExportList contains a list of WordpressExport.
+type WordpressExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WordpressExport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WordpressExport{}, &WordpressExportList{})
+}
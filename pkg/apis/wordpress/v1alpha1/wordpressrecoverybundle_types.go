@@ -0,0 +1,116 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecoveredFromAnnotation records, on the Wordpress of a recovery bundle, the
+// namespace and the name of the WordpressBackup it's recovered from.
+const RecoveredFromAnnotation = "wordpress.presslabs.org/recovered-from"
+
+const (
+	// RecoveryBundleCreatedReason is the reason for a recovery bundle whose ConfigMap was created.
+	RecoveryBundleCreatedReason = "RecoveryBundleCreated"
+	// RecoveryBundleFailedReason is the reason for a recovery bundle which can't be created.
+	RecoveryBundleFailedReason = "RecoveryBundleFailed"
+)
+
+// WordpressRecoveryBundleSpec defines the desired state of WordpressRecoveryBundle.
+type WordpressRecoveryBundleSpec struct {
+	// SiteRef is the Wordpress, in the bundle's namespace, which is bundled.
+	SiteRef corev1.LocalObjectReference `json:"siteRef"`
+	// Backup is the succeeded WordpressBackup of the site whose database the
+	// recovered site is bootstrapped from. Defaults to the site's last
+	// succeeded scheduled backup.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+	// DecryptionKeySecretRef selects, in the cluster the bundle is applied
+	// to, the key of a secret holding the age identity or the GPG private key
+	// the backup is decrypted with. The backups encrypted with a passphrase
+	// are decrypted with it.
+	// +optional
+	DecryptionKeySecretRef *corev1.SecretKeySelector `json:"decryptionKeySecretRef,omitempty"`
+}
+
+// WordpressRecoveryBundlePhase is the phase of a recovery bundle.
+type WordpressRecoveryBundlePhase string
+
+const (
+	// RecoveryBundlePending means the bundle waits for the Wordpress or for
+	// its backup to succeed.
+	RecoveryBundlePending WordpressRecoveryBundlePhase = "Pending"
+	// RecoveryBundleSucceeded means the bundle's ConfigMap was created.
+	RecoveryBundleSucceeded WordpressRecoveryBundlePhase = "Succeeded"
+	// RecoveryBundleFailed means the bundle can't be created, e.g. as its
+	// backup failed.
+	RecoveryBundleFailed WordpressRecoveryBundlePhase = "Failed"
+)
+
+// WordpressRecoveryBundleStatus defines the observed state of WordpressRecoveryBundle.
+type WordpressRecoveryBundleStatus struct {
+	// Phase of the bundle.
+	// +optional
+	Phase WordpressRecoveryBundlePhase `json:"phase,omitempty"`
+	// Message is a human readable message about the bundle's phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Backup is the name of the bundled WordpressBackup.
+	// +optional
+	Backup string `json:"backup,omitempty"`
+	// ConfigMapName is the name of the ConfigMap holding the bundle.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// CompletionTime is the time the bundle was created.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressRecoveryBundle packages the manifest of a Wordpress, bootstrapped
+// from the database of one of its backups, along with the backup's artifacts,
+// into a ConfigMap which can be applied to another cluster.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=wprecovery
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="site",type="string",JSONPath=".spec.siteRef.name",description="wordpress site"
+// +kubebuilder:printcolumn:name="backup",type="string",JSONPath=".status.backup",description="bundled backup"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="bundle phase"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type WordpressRecoveryBundle struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WordpressRecoveryBundleSpec   `json:"spec,omitempty"`
+	Status WordpressRecoveryBundleStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WordpressRecoveryBundleList contains a list of WordpressRecoveryBundle.
+type WordpressRecoveryBundleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WordpressRecoveryBundle `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WordpressRecoveryBundle{}, &WordpressRecoveryBundleList{})
+}
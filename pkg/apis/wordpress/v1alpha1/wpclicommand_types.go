@@ -0,0 +1,108 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WPCliCommandSpec defines the desired state of WPCliCommand.
+type WPCliCommandSpec struct {
+	// SiteRef is the Wordpress, in the command's namespace, the command is run against.
+	SiteRef corev1.LocalObjectReference `json:"siteRef"`
+	// Args are the wp-cli arguments, without the leading wp (eg. [plugin, list]).
+	// The command is run once, so changing them has no effect.
+	// +kubebuilder:validation:MinItems=1
+	Args []string `json:"args"`
+	// TTLSecondsAfterFinished is the time after which the finished command is
+	// deleted, along with its Job. If not set, the command is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// WPCliCommandPhase is the phase of a wp-cli command.
+type WPCliCommandPhase string
+
+const (
+	// WPCliCommandPending means the command's Job is not created yet, eg. as
+	// the Wordpress doesn't exist.
+	WPCliCommandPending WPCliCommandPhase = "Pending"
+	// WPCliCommandRunning means the command's Job is running.
+	WPCliCommandRunning WPCliCommandPhase = "Running"
+	// WPCliCommandSucceeded means the command exited with zero.
+	WPCliCommandSucceeded WPCliCommandPhase = "Succeeded"
+	// WPCliCommandFailed means the command exited with non-zero or its Job failed.
+	WPCliCommandFailed WPCliCommandPhase = "Failed"
+)
+
+// WPCliCommandStatus defines the observed state of WPCliCommand.
+type WPCliCommandStatus struct {
+	// Phase of the command.
+	// +optional
+	Phase WPCliCommandPhase `json:"phase,omitempty"`
+	// Message is a human readable message about the command's phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// JobName is the name of the Job running the command.
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+	// StartTime is the time the command's Job was created.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the command finished.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// ExitCode of the command.
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Output is the tail of the command's output, up to 4KiB.
+	// +optional
+	Output string `json:"output,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WPCliCommand runs a wp-cli command against a Wordpress site, in a Job.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=wpcli
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="site",type="string",JSONPath=".spec.siteRef.name",description="wordpress site"
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="command phase"
+// +kubebuilder:printcolumn:name="exit-code",type="integer",JSONPath=".status.exitCode",description="command exit code"
+// +kubebuilder:printcolumn:name="age",type="date",JSONPath=".metadata.creationTimestamp"
+type WPCliCommand struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WPCliCommandSpec   `json:"spec,omitempty"`
+	Status WPCliCommandStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WPCliCommandList contains a list of WPCliCommand.
+type WPCliCommandList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WPCliCommand `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WPCliCommand{}, &WPCliCommandList{})
+}
@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -30,12 +31,95 @@ var (
 	// GitCloneImage is the image used by the init container that clones the code.
 	GitCloneImage = "docker.io/library/buildpack-deps:stretch-scm"
 
+	// MysqlClientImage is the image used by the jobs which bootstrap external databases.
+	MysqlClientImage = "docker.io/library/mysql:8.0"
+
+	// BackupVerificationDatabaseImage is the image of the throwaway database the backups are restored into.
+	BackupVerificationDatabaseImage = "docker.io/library/mysql:8.0"
+
+	// ProxySQLImage is the image of the sidecar pooling the database connections.
+	ProxySQLImage = "docker.io/proxysql/proxysql:2.3.2"
+
+	// CloudSQLProxyImage is the image of the sidecar connecting to Cloud SQL instances.
+	CloudSQLProxyImage = "gcr.io/cloud-sql-connectors/cloud-sql-proxy:2.1.2"
+
+	// MemcachedImage is the image used for caching objects in memcached.
+	MemcachedImage = "docker.io/library/memcached:1.6.12-alpine"
+
+	// VarnishImage is the image of the full-page cache.
+	VarnishImage = "docker.io/library/varnish:7.0.2"
+
+	// WAFImage is the image of the ModSecurity sidecar protecting the sites.
+	WAFImage = "docker.io/owasp/modsecurity-crs:3.3-nginx"
+
+	// PHPFPMExporterImage is the image of the sidecar exporting the PHP-FPM metrics of the sites.
+	PHPFPMExporterImage = "docker.io/hipages/php-fpm_exporter:2.2.0"
+
+	// ApacheExporterImage is the image of the sidecar exporting the Apache metrics of the sites.
+	ApacheExporterImage = "quay.io/prometheuscommunity/apache-exporter:v1.0.1"
+
+	// NginxExporterImage is the image of the sidecar exporting the nginx metrics of the sites.
+	NginxExporterImage = "docker.io/nginx/nginx-prometheus-exporter:0.11.0"
+
+	// LogShipperImage is the image of the fluent-bit sidecar shipping the logs of the sites.
+	LogShipperImage = "docker.io/fluent/fluent-bit:2.1.8"
+
+	// AWSCLIImage is the image used for invalidating the CloudFront distributions.
+	AWSCLIImage = "docker.io/amazon/aws-cli:2.4.6"
+
+	// RcloneImage is the image used for offloading the static assets.
+	RcloneImage = "docker.io/rclone/rclone:1.62.2"
+
+	// ImageOptimizerImage is the image used for optimizing the media images.
+	ImageOptimizerImage = "docker.io/library/alpine:3.15"
+
+	// CosignImage is the image used for verifying the signatures of the sites' images.
+	CosignImage = "gcr.io/projectsigstore/cosign:v2.2.4"
+
 	// WordpressRuntimeImage is the base image used to run your code.
 	WordpressRuntimeImage = "docker.io/bitpoke/wordpress-runtime:5.8.2"
 
 	// IngressClass is the default ingress class used used for creating WordPress ingresses.
 	IngressClass = ""
 
+	// KEDAHTTPInterceptorService is the address of the KEDA HTTP add-on interceptor proxy service.
+	KEDAHTTPInterceptorService = "keda-add-ons-http-interceptor-proxy.keda.svc.cluster.local"
+
+	// KEDAHTTPInterceptorPort is the port of the KEDA HTTP add-on interceptor proxy service.
+	KEDAHTTPInterceptorPort = 8080
+
+	// ProbeProberURL is the default address of the blackbox exporter probing the domains of the
+	// monitored sites. The sites' Probes are not created if it's not set, unless they set their own.
+	ProbeProberURL = ""
+
+	// ProbeModule is the default blackbox exporter module the domains of the monitored sites are probed with.
+	ProbeModule = "http_2xx"
+
+	// BackupGCInterval is the interval at which the orphaned backup artifacts are deleted from the
+	// backups' destinations. It can be set to 0 to disable the garbage collection.
+	BackupGCInterval time.Duration
+
+	// BackupGCGracePeriod is the time the orphaned backup artifacts are kept for, since they were last written.
+	BackupGCGracePeriod = 24 * time.Hour
+
+	// CertificateExpiryWarning is how long before the expiration of the sites' TLS secrets' certificates
+	// they're reported as expiring soon.
+	CertificateExpiryWarning = 14 * 24 * time.Hour
+
+	// VerifyImageSignatures requires the cosign signatures of the runtime, git clone and rclone images
+	// of all the sites to be verified before their pods are rendered with them.
+	VerifyImageSignatures = false
+
+	// ImageSignatureKey is the default cosign key reference the images' signatures are verified with.
+	ImageSignatureKey = ""
+
+	// ImageSignatureIdentity is the default regular expression the identity of the keyless signatures'
+	// certificates must match.
+	ImageSignatureIdentity = ""
+
+	// ImageSignatureOIDCIssuer is the default OIDC issuer of the keyless signatures' certificates.
+	ImageSignatureOIDCIssuer = ""
+
 	// LeaderElection determines whether or not to use leader election when starting the manager.
 	LeaderElection = false
 
@@ -52,6 +136,16 @@ var (
 	// HealthProbeBindAddress is the TCP address that the controller should bind to for serving health probes.
 	HealthProbeBindAddress = ":8081"
 
+	// TracingOTLPEndpoint is the address of the OTLP gRPC collector the reconciles' spans are exported to.
+	// The tracing is disabled if it's not set.
+	TracingOTLPEndpoint = ""
+
+	// TracingOTLPInsecure disables the TLS of the connection to the OTLP collector.
+	TracingOTLPInsecure = false
+
+	// TracingSampleRatio is the ratio of the reconciles which are traced.
+	TracingSampleRatio = 1.0
+
 	// WatchNamespace sets the Namespace field, which restricts the manager's cache to watch objects in the desired namespace.
 	WatchNamespace = os.Getenv("WATCH_NAMESPACE")
 )
@@ -77,12 +171,47 @@ func namespace() string {
 // AddToFlagSet set command line arguments.
 func AddToFlagSet(flag *pflag.FlagSet) {
 	flag.StringVar(&GitCloneImage, "git-clone-image", GitCloneImage, "The image used when cloning code from git.")
+	flag.StringVar(&MysqlClientImage, "mysql-client-image", MysqlClientImage, "The image used when bootstrapping external databases.")
+	flag.StringVar(&BackupVerificationDatabaseImage, "backup-verification-database-image", BackupVerificationDatabaseImage, "The image of the throwaway database the backups are restored into.")
+	flag.StringVar(&ProxySQLImage, "proxysql-image", ProxySQLImage, "The image used for pooling database connections.")
+	flag.StringVar(&CloudSQLProxyImage, "cloudsql-proxy-image", CloudSQLProxyImage, "The image used for connecting to Cloud SQL instances.")
+	flag.StringVar(&MemcachedImage, "memcached-image", MemcachedImage, "The image used for caching objects in memcached.")
+	flag.StringVar(&VarnishImage, "varnish-image", VarnishImage, "The image used for the full-page cache.")
+	flag.StringVar(&WAFImage, "waf-image", WAFImage, "The image of the ModSecurity sidecar protecting the sites.")
+	flag.StringVar(&PHPFPMExporterImage, "php-fpm-exporter-image", PHPFPMExporterImage, "The image of the sidecar exporting the PHP-FPM metrics of the sites.")
+	flag.StringVar(&ApacheExporterImage, "apache-exporter-image", ApacheExporterImage, "The image of the sidecar exporting the Apache metrics of the sites.")
+	flag.StringVar(&NginxExporterImage, "nginx-exporter-image", NginxExporterImage, "The image of the sidecar exporting the nginx metrics of the sites.")
+	flag.StringVar(&LogShipperImage, "log-shipper-image", LogShipperImage, "The image of the fluent-bit sidecar shipping the logs of the sites.")
+	flag.StringVar(&AWSCLIImage, "aws-cli-image", AWSCLIImage, "The image used for invalidating CloudFront distributions.")
+	flag.StringVar(&RcloneImage, "rclone-image", RcloneImage, "The image used for offloading the static assets.")
+	flag.StringVar(&ImageOptimizerImage, "image-optimizer-image", ImageOptimizerImage, "The image used for optimizing the media images.")
+	flag.StringVar(&CosignImage, "cosign-image", CosignImage, "The image used for verifying the signatures of the sites' images.")
 	flag.StringVar(&WordpressRuntimeImage, "wordpress-runtime-image", WordpressRuntimeImage, "The base image used for Wordpress.")
 	flag.StringVar(&IngressClass, "ingress-class", IngressClass, "The default ingress class for WordPress sites.")
+	flag.StringVar(&KEDAHTTPInterceptorService, "keda-http-interceptor-service", KEDAHTTPInterceptorService, "The address of the KEDA HTTP add-on interceptor proxy service.")
+	flag.IntVar(&KEDAHTTPInterceptorPort, "keda-http-interceptor-port", KEDAHTTPInterceptorPort, "The port of the KEDA HTTP add-on interceptor proxy service.")
+	flag.StringVar(&ProbeProberURL, "probe-prober-url", ProbeProberURL, "The default address of the blackbox exporter probing the domains of the monitored sites.")
+	flag.StringVar(&ProbeModule, "probe-module", ProbeModule, "The default blackbox exporter module the domains of the monitored sites are probed with.")
+	flag.DurationVar(&BackupGCInterval, "backup-gc-interval", BackupGCInterval, "The interval at which the orphaned backup artifacts are deleted."+
+		" It can be set to 0 to disable the garbage collection.")
+	flag.DurationVar(&BackupGCGracePeriod, "backup-gc-grace-period", BackupGCGracePeriod, "The time the orphaned backup artifacts are kept for, since they were last written.")
+	flag.DurationVar(&CertificateExpiryWarning, "certificate-expiry-warning", CertificateExpiryWarning,
+		"How long before their expiration the certificates of the sites' TLS secrets are reported as expiring soon.")
+	flag.BoolVar(&VerifyImageSignatures, "verify-image-signatures", VerifyImageSignatures,
+		"Verify the cosign signatures of the images of all the sites before rolling them out.")
+	flag.StringVar(&ImageSignatureKey, "image-signature-key", ImageSignatureKey, "The default cosign key reference the images' signatures are verified with.")
+	flag.StringVar(&ImageSignatureIdentity, "image-signature-identity", ImageSignatureIdentity,
+		"The default regular expression the identity of the keyless signatures' certificates must match.")
+	flag.StringVar(&ImageSignatureOIDCIssuer, "image-signature-oidc-issuer", ImageSignatureOIDCIssuer,
+		"The default OIDC issuer of the keyless signatures' certificates.")
 	flag.BoolVar(&LeaderElection, "leader-election", LeaderElection, "Enables or disables controller leader election.")
 	flag.StringVar(&LeaderElectionNamespace, "leader-election-namespace", LeaderElectionNamespace, "The namespace in which the leader election resource will be created.")
 	flag.StringVar(&LeaderElectionID, "leader-election-id", LeaderElectionID, "The name of the resource that leader election will use for holding the leader lock.")
 	flag.StringVar(&MetricsBindAddress, "metrics-addr", MetricsBindAddress, "The TCP address that the controller should bind to for serving prometheus metrics."+
 		" It can be set to \"0\" to disable the metrics serving.")
 	flag.StringVar(&HealthProbeBindAddress, "healthz-addr", HealthProbeBindAddress, "The TCP address that the controller should bind to for serving health probes.")
+	flag.StringVar(&TracingOTLPEndpoint, "tracing-otlp-endpoint", TracingOTLPEndpoint, "The address of the OTLP gRPC collector the reconciles' spans are exported to."+
+		" The tracing is disabled if it's not set.")
+	flag.BoolVar(&TracingOTLPInsecure, "tracing-otlp-insecure", TracingOTLPInsecure, "Disables the TLS of the connection to the OTLP collector.")
+	flag.Float64Var(&TracingSampleRatio, "tracing-sample-ratio", TracingSampleRatio, "The ratio of the reconciles which are traced, between 0 and 1.")
 }
@@ -0,0 +1,240 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupgc
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	collectorName = "backup-gc"
+
+	destinationLabel = "wordpress.presslabs.org/backup-destination"
+
+	jobTTLSeconds = int32(86400)
+)
+
+var controllerLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+	"app.kubernetes.io/component":  collectorName,
+}
+
+// Add adds the collector of the orphaned backup artifacts to the Manager, if
+// the garbage collection is enabled. It runs only on the leader.
+func Add(mgr manager.Manager) error {
+	if options.BackupGCInterval <= 0 {
+		return nil
+	}
+
+	return mgr.Add(&collector{
+		Client:   mgr.GetClient(),
+		log:      logf.Log.WithName(collectorName),
+		interval: options.BackupGCInterval,
+		grace:    options.BackupGCGracePeriod,
+	})
+}
+
+// collector deletes, from the backups' destinations, the artifacts of the
+// backups which don't exist anymore, e.g. as they were deleted along with
+// their namespace, or without deleting their artifacts.
+type collector struct {
+	client.Client
+	log      logr.Logger
+	interval time.Duration
+	grace    time.Duration
+}
+
+// destination is a backups destination, along with the namespace whose
+// credentials it's reached with and the backups uploaded to it.
+type destination struct {
+	spec      *wordpressv1alpha1.BackupDestination
+	namespace string
+	keep      []string
+}
+
+type destinations map[string]*destination
+
+// add adds a destination referenced from the namespace. The destination is
+// reached with the credentials of the first namespace referencing it.
+func (ds destinations) add(namespace string, spec *wordpressv1alpha1.BackupDestination) *destination {
+	url := wordpress.BackupDestinationURL(spec)
+
+	d, ok := ds[url]
+	if !ok {
+		d = &destination{}
+		ds[url] = d
+	}
+
+	if d.spec == nil || namespace < d.namespace {
+		d.spec, d.namespace = spec, namespace
+	}
+
+	return d
+}
+
+// Start runs the garbage collection at the interval, until the context is
+// done.
+func (c *collector) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := c.collect(ctx); err != nil {
+			c.log.Error(err, "failed to collect the orphaned backup artifacts")
+		}
+	}, c.interval)
+
+	return nil
+}
+
+// collect runs a Job for each destination referenced by the sites and the
+// backups, deleting the artifacts of the backups not uploaded by any of the
+// existing backups. The destinations which are no longer referenced are not
+// collected, as their credentials are not known. A destination failing to be
+// collected doesn't hold back the others.
+func (c *collector) collect(ctx context.Context) error {
+	sites := &wordpressv1alpha1.WordpressList{}
+	if err := c.List(ctx, sites); err != nil {
+		return err
+	}
+
+	backups := &wordpressv1alpha1.WordpressBackupList{}
+	if err := c.List(ctx, backups); err != nil {
+		return err
+	}
+
+	for url, d := range destinationsOf(sites.Items, backups.Items) {
+		if d.spec.S3 == nil && d.spec.GCS == nil {
+			continue
+		}
+
+		if err := c.run(ctx, url, d); err != nil {
+			c.log.Error(err, "failed to collect the orphaned backup artifacts", "destination", url)
+		}
+	}
+
+	return nil
+}
+
+// destinationsOf returns the destinations referenced by the sites and the
+// backups, keeping the prefixes of the existing backups, by their namespace
+// and name.
+func destinationsOf(sites []wordpressv1alpha1.Wordpress, backups []wordpressv1alpha1.WordpressBackup) destinations {
+	ds := destinations{}
+
+	for i := range sites {
+		spec := sites[i].Spec
+
+		if spec.Backups != nil {
+			ds.add(sites[i].Namespace, &spec.Backups.Destination)
+		}
+
+		if spec.Updates != nil && spec.Updates.BackupBeforeUpgrade != nil && spec.Updates.BackupBeforeUpgrade.Destination != nil {
+			ds.add(sites[i].Namespace, spec.Updates.BackupBeforeUpgrade.Destination)
+		}
+	}
+
+	for i := range backups {
+		backup := &backups[i]
+
+		d := ds.add(backup.Namespace, &backup.Spec.Destination)
+		d.keep = append(d.keep, wordpress.BackupPath(backup))
+	}
+
+	return ds
+}
+
+// run creates the Job collecting the destination, unless the previous one is
+// still running. The finished Jobs are deleted.
+func (c *collector) run(ctx context.Context, url string, d *destination) error {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(url))
+	id := fmt.Sprintf("%08x", hash.Sum32())
+
+	jobs := &batchv1.JobList{}
+
+	err := c.List(ctx, jobs, client.InNamespace(d.namespace), client.MatchingLabels{destinationLabel: id})
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !isJobFinished(job) {
+			return nil
+		}
+
+		err = c.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	objLabels := map[string]string{destinationLabel: id}
+	for k, v := range controllerLabels {
+		objLabels[k] = v
+	}
+
+	backoffLimit := int32(2)
+	ttl := jobTTLSeconds
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%d", collectorName, id, time.Now().Unix()/60),
+			Namespace: d.namespace,
+			Labels:    objLabels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoffLimit,
+			TTLSecondsAfterFinished: &ttl,
+			Template:                wordpress.BackupGCPodTemplateSpec(d.spec, d.keep, c.grace),
+		},
+	}
+
+	c.log.Info("collecting the orphaned backup artifacts", "destination", url, "job", client.ObjectKeyFromObject(job))
+
+	err = c.Create(ctx, job)
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return nil
+}
+
+func isJobFinished(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if (cond.Type == batchv1.JobComplete || cond.Type == batchv1.JobFailed) && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupgc
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+var _ = Describe("The destinationsOf function", func() {
+	var dest wordpressv1alpha1.BackupDestination
+
+	backup := func(namespace, name string) wordpressv1alpha1.WordpressBackup {
+		return wordpressv1alpha1.WordpressBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       wordpressv1alpha1.WordpressBackupSpec{Destination: *dest.DeepCopy()},
+		}
+	}
+
+	BeforeEach(func() {
+		dest = wordpressv1alpha1.BackupDestination{
+			S3: &wordpressv1alpha1.S3VolumeSource{Bucket: "backups", PathPrefix: "sites"},
+		}
+	})
+
+	It("should keep the backups by their namespace and name", func() {
+		ds := destinationsOf(nil, []wordpressv1alpha1.WordpressBackup{
+			backup("default", "nightly"),
+			backup("other", "nightly"),
+		})
+
+		Expect(ds).To(HaveLen(1))
+		Expect(ds).To(HaveKey("s3://backups/sites"))
+		Expect(ds["s3://backups/sites"].keep).To(ConsistOf("default/nightly", "other/nightly"))
+		Expect(ds["s3://backups/sites"].namespace).To(Equal("default"))
+	})
+
+	It("should collect the sites' destinations without backups", func() {
+		sites := []wordpressv1alpha1.Wordpress{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "mysite", Namespace: "other"},
+				Spec: wordpressv1alpha1.WordpressSpec{
+					Backups: &wordpressv1alpha1.BackupsSpec{Schedule: "@daily", Destination: dest},
+				},
+			},
+		}
+
+		ds := destinationsOf(sites, nil)
+
+		Expect(ds).To(HaveKey("s3://backups/sites"))
+		Expect(ds["s3://backups/sites"].keep).To(BeEmpty())
+		Expect(ds["s3://backups/sites"].namespace).To(Equal("other"))
+	})
+
+	It("should keep the backups of each destination apart", func() {
+		gcs := backup("default", "weekly")
+		gcs.Spec.Destination = wordpressv1alpha1.BackupDestination{
+			GCS: &wordpressv1alpha1.GCSVolumeSource{Bucket: "backups"},
+		}
+
+		ds := destinationsOf(nil, []wordpressv1alpha1.WordpressBackup{backup("default", "nightly"), gcs})
+
+		Expect(ds).To(HaveLen(2))
+		Expect(ds["s3://backups/sites"].keep).To(ConsistOf("default/nightly"))
+		Expect(ds["gs://backups"].keep).To(ConsistOf("default/weekly"))
+	})
+})
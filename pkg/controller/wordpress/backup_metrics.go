@@ -0,0 +1,153 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var (
+	backupLastSuccessTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_backup_last_success_timestamp_seconds",
+		Help: "The time the site's last succeeded backup finished at, or 0 if none succeeded.",
+	}, []string{"namespace", "name"})
+
+	backupLastFailureTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_backup_last_failure_timestamp_seconds",
+		Help: "The time the site's last failed backup finished at, or 0 if none failed.",
+	}, []string{"namespace", "name"})
+
+	backupLastScheduleTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_backup_last_schedule_timestamp_seconds",
+		Help: "The time the site's last scheduled backup was scheduled at.",
+	}, []string{"namespace", "name"})
+
+	backupLastDurationSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_backup_last_duration_seconds",
+		Help: "The time the site's last succeeded backup took.",
+	}, []string{"namespace", "name"})
+
+	backupLastSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_backup_last_size_bytes",
+		Help: "The size of the artifacts uploaded by the site's last succeeded backup.",
+	}, []string{"namespace", "name"})
+
+	backupMetrics = []*prometheus.GaugeVec{
+		backupLastSuccessTimestamp,
+		backupLastFailureTimestamp,
+		backupLastScheduleTimestamp,
+		backupLastDurationSeconds,
+		backupLastSizeBytes,
+	}
+)
+
+func init() {
+	for _, m := range backupMetrics {
+		metrics.Registry.MustRegister(m)
+	}
+}
+
+// reportBackupMetrics publishes the metrics of the backups of the sites with
+// scheduled backups, so that a site which has no succeeded backup since its
+// last scheduled one can be alerted on. All the site's backups are taken into
+// account, e.g. the ones taken before upgrades.
+func (r *ReconcileWordpress) reportBackupMetrics(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.SchedulesBackups() {
+		deleteBackupMetrics(types.NamespacedName{Namespace: wp.Namespace, Name: wp.Name})
+
+		return nil
+	}
+
+	backups := &wordpressv1alpha1.WordpressBackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(wp.Namespace)); err != nil {
+		return err
+	}
+
+	var succeeded, failed *wordpressv1alpha1.WordpressBackup
+
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.Spec.SiteRef.Name != wp.Name || backup.Status.CompletionTime == nil {
+			continue
+		}
+
+		switch backup.Status.Phase {
+		case wordpressv1alpha1.BackupSucceeded:
+			if succeeded == nil || succeeded.Status.CompletionTime.Before(backup.Status.CompletionTime) {
+				succeeded = backup
+			}
+		case wordpressv1alpha1.BackupFailed:
+			if failed == nil || failed.Status.CompletionTime.Before(backup.Status.CompletionTime) {
+				failed = backup
+			}
+		}
+	}
+
+	labels := []string{wp.Namespace, wp.Name}
+
+	if wp.Status.Backups != nil && wp.Status.Backups.LastScheduleTime != nil {
+		scheduledAt := wp.Status.Backups.LastScheduleTime
+		backupLastScheduleTimestamp.WithLabelValues(labels...).Set(float64(scheduledAt.Unix()))
+	}
+
+	if failed != nil {
+		backupLastFailureTimestamp.WithLabelValues(labels...).Set(float64(failed.Status.CompletionTime.Unix()))
+	} else {
+		backupLastFailureTimestamp.WithLabelValues(labels...).Set(0)
+	}
+
+	if succeeded == nil {
+		backupLastSuccessTimestamp.WithLabelValues(labels...).Set(0)
+		backupLastDurationSeconds.DeleteLabelValues(labels...)
+		backupLastSizeBytes.DeleteLabelValues(labels...)
+
+		return nil
+	}
+
+	status := succeeded.Status
+	backupLastSuccessTimestamp.WithLabelValues(labels...).Set(float64(status.CompletionTime.Unix()))
+
+	if status.StartTime != nil {
+		duration := status.CompletionTime.Sub(status.StartTime.Time)
+		backupLastDurationSeconds.WithLabelValues(labels...).Set(duration.Seconds())
+	}
+
+	var size int64
+	for _, artifact := range status.Artifacts {
+		size += artifact.Size
+	}
+
+	backupLastSizeBytes.WithLabelValues(labels...).Set(float64(size))
+
+	return nil
+}
+
+// deleteBackupMetrics removes the backup metrics of a site which no longer
+// schedules backups or was deleted.
+func deleteBackupMetrics(key types.NamespacedName) {
+	for _, m := range backupMetrics {
+		m.DeleteLabelValues(key.Namespace, key.Name)
+	}
+}
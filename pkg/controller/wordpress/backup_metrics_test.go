@@ -0,0 +1,128 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The backup metrics", func() {
+	var (
+		wp        *wordpress.Wordpress
+		completed time.Time
+	)
+
+	newBackup := func(name string, phase wordpressv1alpha1.WordpressBackupPhase, completion time.Time) *wordpressv1alpha1.WordpressBackup {
+		return &wordpressv1alpha1.WordpressBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: wp.Namespace},
+			Spec: wordpressv1alpha1.WordpressBackupSpec{
+				SiteRef: corev1.LocalObjectReference{Name: wp.Name},
+			},
+			Status: wordpressv1alpha1.WordpressBackupStatus{
+				Phase:          phase,
+				StartTime:      &metav1.Time{Time: completion.Add(-time.Minute)},
+				CompletionTime: &metav1.Time{Time: completion},
+				Artifacts: []wordpressv1alpha1.BackupArtifact{
+					{Name: "database", Size: 100},
+					{Name: "media", Size: 200},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "backed-up", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Backups: &wordpressv1alpha1.BackupsSpec{Schedule: "@daily"},
+			},
+		})
+		completed = time.Now().Truncate(time.Second)
+	})
+
+	AfterEach(func() {
+		deleteBackupMetrics(types.NamespacedName{Namespace: wp.Namespace, Name: wp.Name})
+	})
+
+	It("should report the site's last succeeded backup", func() {
+		r := newTestReconciler(
+			newBackup("older", wordpressv1alpha1.BackupSucceeded, completed.Add(-time.Hour)),
+			newBackup("latest", wordpressv1alpha1.BackupSucceeded, completed),
+			newBackup("failed", wordpressv1alpha1.BackupFailed, completed.Add(time.Hour)),
+		)
+
+		Expect(r.reportBackupMetrics(context.TODO(), wp)).To(Succeed())
+
+		Expect(testutil.ToFloat64(backupLastSuccessTimestamp.WithLabelValues(wp.Namespace, wp.Name))).
+			To(Equal(float64(completed.Unix())))
+		Expect(testutil.ToFloat64(backupLastFailureTimestamp.WithLabelValues(wp.Namespace, wp.Name))).
+			To(Equal(float64(completed.Add(time.Hour).Unix())))
+		Expect(testutil.ToFloat64(backupLastDurationSeconds.WithLabelValues(wp.Namespace, wp.Name))).
+			To(Equal(time.Minute.Seconds()))
+		Expect(testutil.ToFloat64(backupLastSizeBytes.WithLabelValues(wp.Namespace, wp.Name))).
+			To(Equal(float64(300)))
+	})
+
+	It("should report 0 when no backup succeeded", func() {
+		r := newTestReconciler(newBackup("failed", wordpressv1alpha1.BackupFailed, completed))
+
+		Expect(r.reportBackupMetrics(context.TODO(), wp)).To(Succeed())
+
+		Expect(testutil.ToFloat64(backupLastSuccessTimestamp.WithLabelValues(wp.Namespace, wp.Name))).To(BeZero())
+		Expect(backupLastSizeBytes.DeleteLabelValues(wp.Namespace, wp.Name)).To(BeFalse())
+	})
+
+	It("should remove the metrics when the site no longer schedules backups", func() {
+		r := newTestReconciler(newBackup("latest", wordpressv1alpha1.BackupSucceeded, completed))
+
+		Expect(r.reportBackupMetrics(context.TODO(), wp)).To(Succeed())
+
+		wp.Spec.Backups = nil
+		Expect(r.reportBackupMetrics(context.TODO(), wp)).To(Succeed())
+
+		for _, m := range backupMetrics {
+			Expect(m.DeleteLabelValues(wp.Namespace, wp.Name)).To(BeFalse())
+		}
+	})
+
+	It("should remove the metrics when the site is deleted", func() {
+		r := newTestReconciler(newBackup("latest", wordpressv1alpha1.BackupSucceeded, completed))
+
+		Expect(r.reportBackupMetrics(context.TODO(), wp)).To(Succeed())
+
+		key := types.NamespacedName{Namespace: wp.Namespace, Name: wp.Name}
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, m := range backupMetrics {
+			Expect(m.DeleteLabelValues(wp.Namespace, wp.Name)).To(BeFalse())
+		}
+	})
+})
@@ -0,0 +1,299 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncBackups creates a WordpressBackup each time the backups CronJob is
+// scheduled and prunes the finished ones by the retention policy. The
+// backups are not owned by the Wordpress, so they outlive it.
+func (r *ReconcileWordpress) syncBackups(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.SchedulesBackups() {
+		wp.Status.Backups = nil
+
+		return nil
+	}
+
+	if wp.Status.Backups == nil {
+		wp.Status.Backups = &wordpressv1alpha1.BackupsStatus{}
+	}
+
+	status := wp.Status.Backups
+
+	cronJob := &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressBackups))}
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(cronJob), cronJob)
+	if ignoreNotFound(err) != nil {
+		return err
+	}
+
+	scheduledAt := cronJob.Status.LastScheduleTime
+	if scheduledAt != nil && (status.LastScheduleTime == nil || scheduledAt.After(status.LastScheduleTime.Time)) {
+		backup := wp.ScheduledBackup(scheduledAt.Time)
+
+		err = r.Create(ctx, backup)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+
+		if err == nil {
+			r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.BackupScheduledReason,
+				fmt.Sprintf("created the %s backup", backup.Name))
+		}
+
+		status.LastScheduleTime = scheduledAt
+		status.LastBackup = backup.Name
+	}
+
+	return r.pruneBackups(ctx, wp)
+}
+
+// pruneBackups deletes the finished scheduled backups which are beyond the
+// last ones to keep or older than the max age. The succeeded and the failed
+// backups are counted apart, so a series of failures doesn't prune the
+// succeeded backups, and the last succeeded backup is always kept. Their
+// artifacts are deleted along with them.
+func (r *ReconcileWordpress) pruneBackups(ctx context.Context, wp *wordpress.Wordpress) error {
+	retention := wp.Spec.Backups.Retention
+	if retention == nil {
+		return nil
+	}
+
+	backups := &wordpressv1alpha1.WordpressBackupList{}
+
+	err := r.List(ctx, backups,
+		client.InNamespace(wp.Namespace),
+		client.MatchingLabels(wp.ComponentLabels(wordpress.WordpressBackups)),
+	)
+	if err != nil {
+		return err
+	}
+
+	succeeded := []*wordpressv1alpha1.WordpressBackup{}
+	failed := []*wordpressv1alpha1.WordpressBackup{}
+
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.DeletionTimestamp != nil {
+			continue
+		}
+
+		switch backup.Status.Phase {
+		case wordpressv1alpha1.BackupSucceeded:
+			succeeded = append(succeeded, backup)
+		case wordpressv1alpha1.BackupFailed:
+			failed = append(failed, backup)
+		}
+	}
+
+	pruned := []*wordpressv1alpha1.WordpressBackup{}
+
+	for i, backup := range newestFirst(succeeded) {
+		if i > 0 && isBackupPruned(retention, i, backup) {
+			pruned = append(pruned, backup)
+		}
+	}
+
+	for i, backup := range newestFirst(failed) {
+		if isBackupPruned(retention, i, backup) {
+			pruned = append(pruned, backup)
+		}
+	}
+
+	for _, backup := range pruned {
+		err = r.Delete(ctx, backup, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if ignoreNotFound(err) != nil {
+			return err
+		}
+
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.BackupPrunedReason,
+			fmt.Sprintf("pruned the %s backup", backup.Name))
+	}
+
+	return nil
+}
+
+// newestFirst sorts the backups by their creation, the newest first.
+func newestFirst(backups []*wordpressv1alpha1.WordpressBackup) []*wordpressv1alpha1.WordpressBackup {
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[j].CreationTimestamp.Before(&backups[i].CreationTimestamp)
+	})
+
+	return backups
+}
+
+// isBackupPruned returns true if the backup, the i-th newest of its phase, is
+// beyond the last ones to keep or older than the max age.
+func isBackupPruned(retention *wordpressv1alpha1.BackupRetentionSpec, i int, backup *wordpressv1alpha1.WordpressBackup) bool {
+	if retention.KeepLast != nil && i >= int(*retention.KeepLast) {
+		return true
+	}
+
+	return retention.MaxAge != nil && time.Since(backup.CreationTimestamp.Time) > retention.MaxAge.Duration
+}
+
+// verifiableBackup returns the last succeeded scheduled backup which has a
+// database artifact, or nil if there's none or the backups are not verified.
+func (r *ReconcileWordpress) verifiableBackup(ctx context.Context, wp *wordpress.Wordpress) (*wordpressv1alpha1.WordpressBackup, error) {
+	if !wp.VerifiesBackups() {
+		return nil, nil
+	}
+
+	backups := &wordpressv1alpha1.WordpressBackupList{}
+
+	err := r.List(ctx, backups,
+		client.InNamespace(wp.Namespace),
+		client.MatchingLabels(wp.ComponentLabels(wordpress.WordpressBackups)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var out *wordpressv1alpha1.WordpressBackup
+
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.DeletionTimestamp != nil || backup.Status.Phase != wordpressv1alpha1.BackupSucceeded ||
+			wordpress.DatabaseArtifact(backup) == nil {
+			continue
+		}
+
+		if out == nil || out.CreationTimestamp.Before(&backup.CreationTimestamp) {
+			out = backup
+		}
+	}
+
+	return out, nil
+}
+
+// syncBackupVerification publishes the results of the last verification Job,
+// run by the backup verification CronJob, in the status and in the
+// BackupRestorable condition.
+func (r *ReconcileWordpress) syncBackupVerification(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.VerifiesBackups() {
+		if wp.Status.Backups != nil {
+			wp.Status.Backups.Verification = nil
+		}
+
+		wp.RemoveCondition(wordpressv1alpha1.BackupRestorableCondition)
+
+		return nil
+	}
+
+	if wp.Status.Backups.Verification == nil {
+		wp.Status.Backups.Verification = &wordpressv1alpha1.BackupVerificationStatus{}
+	}
+
+	status := wp.Status.Backups.Verification
+
+	backup, err := r.verifiableBackup(ctx, wp)
+	if err != nil {
+		return err
+	}
+
+	// the CronJob is not created for the backups which can't be decrypted
+	if backup != nil {
+		if err = wp.CanVerifyBackup(backup); err != nil {
+			r.setBackupRestorable(wp, corev1.ConditionFalse,
+				wordpressv1alpha1.BackupVerificationErrorReason, err.Error())
+
+			return nil
+		}
+	}
+
+	finished, err := r.finishedJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressBackupVerification), status.VerifiedAt)
+	if err != nil || len(finished) == 0 {
+		return err
+	}
+
+	// only the results of the last run are published
+	job := finished[len(finished)-1]
+	status.VerifiedAt = jobFinishedAt(job)
+	status.Backup = job.Annotations[wordpress.VerifiedBackupAnnotation]
+
+	if isJobFailed(job) {
+		status.Checks = nil
+		r.setBackupRestorable(wp, corev1.ConditionFalse, wordpressv1alpha1.BackupVerificationErrorReason,
+			fmt.Sprintf("the backup verification job %s failed to restore the %s backup", job.Name, status.Backup))
+
+		return nil
+	}
+
+	report, err := r.jobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	status.Checks = wordpress.ParseBackupVerification(report)
+
+	if len(status.Checks) == 0 {
+		r.setBackupRestorable(wp, corev1.ConditionFalse, wordpressv1alpha1.BackupVerificationErrorReason,
+			fmt.Sprintf("the backup verification job %s reported no checks", job.Name))
+
+		return nil
+	}
+
+	failed := []string{}
+
+	for _, check := range status.Checks {
+		if !check.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		}
+	}
+
+	if len(failed) == 0 {
+		r.setBackupRestorable(wp, corev1.ConditionTrue, wordpressv1alpha1.BackupRestorableReason,
+			fmt.Sprintf("the %s backup was restored", status.Backup))
+
+		return nil
+	}
+
+	r.setBackupRestorable(wp, corev1.ConditionFalse, wordpressv1alpha1.BackupNotRestorableReason,
+		fmt.Sprintf("the %s backup: %s", status.Backup, strings.Join(failed, "; ")))
+
+	return nil
+}
+
+// setBackupRestorable sets the BackupRestorable condition, recording an event
+// when its reason or its message changes, e.g. for each verified backup.
+func (r *ReconcileWordpress) setBackupRestorable(wp *wordpress.Wordpress, status corev1.ConditionStatus, reason, message string) {
+	if cond := wp.GetCondition(wordpressv1alpha1.BackupRestorableCondition); cond == nil || cond.Reason != reason || cond.Message != message {
+		eventType := corev1.EventTypeNormal
+		if status != corev1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+
+		r.recorder.Event(wp.Unwrap(), eventType, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.BackupRestorableCondition, status, reason, message)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2019 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The pruneBackups function", func() {
+	var (
+		wp  *wordpress.Wordpress
+		now time.Time
+	)
+
+	newBackup := func(name string, phase wordpressv1alpha1.WordpressBackupPhase, age time.Duration) client.Object {
+		return &wordpressv1alpha1.WordpressBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         wp.Namespace,
+				Labels:            wp.ComponentLabels(wordpress.WordpressBackups),
+				CreationTimestamp: metav1.Time{Time: now.Add(-age)},
+			},
+			Status: wordpressv1alpha1.WordpressBackupStatus{Phase: phase},
+		}
+	}
+
+	remaining := func(r *ReconcileWordpress) []string {
+		backups := &wordpressv1alpha1.WordpressBackupList{}
+		Expect(r.List(context.TODO(), backups, client.InNamespace(wp.Namespace))).To(Succeed())
+
+		names := []string{}
+		for i := range backups.Items {
+			names = append(names, backups.Items[i].Name)
+		}
+
+		return names
+	}
+
+	BeforeEach(func() {
+		keepLast := int32(2)
+
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "backed-up", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Backups: &wordpressv1alpha1.BackupsSpec{
+					Schedule:  "@daily",
+					Retention: &wordpressv1alpha1.BackupRetentionSpec{KeepLast: &keepLast},
+				},
+			},
+		})
+		now = time.Now()
+	})
+
+	It("should keep the last backups", func() {
+		r := newTestReconciler(
+			newBackup("day-1", wordpressv1alpha1.BackupSucceeded, 24*time.Hour),
+			newBackup("day-2", wordpressv1alpha1.BackupSucceeded, 48*time.Hour),
+			newBackup("day-3", wordpressv1alpha1.BackupSucceeded, 72*time.Hour),
+			newBackup("running", wordpressv1alpha1.BackupRunning, 0),
+		)
+
+		Expect(r.pruneBackups(context.TODO(), wp)).To(Succeed())
+		Expect(remaining(r)).To(ConsistOf("day-1", "day-2", "running"))
+	})
+
+	It("should keep the succeeded backups through a series of failures", func() {
+		r := newTestReconciler(
+			newBackup("failed-1", wordpressv1alpha1.BackupFailed, 24*time.Hour),
+			newBackup("failed-2", wordpressv1alpha1.BackupFailed, 48*time.Hour),
+			newBackup("failed-3", wordpressv1alpha1.BackupFailed, 72*time.Hour),
+			newBackup("failed-4", wordpressv1alpha1.BackupFailed, 96*time.Hour),
+			newBackup("day-5", wordpressv1alpha1.BackupSucceeded, 120*time.Hour),
+			newBackup("day-6", wordpressv1alpha1.BackupSucceeded, 144*time.Hour),
+			newBackup("day-7", wordpressv1alpha1.BackupSucceeded, 168*time.Hour),
+		)
+
+		Expect(r.pruneBackups(context.TODO(), wp)).To(Succeed())
+		Expect(remaining(r)).To(ConsistOf("failed-1", "failed-2", "day-5", "day-6"))
+	})
+
+	It("should keep the last succeeded backup once it's older than the max age", func() {
+		wp.Spec.Backups.Retention = &wordpressv1alpha1.BackupRetentionSpec{
+			MaxAge: &metav1.Duration{Duration: 36 * time.Hour},
+		}
+
+		r := newTestReconciler(
+			newBackup("failed-1", wordpressv1alpha1.BackupFailed, 24*time.Hour),
+			newBackup("failed-2", wordpressv1alpha1.BackupFailed, 48*time.Hour),
+			newBackup("day-3", wordpressv1alpha1.BackupSucceeded, 72*time.Hour),
+			newBackup("day-4", wordpressv1alpha1.BackupSucceeded, 96*time.Hour),
+		)
+
+		Expect(r.pruneBackups(context.TODO(), wp)).To(Succeed())
+		Expect(remaining(r)).To(ConsistOf("failed-1", "day-3"))
+	})
+})
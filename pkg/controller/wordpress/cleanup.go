@@ -0,0 +1,108 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncCleanup records the results of the cleanup Jobs, run by the cleanup
+// CronJob, which finished since the last recorded one.
+func (r *ReconcileWordpress) syncCleanup(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.SchedulesCleanup() {
+		wp.Status.Cleanup = nil
+
+		return nil
+	}
+
+	if wp.Status.Cleanup == nil {
+		wp.Status.Cleanup = &wordpressv1alpha1.CleanupStatus{}
+	}
+
+	finished, err := r.finishedJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCleanup), wp.Status.Cleanup.LastRunAt)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range finished {
+		if err = r.recordCleanup(ctx, wp, job); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordCleanup adds the items deleted by a finished cleanup Job to the
+// counters and records an event.
+func (r *ReconcileWordpress) recordCleanup(ctx context.Context, wp *wordpress.Wordpress, job *batchv1.Job) error {
+	status := wp.Status.Cleanup
+	status.LastRunAt = jobFinishedAt(job)
+	status.LastRunSucceeded = !isJobFailed(job)
+
+	if !status.LastRunSucceeded {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.CleanupFailedReason,
+			fmt.Sprintf("the cleanup job %s failed", job.Name))
+
+		return nil
+	}
+
+	report, err := r.jobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	deleted := []string{}
+
+	for _, line := range report {
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		count, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch wordpressv1alpha1.CleanupTask(parts[0]) {
+		case wordpressv1alpha1.CleanupSpamComments:
+			status.SpamComments += count
+			deleted = append(deleted, fmt.Sprintf("%d spam comments", count))
+		case wordpressv1alpha1.CleanupExpiredTransients:
+			status.ExpiredTransients += count
+			deleted = append(deleted, fmt.Sprintf("%d expired transients", count))
+		case wordpressv1alpha1.CleanupOrphanedPostMeta:
+			status.OrphanedPostMeta += count
+			deleted = append(deleted, fmt.Sprintf("%d orphaned post meta", count))
+		}
+	}
+
+	r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.CleanupCompletedReason,
+		fmt.Sprintf("the cleanup deleted %s", strings.Join(deleted, ", ")))
+
+	return nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errCoreVersionNotReported = errors.New("the core update job did not report the core version")
+
+// syncCoreUpdates checks for core updates periodically and applies the ones
+// allowed by the policy within the maintenance window, recording the versions
+// in the status and events.
+func (r *ReconcileWordpress) syncCoreUpdates(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.ChecksCoreUpdates() {
+		wp.Status.CoreUpdate = nil
+
+		err := r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCoreUpdateCheck), "")
+		if err != nil {
+			return err
+		}
+
+		return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCoreUpdate), "")
+	}
+
+	if wp.Status.CoreUpdate == nil {
+		wp.Status.CoreUpdate = &wordpressv1alpha1.CoreUpdateStatus{}
+	}
+
+	checkedAt := wp.Status.CoreUpdate.CheckedAt
+	if checkedAt == nil || time.Since(checkedAt.Time) >= wp.CoreUpdateCheckInterval() {
+		return r.checkCoreUpdates(ctx, wp)
+	}
+
+	if wp.CoreUpdatePolicy() == wordpressv1alpha1.CoreUpdateNone || wp.AvailableCoreVersion() == "" {
+		return nil
+	}
+
+	inWindow, err := wp.InCoreUpdateWindow(time.Now())
+	if err != nil || !inWindow {
+		return err
+	}
+
+	if wp.BacksUpBeforeUpgrade() {
+		backedUp, err := r.backupBeforeUpgrade(ctx, wp, "WordPress "+wp.AvailableCoreVersion())
+		if err != nil || !backedUp {
+			return err
+		}
+	}
+
+	return r.updateCore(ctx, wp)
+}
+
+// checkCoreUpdates runs the Job which checks for core updates and records
+// the installed and the available versions.
+func (r *ReconcileWordpress) checkCoreUpdates(ctx context.Context, wp *wordpress.Wordpress) error {
+	jobSyncer := sync.NewCoreUpdateCheckJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+
+	switch {
+	case isJobFailed(job):
+		// the check is retried after an interval
+		if job.Status.StartTime != nil && time.Since(job.Status.StartTime.Time) < wp.CoreUpdateCheckInterval() {
+			return nil
+		}
+
+		return r.deleteJob(ctx, job)
+	case job.Status.Succeeded == 0:
+		return nil
+	}
+
+	versions, err := r.jobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) == 0 {
+		return errCoreVersionNotReported
+	}
+
+	status := wp.Status.CoreUpdate
+	previouslyAvailable := status.AvailableVersion
+
+	now := metav1.Now()
+	status.CheckedAt = &now
+	status.Version = versions[0]
+	status.AvailableVersion = ""
+
+	if len(versions) > 1 {
+		status.AvailableVersion = versions[1]
+	}
+
+	if available := wp.AvailableCoreVersion(); available != "" && available != previouslyAvailable {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.CoreUpdateAvailableReason,
+			fmt.Sprintf("WordPress %s is available, %s is installed", available, status.Version))
+	}
+
+	return r.deleteJob(ctx, job)
+}
+
+// updateCore runs the Job which updates the core to the available version,
+// and records the previous and the installed versions. A failed update is
+// retried once the update is found again by a check.
+func (r *ReconcileWordpress) updateCore(ctx context.Context, wp *wordpress.Wordpress) error {
+	jobSyncer := sync.NewCoreUpdateJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	status := wp.Status.CoreUpdate
+
+	switch {
+	case isJobFailed(job):
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.CoreUpdateFailedReason,
+			fmt.Sprintf("the WordPress update from %s to %s failed", status.Version, status.AvailableVersion))
+
+		status.AvailableVersion = ""
+
+		return r.deleteJob(ctx, job)
+	case job.Status.Succeeded == 0:
+		return nil
+	}
+
+	versions, err := r.jobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	if len(versions) < 2 {
+		return errCoreVersionNotReported
+	}
+
+	now := metav1.Now()
+	status.PreviousVersion = versions[0]
+	status.Version = versions[1]
+	status.AvailableVersion = ""
+	status.UpdatedAt = &now
+
+	r.recordChange(wp, wordpressv1alpha1.CoreUpdatedReason,
+		fmt.Sprintf("WordPress was updated from %s to %s", status.PreviousVersion, status.Version))
+
+	return r.deleteJob(ctx, job)
+}
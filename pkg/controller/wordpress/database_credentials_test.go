@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The database credentials rotation", func() {
+	var (
+		wp       *wordpress.Wordpress
+		secret   *corev1.Secret
+		workload *appsv1.Deployment
+		job      *batchv1.Job
+	)
+
+	BeforeEach(func() {
+		previous := ""
+
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Database: &wordpressv1alpha1.DatabaseSpec{Provision: true, RotateCredentials: "2021-10-01"},
+			},
+		})
+		wp.Status.DatabaseCredentials = &wordpressv1alpha1.DatabaseCredentialsStatus{
+			Generation:         wp.DesiredDatabaseCredentials(),
+			PreviousGeneration: &previous,
+		}
+
+		secret = &corev1.Secret{
+			Data: map[string][]byte{"DB_USER": []byte(wp.DatabaseUser(wp.DesiredDatabaseCredentials()))},
+		}
+
+		replicas := int32(1)
+		workload = &appsv1.Deployment{
+			Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+			Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+		}
+
+		job = &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-wp-cron-28000000",
+				Namespace: wp.Namespace,
+				Labels:    wp.ComponentLabels(wordpress.WordpressCron),
+			},
+			Status: batchv1.JobStatus{Active: 1},
+		}
+	})
+
+	It("should wait for the site's running Jobs to revoke the previous user", func() {
+		r := newTestReconciler(job)
+
+		rotating, err := r.syncDatabaseCredentials(context.TODO(), wp, nil, secret, workload)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotating).To(BeTrue())
+		Expect(wp.Status.DatabaseCredentials.PreviousGeneration).NotTo(BeNil())
+	})
+
+	It("should revoke the previous user once the site's Jobs finished", func() {
+		now := metav1.Now()
+		job.Status = batchv1.JobStatus{CompletionTime: &now}
+		r := newTestReconciler(job)
+
+		rotating, err := r.syncDatabaseCredentials(context.TODO(), wp, nil, secret, workload)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rotating).To(BeFalse())
+		Expect(wp.Status.DatabaseCredentials.PreviousGeneration).To(BeNil())
+	})
+})
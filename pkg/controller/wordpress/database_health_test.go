@@ -0,0 +1,115 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The database health check", func() {
+	var (
+		r        *ReconcileWordpress
+		recorder *record.FakeRecorder
+		wp       *wordpress.Wordpress
+		listener net.Listener
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, p, err := net.SplitHostPort(listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+
+		port, err := strconv.Atoi(p)
+		Expect(err).NotTo(HaveOccurred())
+
+		dbPort := int32(port)
+
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Database: &wordpressv1alpha1.DatabaseSpec{Host: "127.0.0.1", Port: &dbPort},
+			},
+		})
+		wp.SetDefaults()
+
+		r = newTestReconciler()
+		recorder = r.recorder.(*record.FakeRecorder)
+	})
+
+	AfterEach(func() {
+		// nolint: errcheck
+		listener.Close()
+	})
+
+	It("should set the DatabaseHealthy condition and check it periodically", func() {
+		Expect(wp.DatabaseAddress()).To(Equal(listener.Addr().String()))
+
+		r.syncDatabaseHealth(context.TODO(), wp)
+
+		cond := wp.GetCondition(wordpressv1alpha1.DatabaseHealthyCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(corev1.ConditionTrue))
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal " + wordpressv1alpha1.DatabaseHealthyReason)))
+		Expect(requeueResult(wp).RequeueAfter).To(Equal(databaseHealthCheckInterval))
+
+		// the event is only recorded when the health changes
+		r.syncDatabaseHealth(context.TODO(), wp)
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should report the database as unhealthy when it refuses connections", func() {
+		Expect(listener.Close()).To(Succeed())
+
+		r.syncDatabaseHealth(context.TODO(), wp)
+
+		cond := wp.GetCondition(wordpressv1alpha1.DatabaseHealthyCondition)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(corev1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(wordpressv1alpha1.DatabaseUnhealthyReason))
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning " + wordpressv1alpha1.DatabaseUnhealthyReason)))
+
+		status, reason, _ := externalDatabaseStatus(wp, nil)
+		Expect(status).To(Equal(corev1.ConditionFalse))
+		Expect(reason).To(Equal(wordpressv1alpha1.DatabaseUnreachableReason))
+	})
+
+	It("should not check the database through the Cloud SQL Auth Proxy", func() {
+		wp.SetCondition(wordpressv1alpha1.DatabaseHealthyCondition, corev1.ConditionTrue, wordpressv1alpha1.DatabaseHealthyReason, "")
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{CloudSQL: &wordpressv1alpha1.CloudSQLSpec{}}
+
+		r.syncDatabaseHealth(context.TODO(), wp)
+
+		Expect(wp.GetCondition(wordpressv1alpha1.DatabaseHealthyCondition)).To(BeNil())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
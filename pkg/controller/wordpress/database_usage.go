@@ -0,0 +1,180 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/presslabs/controller-util/syncer"
+	"github.com/prometheus/client_golang/prometheus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errDatabaseSizeNotReported = errors.New("the database usage job did not report the database size")
+
+var databaseSizeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "wordpress_database_size_bytes",
+	Help: "The size of the site's database tables and indexes.",
+}, []string{"namespace", "name"})
+
+func init() {
+	metrics.Registry.MustRegister(databaseSizeBytes)
+}
+
+// syncDatabaseUsage measures the size of the site's database periodically,
+// using a Job which reports it through its termination message, and checks it
+// against the soft quota.
+func (r *ReconcileWordpress) syncDatabaseUsage(ctx context.Context, wp *wordpress.Wordpress) error {
+	job := &batchv1.Job{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressDBUsage))}
+
+	if !wp.MeasuresDatabaseUsage() {
+		wp.Status.DatabaseUsage = nil
+		wp.RemoveCondition(wordpressv1alpha1.DatabaseQuotaExceededCondition)
+		databaseSizeBytes.DeleteLabelValues(wp.Namespace, wp.Name)
+
+		return r.deleteOwned(ctx, wp, job)
+	}
+
+	if usage := wp.Status.DatabaseUsage; usage != nil {
+		r.reportDatabaseUsage(wp)
+
+		if time.Since(usage.MeasuredAt.Time) < wp.DatabaseUsageInterval() {
+			return nil
+		}
+	}
+
+	jobSyncer := sync.NewDBUsageJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job = jobSyncer.Object().(*batchv1.Job)
+
+	switch {
+	case isJobFailed(job):
+		// the measurement is retried after an interval
+		if job.Status.StartTime != nil && time.Since(job.Status.StartTime.Time) < wp.DatabaseUsageInterval() {
+			return nil
+		}
+
+		return r.deleteJob(ctx, job)
+	case job.Status.Succeeded == 0:
+		return nil
+	}
+
+	size, err := r.databaseSize(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	wp.Status.DatabaseUsage = &wordpressv1alpha1.DatabaseUsageStatus{
+		Size:       size,
+		MeasuredAt: metav1.Now(),
+	}
+	r.reportDatabaseUsage(wp)
+
+	return r.deleteJob(ctx, job)
+}
+
+// reportDatabaseUsage publishes the last measured size of the database and
+// checks it against the soft quota, recording an event when it gets exceeded.
+func (r *ReconcileWordpress) reportDatabaseUsage(wp *wordpress.Wordpress) {
+	size := wp.Status.DatabaseUsage.Size
+	databaseSizeBytes.WithLabelValues(wp.Namespace, wp.Name).Set(float64(size.Value()))
+
+	quota := wp.Spec.Database.Usage.Quota
+	if quota == nil {
+		wp.RemoveCondition(wordpressv1alpha1.DatabaseQuotaExceededCondition)
+
+		return
+	}
+
+	if size.Cmp(*quota) <= 0 {
+		wp.SetCondition(wordpressv1alpha1.DatabaseQuotaExceededCondition, corev1.ConditionFalse, wordpressv1alpha1.DatabaseWithinQuotaReason, "")
+
+		return
+	}
+
+	message := fmt.Sprintf("the database size of %s exceeds the %s quota", size.String(), quota.String())
+
+	if !wp.IsConditionTrue(wordpressv1alpha1.DatabaseQuotaExceededCondition) {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.DatabaseQuotaExceededReason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.DatabaseQuotaExceededCondition, corev1.ConditionTrue, wordpressv1alpha1.DatabaseQuotaExceededReason, message)
+}
+
+// databaseSize returns the size reported by the database usage Job's pod.
+func (r *ReconcileWordpress) databaseSize(ctx context.Context, job *batchv1.Job) (resource.Quantity, error) {
+	pods := &corev1.PodList{}
+
+	// the pods are not cached
+	err := r.apiReader.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"controller-uid": string(job.UID)},
+	)
+	if err != nil {
+		return resource.Quantity{}, err
+	}
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.ExitCode != 0 {
+				continue
+			}
+
+			size, err := strconv.ParseInt(strings.TrimSpace(status.State.Terminated.Message), 10, 64)
+			if err != nil {
+				return resource.Quantity{}, err
+			}
+
+			return *resource.NewQuantity(size, resource.BinarySI), nil
+		}
+	}
+
+	return resource.Quantity{}, errDatabaseSizeNotReported
+}
+
+func (r *ReconcileWordpress) deleteJob(ctx context.Context, job *batchv1.Job) error {
+	err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+
+	return ignoreNotFound(err)
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
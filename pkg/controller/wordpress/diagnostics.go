@@ -0,0 +1,89 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncDiagnostics publishes the results of the last diagnostics Job, run by
+// the diagnostics CronJob, in the status and in the DiagnosticsPassed
+// condition.
+func (r *ReconcileWordpress) syncDiagnostics(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.SchedulesDiagnostics() {
+		wp.Status.Diagnostics = nil
+		wp.RemoveCondition(wordpressv1alpha1.DiagnosticsPassedCondition)
+
+		return nil
+	}
+
+	if wp.Status.Diagnostics == nil {
+		wp.Status.Diagnostics = &wordpressv1alpha1.DiagnosticsStatus{}
+	}
+
+	status := wp.Status.Diagnostics
+
+	finished, err := r.finishedJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressDiagnostics), status.CheckedAt)
+	if err != nil || len(finished) == 0 {
+		return err
+	}
+
+	// only the results of the last run are published
+	job := finished[len(finished)-1]
+	status.CheckedAt = jobFinishedAt(job)
+
+	if isJobFailed(job) {
+		status.Checks = nil
+		wp.SetCondition(wordpressv1alpha1.DiagnosticsPassedCondition, corev1.ConditionUnknown, wordpressv1alpha1.DiagnosticsErrorReason,
+			fmt.Sprintf("the diagnostics job %s failed", job.Name))
+
+		return nil
+	}
+
+	report, err := r.jobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	status.Checks = wordpress.ParseDiagnostics(report)
+
+	failed := []string{}
+
+	for _, check := range status.Checks {
+		if !check.Passed {
+			failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		}
+	}
+
+	if len(failed) == 0 {
+		wp.SetCondition(wordpressv1alpha1.DiagnosticsPassedCondition, corev1.ConditionTrue, wordpressv1alpha1.DiagnosticsPassedReason, "")
+
+		return nil
+	}
+
+	wp.SetCondition(wordpressv1alpha1.DiagnosticsPassedCondition, corev1.ConditionFalse, wordpressv1alpha1.DiagnosticsFailedReason,
+		strings.Join(failed, "; "))
+
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// failingClient fails the creation of all the objects with err.
+type failingClient struct {
+	client.Client
+	err error
+}
+
+func (c *failingClient) Create(context.Context, client.Object, ...client.CreateOption) error {
+	return c.err
+}
+
+var _ = Describe("The site's events", func() {
+	var (
+		r        *ReconcileWordpress
+		recorder *record.FakeRecorder
+		wp       *wordpress.Wordpress
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "test-uid"},
+		})
+
+		r = newTestReconciler(wp.Unwrap())
+		recorder = r.recorder.(*record.FakeRecorder)
+	})
+
+	It("should record the starts and the completions of the rollouts", func() {
+		r.setDeploymentReady(wp, corev1.ConditionFalse, wordpressv1alpha1.RolloutInProgressReason, "the web pods are being rolled out")
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal " + wordpressv1alpha1.RolloutInProgressReason)))
+
+		r.setDeploymentReady(wp, corev1.ConditionFalse, wordpressv1alpha1.RolloutInProgressReason, "the web pods are being rolled out")
+		Expect(recorder.Events).NotTo(Receive())
+
+		r.setDeploymentReady(wp, corev1.ConditionTrue, wordpressv1alpha1.DeploymentAvailableReason, "the web pods are updated and available")
+		Expect(recorder.Events).To(Receive(HavePrefix("Normal " + wordpressv1alpha1.DeploymentAvailableReason)))
+	})
+
+	It("should record the results of the backup verifications", func() {
+		r.setBackupRestorable(wp, corev1.ConditionTrue, wordpressv1alpha1.BackupRestorableReason, "the nightly-1 backup was restored")
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal " + wordpressv1alpha1.BackupRestorableReason + " the nightly-1 backup was restored")))
+
+		// each verified backup is recorded
+		r.setBackupRestorable(wp, corev1.ConditionTrue, wordpressv1alpha1.BackupRestorableReason, "the nightly-2 backup was restored")
+		Expect(recorder.Events).To(Receive(HaveSuffix("the nightly-2 backup was restored")))
+
+		r.setBackupRestorable(wp, corev1.ConditionFalse, wordpressv1alpha1.BackupNotRestorableReason, "the nightly-3 backup: homepage failed")
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning " + wordpressv1alpha1.BackupNotRestorableReason)))
+
+		r.setBackupRestorable(wp, corev1.ConditionFalse, wordpressv1alpha1.BackupNotRestorableReason, "the nightly-3 backup: homepage failed")
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should record the failed reconciles", func() {
+		r.Client = &failingClient{Client: r.Client, err: errors.New("the API server is unavailable")}
+
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: wp.Namespace, Name: wp.Name}})
+		Expect(err).To(HaveOccurred())
+
+		Eventually(recorder.Events).Should(Receive(Equal(
+			"Warning " + wordpressv1alpha1.ReconcileFailedReason + " the API server is unavailable")))
+	})
+
+	It("should not record the conflicts", func() {
+		conflict := apierrors.NewConflict(schema.GroupResource{Resource: "secrets"}, "test-wp", errors.New("modified"))
+		r.Client = &failingClient{Client: r.Client, err: conflict}
+
+		_, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: wp.Namespace, Name: wp.Name}})
+		Expect(apierrors.IsConflict(err)).To(BeTrue())
+
+		Consistently(recorder.Events).ShouldNot(Receive(HavePrefix("Warning " + wordpressv1alpha1.ReconcileFailedReason)))
+	})
+})
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// historyLimit is the number of changes kept in status.history.
+const historyLimit = 20
+
+// syncCodeVersion records the code version once the web pods are rolled out with it.
+func syncCodeVersion(wp *wordpress.Wordpress, workload interface{}) {
+	if isWorkloadRolledOut(workload) {
+		wp.Status.CodeVersion = wp.CodeVersion()
+	}
+}
+
+// recordChanges records the changes made by the operator during the
+// reconcile, found by comparing the site's status with the previous one.
+func (r *ReconcileWordpress) recordChanges(wp *wordpress.Wordpress, old *wordpressv1alpha1.WordpressStatus) {
+	status := &wp.Status
+
+	if old.CodeVersion != "" && old.CodeVersion != status.CodeVersion {
+		r.recordChange(wp, wordpressv1alpha1.RolledOutReason,
+			fmt.Sprintf("the web pods were rolled out with %s, replacing %s", status.CodeVersion, old.CodeVersion))
+	}
+
+	if old.HomeURL != "" && old.HomeURL != status.HomeURL {
+		r.recordChange(wp, wordpressv1alpha1.HomeURLChangedReason,
+			fmt.Sprintf("the home URL changed from %s to %s", old.HomeURL, status.HomeURL))
+	}
+
+	if !old.Bootstrapped && status.Bootstrapped {
+		message := "the site was bootstrapped"
+		if wp.Spec.WordpressBootstrapSpec != nil && wp.Spec.WordpressBootstrapSpec.ImportFrom != nil {
+			message = "the site was bootstrapped from the imported database"
+		}
+
+		r.recordChange(wp, wordpressv1alpha1.BootstrappedReason, message)
+	}
+
+	for _, synced := range []struct {
+		reason       string
+		what         string
+		old, current string
+		drift        []string
+	}{
+		{wordpressv1alpha1.PluginsSyncedReason, "plugins", old.PluginsSyncedFor, status.PluginsSyncedFor, status.PluginsDrift},
+		{wordpressv1alpha1.ThemesSyncedReason, "themes", old.ThemesSyncedFor, status.ThemesSyncedFor, status.ThemesDrift},
+		{wordpressv1alpha1.OptionsSyncedReason, "options", old.OptionsSyncedFor, status.OptionsSyncedFor, status.OptionsDrift},
+		{wordpressv1alpha1.UsersSyncedReason, "users", old.UsersSyncedFor, status.UsersSyncedFor, status.UsersDrift},
+	} {
+		// only the syncs which corrected a drift changed the site
+		if synced.current != "" && synced.current != synced.old && len(synced.drift) > 0 {
+			r.recordChange(wp, synced.reason,
+				fmt.Sprintf("the %s were synced with the spec: %s", synced.what, strings.Join(synced.drift, "; ")))
+		}
+	}
+}
+
+// recordChange records an event for a change made by the operator and adds
+// it to status.history, dropping the oldest changes over the limit.
+func (r *ReconcileWordpress) recordChange(wp *wordpress.Wordpress, reason, message string) {
+	r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, reason, message)
+
+	wp.Status.History = append(wp.Status.History, wordpressv1alpha1.ChangeRecord{
+		Time:       metav1.Now(),
+		Reason:     reason,
+		Message:    message,
+		Generation: wp.Generation,
+	})
+
+	if len(wp.Status.History) > historyLimit {
+		wp.Status.History = wp.Status.History[len(wp.Status.History)-historyLimit:]
+	}
+}
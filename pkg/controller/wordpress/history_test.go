@@ -0,0 +1,110 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The recorded changes", func() {
+	var (
+		r        *ReconcileWordpress
+		recorder *record.FakeRecorder
+		wp       *wordpress.Wordpress
+		old      *wordpressv1alpha1.WordpressStatus
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", Generation: 3},
+		})
+		old = wp.Status.DeepCopy()
+
+		r = newTestReconciler()
+		recorder = r.recorder.(*record.FakeRecorder)
+	})
+
+	It("should record the rollouts of new code versions", func() {
+		old.CodeVersion = "v1"
+		wp.Status.CodeVersion = "v2"
+
+		r.recordChanges(wp, old)
+
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal " + wordpressv1alpha1.RolledOutReason + " the web pods were rolled out with v2, replacing v1")))
+		Expect(wp.Status.History).To(HaveLen(1))
+		Expect(wp.Status.History[0].Reason).To(Equal(wordpressv1alpha1.RolledOutReason))
+		Expect(wp.Status.History[0].Generation).To(Equal(int64(3)))
+	})
+
+	It("should not record the first rollout or an unchanged status", func() {
+		wp.Status.CodeVersion = "v1"
+		wp.Status.HomeURL = "https://example.com"
+
+		r.recordChanges(wp, old)
+
+		old = wp.Status.DeepCopy()
+		r.recordChanges(wp, old)
+
+		Expect(recorder.Events).NotTo(Receive())
+		Expect(wp.Status.History).To(BeEmpty())
+	})
+
+	It("should record the bootstrap from an imported database", func() {
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{
+			ImportFrom: &wordpressv1alpha1.DatabaseImportSource{},
+		}
+		wp.Status.Bootstrapped = true
+
+		r.recordChanges(wp, old)
+
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal " + wordpressv1alpha1.BootstrappedReason + " the site was bootstrapped from the imported database")))
+	})
+
+	It("should only record the syncs which corrected a drift", func() {
+		wp.Status.PluginsSyncedFor = "v1"
+		wp.Status.ThemesSyncedFor = "v1"
+		wp.Status.PluginsDrift = []string{"akismet was deactivated"}
+
+		r.recordChanges(wp, old)
+
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal " + wordpressv1alpha1.PluginsSyncedReason + " the plugins were synced with the spec: akismet was deactivated")))
+		Expect(recorder.Events).NotTo(Receive())
+		Expect(wp.Status.History).To(HaveLen(1))
+	})
+
+	It("should keep the latest changes in the history", func() {
+		for i := 0; i < historyLimit+5; i++ {
+			r.recordChange(wp, wordpressv1alpha1.HomeURLChangedReason, fmt.Sprintf("change %d", i))
+		}
+
+		Expect(wp.Status.History).To(HaveLen(historyLimit))
+		Expect(wp.Status.History[0].Message).To(Equal("change 5"))
+		Expect(wp.Status.History[historyLimit-1].Message).To(Equal(fmt.Sprintf("change %d", historyLimit+4)))
+	})
+})
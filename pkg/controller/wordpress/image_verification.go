@@ -0,0 +1,164 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errImagesNotVerified = errors.New("the signatures of the site's images are not verified")
+
+// syncImageVerification runs a Job verifying the cosign signatures of the
+// site's images whenever the images, the verification policy or its public
+// key change. It
+// returns true until the signatures are verified, as the pods are not
+// rendered with unverified images, along with an error once the
+// verification failed.
+func (r *ReconcileWordpress) syncImageVerification(ctx context.Context, wp *wordpress.Wordpress) (bool, error) {
+	jobLabels := wp.ComponentLabels(wordpress.WordpressImageVerification)
+
+	if !wp.VerifiesImages() {
+		wp.Status.ImagesVerifiedFor = ""
+		wp.RemoveCondition(wordpressv1alpha1.ImagesVerifiedCondition)
+
+		return false, r.cleanupJobs(ctx, wp, jobLabels, "")
+	}
+
+	if err := wp.CanVerifyImages(); err != nil {
+		r.setImagesVerified(wp, corev1.ConditionFalse, wordpressv1alpha1.ImageVerificationMisconfiguredReason, err.Error())
+
+		return true, fmt.Errorf("%w: %s", errImagesNotVerified, err)
+	}
+
+	if spec := wp.Spec.ImageVerification; spec != nil && spec.PublicKeySecretRef != nil {
+		key := &corev1.Secret{}
+
+		err := r.Get(ctx, client.ObjectKey{Namespace: wp.Namespace, Name: spec.PublicKeySecretRef.Name}, key)
+		if apierrors.IsNotFound(err) {
+			message := fmt.Sprintf("the %s public key secret is not found", spec.PublicKeySecretRef.Name)
+			r.setImagesVerified(wp, corev1.ConditionFalse, wordpressv1alpha1.ImageVerificationMisconfiguredReason, message)
+
+			return true, fmt.Errorf("%w: %s", errImagesNotVerified, message)
+		} else if err != nil {
+			return true, err
+		}
+
+		wp.SetImageVerificationKeyVersion(key.ResourceVersion)
+	}
+
+	version := wp.ImageVerificationVersion()
+	if wp.Status.ImagesVerifiedFor == version {
+		return false, nil
+	}
+
+	jobSyncer := sync.NewImageVerificationJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return true, err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+
+	switch {
+	case isJobFailed(job):
+		message, err := r.imageVerificationFailure(ctx, wp, job)
+		if err != nil {
+			return true, err
+		}
+
+		r.setImagesVerified(wp, corev1.ConditionFalse, wordpressv1alpha1.ImageSignatureInvalidReason, message)
+
+		return true, fmt.Errorf("%w: %s", errImagesNotVerified, message)
+	case job.Status.Succeeded == 0:
+		r.setImagesVerified(wp, corev1.ConditionFalse, wordpressv1alpha1.ImageVerificationPendingReason,
+			fmt.Sprintf("the %s job verifies the signatures of the site's images", job.Name))
+
+		return true, nil
+	}
+
+	wp.Status.ImagesVerifiedFor = version
+	r.setImagesVerified(wp, corev1.ConditionTrue, wordpressv1alpha1.ImagesVerifiedReason,
+		"the signatures of the site's images were verified")
+
+	// verification jobs are named after the images and the verification policy
+	return false, r.cleanupJobs(ctx, wp, jobLabels, job.Name)
+}
+
+// imageVerificationFailure returns the images whose signatures couldn't be
+// verified by the failed Job, along with the last line of cosign's error.
+func (r *ReconcileWordpress) imageVerificationFailure(ctx context.Context, wp *wordpress.Wordpress, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+
+	// the pods are not cached
+	err := r.apiReader.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"controller-uid": string(job.UID)},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	failures := []string{}
+	seen := map[string]bool{}
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			if status.State.Terminated == nil || status.State.Terminated.ExitCode == 0 || seen[status.Name] {
+				continue
+			}
+
+			seen[status.Name] = true
+
+			lines := strings.Split(strings.TrimSpace(status.State.Terminated.Message), "\n")
+			failures = append(failures, fmt.Sprintf("%s: %s", wp.VerifiedImage(status.Name), lines[len(lines)-1]))
+		}
+	}
+
+	if len(failures) == 0 {
+		return fmt.Sprintf("the %s job failed, delete it to retry", job.Name), nil
+	}
+
+	return fmt.Sprintf("the signatures of %s couldn't be verified, delete the %s job to retry",
+		strings.Join(failures, "; "), job.Name), nil
+}
+
+// setImagesVerified sets the ImagesVerified condition, recording an event
+// when its reason changes.
+func (r *ReconcileWordpress) setImagesVerified(wp *wordpress.Wordpress, status corev1.ConditionStatus, reason, message string) {
+	if cond := wp.GetCondition(wordpressv1alpha1.ImagesVerifiedCondition); cond == nil || cond.Reason != reason {
+		eventType := corev1.EventTypeNormal
+		if reason == wordpressv1alpha1.ImageSignatureInvalidReason || reason == wordpressv1alpha1.ImageVerificationMisconfiguredReason {
+			eventType = corev1.EventTypeWarning
+		}
+
+		r.recorder.Event(wp.Unwrap(), eventType, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.ImagesVerifiedCondition, status, reason, message)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/presslabs/controller-util/syncer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The image verification gate", func() {
+	var wp *wordpress.Wordpress
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		})
+		wp.SetDefaults()
+	})
+
+	It("should leave out the Jobs and the CronJobs", func() {
+		r := newTestReconciler()
+
+		syncers := withoutJobs([]syncer.Interface{
+			sync.NewServiceSyncer(wp, r.Client),
+			sync.NewWPCronSyncer(wp, r.Client),
+			sync.NewDBUsageJobSyncer(wp, r.Client),
+		})
+
+		Expect(syncers).To(HaveLen(1))
+		Expect(syncers[0].Object()).To(BeAssignableToTypeOf(&corev1.Service{}))
+	})
+
+	It("should read the web workload as it is", func() {
+		replicas := int32(3)
+		r := newTestReconciler(&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: wp.ComponentName(wordpress.WordpressDeployment), Namespace: wp.Namespace},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		})
+
+		deploy := &appsv1.Deployment{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressDeployment))}
+		Expect(r.getWorkload(context.TODO(), deploy)).To(Succeed())
+		Expect(deploy.Spec.Replicas).To(PointTo(Equal(replicas)))
+	})
+
+	It("should leave a missing web workload empty", func() {
+		r := newTestReconciler()
+
+		sts := &appsv1.StatefulSet{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressStatefulSet))}
+		Expect(r.getWorkload(context.TODO(), sts)).To(Succeed())
+		Expect(webReplicas(sts)).To(BeZero())
+		Expect(isWorkloadRolledOut(sts)).To(BeFalse())
+	})
+
+	It("should verify the images again when the public key changes", func() {
+		wp.Spec.Image = "docker.io/bitpoke/wordpress-runtime@sha256:" + strings.Repeat("a", 64)
+		wp.Spec.ImageVerification = &wordpressv1alpha1.ImageVerificationSpec{
+			PublicKeySecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "cosign"},
+				Key:                  "cosign.pub",
+			},
+		}
+
+		r := newTestReconciler()
+
+		pending, err := r.syncImageVerification(context.TODO(), wp)
+		Expect(err).To(MatchError(ContainSubstring("the cosign public key secret is not found")))
+		Expect(pending).To(BeTrue())
+		Expect(wp.GetCondition(wordpressv1alpha1.ImagesVerifiedCondition).Reason).
+			To(Equal(wordpressv1alpha1.ImageVerificationMisconfiguredReason))
+
+		key := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "cosign", Namespace: wp.Namespace},
+			Data:       map[string][]byte{"cosign.pub": []byte("old")},
+		}
+		Expect(r.Create(context.TODO(), key)).To(Succeed())
+		Expect(r.Get(context.TODO(), client.ObjectKeyFromObject(key), key)).To(Succeed())
+
+		wp.SetImageVerificationKeyVersion(key.ResourceVersion)
+		wp.Status.ImagesVerifiedFor = wp.ImageVerificationVersion()
+
+		pending, err = r.syncImageVerification(context.TODO(), wp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(BeFalse())
+
+		key.Data["cosign.pub"] = []byte("new")
+		Expect(r.Update(context.TODO(), key)).To(Succeed())
+
+		pending, err = r.syncImageVerification(context.TODO(), wp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pending).To(BeTrue())
+		Expect(wp.GetCondition(wordpressv1alpha1.ImagesVerifiedCondition).Reason).
+			To(Equal(wordpressv1alpha1.ImageVerificationPendingReason))
+	})
+})
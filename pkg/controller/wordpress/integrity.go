@@ -0,0 +1,123 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// modifiedFilesInMessage is the number of modified files listed in the
+// IntegrityCompromised condition, all of them being listed in the status.
+const modifiedFilesInMessage = 5
+
+// syncIntegrityCheck publishes the files found modified by the last integrity
+// check Job, run by the integrity check CronJob, in the status and in the
+// IntegrityCompromised condition.
+func (r *ReconcileWordpress) syncIntegrityCheck(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.SchedulesIntegrityCheck() {
+		wp.Status.IntegrityCheck = nil
+		wp.RemoveCondition(wordpressv1alpha1.IntegrityCompromisedCondition)
+
+		return nil
+	}
+
+	if wp.Status.IntegrityCheck == nil {
+		wp.Status.IntegrityCheck = &wordpressv1alpha1.IntegrityCheckStatus{}
+	}
+
+	status := wp.Status.IntegrityCheck
+
+	finished, err := r.finishedJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressIntegrityCheck), status.CheckedAt)
+	if err != nil || len(finished) == 0 {
+		return err
+	}
+
+	// only the results of the last run are published
+	job := finished[len(finished)-1]
+	status.CheckedAt = jobFinishedAt(job)
+
+	if isJobFailed(job) {
+		status.ModifiedFiles = nil
+		r.setIntegrityCompromised(wp, corev1.ConditionUnknown, wordpressv1alpha1.IntegrityCheckErrorReason,
+			fmt.Sprintf("the integrity check job %s failed to verify the checksums", job.Name))
+
+		return nil
+	}
+
+	report, err := r.jobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	status.ModifiedFiles = wordpress.ParseIntegrityCheck(report)
+
+	if len(status.ModifiedFiles) == 0 {
+		r.setIntegrityCompromised(wp, corev1.ConditionFalse, wordpressv1alpha1.IntegrityVerifiedReason,
+			"the core and plugin files match their checksums")
+
+		return nil
+	}
+
+	r.setIntegrityCompromised(wp, corev1.ConditionTrue, wordpressv1alpha1.FilesModifiedReason, modifiedFilesMessage(status.ModifiedFiles))
+
+	return nil
+}
+
+// modifiedFilesMessage summarizes the modified files, e.g. 7 files don't match
+// their checksums: wp-includes/version.php, akismet/akismet.php, ...
+func modifiedFilesMessage(files []wordpressv1alpha1.ModifiedFile) string {
+	paths := []string{}
+
+	for i := range files {
+		if i == modifiedFilesInMessage {
+			paths = append(paths, "...")
+
+			break
+		}
+
+		if files[i].Component == "core" {
+			paths = append(paths, files[i].Path)
+		} else {
+			paths = append(paths, path.Join(files[i].Component, files[i].Path))
+		}
+	}
+
+	return fmt.Sprintf("%d files don't match their checksums: %s", len(files), strings.Join(paths, ", "))
+}
+
+// setIntegrityCompromised sets the IntegrityCompromised condition, recording
+// an event when its reason or its message changes.
+func (r *ReconcileWordpress) setIntegrityCompromised(wp *wordpress.Wordpress, status corev1.ConditionStatus, reason, message string) {
+	if cond := wp.GetCondition(wordpressv1alpha1.IntegrityCompromisedCondition); cond == nil || cond.Reason != reason || cond.Message != message {
+		eventType := corev1.EventTypeNormal
+		if reason != wordpressv1alpha1.IntegrityVerifiedReason {
+			eventType = corev1.EventTypeWarning
+		}
+
+		r.recorder.Event(wp.Unwrap(), eventType, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.IntegrityCompromisedCondition, status, reason, message)
+}
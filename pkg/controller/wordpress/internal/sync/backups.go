@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// NewBackupsCronJobSyncer returns a new sync.Interface for reconciling the
+// CronJob scheduling the site's backups. Its last schedule time is picked up
+// by the controller, which creates the WordpressBackups.
+func NewBackupsCronJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressBackups)
+
+	obj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressBackups),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	var (
+		historyLimit          int32
+		backoffLimit          int32
+		activeDeadlineSeconds int64 = 300
+	)
+
+	return syncer.NewObjectSyncer("BackupsCronJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		obj.Spec.Schedule = wp.Spec.Backups.Schedule
+		obj.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+		obj.Spec.SuccessfulJobsHistoryLimit = &historyLimit
+		obj.Spec.FailedJobsHistoryLimit = &historyLimit
+
+		// the trigger jobs are not kept, so the job policy doesn't apply to them
+		obj.Spec.JobTemplate.ObjectMeta.Labels = objLabels
+		obj.Spec.JobTemplate.Spec.BackoffLimit = &backoffLimit
+		obj.Spec.JobTemplate.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+
+		template := wp.BackupsTriggerPodTemplateSpec()
+
+		obj.Spec.JobTemplate.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.JobTemplate.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+// NewBackupVerificationCronJobSyncer returns a new sync.Interface for
+// reconciling the CronJob verifying that the given backup, the last succeeded
+// one, can be restored. Its jobs are annotated with the backup's name, so
+// that their results are attributed to it.
+func NewBackupVerificationCronJobSyncer(wp *wordpress.Wordpress, backup *wordpressv1alpha1.WordpressBackup,
+	c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressBackupVerification)
+
+	obj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressBackupVerification),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	var (
+		historyLimit          int32 = 1
+		backoffLimit          int32
+		activeDeadlineSeconds int64 = 1800
+	)
+
+	return syncer.NewObjectSyncer("BackupVerificationCronJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		obj.Spec.Schedule = wp.Spec.Backups.Verification.Schedule
+		obj.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+		obj.Spec.SuccessfulJobsHistoryLimit = &historyLimit
+		obj.Spec.FailedJobsHistoryLimit = &historyLimit
+
+		// the jobs are labeled so that their results are found by the controller
+		obj.Spec.JobTemplate.ObjectMeta.Labels = objLabels
+		obj.Spec.JobTemplate.ObjectMeta.Annotations = map[string]string{
+			wordpress.VerifiedBackupAnnotation: backup.Name,
+		}
+		obj.Spec.JobTemplate.Spec.BackoffLimit = &backoffLimit
+		obj.Spec.JobTemplate.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+		applyCronJobPolicy(wp, &obj.Spec)
+
+		template := wp.BackupVerificationPodTemplateSpec(backup)
+
+		obj.Spec.JobTemplate.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.JobTemplate.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
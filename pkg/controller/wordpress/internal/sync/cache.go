@@ -0,0 +1,225 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// NewMemcachedDeploymentSyncer returns a new sync.Interface for reconciling the shared memcached Deployment.
+func NewMemcachedDeploymentSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMemcachedDeployment)
+
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressMemcachedDeployment),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("MemcachedDeployment", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		return mutateCacheDeployment(obj, wp.MemcachedPodTemplateSpec())
+	})
+}
+
+// NewMemcachedServiceSyncer returns a new sync.Interface for reconciling the shared memcached Service.
+func NewMemcachedServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMemcachedService)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressMemcachedService),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("MemcachedService", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		return mutateWebService(obj, wp.ComponentLabels(wordpress.WordpressMemcachedDeployment), []corev1.ServicePort{
+			{
+				Name:       "memcached",
+				Port:       wordpress.MemcachedPort,
+				TargetPort: intstr.FromString("memcached"),
+			},
+		})
+	})
+}
+
+// NewPageCacheDeploymentSyncer returns a new sync.Interface for reconciling the page cache Deployment.
+func NewPageCacheDeploymentSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressPageCacheDeployment)
+
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressPageCacheDeployment),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("PageCacheDeployment", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		return mutateCacheDeployment(obj, wp.PageCachePodTemplateSpec())
+	})
+}
+
+// NewPageCacheServiceSyncer returns a new sync.Interface for reconciling the page cache Service.
+func NewPageCacheServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressPageCacheService)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressPageCacheService),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("PageCacheService", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		return mutateWebService(obj, wp.ComponentLabels(wordpress.WordpressPageCacheDeployment), []corev1.ServicePort{
+			{
+				Name:       "http",
+				Port:       80,
+				TargetPort: intstr.FromString("http"),
+			},
+		})
+	})
+}
+
+// NewCacheFlushJobSyncer returns a new sync.Interface for reconciling the Job
+// flushing the site's caches.
+func NewCacheFlushJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCacheFlush)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.JobName(wordpress.WordpressCacheFlush),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	var (
+		backoffLimit          int32 = 2
+		activeDeadlineSeconds int64 = 300
+	)
+
+	return syncer.NewObjectSyncer("CacheFlushJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job is named after the code version, so it is never updated
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+		obj.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+		applyJobPolicy(wp, &obj.Spec)
+
+		template := wp.CacheFlushPodTemplateSpec()
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+// NewCacheWarmupCronJobSyncer returns a new sync.Interface for reconciling
+// the CronJob warming up the site's caches.
+func NewCacheWarmupCronJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCacheWarmup)
+
+	obj := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressCacheWarmup),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	var (
+		historyLimit          int32 = 1
+		backoffLimit          int32 = 1
+		activeDeadlineSeconds int64 = 3600
+	)
+
+	return syncer.NewObjectSyncer("CacheWarmupCronJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		obj.Spec.Schedule = wp.Spec.Cache.Warmup.Schedule
+		obj.Spec.ConcurrencyPolicy = batchv1.ForbidConcurrent
+		obj.Spec.SuccessfulJobsHistoryLimit = &historyLimit
+		obj.Spec.FailedJobsHistoryLimit = &historyLimit
+
+		obj.Spec.JobTemplate.ObjectMeta.Labels = objLabels
+		obj.Spec.JobTemplate.Spec.BackoffLimit = &backoffLimit
+		obj.Spec.JobTemplate.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+		applyCronJobPolicy(wp, &obj.Spec)
+
+		template := wp.CacheWarmupPodTemplateSpec()
+
+		obj.Spec.JobTemplate.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.JobTemplate.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+// mutateCacheDeployment sets the pod template and selector of a cache
+// Deployment. It runs a single pod, as the cache is not shared between pods
+// and purging it through its service would reach only one of them.
+func mutateCacheDeployment(obj *appsv1.Deployment, template corev1.PodTemplateSpec) error {
+	selector := metav1.SetAsLabelSelector(template.Labels)
+	if !reflect.DeepEqual(selector, obj.Spec.Selector) {
+		if obj.ObjectMeta.CreationTimestamp.IsZero() {
+			obj.Spec.Selector = selector
+		} else {
+			return errImmutableDeploymentSelector
+		}
+	}
+
+	replicas := int32(1)
+	obj.Spec.Replicas = &replicas
+
+	obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+	err := mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	if err != nil {
+		return err
+	}
+
+	obj.Spec.Template.Spec.NodeSelector = template.Spec.NodeSelector
+	obj.Spec.Template.Spec.Tolerations = template.Spec.Tolerations
+
+	return nil
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	canaryAnnotationKey       = "nginx.ingress.kubernetes.io/canary"
+	canaryWeightAnnotationKey = "nginx.ingress.kubernetes.io/canary-weight"
+
+	defaultSmokeTestBackoffLimit = int32(2)
+)
+
+// NewCanaryDeploymentSyncer returns a new sync.Interface for reconciling the canary web Deployment.
+func NewCanaryDeploymentSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, config *corev1.ConfigMap, references string,
+	c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCanaryDeployment)
+
+	obj := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressCanaryDeployment),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	canary := wp.Canary()
+
+	return syncer.NewObjectSyncer("CanaryDeployment", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		template := canary.WebPodTemplateSpec()
+		template.Labels = labels.Merge(template.Labels, wp.CanaryPodLabels())
+
+		err := mutateWebDeployment(obj, canary, template, wp.CanaryPodLabels(), secret, config, references)
+		if err != nil {
+			return err
+		}
+
+		replicas := int32(1)
+		if wp.Spec.Canary.Replicas != nil {
+			replicas = *wp.Spec.Canary.Replicas
+		}
+
+		obj.Spec.Replicas = &replicas
+		obj.Spec.Strategy = deploymentStrategy(wp)
+
+		return nil
+	})
+}
+
+// NewCanaryServiceSyncer returns a new sync.Interface for reconciling the canary web Service.
+func NewCanaryServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCanaryService)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressCanaryService),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("CanaryService", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		return mutateWebService(obj, wp.CanaryPodLabels(), webServicePorts(wp))
+	})
+}
+
+// NewCanaryIngressSyncer returns a new sync.Interface for reconciling the canary
+// Ingress, which routes the given percentage of the site's traffic to the canary pods.
+func NewCanaryIngressSyncer(wp *wordpress.Wordpress, weight int32, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCanaryIngress)
+
+	obj := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressCanaryIngress),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	bk := netv1.IngressBackend{
+		Service: &netv1.IngressServiceBackend{
+			Name: wp.ComponentName(wordpress.WordpressCanaryService),
+			Port: netv1.ServiceBackendPort{Name: "http"},
+		},
+	}
+
+	return syncer.NewObjectSyncer("CanaryIngress", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		mutateIngress(obj, wp, bk)
+
+		obj.ObjectMeta.Annotations[canaryAnnotationKey] = "true"
+		obj.ObjectMeta.Annotations[canaryWeightAnnotationKey] = strconv.Itoa(int(weight))
+
+		return nil
+	})
+}
+
+// NewCanarySmokeTestJobSyncer returns a new sync.Interface for reconciling the canary smoke test Job.
+func NewCanarySmokeTestJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCanarySmokeTest)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.JobName(wordpress.WordpressCanarySmokeTest),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	smokeTest := wp.Spec.Canary.SmokeTest
+
+	return syncer.NewObjectSyncer("CanarySmokeTestJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job is named after the canary revision, so it is never updated
+			return nil
+		}
+
+		backoffLimit := defaultSmokeTestBackoffLimit
+
+		obj.Spec.BackoffLimit = &backoffLimit
+		applyJobPolicy(wp, &obj.Spec)
+
+		// the smoke test's backoff limit takes precedence over the job policy
+		if smokeTest.BackoffLimit != nil {
+			backoffLimit = *smokeTest.BackoffLimit
+			obj.Spec.BackoffLimit = &backoffLimit
+		}
+
+		obj.Spec.Template.Labels = objLabels
+		obj.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyNever
+		obj.Spec.Template.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
+		obj.Spec.Template.Spec.Containers = []corev1.Container{
+			{
+				Name:    "smoke-test",
+				Image:   smokeTest.Image,
+				Command: smokeTest.Command,
+				Args:    smokeTest.Args,
+				Env: []corev1.EnvVar{
+					{
+						Name:  "CANARY_URL",
+						Value: fmt.Sprintf("http://%s.%s.svc", wp.ComponentName(wordpress.WordpressCanaryService), wp.Namespace),
+					},
+					{
+						Name:  "SITE_URL",
+						Value: wp.HomeURL(),
+					},
+				},
+			},
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	defaultIssuerKind  = "Issuer"
+	defaultIssuerGroup = "cert-manager.io"
+)
+
+// CertificateGVK is the GroupVersionKind of cert-manager Certificates.
+var CertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+var errIssuerRefNotDefined = errors.New(".spec.tls.issuerRef is not defined")
+
+// NewCertificateSyncer returns a new sync.Interface for reconciling the
+// cert-manager Certificate for the site's domains.
+func NewCertificateSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCertificate)
+
+	obj := newUnstructured(CertificateGVK, wp.ComponentName(wordpress.WordpressCertificate), wp.Namespace)
+
+	return syncer.NewObjectSyncer("Certificate", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if wp.Spec.TLS == nil || wp.Spec.TLS.IssuerRef == nil {
+			return errIssuerRefNotDefined
+		}
+
+		issuerRef := wp.Spec.TLS.IssuerRef
+
+		kind := issuerRef.Kind
+		if kind == "" {
+			kind = defaultIssuerKind
+		}
+
+		group := issuerRef.Group
+		if group == "" {
+			group = defaultIssuerGroup
+		}
+
+		dnsNames := []interface{}{}
+		for _, domain := range wp.CertificateDomains() {
+			dnsNames = append(dnsNames, domain)
+		}
+
+		if err := unstructured.SetNestedField(obj.Object, wp.ComponentName(wordpress.WordpressCertificate), "spec", "secretName"); err != nil {
+			return err
+		}
+
+		if err := unstructured.SetNestedSlice(obj.Object, dnsNames, "spec", "dnsNames"); err != nil {
+			return err
+		}
+
+		return unstructured.SetNestedField(obj.Object, map[string]interface{}{
+			"name":  issuerRef.Name,
+			"kind":  kind,
+			"group": group,
+		}, "spec", "issuerRef")
+	})
+}
+
+// CertificateStatus returns the readiness of a cert-manager Certificate, the
+// message of its Ready condition and its expiration time.
+func CertificateStatus(obj *unstructured.Unstructured) (corev1.ConditionStatus, string, *metav1.Time) {
+	status, message := readyCondition(obj)
+
+	var notAfter *metav1.Time
+
+	if s, found, _ := unstructured.NestedString(obj.Object, "status", "notAfter"); found {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			notAfter = &metav1.Time{Time: t}
+		}
+	}
+
+	return status, message, notAfter
+}
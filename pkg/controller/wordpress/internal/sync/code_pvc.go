@@ -43,7 +43,7 @@ func NewCodePVCSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 	}
 
 	return syncer.NewObjectSyncer("CodePVC", wp.Unwrap(), obj, c, func() error {
-		obj.Labels = labels.Merge(labels.Merge(wp.Spec.CodeVolumeSpec.Labels, objLabels), controllerLabels)
+		obj.Labels = labels.Merge(labels.Merge(wp.Spec.CodeVolumeSpec.Labels, objLabels), controllerLabels(wp))
 
 		if wp.Spec.CodeVolumeSpec == nil || wp.Spec.CodeVolumeSpec.PersistentVolumeClaim == nil {
 			return errCodeVolumeClaimNotDefined
@@ -16,6 +16,85 @@ limitations under the License.
 
 package sync
 
-var controllerLabels = map[string]string{
-	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// controllerLabels returns the labels set on all the objects synced for the
+// site, besides their component labels.
+func controllerLabels(wp *wordpress.Wordpress) labels.Set {
+	return labels.Set{
+		"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+		wordpressv1alpha1.SiteLabel:    wp.Name,
+	}
+}
+
+// readyCondition returns the status and the message of the Ready condition of
+// an unstructured object.
+func readyCondition(obj *unstructured.Unstructured) (corev1.ConditionStatus, string) {
+	status := corev1.ConditionUnknown
+	message := ""
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+
+		if s, ok := cond["status"].(string); ok {
+			status = corev1.ConditionStatus(s)
+		}
+
+		message, _ = cond["message"].(string)
+	}
+
+	return status, message
+}
+
+// applyJobPolicy overrides the execution policy of a Job with the site's job
+// policy.
+func applyJobPolicy(wp *wordpress.Wordpress, spec *batchv1.JobSpec) {
+	// the finished jobs are kept, unless the policy sets a TTL
+	spec.TTLSecondsAfterFinished = nil
+
+	policy := wp.Spec.JobPolicy
+	if policy == nil {
+		return
+	}
+
+	if policy.TTLSecondsAfterFinished != nil {
+		ttl := *policy.TTLSecondsAfterFinished
+		spec.TTLSecondsAfterFinished = &ttl
+	}
+
+	if policy.BackoffLimit != nil {
+		backoffLimit := *policy.BackoffLimit
+		spec.BackoffLimit = &backoffLimit
+	}
+
+	if policy.ActiveDeadlineSeconds != nil {
+		activeDeadlineSeconds := *policy.ActiveDeadlineSeconds
+		spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+	}
+}
+
+// applyCronJobPolicy overrides the execution policy of a CronJob, and of the
+// Jobs it creates, with the site's job policy.
+func applyCronJobPolicy(wp *wordpress.Wordpress, spec *batchv1.CronJobSpec) {
+	applyJobPolicy(wp, &spec.JobTemplate.Spec)
+
+	if wp.Spec.JobPolicy == nil || wp.Spec.JobPolicy.HistoryLimit == nil {
+		return
+	}
+
+	historyLimit := *wp.Spec.JobPolicy.HistoryLimit
+	spec.SuccessfulJobsHistoryLimit = &historyLimit
+	spec.FailedJobsHistoryLimit = &historyLimit
 }
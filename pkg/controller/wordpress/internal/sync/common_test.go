@@ -0,0 +1,84 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The job policy", func() {
+	var (
+		wp           *wordpress.Wordpress
+		backoffLimit int32 = 2
+		deadline     int64 = 600
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		})
+		wp.SetDefaults()
+	})
+
+	It("should keep the defaults of the job when there's no policy", func() {
+		spec := batchv1.JobSpec{BackoffLimit: &backoffLimit, ActiveDeadlineSeconds: &deadline}
+
+		applyJobPolicy(wp, &spec)
+
+		Expect(*spec.BackoffLimit).To(Equal(backoffLimit))
+		Expect(*spec.ActiveDeadlineSeconds).To(Equal(deadline))
+		Expect(spec.TTLSecondsAfterFinished).To(BeNil())
+	})
+
+	It("should override the defaults of the job with the ones set by the policy", func() {
+		var (
+			ttl     int32 = 3600
+			retries int32
+		)
+
+		wp.Spec.JobPolicy = &wordpressv1alpha1.JobPolicySpec{
+			TTLSecondsAfterFinished: &ttl,
+			BackoffLimit:            &retries,
+		}
+		spec := batchv1.JobSpec{BackoffLimit: &backoffLimit, ActiveDeadlineSeconds: &deadline}
+
+		applyJobPolicy(wp, &spec)
+
+		Expect(*spec.TTLSecondsAfterFinished).To(Equal(ttl))
+		Expect(*spec.BackoffLimit).To(Equal(retries))
+		Expect(*spec.ActiveDeadlineSeconds).To(Equal(deadline))
+	})
+
+	It("should set the history limits of the cron jobs", func() {
+		var historyLimit int32 = 3
+
+		wp.Spec.JobPolicy = &wordpressv1alpha1.JobPolicySpec{HistoryLimit: &historyLimit}
+		spec := batchv1.CronJobSpec{}
+
+		applyCronJobPolicy(wp, &spec)
+
+		Expect(*spec.SuccessfulJobsHistoryLimit).To(Equal(historyLimit))
+		Expect(*spec.FailedJobsHistoryLimit).To(Equal(historyLimit))
+	})
+})
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/appscode/mergo"
+
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// NewCoreUpdateCheckJobSyncer returns a new sync.Interface for reconciling
+// the Job which checks for core updates.
+func NewCoreUpdateCheckJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCoreUpdateCheck)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressCoreUpdateCheck),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	var (
+		backoffLimit          int32 = 2
+		activeDeadlineSeconds int64 = 300
+	)
+
+	return syncer.NewObjectSyncer("CoreUpdateCheckJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job template is immutable
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+		obj.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+		applyJobPolicy(wp, &obj.Spec)
+
+		template := wp.CoreUpdateCheckPodTemplateSpec()
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+// NewCoreUpdateJobSyncer returns a new sync.Interface for reconciling the Job
+// which updates the core to the available version.
+func NewCoreUpdateJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressCoreUpdate)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.JobName(wordpress.WordpressCoreUpdate),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	var (
+		// the update is not retried, as it may leave the core half updated
+		backoffLimit          int32
+		activeDeadlineSeconds int64 = 1800
+	)
+
+	return syncer.NewObjectSyncer("CoreUpdateJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job is named after the version it updates to, so it is never updated
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+		obj.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+		applyJobPolicy(wp, &obj.Spec)
+
+		template := wp.CoreUpdatePodTemplateSpec()
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
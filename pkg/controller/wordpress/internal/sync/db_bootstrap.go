@@ -0,0 +1,58 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// NewDBBootstrapJobSyncer returns a new sync.Interface for reconciling the Job
+// which creates the site's database and user within the external database.
+func NewDBBootstrapJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressDBBootstrap)
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressDBBootstrap),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	var backoffLimit int32 = 6
+
+	return syncer.NewObjectSyncer("DBBootstrapJob", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		if !obj.CreationTimestamp.IsZero() {
+			// the job template is immutable
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+		applyJobPolicy(wp, &obj.Spec)
+		obj.Spec.Template = wp.DatabaseBootstrapPodTemplateSpec()
+
+		return nil
+	})
+}
@@ -19,11 +19,13 @@ package sync
 import (
 	"errors"
 	"reflect"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/appscode/mergo"
@@ -36,8 +38,35 @@ import (
 
 var errImmutableDeploymentSelector = errors.New("deployment selector is immutable")
 
+// deploymentStrategy returns the deployment strategy for the web pods. If not
+// specified, sites using ReadWriteOnce volumes are recreated, since the new
+// pods can't mount the volumes while the old ones are still running.
+func deploymentStrategy(wp *wordpress.Wordpress) appsv1.DeploymentStrategy {
+	if wp.Spec.DeploymentStrategy != nil {
+		return *wp.Spec.DeploymentStrategy.DeepCopy()
+	}
+
+	if wp.HasReadWriteOnceVolumes() {
+		return appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}
+	}
+
+	maxUnavailable := intstr.FromString("25%")
+	maxSurge := intstr.FromString("25%")
+
+	return appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxUnavailable: &maxUnavailable,
+			MaxSurge:       &maxSurge,
+		},
+	}
+}
+
 // NewDeploymentSyncer returns a new sync.Interface for reconciling web Deployment.
-func NewDeploymentSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, c client.Client) syncer.Interface {
+func NewDeploymentSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, config *corev1.ConfigMap, references string,
+	c client.Client) syncer.Interface {
 	objLabels := wp.ComponentLabels(wordpress.WordpressDeployment)
 
 	obj := &appsv1.Deployment{
@@ -48,42 +77,107 @@ func NewDeploymentSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, c clien
 	}
 
 	return syncer.NewObjectSyncer("Deployment", wp.Unwrap(), obj, c, func() error {
-		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
-
-		template := wp.WebPodTemplateSpec()
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
 
-		if len(template.Annotations) == 0 {
-			template.Annotations = make(map[string]string)
+		err := mutateWebDeployment(obj, wp, wp.WebPodTemplateSpec(), wp.WebPodLabels(), secret, config, references)
+		if err != nil {
+			return err
 		}
-		template.Annotations["wordpress.presslabs.org/secretVersion"] = secret.ResourceVersion
 
-		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+		obj.Spec.Replicas = webReplicas(wp, obj.Spec.Replicas, !obj.ObjectMeta.CreationTimestamp.IsZero())
 
-		selector := metav1.SetAsLabelSelector(wp.WebPodLabels())
-		if !reflect.DeepEqual(selector, obj.Spec.Selector) {
-			if obj.ObjectMeta.CreationTimestamp.IsZero() {
-				obj.Spec.Selector = selector
-			} else {
-				return errImmutableDeploymentSelector
-			}
-		}
+		obj.Spec.Strategy = deploymentStrategy(wp)
 
-		err := mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
-		if err != nil {
-			return err
+		return nil
+	})
+}
+
+// mutateWebDeployment sets the pod template and selector of a deployment running web pods.
+func mutateWebDeployment(obj *appsv1.Deployment, wp *wordpress.Wordpress, template corev1.PodTemplateSpec,
+	podLabels labels.Set, secret *corev1.Secret, config *corev1.ConfigMap, references string) error {
+	selector := metav1.SetAsLabelSelector(podLabels)
+	if !reflect.DeepEqual(selector, obj.Spec.Selector) {
+		if obj.ObjectMeta.CreationTimestamp.IsZero() {
+			obj.Spec.Selector = selector
+		} else {
+			return errImmutableDeploymentSelector
 		}
+	}
 
-		obj.Spec.Template.Spec.NodeSelector = wp.Spec.NodeSelector
-		obj.Spec.Template.Spec.Tolerations = wp.Spec.Tolerations
+	return mutateWebPodTemplate(&obj.Spec.Template, wp, template, secret, config, references)
+}
+
+// DeferrableRestartAnnotations returns the pod template annotations rolling
+// the web pods when the web server config map or the referenced Secrets and
+// ConfigMaps change, which is deferred to the site's maintenance window.
+func DeferrableRestartAnnotations(config *corev1.ConfigMap, references string) map[string]string {
+	annotations := map[string]string{}
 
-		if wp.Spec.Replicas != nil {
-			obj.Spec.Replicas = wp.Spec.Replicas
+	if config != nil {
+		annotations["wordpress.presslabs.org/webServerConfigVersion"] = config.ResourceVersion
+	}
+
+	if references != "" {
+		annotations["wordpress.presslabs.org/referencesVersion"] = references
+	}
+
+	return annotations
+}
+
+// mutateWebPodTemplate merges the generated web pod template into the workload's pod template.
+// The pods are rolled when the site's secret or web server config map changes,
+// as well as when the version of the referenced Secrets and ConfigMaps changes.
+// Outside of the maintenance window, the pods keep the previous versions of
+// the config map and of the referenced Secrets and ConfigMaps, while the
+// site's secret, holding the database credentials, always rolls them.
+func mutateWebPodTemplate(obj *corev1.PodTemplateSpec, wp *wordpress.Wordpress, template corev1.PodTemplateSpec,
+	secret *corev1.Secret, config *corev1.ConfigMap, references string) error {
+	if len(template.Annotations) == 0 {
+		template.Annotations = make(map[string]string)
+	}
+	template.Annotations["wordpress.presslabs.org/secretVersion"] = secret.ResourceVersion
+
+	deferred := wp.DefersRestarts(time.Now())
+
+	for k, v := range DeferrableRestartAnnotations(config, references) {
+		if current, ok := obj.Annotations[k]; ok && deferred {
+			v = current
 		}
 
-		if wp.Spec.DeploymentStrategy != nil {
-			obj.Spec.Strategy = *wp.Spec.DeploymentStrategy
+		template.Annotations[k] = v
+	}
+
+	obj.ObjectMeta = template.ObjectMeta
+
+	err := mergo.Merge(&obj.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	if err != nil {
+		return err
+	}
+
+	obj.Spec.NodeSelector = wp.Spec.NodeSelector
+	obj.Spec.Tolerations = wp.Spec.Tolerations
+	obj.Spec.Affinity = template.Spec.Affinity
+	obj.Spec.Subdomain = template.Spec.Subdomain
+
+	return nil
+}
+
+// webReplicas returns the number of web pods of a workload, given the current one.
+func webReplicas(wp *wordpress.Wordpress, current *int32, created bool) *int32 {
+	if wp.IsAutoscaled() {
+		// the replica count is managed by the autoscaler, so it is set only on creation
+		if created {
+			return current
 		}
 
-		return nil
-	})
+		replicas := wp.MinReplicas()
+
+		return &replicas
+	}
+
+	if wp.Spec.Replicas != nil {
+		return wp.Spec.Replicas
+	}
+
+	return current
 }
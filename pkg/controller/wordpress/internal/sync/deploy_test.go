@@ -0,0 +1,111 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The deploymentStrategy function", func() {
+	var wp *wordpress.Wordpress
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		})
+		wp.SetDefaults()
+	})
+
+	It("should default to rolling updates", func() {
+		strategy := deploymentStrategy(wp)
+		Expect(strategy.Type).To(Equal(appsv1.RollingUpdateDeploymentStrategyType))
+		Expect(strategy.RollingUpdate.MaxSurge.String()).To(Equal("25%"))
+		Expect(strategy.RollingUpdate.MaxUnavailable.String()).To(Equal("25%"))
+	})
+
+	It("should recreate the pods when using ReadWriteOnce volumes", func() {
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			},
+		}
+		Expect(deploymentStrategy(wp)).To(Equal(appsv1.DeploymentStrategy{
+			Type: appsv1.RecreateDeploymentStrategyType,
+		}))
+	})
+
+	It("should use the strategy specified in the Wordpress resource", func() {
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			},
+		}
+		wp.Spec.DeploymentStrategy = &appsv1.DeploymentStrategy{
+			Type: appsv1.RollingUpdateDeploymentStrategyType,
+		}
+		Expect(deploymentStrategy(wp)).To(Equal(*wp.Spec.DeploymentStrategy))
+	})
+})
+
+var _ = Describe("The mutateWebPodTemplate function", func() {
+	var (
+		wp     *wordpress.Wordpress
+		obj    *corev1.PodTemplateSpec
+		secret *corev1.Secret
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		})
+		wp.SetDefaults()
+
+		obj = &corev1.PodTemplateSpec{}
+		secret = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"}}
+	})
+
+	It("should defer the restarts for changed references until the maintenance window opens", func() {
+		Expect(mutateWebPodTemplate(obj, wp, wp.WebPodTemplateSpec(), secret, nil, "v1")).To(Succeed())
+		Expect(obj.Annotations).To(HaveKeyWithValue("wordpress.presslabs.org/referencesVersion", "v1"))
+
+		// a window which just closed, on every day
+		end := time.Now().UTC().Add(-time.Minute)
+		wp.Spec.MaintenanceWindow = &wordpressv1alpha1.MaintenanceWindow{
+			Start: end.Add(-time.Hour).Format("15:04"),
+			End:   end.Format("15:04"),
+		}
+
+		secret.ResourceVersion = "2"
+		Expect(mutateWebPodTemplate(obj, wp, wp.WebPodTemplateSpec(), secret, nil, "v2")).To(Succeed())
+		Expect(obj.Annotations).To(HaveKeyWithValue("wordpress.presslabs.org/referencesVersion", "v1"))
+		Expect(obj.Annotations).To(HaveKeyWithValue("wordpress.presslabs.org/secretVersion", "2"))
+
+		wp.Spec.MaintenanceWindow = nil
+		Expect(mutateWebPodTemplate(obj, wp, wp.WebPodTemplateSpec(), secret, nil, "v2")).To(Succeed())
+		Expect(obj.Annotations).To(HaveKeyWithValue("wordpress.presslabs.org/referencesVersion", "v2"))
+	})
+})
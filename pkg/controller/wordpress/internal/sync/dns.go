@@ -0,0 +1,121 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	externalDNSHostnameAnnotationKey = "external-dns.alpha.kubernetes.io/hostname"
+	externalDNSTTLAnnotationKey      = "external-dns.alpha.kubernetes.io/ttl"
+
+	defaultDNSRecordType = "CNAME"
+)
+
+// DNSEndpointGVK is the GroupVersionKind of external-dns DNSEndpoints.
+var DNSEndpointGVK = schema.GroupVersionKind{Group: "externaldns.k8s.io", Version: "v1alpha1", Kind: "DNSEndpoint"}
+
+var errDNSEndpointNotDefined = errors.New(".spec.dns.endpoint is not defined")
+
+// mutateDNSAnnotations sets the external-dns annotations publishing the given
+// hosts, or removes the managed ones if DNS is not enabled for the site.
+func mutateDNSAnnotations(annotations map[string]string, wp *wordpress.Wordpress, hosts []string) map[string]string {
+	dns := wp.Spec.DNS
+
+	if dns == nil || !dns.Enabled || len(hosts) == 0 {
+		delete(annotations, externalDNSHostnameAnnotationKey)
+		delete(annotations, externalDNSTTLAnnotationKey)
+
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	for k, v := range dns.Annotations {
+		annotations[k] = v
+	}
+
+	annotations[externalDNSHostnameAnnotationKey] = strings.Join(hosts, ",")
+
+	if dns.TTL != nil {
+		annotations[externalDNSTTLAnnotationKey] = strconv.FormatInt(*dns.TTL, 10)
+	} else {
+		delete(annotations, externalDNSTTLAnnotationKey)
+	}
+
+	return annotations
+}
+
+// NewDNSEndpointSyncer returns a new sync.Interface for reconciling the
+// external-dns DNSEndpoint for the site's domains.
+func NewDNSEndpointSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressDNSEndpoint)
+
+	obj := newUnstructured(DNSEndpointGVK, wp.ComponentName(wordpress.WordpressDNSEndpoint), wp.Namespace)
+
+	return syncer.NewObjectSyncer("DNSEndpoint", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if wp.Spec.DNS == nil || wp.Spec.DNS.Endpoint == nil {
+			return errDNSEndpointNotDefined
+		}
+
+		spec := wp.Spec.DNS.Endpoint
+
+		recordType := spec.RecordType
+		if recordType == "" {
+			recordType = defaultDNSRecordType
+		}
+
+		targets := []interface{}{}
+		for _, target := range spec.Targets {
+			targets = append(targets, target)
+		}
+
+		endpoints := []interface{}{}
+
+		for _, domain := range wp.Domains() {
+			endpoint := map[string]interface{}{
+				"dnsName":    domain,
+				"recordType": recordType,
+				"targets":    targets,
+			}
+
+			if wp.Spec.DNS.TTL != nil {
+				endpoint["recordTTL"] = *wp.Spec.DNS.TTL
+			}
+
+			endpoints = append(endpoints, endpoint)
+		}
+
+		return unstructured.SetNestedSlice(obj.Object, endpoints, "spec", "endpoints")
+	})
+}
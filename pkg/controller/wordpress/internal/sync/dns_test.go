@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The mutateDNSAnnotations function", func() {
+	var wp *wordpress.Wordpress
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes:  []wordpressv1alpha1.RouteSpec{{Domain: "example.com"}},
+				Aliases: []string{"www.example.com"},
+			},
+		})
+		wp.SetDefaults()
+	})
+
+	It("should publish the given hosts when DNS is enabled", func() {
+		ttl := int64(300)
+		wp.Spec.DNS = &wordpressv1alpha1.DNSSpec{
+			Enabled:     true,
+			TTL:         &ttl,
+			Annotations: map[string]string{"external-dns.alpha.kubernetes.io/target": "lb.example.net"},
+		}
+
+		annotations := mutateDNSAnnotations(nil, wp, wp.Domains())
+		Expect(annotations).To(HaveKeyWithValue(externalDNSHostnameAnnotationKey, "example.com,www.example.com"))
+		Expect(annotations).To(HaveKeyWithValue(externalDNSTTLAnnotationKey, "300"))
+		Expect(annotations).To(HaveKeyWithValue("external-dns.alpha.kubernetes.io/target", "lb.example.net"))
+	})
+
+	It("should remove the hostname annotations when DNS is disabled", func() {
+		annotations := map[string]string{
+			externalDNSHostnameAnnotationKey: "example.com",
+			externalDNSTTLAnnotationKey:      "300",
+			"other":                          "value",
+		}
+
+		Expect(mutateDNSAnnotations(annotations, wp, wp.Domains())).To(Equal(map[string]string{"other": "value"}))
+	})
+})
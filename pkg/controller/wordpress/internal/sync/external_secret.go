@@ -0,0 +1,89 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// ExternalSecretGVK is the GroupVersionKind of External Secrets Operator ExternalSecrets.
+var ExternalSecretGVK = schema.GroupVersionKind{Group: "external-secrets.io", Version: "v1beta1", Kind: "ExternalSecret"}
+
+var errExternalSecretNotDefined = errors.New(".spec.secretsProvider.externalSecret is not defined")
+
+// NewExternalSecretSyncer returns a new sync.Interface for reconciling the
+// ExternalSecret whose target secret is added to the site's environment.
+func NewExternalSecretSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressExternalSecret)
+
+	obj := newUnstructured(ExternalSecretGVK, wp.ComponentName(wordpress.WordpressExternalSecret), wp.Namespace)
+
+	return syncer.NewObjectSyncer("ExternalSecret", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if !wp.UsesExternalSecret() {
+			return errExternalSecretNotDefined
+		}
+
+		spec := wp.Spec.SecretsProvider.ExternalSecret
+
+		data := []interface{}{}
+
+		for _, d := range spec.Data {
+			remoteRef := map[string]interface{}{"key": d.RemoteKey}
+			if d.Property != "" {
+				remoteRef["property"] = d.Property
+			}
+
+			data = append(data, map[string]interface{}{
+				"secretKey": d.SecretKey,
+				"remoteRef": remoteRef,
+			})
+		}
+
+		dataFrom := []interface{}{}
+		for _, key := range spec.DataFrom {
+			dataFrom = append(dataFrom, map[string]interface{}{
+				"extract": map[string]interface{}{"key": key},
+			})
+		}
+
+		// the target secret is owned by the ExternalSecret, so it's removed along with it
+		return unstructured.SetNestedField(obj.Object, map[string]interface{}{
+			"refreshInterval": wp.ExternalSecretRefreshInterval().String(),
+			"secretStoreRef": map[string]interface{}{
+				"name": spec.SecretStoreRef.Name,
+				"kind": wp.ExternalSecretStoreKind(),
+			},
+			"target": map[string]interface{}{
+				"name":           wp.ComponentName(wordpress.WordpressExternalSecret),
+				"creationPolicy": "Owner",
+			},
+			"data":     data,
+			"dataFrom": dataFrom,
+		}, "spec")
+	})
+}
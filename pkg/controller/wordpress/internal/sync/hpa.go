@@ -0,0 +1,99 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errAutoscalingNotDefined = errors.New(".spec.autoscaling is not defined")
+
+func resourceUtilizationMetric(name corev1.ResourceName, utilization int32) autoscalingv2beta2.MetricSpec {
+	return autoscalingv2beta2.MetricSpec{
+		Type: autoscalingv2beta2.ResourceMetricSourceType,
+		Resource: &autoscalingv2beta2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2beta2.MetricTarget{
+				Type:               autoscalingv2beta2.UtilizationMetricType,
+				AverageUtilization: &utilization,
+			},
+		},
+	}
+}
+
+// webWorkloadKind returns the kind of the workload running the web pods.
+func webWorkloadKind(wp *wordpress.Wordpress) string {
+	if wp.IsStatefulSet() {
+		return "StatefulSet"
+	}
+
+	return "Deployment"
+}
+
+// NewHPASyncer returns a new sync.Interface for reconciling web HorizontalPodAutoscaler.
+func NewHPASyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressHPA)
+
+	obj := &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressHPA),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("HPA", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		spec := wp.Spec.Autoscaling
+		if spec == nil {
+			return errAutoscalingNotDefined
+		}
+
+		obj.Spec.ScaleTargetRef = autoscalingv2beta2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       webWorkloadKind(wp),
+			Name:       wp.ComponentName(wordpress.WordpressDeployment),
+		}
+
+		obj.Spec.MinReplicas = spec.MinReplicas
+		obj.Spec.MaxReplicas = spec.MaxReplicas
+
+		var metrics []autoscalingv2beta2.MetricSpec
+
+		if spec.TargetCPUUtilizationPercentage != nil {
+			metrics = append(metrics, resourceUtilizationMetric(corev1.ResourceCPU, *spec.TargetCPUUtilizationPercentage))
+		}
+
+		if spec.TargetMemoryUtilizationPercentage != nil {
+			metrics = append(metrics, resourceUtilizationMetric(corev1.ResourceMemory, *spec.TargetMemoryUtilizationPercentage))
+		}
+
+		obj.Spec.Metrics = append(metrics, spec.Metrics...)
+
+		return nil
+	})
+}
@@ -17,6 +17,11 @@ limitations under the License.
 package sync
 
 import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -24,11 +29,30 @@ import (
 
 	"github.com/presslabs/controller-util/syncer"
 
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
 	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
 	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
 )
 
-const ingressClassAnnotationKey = "kubernetes.io/ingress.class"
+const (
+	ingressClassAnnotationKey         = "kubernetes.io/ingress.class"
+	permanentRedirectAnnotationKey    = "nginx.ingress.kubernetes.io/permanent-redirect"
+	sslRedirectAnnotationKey          = "nginx.ingress.kubernetes.io/ssl-redirect"
+	forceSSLRedirectAnnotationKey     = "nginx.ingress.kubernetes.io/force-ssl-redirect"
+	configurationSnippetAnnotationKey = "nginx.ingress.kubernetes.io/configuration-snippet"
+	whitelistSourceRangeAnnotationKey = "nginx.ingress.kubernetes.io/whitelist-source-range"
+	authTypeAnnotationKey             = "nginx.ingress.kubernetes.io/auth-type"
+	authSecretAnnotationKey           = "nginx.ingress.kubernetes.io/auth-secret"
+	limitRPMAnnotationKey             = "nginx.ingress.kubernetes.io/limit-rpm"
+	limitBurstMultiplierAnnotationKey = "nginx.ingress.kubernetes.io/limit-burst-multiplier"
+
+	setHeaderDirective           = "more_set_headers"
+	realIPProxyProtocolDirective = "real_ip_header proxy_protocol;"
+	denyXMLRPCDirective          = `if ($uri ~ "/xmlrpc\.php$") { return 403; }`
+
+	defaultLoginRequestsPerMinute = int32(10)
+	defaultLoginBurstMultiplier   = int32(5)
+)
 
 func upsertPath(rules []netv1.IngressRule, domain, path string, bk netv1.IngressBackend) []netv1.IngressRule {
 	var rule *netv1.IngressRule
@@ -90,47 +114,346 @@ func NewIngressSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 		},
 	}
 
+	// when scaling on HTTP traffic, requests are routed through the KEDA
+	// interceptor, unless they go through the page cache, which forwards them
+	switch {
+	case wp.UsesPageCache():
+		bk.Service.Name = wp.ComponentName(wordpress.WordpressPageCacheService)
+	case wp.Spec.ScaleToZero != nil && wp.Spec.ScaleToZero.HTTP != nil:
+		bk.Service.Name = wp.ComponentName(wordpress.WordpressInterceptorService)
+	}
+
 	return syncer.NewObjectSyncer("Ingress", wp.Unwrap(), obj, c, func() error {
-		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		mutateIngress(obj, wp, bk)
+
+		obj.ObjectMeta.Annotations = mutateDNSAnnotations(obj.ObjectMeta.Annotations, wp, wp.Domains())
+
+		return nil
+	})
+}
+
+// NewAdminIngressSyncer returns a new sync.Interface for reconciling the
+// Ingress which restricts the access to the WordPress admin and login page.
+func NewAdminIngressSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressAdminIngress)
+
+	obj := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressAdminIngress),
+			Namespace: wp.Namespace,
+		},
+	}
 
-		if len(obj.ObjectMeta.Annotations) == 0 {
-			obj.ObjectMeta.Annotations = make(map[string]string)
+	bk := netv1.IngressBackend{
+		Service: &netv1.IngressServiceBackend{
+			Name: wp.ComponentName(wordpress.WordpressService),
+			Port: netv1.ServiceBackendPort{Name: "http"},
+		},
+	}
+
+	return syncer.NewObjectSyncer("AdminIngress", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		mutateIngressClass(obj, wp)
+		mutateAdminAccessAnnotations(obj.ObjectMeta.Annotations, wp.Spec.AdminAccess)
+
+		rules := []netv1.IngressRule{}
+		domains := []string{}
+
+		for _, route := range wp.Routes() {
+			for _, p := range adminPaths(wp, route, "wp-admin", "wp-login.php") {
+				rules = upsertPath(rules, route.Domain, p, bk)
+			}
+
+			domains = append(domains, route.Domain)
 		}
 
-		for k, v := range wp.Spec.IngressAnnotations {
-			obj.ObjectMeta.Annotations[k] = v
+		obj.Spec.Rules = rules
+		obj.Spec.TLS = ingressTLS(wp, domains)
+
+		return nil
+	})
+}
+
+// mutateAdminAccessAnnotations sets the annotations restricting the access to an ingress.
+func mutateAdminAccessAnnotations(annotations map[string]string, spec *wordpressv1alpha1.AdminAccessSpec) {
+	if spec != nil && len(spec.AllowedSourceRanges) > 0 {
+		annotations[whitelistSourceRangeAnnotationKey] = strings.Join(spec.AllowedSourceRanges, ",")
+	} else {
+		delete(annotations, whitelistSourceRangeAnnotationKey)
+	}
+
+	if spec != nil && len(spec.BasicAuthSecretRef) > 0 {
+		annotations[authTypeAnnotationKey] = "basic"
+		annotations[authSecretAnnotationKey] = string(spec.BasicAuthSecretRef)
+	} else {
+		delete(annotations, authTypeAnnotationKey)
+		delete(annotations, authSecretAnnotationKey)
+	}
+}
+
+// NewLoginIngressSyncer returns a new sync.Interface for reconciling the
+// Ingress which rate limits the requests to the login page and xmlrpc.php.
+func NewLoginIngressSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressLoginIngress)
+
+	obj := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressLoginIngress),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	bk := netv1.IngressBackend{
+		Service: &netv1.IngressServiceBackend{
+			Name: wp.ComponentName(wordpress.WordpressService),
+			Port: netv1.ServiceBackendPort{Name: "http"},
+		},
+	}
+
+	return syncer.NewObjectSyncer("LoginIngress", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		mutateLoginIngress(obj, wp, bk)
+
+		return nil
+	})
+}
+
+// mutateLoginIngress sets the annotations, class, rules and TLS of the ingress
+// protecting the login page and xmlrpc.php. When the admin access is
+// restricted, the login page is left to the admin ingress.
+func mutateLoginIngress(obj *netv1.Ingress, wp *wordpress.Wordpress, bk netv1.IngressBackend) {
+	mutateIngressClass(obj, wp)
+	mutateLoginProtectionAnnotations(obj.ObjectMeta.Annotations, wp.Spec.LoginProtection)
+
+	files := []string{"xmlrpc.php"}
+	if wp.Spec.AdminAccess == nil {
+		files = append(files, "wp-login.php")
+	}
+
+	rules := []netv1.IngressRule{}
+	domains := []string{}
+
+	for _, route := range wp.Routes() {
+		for _, p := range adminPaths(wp, route, files...) {
+			rules = upsertPath(rules, route.Domain, p, bk)
 		}
-		delete(obj.ObjectMeta.Annotations, ingressClassAnnotationKey)
 
-		if options.IngressClass != "" {
-			obj.Spec.IngressClassName = &options.IngressClass
-		} else {
-			obj.Spec.IngressClassName = nil
+		domains = append(domains, route.Domain)
+	}
+
+	obj.Spec.Rules = rules
+	obj.Spec.TLS = ingressTLS(wp, domains)
+}
+
+// mutateLoginProtectionAnnotations sets the annotations rate limiting the
+// requests to an ingress and denying the ones to xmlrpc.php, if disabled.
+func mutateLoginProtectionAnnotations(annotations map[string]string, spec *wordpressv1alpha1.LoginProtectionSpec) {
+	rpm := defaultLoginRequestsPerMinute
+	if spec.RequestsPerMinute > 0 {
+		rpm = spec.RequestsPerMinute
+	}
+
+	burst := defaultLoginBurstMultiplier
+	if spec.BurstMultiplier > 0 {
+		burst = spec.BurstMultiplier
+	}
+
+	annotations[limitRPMAnnotationKey] = strconv.Itoa(int(rpm))
+	annotations[limitBurstMultiplierAnnotationKey] = strconv.Itoa(int(burst))
+
+	// the configuration snippet is rendered, without the directive, by mutateIngressClass
+	if spec.DisableXMLRPC {
+		snippet := []string{denyXMLRPCDirective}
+		if s := annotations[configurationSnippetAnnotationKey]; len(s) > 0 {
+			snippet = append(snippet, s)
 		}
 
+		annotations[configurationSnippetAnnotationKey] = strings.Join(snippet, "\n")
+	}
+}
+
+// adminPaths returns the paths of the given WordPress admin files of a route,
+// both directly under the route's path and under the WordPress path prefix.
+func adminPaths(wp *wordpress.Wordpress, route wordpressv1alpha1.RouteSpec, files ...string) []string {
+	paths := []string{}
+
+	for _, prefix := range []string{"/", wp.Spec.WordpressPathPrefix} {
+		for _, file := range files {
+			paths = append(paths, path.Join("/", wp.RoutePath(route), prefix, file))
+		}
+	}
+
+	return paths
+}
+
+// NewAliasesIngressSyncer returns a new sync.Interface for reconciling the
+// Ingress which redirects the site's aliases to its main domain.
+func NewAliasesIngressSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressAliasesIngress)
+
+	obj := &netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressAliasesIngress),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	// the backend is never reached, but it is required by the ingress spec
+	bk := netv1.IngressBackend{
+		Service: &netv1.IngressServiceBackend{
+			Name: wp.ComponentName(wordpress.WordpressService),
+			Port: netv1.ServiceBackendPort{Name: "http"},
+		},
+	}
+
+	return syncer.NewObjectSyncer("AliasesIngress", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		mutateIngressClass(obj, wp)
+
+		obj.ObjectMeta.Annotations[permanentRedirectAnnotationKey] = fmt.Sprintf("%s://%s$request_uri", wp.Scheme(), wp.MainDomain())
+
 		rules := []netv1.IngressRule{}
-		for _, route := range wp.Spec.Routes {
-			path := route.Path
-			if path == "" {
-				path = "/"
-			}
-			rules = upsertPath(rules, route.Domain, path, bk)
+		for _, alias := range wp.Spec.Aliases {
+			rules = upsertPath(rules, alias, "/", bk)
 		}
 
 		obj.Spec.Rules = rules
 
-		if len(wp.Spec.TLSSecretRef) > 0 {
-			tls := netv1.IngressTLS{
-				SecretName: string(wp.Spec.TLSSecretRef),
-			}
-			for _, route := range wp.Spec.Routes {
-				tls.Hosts = append(tls.Hosts, route.Domain)
-			}
-			obj.Spec.TLS = []netv1.IngressTLS{tls}
-		} else {
-			obj.Spec.TLS = nil
-		}
+		obj.Spec.TLS = ingressTLS(wp, wp.Spec.Aliases)
 
 		return nil
 	})
 }
+
+// mutateIngressClass sets the annotations and class of an ingress.
+func mutateIngressClass(obj *netv1.Ingress, wp *wordpress.Wordpress) {
+	if len(obj.ObjectMeta.Annotations) == 0 {
+		obj.ObjectMeta.Annotations = make(map[string]string)
+	}
+
+	for k, v := range wp.Spec.IngressAnnotations {
+		obj.ObjectMeta.Annotations[k] = v
+	}
+	delete(obj.ObjectMeta.Annotations, ingressClassAnnotationKey)
+
+	mutateTLSAnnotations(obj.ObjectMeta.Annotations, wp)
+	mutateConfigurationSnippet(obj.ObjectMeta.Annotations, wp)
+
+	ingressClass := options.IngressClass
+	if wp.Spec.IngressClassName != "" {
+		ingressClass = wp.Spec.IngressClassName
+	}
+
+	if ingressClass != "" {
+		obj.Spec.IngressClassName = &ingressClass
+	} else {
+		obj.Spec.IngressClassName = nil
+	}
+}
+
+// mutateTLSAnnotations sets the annotations enforcing HTTPS.
+func mutateTLSAnnotations(annotations map[string]string, wp *wordpress.Wordpress) {
+	if wp.Scheme() == "https" {
+		redirect := strconv.FormatBool(wp.RedirectToHTTPS())
+		annotations[sslRedirectAnnotationKey] = redirect
+		annotations[forceSSLRedirectAnnotationKey] = redirect
+	} else {
+		delete(annotations, sslRedirectAnnotationKey)
+		delete(annotations, forceSSLRedirectAnnotationKey)
+	}
+}
+
+// mutateConfigurationSnippet appends the directives setting the site's response
+// headers and real client IP to the configuration snippet, replacing the
+// previously set ones.
+func mutateConfigurationSnippet(annotations map[string]string, wp *wordpress.Wordpress) {
+	snippet := []string{}
+
+	for _, line := range strings.Split(annotations[configurationSnippetAnnotationKey], "\n") {
+		if len(line) > 0 && line != realIPProxyProtocolDirective && line != denyXMLRPCDirective && !isResponseHeaderDirective(line) {
+			snippet = append(snippet, line)
+		}
+	}
+
+	if wp.IngressProxyProtocol() {
+		snippet = append(snippet, realIPProxyProtocolDirective)
+	}
+
+	for _, header := range wp.ResponseHeaders() {
+		snippet = append(snippet, fmt.Sprintf(`%s "%s: %s";`, setHeaderDirective, header.Name, header.Value))
+	}
+
+	if len(snippet) > 0 {
+		annotations[configurationSnippetAnnotationKey] = strings.Join(snippet, "\n")
+	} else {
+		delete(annotations, configurationSnippetAnnotationKey)
+	}
+}
+
+func isResponseHeaderDirective(line string) bool {
+	for _, name := range wordpress.ResponseHeaderNames {
+		if strings.HasPrefix(line, fmt.Sprintf(`%s "%s:`, setHeaderDirective, name)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mutateIngress sets the annotations, class, rules and TLS of an ingress
+// routing the site's traffic to the given backend.
+func mutateIngress(obj *netv1.Ingress, wp *wordpress.Wordpress, bk netv1.IngressBackend) {
+	mutateIngressClass(obj, wp)
+
+	rules := []netv1.IngressRule{}
+	domains := []string{}
+
+	for _, route := range wp.Routes() {
+		rules = upsertPath(rules, route.Domain, wp.RoutePath(route), bk)
+		domains = append(domains, route.Domain)
+
+		// keep admin-ajax.php public, as it's used by themes and plugins
+		if wp.Spec.AdminAccess != nil {
+			for _, p := range adminPaths(wp, route, "wp-admin/admin-ajax.php") {
+				rules = upsertPath(rules, route.Domain, p, bk)
+			}
+		}
+	}
+
+	obj.Spec.Rules = rules
+	obj.Spec.TLS = ingressTLS(wp, domains)
+}
+
+// ingressTLS returns the TLS configuration of an ingress serving the given
+// domains, grouping them by TLS secret.
+func ingressTLS(wp *wordpress.Wordpress, domains []string) []netv1.IngressTLS {
+	var tls []netv1.IngressTLS
+
+	index := map[string]int{}
+	seen := map[string]bool{}
+
+	for _, domain := range domains {
+		secretName := wp.DomainTLSSecretName(domain)
+		if len(secretName) == 0 || seen[domain] {
+			continue
+		}
+
+		seen[domain] = true
+
+		i, ok := index[secretName]
+		if !ok {
+			i = len(tls)
+			index[secretName] = i
+			tls = append(tls, netv1.IngressTLS{SecretName: secretName})
+		}
+
+		tls[i].Hosts = append(tls[i].Hosts, domain)
+	}
+
+	return tls
+}
@@ -21,6 +21,11 @@ import (
 	. "github.com/onsi/gomega"
 
 	netv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
 )
 
 var _ = Describe("The upsertPath function", func() {
@@ -117,3 +122,240 @@ var _ = Describe("The upsertPath function", func() {
 		})
 	})
 })
+
+var _ = Describe("The mutateIngress function", func() {
+	var (
+		wp  *wordpress.Wordpress
+		obj *netv1.Ingress
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes: []wordpressv1alpha1.RouteSpec{{Domain: "bitpoke.io"}},
+			},
+		})
+		obj = &netv1.Ingress{}
+	})
+
+	AfterEach(func() {
+		options.IngressClass = ""
+	})
+
+	It("should use the default ingress class", func() {
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Spec.IngressClassName).To(BeNil())
+
+		options.IngressClass = "nginx"
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(*obj.Spec.IngressClassName).To(Equal("nginx"))
+	})
+
+	It("should use the ingress class specified in the Wordpress resource", func() {
+		options.IngressClass = "nginx"
+		wp.Spec.IngressClassName = "traefik"
+		wp.Spec.IngressAnnotations = map[string]string{"kubernetes.io/ingress.class": "nginx"}
+
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(*obj.Spec.IngressClassName).To(Equal("traefik"))
+		Expect(obj.Annotations).ToNot(HaveKey("kubernetes.io/ingress.class"))
+	})
+
+	It("should set the security headers", func() {
+		wp.Spec.SecurityHeaders = &wordpressv1alpha1.SecurityHeadersSpec{
+			FrameOptions:       "SAMEORIGIN",
+			ContentTypeNosniff: true,
+		}
+
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey,
+			"more_set_headers \"X-Frame-Options: SAMEORIGIN\";\nmore_set_headers \"X-Content-Type-Options: nosniff\";"))
+
+		wp.Spec.SecurityHeaders.FrameOptions = ""
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey,
+			"more_set_headers \"X-Content-Type-Options: nosniff\";"))
+
+		wp.Spec.SecurityHeaders = nil
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).ToNot(HaveKey(configurationSnippetAnnotationKey))
+	})
+
+	It("should group the domains by TLS secret", func() {
+		wp.Spec.Routes = []wordpressv1alpha1.RouteSpec{
+			{Domain: "bitpoke.io"},
+			{Domain: "bitpoke.io", Path: "/blog"},
+			{Domain: "presslabs.com"},
+			{Domain: "example.com"},
+		}
+		wp.Spec.TLSSecretRef = "tls"
+		wp.Spec.TLS = &wordpressv1alpha1.TLSSpec{
+			Secrets: []wordpressv1alpha1.DomainTLSSecret{
+				{Domains: []string{"example.com"}, SecretName: "example-tls"},
+			},
+		}
+
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Spec.TLS).To(Equal([]netv1.IngressTLS{
+			{SecretName: "tls", Hosts: []string{"bitpoke.io", "presslabs.com"}},
+			{SecretName: "example-tls", Hosts: []string{"example.com"}},
+		}))
+
+		wp.Spec.TLSSecretRef = ""
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Spec.TLS).To(Equal([]netv1.IngressTLS{
+			{SecretName: "example-tls", Hosts: []string{"example.com"}},
+		}))
+		Expect(wp.Scheme()).To(Equal("http"))
+	})
+
+	It("should redirect to HTTPS and set the HSTS header when TLS is configured", func() {
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).ToNot(HaveKey(sslRedirectAnnotationKey))
+
+		wp.Spec.TLSSecretRef = "tls"
+		wp.Spec.TLS = &wordpressv1alpha1.TLSSpec{HSTS: &wordpressv1alpha1.HSTSSpec{Preload: true}}
+		wp.Spec.IngressAnnotations = map[string]string{configurationSnippetAnnotationKey: "more_set_headers \"X-Custom: 1\";"}
+
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(sslRedirectAnnotationKey, "true"))
+		Expect(obj.Annotations).To(HaveKeyWithValue(forceSSLRedirectAnnotationKey, "true"))
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey,
+			"more_set_headers \"X-Custom: 1\";\nmore_set_headers \"Strict-Transport-Security: max-age=31536000; preload\";"))
+
+		wp.Spec.TLS = nil
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey, "more_set_headers \"X-Custom: 1\";"))
+	})
+	It("should use the client address from the PROXY protocol", func() {
+		wp.Spec.ProxyProtocol = &wordpressv1alpha1.ProxyProtocolSpec{Ingress: true}
+		wp.Spec.IngressAnnotations = map[string]string{configurationSnippetAnnotationKey: "more_set_headers \"X-Custom: 1\";"}
+
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey,
+			"more_set_headers \"X-Custom: 1\";\nreal_ip_header proxy_protocol;"))
+
+		wp.Spec.ProxyProtocol = nil
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey, "more_set_headers \"X-Custom: 1\";"))
+	})
+
+	It("should keep admin-ajax.php public when the admin access is restricted", func() {
+		wp.Spec.WordpressPathPrefix = "/wp"
+		wp.Spec.AdminAccess = &wordpressv1alpha1.AdminAccessSpec{AllowedSourceRanges: []string{"10.0.0.0/8"}}
+
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Spec.Rules).To(HaveLen(1))
+
+		paths := []string{}
+		for _, p := range obj.Spec.Rules[0].HTTP.Paths {
+			paths = append(paths, p.Path)
+		}
+		Expect(paths).To(ConsistOf("/", "/wp-admin/admin-ajax.php", "/wp/wp-admin/admin-ajax.php"))
+	})
+
+	It("should route the subdomains and the mapped domains of the network's sub-sites", func() {
+		wp.Spec.Multisite = &wordpressv1alpha1.MultisiteSpec{
+			Mode: wordpressv1alpha1.MultisiteSubdomain,
+			Sites: []wordpressv1alpha1.SubsiteSpec{
+				{Slug: "shop", Domains: []string{"shop.example.com"}},
+			},
+		}
+
+		mutateIngress(obj, wp, netv1.IngressBackend{})
+
+		hosts := []string{}
+		for _, rule := range obj.Spec.Rules {
+			hosts = append(hosts, rule.Host)
+		}
+		Expect(hosts).To(Equal([]string{"bitpoke.io", "shop.bitpoke.io", "shop.example.com"}))
+	})
+})
+
+var _ = Describe("The mutateAdminAccessAnnotations function", func() {
+	It("should set and remove the access restriction annotations", func() {
+		annotations := map[string]string{}
+
+		mutateAdminAccessAnnotations(annotations, &wordpressv1alpha1.AdminAccessSpec{
+			AllowedSourceRanges: []string{"10.0.0.0/8", "192.168.0.0/16"},
+			BasicAuthSecretRef:  "admin-auth",
+		})
+		Expect(annotations).To(HaveKeyWithValue(whitelistSourceRangeAnnotationKey, "10.0.0.0/8,192.168.0.0/16"))
+		Expect(annotations).To(HaveKeyWithValue(authTypeAnnotationKey, "basic"))
+		Expect(annotations).To(HaveKeyWithValue(authSecretAnnotationKey, "admin-auth"))
+
+		mutateAdminAccessAnnotations(annotations, &wordpressv1alpha1.AdminAccessSpec{BasicAuthSecretRef: "admin-auth"})
+		Expect(annotations).ToNot(HaveKey(whitelistSourceRangeAnnotationKey))
+		Expect(annotations).To(HaveKey(authSecretAnnotationKey))
+
+		mutateAdminAccessAnnotations(annotations, nil)
+		Expect(annotations).To(BeEmpty())
+	})
+})
+
+var _ = Describe("The mutateLoginIngress function", func() {
+	var (
+		wp  *wordpress.Wordpress
+		obj *netv1.Ingress
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes:              []wordpressv1alpha1.RouteSpec{{Domain: "bitpoke.io"}},
+				WordpressPathPrefix: "/wp",
+				LoginProtection:     &wordpressv1alpha1.LoginProtectionSpec{},
+			},
+		})
+		obj = &netv1.Ingress{}
+	})
+
+	paths := func() []string {
+		paths := []string{}
+		for _, p := range obj.Spec.Rules[0].HTTP.Paths {
+			paths = append(paths, p.Path)
+		}
+
+		return paths
+	}
+
+	It("should rate limit the login page and xmlrpc.php", func() {
+		mutateLoginIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(limitRPMAnnotationKey, "10"))
+		Expect(obj.Annotations).To(HaveKeyWithValue(limitBurstMultiplierAnnotationKey, "5"))
+		Expect(obj.Annotations).ToNot(HaveKey(configurationSnippetAnnotationKey))
+		Expect(paths()).To(ConsistOf("/xmlrpc.php", "/wp/xmlrpc.php", "/wp-login.php", "/wp/wp-login.php"))
+
+		wp.Spec.LoginProtection.RequestsPerMinute = 30
+		wp.Spec.LoginProtection.BurstMultiplier = 2
+		mutateLoginIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(limitRPMAnnotationKey, "30"))
+		Expect(obj.Annotations).To(HaveKeyWithValue(limitBurstMultiplierAnnotationKey, "2"))
+	})
+
+	It("should leave the login page to the admin ingress when the admin access is restricted", func() {
+		wp.Spec.AdminAccess = &wordpressv1alpha1.AdminAccessSpec{AllowedSourceRanges: []string{"10.0.0.0/8"}}
+
+		mutateLoginIngress(obj, wp, netv1.IngressBackend{})
+		Expect(paths()).To(ConsistOf("/xmlrpc.php", "/wp/xmlrpc.php"))
+		Expect(obj.Annotations).ToNot(HaveKey(whitelistSourceRangeAnnotationKey))
+	})
+
+	It("should deny the requests to xmlrpc.php when XML-RPC is disabled", func() {
+		wp.Spec.LoginProtection.DisableXMLRPC = true
+		wp.Spec.IngressAnnotations = map[string]string{configurationSnippetAnnotationKey: "more_set_headers \"X-Custom: 1\";"}
+
+		mutateLoginIngress(obj, wp, netv1.IngressBackend{})
+		mutateLoginIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey,
+			"if ($uri ~ \"/xmlrpc\\.php$\") { return 403; }\nmore_set_headers \"X-Custom: 1\";"))
+
+		wp.Spec.LoginProtection.DisableXMLRPC = false
+		mutateLoginIngress(obj, wp, netv1.IngressBackend{})
+		Expect(obj.Annotations).To(HaveKeyWithValue(configurationSnippetAnnotationKey, "more_set_headers \"X-Custom: 1\";"))
+	})
+})
@@ -0,0 +1,209 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	defaultScaleSchedulesTimezone = "Etc/UTC"
+	defaultTargetPendingRequests  = 100
+)
+
+var (
+	// ScaledObjectGVK is the GroupVersionKind of KEDA ScaledObjects.
+	ScaledObjectGVK = schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}
+	// HTTPScaledObjectGVK is the GroupVersionKind of KEDA HTTP add-on HTTPScaledObjects.
+	HTTPScaledObjectGVK = schema.GroupVersionKind{Group: "http.keda.sh", Version: "v1alpha1", Kind: "HTTPScaledObject"}
+)
+
+var (
+	errScaleToZeroNotDefined   = errors.New(".spec.scaleToZero is not defined")
+	errScaleToZeroNoSchedules  = errors.New(".spec.scaleToZero.schedules is empty")
+	errScaleToZeroHTTPDisabled = errors.New(".spec.scaleToZero.http is not defined")
+)
+
+func scaleToZeroMaxReplicas(wp *wordpress.Wordpress) int64 {
+	if wp.Spec.ScaleToZero.MaxReplicas != nil {
+		return int64(*wp.Spec.ScaleToZero.MaxReplicas)
+	}
+
+	return 1
+}
+
+func cronTriggers(wp *wordpress.Wordpress) []interface{} {
+	triggers := []interface{}{}
+
+	for _, schedule := range wp.Spec.ScaleToZero.Schedules {
+		timezone := schedule.Timezone
+		if timezone == "" {
+			timezone = defaultScaleSchedulesTimezone
+		}
+
+		replicas := int32(1)
+		if schedule.Replicas != nil {
+			replicas = *schedule.Replicas
+		}
+
+		triggers = append(triggers, map[string]interface{}{
+			"type": "cron",
+			"metadata": map[string]interface{}{
+				"timezone":        timezone,
+				"start":           schedule.Start,
+				"end":             schedule.End,
+				"desiredReplicas": strconv.Itoa(int(replicas)),
+			},
+		})
+	}
+
+	return triggers
+}
+
+func newUnstructured(gvk schema.GroupVersionKind, name, namespace string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+
+	return obj
+}
+
+// NewScaledObjectSyncer returns a new sync.Interface for reconciling the KEDA ScaledObject
+// which keeps the site running during the configured schedules.
+func NewScaledObjectSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressScaledObject)
+
+	obj := newUnstructured(ScaledObjectGVK, wp.ComponentName(wordpress.WordpressScaledObject), wp.Namespace)
+
+	return syncer.NewObjectSyncer("ScaledObject", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if wp.Spec.ScaleToZero == nil {
+			return errScaleToZeroNotDefined
+		}
+
+		if len(wp.Spec.ScaleToZero.Schedules) == 0 {
+			return errScaleToZeroNoSchedules
+		}
+
+		spec := map[string]interface{}{
+			"scaleTargetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       webWorkloadKind(wp),
+				"name":       wp.ComponentName(wordpress.WordpressDeployment),
+			},
+			"minReplicaCount": int64(0),
+			"maxReplicaCount": scaleToZeroMaxReplicas(wp),
+			"triggers":        cronTriggers(wp),
+		}
+
+		if wp.Spec.ScaleToZero.CooldownPeriod != nil {
+			spec["cooldownPeriod"] = int64(*wp.Spec.ScaleToZero.CooldownPeriod)
+		}
+
+		return unstructured.SetNestedField(obj.Object, spec, "spec")
+	})
+}
+
+// NewHTTPScaledObjectSyncer returns a new sync.Interface for reconciling the KEDA HTTPScaledObject
+// which wakes up the site on incoming traffic.
+func NewHTTPScaledObjectSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressHTTPScaledObject)
+
+	obj := newUnstructured(HTTPScaledObjectGVK, wp.ComponentName(wordpress.WordpressHTTPScaledObject), wp.Namespace)
+
+	return syncer.NewObjectSyncer("HTTPScaledObject", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if wp.Spec.ScaleToZero == nil || wp.Spec.ScaleToZero.HTTP == nil {
+			return errScaleToZeroHTTPDisabled
+		}
+
+		hosts := []interface{}{}
+		for _, route := range wp.Routes() {
+			hosts = append(hosts, route.Domain)
+		}
+
+		targetPendingRequests := int64(defaultTargetPendingRequests)
+		if wp.Spec.ScaleToZero.HTTP.TargetPendingRequests != nil {
+			targetPendingRequests = int64(*wp.Spec.ScaleToZero.HTTP.TargetPendingRequests)
+		}
+
+		spec := map[string]interface{}{
+			"hosts": hosts,
+			"scaleTargetRef": map[string]interface{}{
+				"deployment": wp.ComponentName(wordpress.WordpressDeployment),
+				"service":    wp.ComponentName(wordpress.WordpressService),
+				"port":       int64(80),
+			},
+			"replicas": map[string]interface{}{
+				"min": int64(0),
+				"max": scaleToZeroMaxReplicas(wp),
+			},
+			"targetPendingRequests": targetPendingRequests,
+		}
+
+		if wp.Spec.ScaleToZero.CooldownPeriod != nil {
+			spec["scaledownPeriod"] = int64(*wp.Spec.ScaleToZero.CooldownPeriod)
+		}
+
+		return unstructured.SetNestedField(obj.Object, spec, "spec")
+	})
+}
+
+// NewInterceptorServiceSyncer returns a new sync.Interface for reconciling the
+// ExternalName Service which routes the site's ingress through the KEDA HTTP add-on interceptor.
+func NewInterceptorServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressInterceptorService)
+
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressInterceptorService),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("InterceptorService", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		obj.Spec.Type = corev1.ServiceTypeExternalName
+		obj.Spec.ExternalName = options.KEDAHTTPInterceptorService
+
+		if len(obj.Spec.Ports) != 1 {
+			obj.Spec.Ports = make([]corev1.ServicePort, 1)
+		}
+
+		obj.Spec.Ports[0].Name = "http"
+		obj.Spec.Ports[0].Port = int32(options.KEDAHTTPInterceptorPort)
+
+		return nil
+	})
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var (
+	// VirtualServiceGVK is the GroupVersionKind of Istio VirtualServices.
+	VirtualServiceGVK = schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "VirtualService"}
+	// DestinationRuleGVK is the GroupVersionKind of Istio DestinationRules.
+	DestinationRuleGVK = schema.GroupVersionKind{Group: "networking.istio.io", Version: "v1beta1", Kind: "DestinationRule"}
+)
+
+var errVirtualServiceNotDefined = errors.New(".spec.serviceMesh.virtualService is not defined")
+
+// serviceHost returns the cluster local host name of the site's service.
+func serviceHost(wp *wordpress.Wordpress) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", wp.ComponentName(wordpress.WordpressService), wp.Namespace)
+}
+
+// NewVirtualServiceSyncer returns a new sync.Interface for reconciling the
+// Istio VirtualService routing the site's domains to its service.
+func NewVirtualServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressVirtualService)
+
+	obj := newUnstructured(VirtualServiceGVK, wp.ComponentName(wordpress.WordpressVirtualService), wp.Namespace)
+
+	return syncer.NewObjectSyncer("VirtualService", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if wp.Spec.ServiceMesh == nil || wp.Spec.ServiceMesh.VirtualService == nil {
+			return errVirtualServiceNotDefined
+		}
+
+		hosts := []interface{}{}
+		for _, domain := range wp.Domains() {
+			hosts = append(hosts, domain)
+		}
+
+		gateways := []interface{}{}
+		for _, gateway := range wp.Spec.ServiceMesh.VirtualService.Gateways {
+			gateways = append(gateways, gateway)
+		}
+
+		route := []interface{}{
+			map[string]interface{}{
+				"destination": map[string]interface{}{
+					"host": serviceHost(wp),
+					"port": map[string]interface{}{"number": int64(80)},
+				},
+			},
+		}
+
+		http := []interface{}{}
+
+		for _, r := range wp.Routes() {
+			http = append(http, map[string]interface{}{
+				"match": []interface{}{
+					map[string]interface{}{
+						"authority": map[string]interface{}{"exact": r.Domain},
+						"uri":       map[string]interface{}{"prefix": wp.RoutePath(r)},
+					},
+				},
+				"route": route,
+			})
+		}
+
+		return unstructured.SetNestedField(obj.Object, map[string]interface{}{
+			"hosts":    hosts,
+			"gateways": gateways,
+			"http":     http,
+		}, "spec")
+	})
+}
+
+// NewDestinationRuleSyncer returns a new sync.Interface for reconciling the
+// Istio DestinationRule for the site's service.
+func NewDestinationRuleSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressDestinationRule)
+
+	obj := newUnstructured(DestinationRuleGVK, wp.ComponentName(wordpress.WordpressDestinationRule), wp.Namespace)
+
+	return syncer.NewObjectSyncer("DestinationRule", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if err := unstructured.SetNestedField(obj.Object, serviceHost(wp), "spec", "host"); err != nil {
+			return err
+		}
+
+		return unstructured.SetNestedField(obj.Object, "ISTIO_MUTUAL", "spec", "trafficPolicy", "tls", "mode")
+	})
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/rand"
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	databasePasswordLength = 32
+	rootPasswordKey        = "ROOT_PASSWORD"
+	databasePasswordKey    = "DB_PASSWORD"
+)
+
+var (
+	// MysqlClusterGVK is the GroupVersionKind of mysql-operator MysqlClusters.
+	MysqlClusterGVK = schema.GroupVersionKind{Group: "mysql.presslabs.org", Version: "v1alpha1", Kind: "MysqlCluster"}
+	// MysqlDatabaseGVK is the GroupVersionKind of mysql-operator MysqlDatabases.
+	MysqlDatabaseGVK = schema.GroupVersionKind{Group: "mysql.presslabs.org", Version: "v1alpha1", Kind: "MysqlDatabase"}
+	// MysqlUserGVK is the GroupVersionKind of mysql-operator MysqlUsers.
+	MysqlUserGVK = schema.GroupVersionKind{Group: "mysql.presslabs.org", Version: "v1alpha1", Kind: "MysqlUser"}
+)
+
+var (
+	errDatabaseProvisionDisabled = errors.New(".spec.database.provision is not enabled")
+	errDatabasePasswordMissing   = errors.New("the database password is not generated yet")
+)
+
+// mutateDatabaseCredentials sets the current credentials of the provisioned
+// database into the site's secret data. The passwords are generated into the
+// database's secret, to be able to create a new user before switching to it.
+func mutateDatabaseCredentials(data map[string][]byte, wp *wordpress.Wordpress, dbSecret *corev1.Secret) error {
+	generation := wp.CurrentDatabaseCredentials()
+
+	password := dbSecret.Data[databasePasswordKeyFor(generation)]
+	if len(password) == 0 {
+		return errDatabasePasswordMissing
+	}
+
+	data["DB_HOST"] = []byte(wp.DatabaseHost())
+	data["DB_NAME"] = []byte(wp.Spec.Database.Name)
+	data["DB_USER"] = []byte(wp.DatabaseUser(generation))
+	data[databasePasswordKey] = password
+
+	return nil
+}
+
+// databasePasswordKeyFor returns the key of the database's secret holding the
+// password for the given generation of credentials.
+func databasePasswordKeyFor(generation string) string {
+	if generation == "" {
+		return databasePasswordKey
+	}
+
+	return databasePasswordKey + "_" + generation
+}
+
+// mutateDatabasePasswords generates the passwords of the live generations of
+// credentials and removes the ones of the revoked generations.
+func mutateDatabasePasswords(data map[string][]byte, wp *wordpress.Wordpress) error {
+	live := map[string]bool{}
+
+	for _, generation := range wp.DatabaseCredentialsGenerations() {
+		key := databasePasswordKeyFor(generation)
+		live[key] = true
+
+		if err := generatePassword(data, key); err != nil {
+			return err
+		}
+	}
+
+	for key := range data {
+		if strings.HasPrefix(key, databasePasswordKey) && !live[key] {
+			delete(data, key)
+		}
+	}
+
+	return nil
+}
+
+func generatePassword(data map[string][]byte, key string) error {
+	if len(data[key]) > 0 {
+		return nil
+	}
+
+	password, err := rand.AlphaNumericString(databasePasswordLength)
+	if err != nil {
+		return err
+	}
+
+	data[key] = []byte(password)
+
+	return nil
+}
+
+func clusterRef(wp *wordpress.Wordpress) map[string]interface{} {
+	return map[string]interface{}{
+		"name":      wp.ComponentName(wordpress.WordpressMysqlCluster),
+		"namespace": wp.Namespace,
+	}
+}
+
+// NewMysqlSecretSyncer returns a new sync.Interface for reconciling the secret
+// holding the root password of the provisioned MysqlCluster and the passwords
+// of the site's database users.
+func NewMysqlSecretSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMysqlSecret)
+
+	obj := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressMysqlSecret),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("MysqlSecret", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		if len(obj.Data) == 0 {
+			obj.Data = make(map[string][]byte)
+		}
+
+		if err := generatePassword(obj.Data, rootPasswordKey); err != nil {
+			return err
+		}
+
+		return mutateDatabasePasswords(obj.Data, wp)
+	})
+}
+
+// NewMysqlClusterSyncer returns a new sync.Interface for reconciling the
+// site's mysql-operator MysqlCluster.
+func NewMysqlClusterSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMysqlCluster)
+
+	obj := newUnstructured(MysqlClusterGVK, wp.ComponentName(wordpress.WordpressMysqlCluster), wp.Namespace)
+
+	return syncer.NewObjectSyncer("MysqlCluster", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if !wp.ProvisionsDatabase() {
+			return errDatabaseProvisionDisabled
+		}
+
+		replicas := int64(1)
+		spec := wp.Spec.Database.MysqlCluster
+
+		if spec != nil && spec.Replicas != nil {
+			replicas = int64(*spec.Replicas)
+		}
+
+		if err := unstructured.SetNestedField(obj.Object, replicas, "spec", "replicas"); err != nil {
+			return err
+		}
+
+		if err := unstructured.SetNestedField(obj.Object, wp.ComponentName(wordpress.WordpressMysqlSecret), "spec", "secretName"); err != nil {
+			return err
+		}
+
+		if spec != nil && spec.MysqlVersion != "" {
+			if err := unstructured.SetNestedField(obj.Object, spec.MysqlVersion, "spec", "mysqlVersion"); err != nil {
+				return err
+			}
+		}
+
+		if spec == nil || spec.PersistentVolumeClaim == nil {
+			return nil
+		}
+
+		pvc, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spec.PersistentVolumeClaim)
+		if err != nil {
+			return err
+		}
+
+		return unstructured.SetNestedMap(obj.Object, pvc, "spec", "volumeSpec", "persistentVolumeClaim")
+	})
+}
+
+// NewMysqlDatabaseSyncer returns a new sync.Interface for reconciling the
+// site's database within the provisioned MysqlCluster.
+func NewMysqlDatabaseSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMysqlDatabase)
+
+	obj := newUnstructured(MysqlDatabaseGVK, wp.ComponentName(wordpress.WordpressMysqlDatabase), wp.Namespace)
+
+	return syncer.NewObjectSyncer("MysqlDatabase", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if !wp.ProvisionsDatabase() {
+			return errDatabaseProvisionDisabled
+		}
+
+		return unstructured.SetNestedField(obj.Object, map[string]interface{}{
+			"database":   wp.Spec.Database.Name,
+			"clusterRef": clusterRef(wp),
+		}, "spec")
+	})
+}
+
+// NewMysqlUserSyncer returns a new sync.Interface for reconciling the site's
+// database user for the given generation of credentials, whose password is
+// read from the database's secret.
+func NewMysqlUserSyncer(wp *wordpress.Wordpress, generation string, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressMysqlUser)
+
+	obj := newUnstructured(MysqlUserGVK, wp.MysqlUserName(generation), wp.Namespace)
+
+	return syncer.NewObjectSyncer("MysqlUser", wp.Unwrap(), obj, c, func() error {
+		obj.SetLabels(labels.Merge(labels.Merge(obj.GetLabels(), objLabels), controllerLabels(wp)))
+
+		if !wp.ProvisionsDatabase() {
+			return errDatabaseProvisionDisabled
+		}
+
+		return unstructured.SetNestedField(obj.Object, map[string]interface{}{
+			"user":       wp.DatabaseUser(generation),
+			"clusterRef": clusterRef(wp),
+			"password": map[string]interface{}{
+				"name": wp.ComponentName(wordpress.WordpressMysqlSecret),
+				"key":  databasePasswordKeyFor(generation),
+			},
+			"allowedHosts": []interface{}{"%"},
+			"permissions": []interface{}{
+				map[string]interface{}{
+					"schema":      wp.Spec.Database.Name,
+					"tables":      []interface{}{"*"},
+					"permissions": []interface{}{"ALL"},
+				},
+			},
+		}, "spec")
+	})
+}
+
+// DatabaseStatus returns the readiness of the provisioned database, which is
+// ready once all of the given mysql-operator resources are ready, and the
+// message of the first one which is not.
+func DatabaseStatus(objs ...*unstructured.Unstructured) (corev1.ConditionStatus, string) {
+	for _, obj := range objs {
+		status, message := readyCondition(obj)
+		if status != corev1.ConditionTrue {
+			return status, fmt.Sprintf("%s %s is not ready: %s", obj.GetKind(), obj.GetName(), message)
+		}
+	}
+
+	return corev1.ConditionTrue, ""
+}
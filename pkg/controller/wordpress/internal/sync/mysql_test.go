@@ -0,0 +1,114 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+func readyObject(gvk schema.GroupVersionKind, name, status string) *unstructured.Unstructured {
+	obj := newUnstructured(gvk, name, "default")
+	_ = unstructured.SetNestedSlice(obj.Object, []interface{}{
+		map[string]interface{}{"type": "Ready", "status": status, "message": "reason"},
+	}, "status", "conditions")
+
+	return obj
+}
+
+var _ = Describe("The mutateDatabaseCredentials function", func() {
+	var wp *wordpress.Wordpress
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Database: &wordpressv1alpha1.DatabaseSpec{Provision: true},
+			},
+		})
+		wp.SetDefaults()
+	})
+
+	It("should set the current credentials of the provisioned database", func() {
+		dbSecret := &corev1.Secret{Data: map[string][]byte{}}
+		Expect(mutateDatabasePasswords(dbSecret.Data, wp)).To(Succeed())
+		Expect(dbSecret.Data[databasePasswordKey]).To(HaveLen(databasePasswordLength))
+
+		data := map[string][]byte{}
+		Expect(mutateDatabaseCredentials(data, wp, dbSecret)).To(Succeed())
+		Expect(data).To(HaveKeyWithValue("DB_HOST", []byte("test-mysql-master.default")))
+		Expect(data).To(HaveKeyWithValue("DB_NAME", []byte("wordpress")))
+		Expect(data).To(HaveKeyWithValue("DB_USER", []byte("wordpress")))
+		Expect(data).To(HaveKeyWithValue(databasePasswordKey, dbSecret.Data[databasePasswordKey]))
+	})
+
+	It("should fail while the password is not generated", func() {
+		Expect(mutateDatabaseCredentials(map[string][]byte{}, wp, &corev1.Secret{})).To(MatchError(errDatabasePasswordMissing))
+	})
+
+	It("should keep the passwords of the rotated credentials until they are revoked", func() {
+		data := map[string][]byte{}
+		Expect(mutateDatabasePasswords(data, wp)).To(Succeed())
+		password := data[databasePasswordKey]
+
+		wp.Spec.Database.RotateCredentials = "2021-10-01"
+		generation := wp.DesiredDatabaseCredentials()
+		rotatedKey := databasePasswordKeyFor(generation)
+
+		Expect(mutateDatabasePasswords(data, wp)).To(Succeed())
+		Expect(data).To(HaveKeyWithValue(databasePasswordKey, password))
+		Expect(data[rotatedKey]).To(HaveLen(databasePasswordLength))
+
+		wp.Status.DatabaseCredentials = &wordpressv1alpha1.DatabaseCredentialsStatus{
+			Generation:         generation,
+			PreviousGeneration: new(string),
+		}
+		Expect(mutateDatabasePasswords(data, wp)).To(Succeed())
+		Expect(data).To(HaveKey(databasePasswordKey))
+
+		wp.Status.DatabaseCredentials.PreviousGeneration = nil
+		Expect(mutateDatabasePasswords(data, wp)).To(Succeed())
+		Expect(data).NotTo(HaveKey(databasePasswordKey))
+		Expect(data).To(HaveKey(rotatedKey))
+	})
+})
+
+var _ = Describe("The DatabaseStatus function", func() {
+	It("should be ready once all the resources are ready", func() {
+		cluster := readyObject(MysqlClusterGVK, "test", "True")
+		database := readyObject(MysqlDatabaseGVK, "test", "True")
+
+		status, _ := DatabaseStatus(cluster, database)
+		Expect(status).To(Equal(corev1.ConditionTrue))
+
+		database = readyObject(MysqlDatabaseGVK, "test", "False")
+		status, message := DatabaseStatus(cluster, database)
+		Expect(status).To(Equal(corev1.ConditionFalse))
+		Expect(message).To(Equal("MysqlDatabase test is not ready: reason"))
+
+		status, _ = DatabaseStatus(cluster, newUnstructured(MysqlUserGVK, "test", "default"))
+		Expect(status).To(Equal(corev1.ConditionUnknown))
+	})
+})
@@ -0,0 +1,86 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// ProbeGVK is the GroupVersionKind of Prometheus Operator Probes.
+var ProbeGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "Probe"}
+
+var errProberNotDefined = errors.New(".spec.monitoring.probe.proberURL is not defined, nor the --probe-prober-url flag")
+
+// NewProbeSyncer returns a new sync.Interface for reconciling the Prometheus
+// Operator Probe monitoring the availability of the site's domains.
+func NewProbeSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressProbe)
+
+	obj := newUnstructured(ProbeGVK, wp.ComponentName(wordpress.WordpressProbe), wp.Namespace)
+
+	return syncer.NewObjectSyncer("Probe", wp.Unwrap(), obj, c, func() error {
+		if !wp.ProbesDomains() {
+			return errProberNotDefined
+		}
+
+		probe := wp.Spec.Monitoring.Probe
+
+		probeLabels := labels.Merge(objLabels, controllerLabels(wp))
+		if probe != nil {
+			probeLabels = labels.Merge(probe.Labels, probeLabels)
+		}
+
+		obj.SetLabels(labels.Merge(obj.GetLabels(), probeLabels))
+
+		targets := []interface{}{}
+		for _, target := range wp.ProbeTargets() {
+			targets = append(targets, target)
+		}
+
+		spec := map[string]interface{}{
+			"jobName": "wordpress-probe",
+			"module":  wp.ProbeModule(),
+			"prober": map[string]interface{}{
+				"url": wp.ProbeProberURL(),
+			},
+			"targets": map[string]interface{}{
+				"staticConfig": map[string]interface{}{
+					"static": targets,
+					"labels": map[string]interface{}{
+						"namespace": wp.Namespace,
+						"site":      wp.Name,
+					},
+				},
+			},
+		}
+
+		if probe != nil && probe.Interval != "" {
+			spec["interval"] = probe.Interval
+		}
+
+		return unstructured.SetNestedField(obj.Object, spec, "spec")
+	})
+}
@@ -28,6 +28,10 @@ import (
 	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
 )
 
+// saltsGenerationAnnotation records the generation of the salts of the
+// site's secret.
+const saltsGenerationAnnotation = "wordpress.presslabs.org/saltsGeneration"
+
 var generatedSalts = map[string]int{
 	"AUTH_KEY":         64,
 	"SECURE_AUTH_KEY":  64,
@@ -40,7 +44,8 @@ var generatedSalts = map[string]int{
 }
 
 // NewSecretSyncer returns a new sync.Interface for reconciling wordpress secret.
-func NewSecretSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+// The database's secret is only used when the database is provisioned.
+func NewSecretSyncer(wp *wordpress.Wordpress, dbSecret *corev1.Secret, c client.Client) syncer.Interface {
 	objLabels := wp.ComponentLabels(wordpress.WordpressSecret)
 
 	obj := &corev1.Secret{
@@ -51,22 +56,68 @@ func NewSecretSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 	}
 
 	return syncer.NewObjectSyncer("Secret", wp.Unwrap(), obj, c, func() error {
-		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
 
 		if len(obj.Data) == 0 {
 			obj.Data = make(map[string][]byte)
 		}
 
-		for name, size := range generatedSalts {
-			if len(obj.Data[name]) == 0 {
-				random, err := rand.ASCIIString(size)
-				if err != nil {
-					return err
-				}
-				obj.Data[name] = []byte(random)
+		if err := mutateSalts(obj, wp); err != nil {
+			return err
+		}
+
+		if wp.UsesPageCache() && len(obj.Data[wordpress.PageCachePurgeTokenKey]) == 0 {
+			token, err := rand.AlphaNumericString(32)
+			if err != nil {
+				return err
+			}
+			obj.Data[wordpress.PageCachePurgeTokenKey] = []byte(token)
+		}
+
+		if wp.ProvisionsDatabase() {
+			return mutateDatabaseCredentials(obj.Data, wp, dbSecret)
+		}
+
+		// the credentials of a database which is neither provisioned nor
+		// external are read from the site's secret, where they're filled in
+		if !wp.HasExternalDatabase() {
+			if _, ok := obj.Data[databasePasswordKey]; !ok {
+				obj.Data[databasePasswordKey] = []byte{}
 			}
 		}
 
 		return nil
 	})
 }
+
+// mutateSalts generates the missing authentication keys and salts of the
+// site's secret, regenerating all of them when spec.rotateSalts is set to a
+// new value. Clearing it keeps the salts and the last applied generation, so
+// setting it back to that value doesn't rotate them again. The web pods are
+// rolled along with any change of the secret.
+func mutateSalts(obj *corev1.Secret, wp *wordpress.Wordpress) error {
+	generation := wp.SaltsGeneration()
+	rotate := generation != "" && obj.Annotations[saltsGenerationAnnotation] != generation
+
+	for name, size := range generatedSalts {
+		if rotate || len(obj.Data[name]) == 0 {
+			random, err := rand.ASCIIString(size)
+			if err != nil {
+				return err
+			}
+			obj.Data[name] = []byte(random)
+		}
+	}
+
+	if generation == "" {
+		return nil
+	}
+
+	if obj.Annotations == nil {
+		obj.Annotations = make(map[string]string)
+	}
+
+	obj.Annotations[saltsGenerationAnnotation] = generation
+
+	return nil
+}
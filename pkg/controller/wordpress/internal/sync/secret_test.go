@@ -0,0 +1,96 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The mutateSalts function", func() {
+	var (
+		wp  *wordpress.Wordpress
+		obj *corev1.Secret
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		})
+		obj = &corev1.Secret{Data: map[string][]byte{}}
+	})
+
+	It("should only generate the missing salts", func() {
+		obj.Data["AUTH_KEY"] = []byte("auth-key")
+
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		Expect(obj.Data).To(HaveKeyWithValue("AUTH_KEY", []byte("auth-key")))
+		Expect(obj.Data["NONCE_SALT"]).To(HaveLen(64))
+		Expect(obj.Annotations).To(HaveKeyWithValue(saltsGenerationAnnotation, "2021-10-01"))
+	})
+
+	It("should regenerate all the salts when rotated", func() {
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		salt := obj.Data["NONCE_SALT"]
+
+		wp.Spec.RotateSalts = "2021-10-01"
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		Expect(obj.Data["NONCE_SALT"]).To(HaveLen(64))
+		Expect(obj.Data["NONCE_SALT"]).NotTo(Equal(salt))
+		Expect(obj.Annotations).To(HaveKeyWithValue(saltsGenerationAnnotation, wp.SaltsGeneration()))
+
+		salt = obj.Data["NONCE_SALT"]
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		Expect(obj.Data).To(HaveKeyWithValue("NONCE_SALT", salt))
+	})
+	It("should keep the salts when the rotation is cleared", func() {
+		wp.Spec.RotateSalts = "2021-10-01"
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		salt := obj.Data["NONCE_SALT"]
+
+		wp.Spec.RotateSalts = ""
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		Expect(obj.Data).To(HaveKeyWithValue("NONCE_SALT", salt))
+		Expect(obj.Annotations).To(HaveKeyWithValue(saltsGenerationAnnotation, "2021-10-01"))
+
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		Expect(obj.Data).To(HaveKeyWithValue("NONCE_SALT", salt))
+	})
+
+	It("should not rotate the salts again when the rotation is cleared and set back", func() {
+		wp.Spec.RotateSalts = "2021-10-01"
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		salt := obj.Data["NONCE_SALT"]
+
+		wp.Spec.RotateSalts = ""
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+
+		wp.Spec.RotateSalts = "2021-10-01"
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		Expect(obj.Data).To(HaveKeyWithValue("NONCE_SALT", salt))
+
+		wp.Spec.RotateSalts = "2021-10-02"
+		Expect(mutateSalts(obj, wp)).To(Succeed())
+		Expect(obj.Data["NONCE_SALT"]).NotTo(Equal(salt))
+	})
+})
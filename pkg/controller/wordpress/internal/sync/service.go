@@ -27,6 +27,7 @@ import (
 
 	"github.com/presslabs/controller-util/syncer"
 
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
 	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
 )
 
@@ -44,29 +45,168 @@ func NewServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface
 	}
 
 	return syncer.NewObjectSyncer("Service", wp.Unwrap(), obj, c, func() error {
-		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
-
-		selector := wp.WebPodLabels()
-		if !labels.Equals(selector, obj.Spec.Selector) {
-			if obj.ObjectMeta.CreationTimestamp.IsZero() {
-				obj.Spec.Selector = selector
-			} else {
-				return errImmutableServiceSelector
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		ports := webServicePorts(wp)
+		serviceType := corev1.ServiceTypeClusterIP
+
+		var sourceRanges []string
+
+		if spec := wp.Spec.Service; spec != nil {
+			ports = append(ports, spec.Ports...)
+			sourceRanges = spec.LoadBalancerSourceRanges
+
+			if len(spec.Type) > 0 {
+				serviceType = spec.Type
 			}
+
+			if len(spec.Annotations) > 0 {
+				obj.Annotations = labels.Merge(obj.Annotations, spec.Annotations)
+			}
+
+			mutateIPFamilies(obj, spec)
 		}
 
-		if len(obj.Spec.Ports) != 2 {
-			obj.Spec.Ports = make([]corev1.ServicePort, 2)
+		obj.Spec.Type = serviceType
+
+		// the fields below are only allowed for some service types, while
+		// external-dns would publish the cluster IP of the other ones
+		dnsHosts := []string{}
+
+		if serviceType == corev1.ServiceTypeLoadBalancer {
+			obj.Spec.LoadBalancerSourceRanges = sourceRanges
+			dnsHosts = wp.Domains()
+		} else {
+			obj.Spec.LoadBalancerSourceRanges = nil
+		}
+
+		obj.Annotations = mutateDNSAnnotations(obj.Annotations, wp, dnsHosts)
+
+		if serviceType == corev1.ServiceTypeClusterIP {
+			obj.Spec.ExternalTrafficPolicy = ""
 		}
 
-		obj.Spec.Ports[0].Name = "http"
-		obj.Spec.Ports[0].Port = int32(80)
-		obj.Spec.Ports[0].TargetPort = intstr.FromInt(wordpress.InternalHTTPPort)
+		return mutateWebService(obj, wp.WebPodLabels(), ports)
+	})
+}
+
+// mutateIPFamilies sets the IP family policy and the IP families of the web
+// Service. They're defaulted by the API server, so they're set only when
+// specified.
+func mutateIPFamilies(obj *corev1.Service, spec *wordpressv1alpha1.ServiceSpec) {
+	if spec.IPFamilyPolicy != nil {
+		obj.Spec.IPFamilyPolicy = spec.IPFamilyPolicy
+	}
+
+	if len(spec.IPFamilies) > 0 {
+		obj.Spec.IPFamilies = spec.IPFamilies
+	}
+}
 
-		obj.Spec.Ports[1].Name = "prometheus"
-		obj.Spec.Ports[1].Port = int32(wordpress.MetricsExporterPort)
-		obj.Spec.Ports[1].TargetPort = intstr.FromInt(wordpress.MetricsExporterPort)
+// NewHeadlessServiceSyncer returns a new sync.Interface for reconciling the
+// headless Service selecting the web pods, which also governs the web StatefulSet.
+func NewHeadlessServiceSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressHeadlessService)
 
-		return nil
+	obj := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressHeadlessService),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("HeadlessService", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		// the cluster IP is immutable
+		if obj.ObjectMeta.CreationTimestamp.IsZero() {
+			obj.Spec.ClusterIP = corev1.ClusterIPNone
+		}
+
+		obj.Spec.PublishNotReadyAddresses = true
+
+		return mutateWebService(obj, wp.WebPodLabels(), webServicePorts(wp))
 	})
 }
+
+// webServicePorts returns the ports of a service exposing web pods.
+func webServicePorts(wp *wordpress.Wordpress) []corev1.ServicePort {
+	ports := []corev1.ServicePort{
+		{
+			Name:       "http",
+			Port:       int32(80),
+			TargetPort: intstr.FromInt(wp.HTTPPort()),
+		},
+		{
+			Name:       "prometheus",
+			Port:       int32(wordpress.MetricsExporterPort),
+			TargetPort: intstr.FromInt(wordpress.MetricsExporterPort),
+		},
+	}
+
+	if wp.RunsExporter() {
+		ports = append(ports, corev1.ServicePort{
+			Name:       wordpress.ExporterMetricsPortName,
+			Port:       int32(wordpress.ExporterMetricsPort),
+			TargetPort: intstr.FromInt(wordpress.ExporterMetricsPort),
+		})
+	}
+
+	// service meshes detect the protocol by the port's app protocol or name prefix
+	if wp.Spec.ServiceMesh != nil {
+		appProtocol := "http"
+		for i := range ports {
+			ports[i].AppProtocol = &appProtocol
+		}
+	}
+
+	if wp.Spec.Service != nil && wp.Spec.Service.AppProtocol != nil {
+		ports[0].AppProtocol = wp.Spec.Service.AppProtocol
+	}
+
+	return ports
+}
+
+// mutateWebService sets the selector and ports of a service exposing web pods.
+func mutateWebService(obj *corev1.Service, selector labels.Set, ports []corev1.ServicePort) error {
+	if !labels.Equals(selector, obj.Spec.Selector) {
+		if obj.ObjectMeta.CreationTimestamp.IsZero() {
+			obj.Spec.Selector = selector
+		} else {
+			return errImmutableServiceSelector
+		}
+	}
+
+	current := obj.Spec.Ports
+	obj.Spec.Ports = make([]corev1.ServicePort, len(ports))
+
+	for i, port := range ports {
+		// keep the fields defaulted by the API server (eg. the allocated node port)
+		for _, p := range current {
+			if p.Name == port.Name {
+				obj.Spec.Ports[i] = p
+
+				break
+			}
+		}
+
+		obj.Spec.Ports[i].Name = port.Name
+		obj.Spec.Ports[i].Port = port.Port
+		obj.Spec.Ports[i].AppProtocol = port.AppProtocol
+
+		obj.Spec.Ports[i].TargetPort = port.TargetPort
+		if port.TargetPort == (intstr.IntOrString{}) {
+			obj.Spec.Ports[i].TargetPort = intstr.FromInt(int(port.Port))
+		}
+
+		if len(port.Protocol) > 0 {
+			obj.Spec.Ports[i].Protocol = port.Protocol
+		}
+
+		if port.NodePort != 0 {
+			obj.Spec.Ports[i].NodePort = port.NodePort
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The mutateWebService function", func() {
+	var (
+		wp  *wordpress.Wordpress
+		svc *corev1.Service
+	)
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		})
+		wp.SetDefaults()
+		svc = &corev1.Service{}
+	})
+
+	It("should expose the http and prometheus ports", func() {
+		Expect(mutateWebService(svc, wp.WebPodLabels(), webServicePorts(wp))).To(Succeed())
+		Expect(svc.Spec.Selector).To(Equal(map[string]string(wp.WebPodLabels())))
+		Expect(svc.Spec.Ports).To(HaveLen(2))
+		Expect(svc.Spec.Ports[0].Name).To(Equal("http"))
+		Expect(svc.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt(wordpress.InternalHTTPPort)))
+		Expect(svc.Spec.Ports[1].Name).To(Equal("prometheus"))
+	})
+
+	It("should send the http requests through the WAF", func() {
+		wp.Spec.WAF = &wordpressv1alpha1.WAFSpec{}
+
+		Expect(mutateWebService(svc, wp.WebPodLabels(), webServicePorts(wp))).To(Succeed())
+		Expect(svc.Spec.Ports[0].TargetPort).To(Equal(intstr.FromInt(wordpress.WAFHTTPPort)))
+	})
+
+	It("should expose the exporter's metrics port", func() {
+		wp.Spec.Monitoring = &wordpressv1alpha1.MonitoringSpec{Exporter: &wordpressv1alpha1.ExporterSpec{}}
+
+		Expect(mutateWebService(svc, wp.WebPodLabels(), webServicePorts(wp))).To(Succeed())
+		Expect(svc.Spec.Ports).To(HaveLen(3))
+		Expect(svc.Spec.Ports[2].Name).To(Equal("metrics"))
+		Expect(svc.Spec.Ports[2].TargetPort).To(Equal(intstr.FromInt(wordpress.ExporterMetricsPort)))
+	})
+
+	It("should set the http app protocol and extra ports", func() {
+		appProtocol := "kubernetes.io/h2c"
+		wp.Spec.Service = &wordpressv1alpha1.ServiceSpec{AppProtocol: &appProtocol}
+
+		ports := append(webServicePorts(wp), corev1.ServicePort{Name: "ssh", Port: 22})
+		Expect(mutateWebService(svc, wp.WebPodLabels(), ports)).To(Succeed())
+		Expect(svc.Spec.Ports).To(HaveLen(3))
+		Expect(svc.Spec.Ports[0].AppProtocol).To(Equal(&appProtocol))
+		Expect(svc.Spec.Ports[2].TargetPort).To(Equal(intstr.FromInt(22)))
+	})
+
+	It("should keep the allocated node ports", func() {
+		svc.Spec.Ports = []corev1.ServicePort{
+			{Name: "http", Port: 80, NodePort: 30080, Protocol: corev1.ProtocolTCP},
+		}
+		Expect(mutateWebService(svc, wp.WebPodLabels(), webServicePorts(wp))).To(Succeed())
+		Expect(svc.Spec.Ports[0].NodePort).To(Equal(int32(30080)))
+		Expect(svc.Spec.Ports[0].Protocol).To(Equal(corev1.ProtocolTCP))
+	})
+
+	It("should not change the selector of an existing service", func() {
+		svc.CreationTimestamp = metav1.Now()
+		svc.Spec.Selector = map[string]string{"app": "other"}
+		Expect(mutateWebService(svc, wp.WebPodLabels(), webServicePorts(wp))).To(MatchError(errImmutableServiceSelector))
+	})
+})
+
+var _ = Describe("The mutateIPFamilies function", func() {
+	var svc *corev1.Service
+
+	BeforeEach(func() {
+		svc = &corev1.Service{}
+	})
+
+	It("should make the service dual-stack", func() {
+		policy := corev1.IPFamilyPolicyRequireDualStack
+		mutateIPFamilies(svc, &wordpressv1alpha1.ServiceSpec{
+			IPFamilyPolicy: &policy,
+			IPFamilies:     []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+		})
+
+		Expect(svc.Spec.IPFamilyPolicy).To(Equal(&policy))
+		Expect(svc.Spec.IPFamilies).To(Equal([]corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}))
+	})
+
+	It("should make the service IPv6 only", func() {
+		policy := corev1.IPFamilyPolicySingleStack
+		mutateIPFamilies(svc, &wordpressv1alpha1.ServiceSpec{
+			IPFamilyPolicy: &policy,
+			IPFamilies:     []corev1.IPFamily{corev1.IPv6Protocol},
+		})
+
+		Expect(svc.Spec.IPFamilyPolicy).To(Equal(&policy))
+		Expect(svc.Spec.IPFamilies).To(Equal([]corev1.IPFamily{corev1.IPv6Protocol}))
+	})
+
+	It("should keep the IP families defaulted by the API server", func() {
+		policy := corev1.IPFamilyPolicyPreferDualStack
+		svc.Spec.IPFamilyPolicy = &policy
+		svc.Spec.IPFamilies = []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+
+		mutateIPFamilies(svc, &wordpressv1alpha1.ServiceSpec{})
+
+		Expect(svc.Spec.IPFamilyPolicy).To(Equal(&policy))
+		Expect(svc.Spec.IPFamilies).To(Equal([]corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}))
+	})
+})
@@ -0,0 +1,105 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"errors"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var errImmutableStatefulSetSelector = errors.New("statefulset selector is immutable")
+
+// NewStatefulSetSyncer returns a new sync.Interface for reconciling web StatefulSet.
+func NewStatefulSetSyncer(wp *wordpress.Wordpress, secret *corev1.Secret, config *corev1.ConfigMap, references string,
+	c client.Client) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressStatefulSet)
+
+	obj := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wp.ComponentName(wordpress.WordpressStatefulSet),
+			Namespace: wp.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("StatefulSet", wp.Unwrap(), obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
+
+		created := !obj.ObjectMeta.CreationTimestamp.IsZero()
+
+		selector := metav1.SetAsLabelSelector(wp.WebPodLabels())
+		if !reflect.DeepEqual(selector, obj.Spec.Selector) {
+			if created {
+				return errImmutableStatefulSetSelector
+			}
+
+			obj.Spec.Selector = selector
+		}
+
+		// the service name, pod management policy and volume claim templates are immutable
+		if !created {
+			obj.Spec.ServiceName = wp.ComponentName(wordpress.WordpressHeadlessService)
+			obj.Spec.PodManagementPolicy = appsv1.ParallelPodManagement
+			obj.Spec.VolumeClaimTemplates = wp.VolumeClaimTemplates()
+		}
+
+		template := wp.WebPodTemplateSpec()
+
+		// the volumes backed by claim templates are added by the statefulset controller
+		claims := sets.NewString()
+		for _, claim := range obj.Spec.VolumeClaimTemplates {
+			claims.Insert(claim.Name)
+		}
+
+		volumes := []corev1.Volume{}
+
+		for _, volume := range template.Spec.Volumes {
+			if !claims.Has(volume.Name) {
+				volumes = append(volumes, volume)
+			}
+		}
+
+		template.Spec.Volumes = volumes
+
+		err := mutateWebPodTemplate(&obj.Spec.Template, wp, template, secret, config, references)
+		if err != nil {
+			return err
+		}
+
+		obj.Spec.Replicas = webReplicas(wp, obj.Spec.Replicas, created)
+
+		partition := int32(0)
+		obj.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+			Type: appsv1.RollingUpdateStatefulSetStrategyType,
+			RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{
+				Partition: &partition,
+			},
+		}
+
+		return nil
+	})
+}
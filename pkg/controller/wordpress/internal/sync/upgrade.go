@@ -36,26 +36,27 @@ func NewDBUpgradeJobSyncer(wp *wordpress.Wordpress, c client.Client) syncer.Inte
 
 	obj := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      wp.ComponentName(wordpress.WordpressDBUpgrade),
+			Name:      wp.JobName(wordpress.WordpressDBUpgrade),
 			Namespace: wp.Namespace,
 		},
 	}
 
 	var (
-		backoffLimit          int32
-		activeDeadlineSeconds int64 = 10
+		backoffLimit          int32 = 2
+		activeDeadlineSeconds int64 = 600
 	)
 
 	return syncer.NewObjectSyncer("DBUpgradeJob", wp.Unwrap(), obj, c, func() error {
-		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels(wp))
 
 		if !obj.CreationTimestamp.IsZero() {
-			// TODO (calind): handle the case that the existing job is failed
+			// the job is named after the code version, so it is never updated
 			return nil
 		}
 
 		obj.Spec.BackoffLimit = &backoffLimit
 		obj.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+		applyJobPolicy(wp, &obj.Spec)
 
 		cmd := []string{"/bin/sh", "-c", "wp core update-db --network || wp core update-db && wp cache flush"}
 		template := wp.JobPodTemplateSpec(cmd...)
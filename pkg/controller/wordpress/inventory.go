@@ -0,0 +1,73 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncInventory publishes the versions collected by the last inventory Job,
+// run by the inventory CronJob, in the status. The previous inventory is kept
+// when the job fails.
+func (r *ReconcileWordpress) syncInventory(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.SchedulesInventory() {
+		wp.Status.Inventory = nil
+
+		return nil
+	}
+
+	if wp.Status.Inventory == nil {
+		wp.Status.Inventory = &wordpressv1alpha1.InventoryStatus{}
+	}
+
+	status := wp.Status.Inventory
+
+	finished, err := r.finishedJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressInventory), status.CollectedAt)
+	if err != nil || len(finished) == 0 {
+		return err
+	}
+
+	// only the results of the last run are published
+	job := finished[len(finished)-1]
+
+	if isJobFailed(job) {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.InventoryFailedReason,
+			fmt.Sprintf("the inventory job %s failed to collect the versions", job.Name))
+
+		// the failed job is not looked at again
+		status.CollectedAt = jobFinishedAt(job)
+
+		return nil
+	}
+
+	report, err := r.jobOutput(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	inventory := wordpress.ParseInventory(report)
+	inventory.CollectedAt = jobFinishedAt(job)
+	*status = inventory
+
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncOptions runs a Job updating the options which differ from the spec
+// once the web pods are rolled out with new code or a new options spec, and
+// records the options it updated.
+func (r *ReconcileWordpress) syncOptions(ctx context.Context, wp *wordpress.Wordpress, workload interface{}) error {
+	if !wp.ManagesOptions() {
+		wp.Status.OptionsSyncedFor = ""
+		wp.Status.OptionsDrift = nil
+
+		return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressOptions), "")
+	}
+
+	version := wp.OptionsVersion()
+
+	if wp.Status.OptionsSyncedFor == version || !isWorkloadRolledOut(workload) {
+		return nil
+	}
+
+	jobSyncer := sync.NewOptionsJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		drift, err := r.jobOutput(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		wp.Status.OptionsSyncedFor = version
+		wp.Status.OptionsDrift = drift
+	}
+
+	// options jobs are named after the options spec and the code version
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressOptions), job.Name)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"strings"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncPlugins runs a Job syncing the plugins with the spec once the web pods
+// are rolled out with new code or a new plugins spec, and records the drift
+// it found.
+func (r *ReconcileWordpress) syncPlugins(ctx context.Context, wp *wordpress.Wordpress, workload interface{}) error {
+	if !wp.ManagesPlugins() {
+		wp.Status.PluginsSyncedFor = ""
+		wp.Status.PluginsDrift = nil
+
+		return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressPlugins), "")
+	}
+
+	version := wp.PluginsVersion()
+
+	if wp.Status.PluginsSyncedFor == version || !isWorkloadRolledOut(workload) {
+		return nil
+	}
+
+	jobSyncer := sync.NewPluginsJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		drift, err := r.jobOutput(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		wp.Status.PluginsSyncedFor = version
+		wp.Status.PluginsDrift = drift
+	}
+
+	// plugins jobs are named after the plugins spec and the code version
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressPlugins), job.Name)
+}
+
+// jobOutput returns the lines of the termination message of the Job's
+// succeeded pod.
+func (r *ReconcileWordpress) jobOutput(ctx context.Context, job *batchv1.Job) ([]string, error) {
+	pods := &corev1.PodList{}
+
+	// the pods are not cached
+	err := r.apiReader.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"controller-uid": string(job.UID)},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			if status.Name != wordpress.WPCliContainerName || status.State.Terminated == nil || status.State.Terminated.ExitCode != 0 {
+				continue
+			}
+
+			message := strings.TrimSpace(status.State.Terminated.Message)
+			if message == "" {
+				return nil, nil
+			}
+
+			return strings.Split(message, "\n"), nil
+		}
+	}
+
+	return nil, nil
+}
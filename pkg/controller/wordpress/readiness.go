@@ -0,0 +1,130 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/presslabs/controller-util/syncer"
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// readinessConditions are the conditions the Ready condition is computed
+// from, when they're set.
+var readinessConditions = []wordpressv1alpha1.WordpressConditionType{
+	wordpressv1alpha1.DeploymentReadyCondition,
+	wordpressv1alpha1.CodeSyncedCondition,
+	wordpressv1alpha1.MediaReadyCondition,
+	wordpressv1alpha1.DatabaseReadyCondition,
+	wordpressv1alpha1.BootstrapCompletedCondition,
+}
+
+// syncReadiness sets the DeploymentReady, CodeSynced, MediaReady and
+// BootstrapCompleted conditions, and the Ready condition summarizing them
+// along with DatabaseReady, for the generation of the site being reconciled.
+func (r *ReconcileWordpress) syncReadiness(wp *wordpress.Wordpress, workload interface{}, syncers []syncer.Interface) {
+	if isWorkloadRolledOut(workload) {
+		r.setDeploymentReady(wp, corev1.ConditionTrue, wordpressv1alpha1.DeploymentAvailableReason,
+			"the web pods are updated and available")
+	} else {
+		r.setDeploymentReady(wp, corev1.ConditionFalse, wordpressv1alpha1.RolloutInProgressReason,
+			"the web pods are being rolled out")
+	}
+
+	if version := wp.CodeVersion(); wp.Status.CodeVersion == version {
+		wp.SetCondition(wordpressv1alpha1.CodeSyncedCondition, corev1.ConditionTrue, wordpressv1alpha1.CodeSyncedReason,
+			fmt.Sprintf("the web pods run %s", version))
+	} else {
+		wp.SetCondition(wordpressv1alpha1.CodeSyncedCondition, corev1.ConditionFalse, wordpressv1alpha1.CodeSyncPendingReason,
+			fmt.Sprintf("the web pods are being rolled out with %s", version))
+	}
+
+	syncMediaReady(wp, syncers)
+	syncBootstrapCompleted(wp)
+
+	notReady := []string{}
+
+	for _, condType := range readinessConditions {
+		if cond := wp.GetCondition(condType); cond != nil && cond.Status != corev1.ConditionTrue {
+			notReady = append(notReady, string(condType))
+		}
+	}
+
+	if len(notReady) == 0 {
+		wp.SetCondition(wordpressv1alpha1.ReadyCondition, corev1.ConditionTrue, wordpressv1alpha1.SiteReadyReason, "the site is ready")
+	} else {
+		wp.SetCondition(wordpressv1alpha1.ReadyCondition, corev1.ConditionFalse, wordpressv1alpha1.SiteNotReadyReason,
+			fmt.Sprintf("waiting for %s", strings.Join(notReady, ", ")))
+	}
+
+	wp.Status.ObservedGeneration = wp.Generation
+}
+
+// setDeploymentReady sets the DeploymentReady condition, recording an event
+// when its reason changes, i.e. when a rollout starts or completes.
+func (r *ReconcileWordpress) setDeploymentReady(wp *wordpress.Wordpress, status corev1.ConditionStatus, reason, message string) {
+	if cond := wp.GetCondition(wordpressv1alpha1.DeploymentReadyCondition); cond == nil || cond.Reason != reason {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.DeploymentReadyCondition, status, reason, message)
+}
+
+// syncMediaReady sets the MediaReady condition for the media persistent
+// volume claim created by the operator. The claims of a StatefulSet are bound
+// along with its pods.
+func syncMediaReady(wp *wordpress.Wordpress, syncers []syncer.Interface) {
+	name := wp.ComponentName(wordpress.WordpressMediaPVC)
+
+	for _, s := range syncers {
+		pvc, ok := s.Object().(*corev1.PersistentVolumeClaim)
+		if !ok || pvc.Name != name {
+			continue
+		}
+
+		if pvc.Status.Phase == corev1.ClaimBound {
+			wp.SetCondition(wordpressv1alpha1.MediaReadyCondition, corev1.ConditionTrue, wordpressv1alpha1.MediaVolumeBoundReason,
+				fmt.Sprintf("the %s persistent volume claim is bound", name))
+		} else {
+			wp.SetCondition(wordpressv1alpha1.MediaReadyCondition, corev1.ConditionFalse, wordpressv1alpha1.MediaVolumePendingReason,
+				fmt.Sprintf("the %s persistent volume claim is not bound yet", name))
+		}
+
+		return
+	}
+
+	wp.RemoveCondition(wordpressv1alpha1.MediaReadyCondition)
+}
+
+// syncBootstrapCompleted sets the BootstrapCompleted condition for the sites
+// which are bootstrapped.
+func syncBootstrapCompleted(wp *wordpress.Wordpress) {
+	switch {
+	case wp.Spec.WordpressBootstrapSpec == nil:
+		wp.RemoveCondition(wordpressv1alpha1.BootstrapCompletedCondition)
+	case wp.Status.Bootstrapped:
+		wp.SetCondition(wordpressv1alpha1.BootstrapCompletedCondition, corev1.ConditionTrue, wordpressv1alpha1.BootstrappedReason,
+			"the site was bootstrapped")
+	default:
+		wp.SetCondition(wordpressv1alpha1.BootstrapCompletedCondition, corev1.ConditionFalse, wordpressv1alpha1.BootstrapPendingReason,
+			"the web pods' bootstrap init containers didn't complete yet")
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"time"
+
+	"github.com/presslabs/controller-util/syncer"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// a histogram per site would multiply its series by the buckets
+	reconcileLastDurationSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_reconcile_last_duration_seconds",
+		Help: "The time the site's last reconcile took.",
+	}, []string{"namespace", "name"})
+
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "wordpress_reconcile_errors_total",
+		Help: "The number of the site's reconciles which returned an error.",
+	}, []string{"namespace", "name"})
+
+	reconcileLastSuccessTimestamp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wordpress_reconcile_last_success_timestamp_seconds",
+		Help: "The time the site's last successful reconcile finished at.",
+	}, []string{"namespace", "name"})
+
+	reconcileChildrenTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "wordpress_reconcile_children_total",
+		Help: "The number of the site's child resources created or updated by the reconciles, by operation.",
+	}, []string{"namespace", "name", "operation"})
+
+	reconcileMetrics = []prometheus.Collector{
+		reconcileLastDurationSeconds,
+		reconcileErrorsTotal,
+		reconcileLastSuccessTimestamp,
+		reconcileChildrenTotal,
+	}
+)
+
+func init() {
+	for _, m := range reconcileMetrics {
+		metrics.Registry.MustRegister(m)
+	}
+}
+
+// reportReconcileMetrics publishes the duration and the outcome of a site's
+// reconcile. A site stuck in a reconcile loop shows up as an increasing error
+// count or a stale last success timestamp.
+func reportReconcileMetrics(key types.NamespacedName, duration time.Duration, err error) {
+	reconcileLastDurationSeconds.WithLabelValues(key.Namespace, key.Name).Set(duration.Seconds())
+
+	if err != nil {
+		reconcileErrorsTotal.WithLabelValues(key.Namespace, key.Name).Inc()
+
+		return
+	}
+
+	reconcileLastSuccessTimestamp.WithLabelValues(key.Namespace, key.Name).SetToCurrentTime()
+}
+
+// deleteReconcileMetrics removes the metrics of a deleted site.
+func deleteReconcileMetrics(key types.NamespacedName) {
+	reconcileLastDurationSeconds.DeleteLabelValues(key.Namespace, key.Name)
+	reconcileErrorsTotal.DeleteLabelValues(key.Namespace, key.Name)
+	reconcileLastSuccessTimestamp.DeleteLabelValues(key.Namespace, key.Name)
+
+	for _, op := range []controllerutil.OperationResult{controllerutil.OperationResultCreated, controllerutil.OperationResultUpdated} {
+		reconcileChildrenTotal.DeleteLabelValues(key.Namespace, key.Name, string(op))
+	}
+}
+
+// countedSyncer counts the child resources created or updated by a syncer,
+// by their owner.
+type countedSyncer struct {
+	syncer.Interface
+}
+
+// Sync implements syncer.Interface.
+func (s *countedSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
+	result, err := s.Interface.Sync(ctx)
+
+	owner, ok := s.ObjectOwner().(client.Object)
+	if !ok {
+		return result, err
+	}
+
+	if result.Operation == controllerutil.OperationResultCreated || result.Operation == controllerutil.OperationResultUpdated {
+		reconcileChildrenTotal.WithLabelValues(owner.GetNamespace(), owner.GetName(), string(result.Operation)).Inc()
+	}
+
+	return result, err
+}
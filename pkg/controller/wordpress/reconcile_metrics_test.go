@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The reconcile metrics", func() {
+	var key types.NamespacedName
+
+	BeforeEach(func() {
+		key = types.NamespacedName{Namespace: "default", Name: "measured"}
+	})
+
+	AfterEach(func() {
+		deleteReconcileMetrics(key)
+	})
+
+	It("should count the failed reconciles", func() {
+		reportReconcileMetrics(key, 2*time.Second, errors.New("failed"))
+		reportReconcileMetrics(key, time.Second, errors.New("failed"))
+
+		Expect(testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues(key.Namespace, key.Name))).To(Equal(float64(2)))
+		Expect(testutil.ToFloat64(reconcileLastDurationSeconds.WithLabelValues(key.Namespace, key.Name))).To(Equal(float64(1)))
+		Expect(reconcileLastSuccessTimestamp.DeleteLabelValues(key.Namespace, key.Name)).To(BeFalse())
+	})
+
+	It("should record the last successful reconcile", func() {
+		before := time.Now().Unix()
+		reportReconcileMetrics(key, time.Second, nil)
+
+		Expect(testutil.ToFloat64(reconcileLastSuccessTimestamp.WithLabelValues(key.Namespace, key.Name))).
+			To(BeNumerically(">=", before))
+		Expect(testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues(key.Namespace, key.Name))).To(BeZero())
+	})
+
+	It("should count the child resources created or updated by the syncers", func() {
+		wp := wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, UID: "test-uid"},
+		})
+		wp.SetDefaults()
+
+		r := newTestReconciler(wp.Unwrap())
+		s := &countedSyncer{Interface: sync.NewServiceSyncer(wp, r.Client)}
+
+		result, err := s.Sync(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Operation).To(Equal(controllerutil.OperationResultCreated))
+
+		// an unchanged child resource is not counted
+		result, err = s.Sync(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Operation).To(Equal(controllerutil.OperationResultNone))
+
+		created := string(controllerutil.OperationResultCreated)
+		Expect(testutil.ToFloat64(reconcileChildrenTotal.WithLabelValues(key.Namespace, key.Name, created))).To(Equal(float64(1)))
+	})
+
+	It("should remove the metrics of a deleted site", func() {
+		reportReconcileMetrics(key, time.Second, nil)
+		reportReconcileMetrics(key, time.Second, errors.New("failed"))
+
+		for _, op := range []controllerutil.OperationResult{controllerutil.OperationResultCreated, controllerutil.OperationResultUpdated} {
+			reconcileChildrenTotal.WithLabelValues(key.Namespace, key.Name, string(op)).Inc()
+		}
+
+		deleteReconcileMetrics(key)
+
+		Expect(reconcileLastDurationSeconds.DeleteLabelValues(key.Namespace, key.Name)).To(BeFalse())
+		Expect(reconcileErrorsTotal.DeleteLabelValues(key.Namespace, key.Name)).To(BeFalse())
+		Expect(reconcileLastSuccessTimestamp.DeleteLabelValues(key.Namespace, key.Name)).To(BeFalse())
+
+		for _, op := range []controllerutil.OperationResult{controllerutil.OperationResultCreated, controllerutil.OperationResultUpdated} {
+			Expect(reconcileChildrenTotal.DeleteLabelValues(key.Namespace, key.Name, string(op))).To(BeFalse())
+		}
+	})
+})
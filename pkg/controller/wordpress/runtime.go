@@ -0,0 +1,53 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncRuntime reports whether the site's PHP version and variant are
+// supported and, once the web pods are rolled out with their runtime image,
+// the PHP version they run.
+func syncRuntime(wp *wordpress.Wordpress, workload interface{}) {
+	if wp.Spec.Runtime == nil {
+		wp.Status.PHPVersion = ""
+		wp.RemoveCondition(wordpressv1alpha1.RuntimeSupportedCondition)
+
+		return
+	}
+
+	image, err := wp.RuntimeImage()
+	if err != nil {
+		wp.Status.PHPVersion = ""
+		wp.SetCondition(wordpressv1alpha1.RuntimeSupportedCondition, corev1.ConditionFalse, wordpressv1alpha1.RuntimeUnsupportedReason, err.Error())
+
+		return
+	}
+
+	wp.SetCondition(wordpressv1alpha1.RuntimeSupportedCondition, corev1.ConditionTrue, wordpressv1alpha1.RuntimeSupportedReason, "")
+
+	// the image set explicitly takes precedence over the runtime
+	if wp.Spec.Image != image {
+		wp.Status.PHPVersion = ""
+	} else if isWorkloadRolledOut(workload) {
+		wp.Status.PHPVersion = wp.Spec.Runtime.PHPVersion
+	}
+}
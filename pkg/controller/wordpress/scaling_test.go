@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/presslabs/controller-util/syncer"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The scaling syncers", func() {
+	var (
+		wp *wordpress.Wordpress
+
+		interceptorService string
+		interceptorPort    int
+	)
+
+	// objects returns the objects reconciled by the syncers.
+	objects := func(syncers []syncer.Interface) []interface{} {
+		objs := []interface{}{}
+		for _, s := range syncers {
+			objs = append(objs, s.Object())
+		}
+
+		return objs
+	}
+
+	BeforeEach(func() {
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "test-uid"},
+			Spec: wordpressv1alpha1.WordpressSpec{
+				Routes: []wordpressv1alpha1.RouteSpec{{Domain: "example.com"}},
+				Autoscaling: &wordpressv1alpha1.AutoscalingSpec{
+					MaxReplicas: 3,
+				},
+			},
+		})
+		wp.SetDefaults()
+
+		interceptorService = options.KEDAHTTPInterceptorService
+		interceptorPort = options.KEDAHTTPInterceptorPort
+	})
+
+	AfterEach(func() {
+		options.KEDAHTTPInterceptorService = interceptorService
+		options.KEDAHTTPInterceptorPort = interceptorPort
+	})
+
+	It("should not create the HorizontalPodAutoscaler when scaling to zero", func() {
+		r := newTestReconciler()
+
+		syncers, err := r.scalingSyncers(context.TODO(), wp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects(syncers)).To(ContainElement(BeAssignableToTypeOf(&autoscalingv2beta2.HorizontalPodAutoscaler{})))
+
+		wp.Spec.ScaleToZero = &wordpressv1alpha1.ScaleToZeroSpec{
+			Schedules: []wordpressv1alpha1.ScaleSchedule{{Start: "0 8 * * *", End: "0 20 * * *"}},
+		}
+
+		syncers, err = r.scalingSyncers(context.TODO(), wp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects(syncers)).NotTo(ContainElement(BeAssignableToTypeOf(&autoscalingv2beta2.HorizontalPodAutoscaler{})))
+	})
+
+	It("should route the ingress to the interceptor set by the options", func() {
+		options.KEDAHTTPInterceptorService = "interceptor.keda.svc.cluster.local"
+		options.KEDAHTTPInterceptorPort = 9090
+
+		wp.Spec.ScaleToZero = &wordpressv1alpha1.ScaleToZeroSpec{HTTP: &wordpressv1alpha1.HTTPScaleSpec{}}
+		r := newTestReconciler(wp.Unwrap())
+
+		syncers, err := r.scalingSyncers(context.TODO(), wp)
+		Expect(err).NotTo(HaveOccurred())
+
+		var service *corev1.Service
+
+		for _, s := range syncers {
+			if obj, ok := s.Object().(*corev1.Service); ok {
+				_, err = s.Sync(context.TODO())
+				Expect(err).NotTo(HaveOccurred())
+
+				service = obj
+			}
+		}
+
+		Expect(service).NotTo(BeNil())
+		Expect(service.Name).To(Equal(wp.ComponentName(wordpress.WordpressInterceptorService)))
+		Expect(service.Spec.Type).To(Equal(corev1.ServiceTypeExternalName))
+		Expect(service.Spec.ExternalName).To(Equal("interceptor.keda.svc.cluster.local"))
+		Expect(service.Spec.Ports).To(HaveLen(1))
+		Expect(service.Spec.Ports[0].Port).To(Equal(int32(9090)))
+	})
+
+	It("should delete the ScaledObject when scaling to zero is turned off", func() {
+		scaledObject := newUnstructured(sync.ScaledObjectGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressScaledObject)))
+		scaledObject.SetOwnerReferences([]metav1.OwnerReference{
+			{APIVersion: wordpressv1alpha1.SchemeGroupVersion.String(), Kind: "Wordpress", Name: wp.Name, UID: wp.UID},
+		})
+
+		r := newTestReconciler(wp.Unwrap(), scaledObject)
+
+		key := client.ObjectKeyFromObject(scaledObject)
+		Expect(r.Get(context.TODO(), key, newUnstructured(sync.ScaledObjectGVK, objectMeta(wp, key.Name)))).To(Succeed())
+
+		syncers, err := r.scalingSyncers(context.TODO(), wp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects(syncers)).To(ContainElement(BeAssignableToTypeOf(&autoscalingv2beta2.HorizontalPodAutoscaler{})))
+
+		err = r.Get(context.TODO(), key, newUnstructured(sync.ScaledObjectGVK, objectMeta(wp, key.Name)))
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,133 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncSMTPTest runs a Job sending a test email whenever the SMTP
+// configuration changes, once the web pods are rolled out with it, and
+// reflects its result in the SMTPVerified condition.
+func (r *ReconcileWordpress) syncSMTPTest(ctx context.Context, wp *wordpress.Wordpress, workload interface{}) error {
+	jobLabels := wp.ComponentLabels(wordpress.WordpressSMTPTest)
+
+	if !wp.TestsSMTP() {
+		wp.Status.SMTPTestedFor = ""
+		wp.RemoveCondition(wordpressv1alpha1.SMTPVerifiedCondition)
+
+		return r.cleanupJobs(ctx, wp, jobLabels, "")
+	}
+
+	version := wp.SMTPVersion()
+
+	if wp.Status.SMTPTestedFor == version || !isWorkloadRolledOut(workload) {
+		return nil
+	}
+
+	jobSyncer := sync.NewSMTPTestJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+
+	switch {
+	case isJobFailed(job):
+		message, err := r.smtpTestFailure(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		r.setSMTPVerified(wp, corev1.ConditionFalse, wordpressv1alpha1.SMTPTestFailedReason, message)
+
+		// the failed job is kept, so the test email is not sent again until it's deleted
+		return nil
+	case job.Status.Succeeded == 0:
+		r.setSMTPVerified(wp, corev1.ConditionUnknown, wordpressv1alpha1.SMTPTestPendingReason,
+			fmt.Sprintf("the %s job sends a test email to %s", job.Name, wp.Spec.SMTP.TestRecipient))
+
+		return nil
+	}
+
+	wp.Status.SMTPTestedFor = version
+	r.setSMTPVerified(wp, corev1.ConditionTrue, wordpressv1alpha1.SMTPVerifiedReason,
+		fmt.Sprintf("a test email was sent to %s", wp.Spec.SMTP.TestRecipient))
+
+	// test jobs are named after the SMTP configuration
+	return r.cleanupJobs(ctx, wp, jobLabels, job.Name)
+}
+
+// smtpTestFailure returns the error the failed Job's wp-cli container
+// reported, along with a hint for retrying.
+func (r *ReconcileWordpress) smtpTestFailure(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+
+	// the pods are not cached
+	err := r.apiReader.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"controller-uid": string(job.UID)},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			if status.Name != wordpress.WPCliContainerName || status.State.Terminated == nil || status.State.Terminated.ExitCode == 0 {
+				continue
+			}
+
+			message := strings.TrimSpace(status.State.Terminated.Message)
+			if message == "" {
+				break
+			}
+
+			lines := strings.Split(message, "\n")
+
+			return fmt.Sprintf("the test email couldn't be sent: %s, delete the %s job to retry", lines[len(lines)-1], job.Name), nil
+		}
+	}
+
+	return fmt.Sprintf("the %s job failed, delete it to retry", job.Name), nil
+}
+
+// setSMTPVerified sets the SMTPVerified condition, recording an event when
+// its reason changes.
+func (r *ReconcileWordpress) setSMTPVerified(wp *wordpress.Wordpress, status corev1.ConditionStatus, reason, message string) {
+	if cond := wp.GetCondition(wordpressv1alpha1.SMTPVerifiedCondition); cond == nil || cond.Reason != reason {
+		eventType := corev1.EventTypeNormal
+		if reason == wordpressv1alpha1.SMTPTestFailedReason {
+			eventType = corev1.EventTypeWarning
+		}
+
+		r.recorder.Event(wp.Unwrap(), eventType, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.SMTPVerifiedCondition, status, reason, message)
+}
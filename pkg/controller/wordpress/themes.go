@@ -0,0 +1,64 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+
+	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncThemes runs a Job syncing the themes with the spec once the web pods
+// are rolled out with new code or a new themes spec, and records the drift
+// it found.
+func (r *ReconcileWordpress) syncThemes(ctx context.Context, wp *wordpress.Wordpress, workload interface{}) error {
+	if !wp.ManagesThemes() {
+		wp.Status.ThemesSyncedFor = ""
+		wp.Status.ThemesDrift = nil
+
+		return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressThemes), "")
+	}
+
+	version := wp.ThemesVersion()
+
+	if wp.Status.ThemesSyncedFor == version || !isWorkloadRolledOut(workload) {
+		return nil
+	}
+
+	jobSyncer := sync.NewThemesJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		drift, err := r.jobOutput(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		wp.Status.ThemesSyncedFor = version
+		wp.Status.ThemesDrift = drift
+	}
+
+	// themes jobs are named after the themes spec and the code version
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressThemes), job.Name)
+}
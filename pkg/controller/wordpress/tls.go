@@ -0,0 +1,126 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncTLSSecret checks that the secret of TLSSecretRef holds a certificate
+// covering the site's domains and reports its validity, instead of serving a
+// missing or mismatched certificate silently.
+func (r *ReconcileWordpress) syncTLSSecret(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.ValidatesTLSSecret() {
+		wp.RemoveCondition(wordpressv1alpha1.TLSSecretValidCondition)
+		wp.RemoveCondition(wordpressv1alpha1.CertificateExpiringSoonCondition)
+		wp.Status.TLSSecret = nil
+
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Name: string(wp.Spec.TLSSecretRef), Namespace: wp.Namespace}
+
+	if err := r.Get(ctx, key, secret); errors.IsNotFound(err) {
+		wp.RemoveCondition(wordpressv1alpha1.CertificateExpiringSoonCondition)
+		wp.Status.TLSSecret = nil
+		r.setTLSSecretValid(wp, corev1.ConditionFalse, wordpressv1alpha1.TLSSecretNotFoundReason,
+			fmt.Sprintf("secret %s not found", key.Name))
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	cert, err := wordpress.ParseTLSCertificate(secret)
+	if err != nil {
+		wp.RemoveCondition(wordpressv1alpha1.CertificateExpiringSoonCondition)
+		wp.Status.TLSSecret = nil
+		r.setTLSSecretValid(wp, corev1.ConditionFalse, wordpressv1alpha1.TLSSecretInvalidReason,
+			fmt.Sprintf("secret %s: %s", key.Name, err))
+
+		return nil
+	}
+
+	wp.Status.TLSSecret = &wordpressv1alpha1.TLSSecretStatus{
+		NotBefore: metav1.NewTime(cert.NotBefore),
+		NotAfter:  metav1.NewTime(cert.NotAfter),
+	}
+
+	status, reason, message := certificateExpiry(cert.NotAfter, time.Now())
+	if cond := wp.GetCondition(wordpressv1alpha1.CertificateExpiringSoonCondition); status == corev1.ConditionTrue &&
+		(cond == nil || cond.Reason != reason) {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.CertificateExpiringSoonCondition, status, reason, message)
+
+	if domains := wp.UncoveredDomains(cert); len(domains) > 0 {
+		r.setTLSSecretValid(wp, corev1.ConditionFalse, wordpressv1alpha1.TLSSecretDomainMismatchReason,
+			fmt.Sprintf("the certificate of secret %s doesn't cover %s", key.Name, strings.Join(domains, ", ")))
+
+		return nil
+	}
+
+	r.setTLSSecretValid(wp, corev1.ConditionTrue, wordpressv1alpha1.TLSSecretValidReason,
+		fmt.Sprintf("the certificate of secret %s covers the site's domains", key.Name))
+
+	return nil
+}
+
+// setTLSSecretValid sets the TLSSecretValid condition, recording an event
+// when its reason changes.
+func (r *ReconcileWordpress) setTLSSecretValid(wp *wordpress.Wordpress, status corev1.ConditionStatus, reason, message string) {
+	if cond := wp.GetCondition(wordpressv1alpha1.TLSSecretValidCondition); cond == nil || cond.Reason != reason {
+		eventType := corev1.EventTypeNormal
+		if status != corev1.ConditionTrue {
+			eventType = corev1.EventTypeWarning
+		}
+
+		r.recorder.Event(wp.Unwrap(), eventType, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.TLSSecretValidCondition, status, reason, message)
+}
+
+// certificateExpiry returns the CertificateExpiringSoon condition of a
+// certificate expiring at notAfter.
+func certificateExpiry(notAfter, now time.Time) (corev1.ConditionStatus, string, string) {
+	switch {
+	case !now.Before(notAfter):
+		return corev1.ConditionTrue, wordpressv1alpha1.CertificateExpiredReason,
+			fmt.Sprintf("the certificate expired at %s", notAfter.UTC().Format(time.RFC3339))
+	case now.Add(options.CertificateExpiryWarning).After(notAfter):
+		return corev1.ConditionTrue, wordpressv1alpha1.CertificateExpiringSoonReason,
+			fmt.Sprintf("the certificate expires at %s", notAfter.UTC().Format(time.RFC3339))
+	default:
+		return corev1.ConditionFalse, wordpressv1alpha1.CertificateNotExpiringReason,
+			fmt.Sprintf("the certificate expires at %s", notAfter.UTC().Format(time.RFC3339))
+	}
+}
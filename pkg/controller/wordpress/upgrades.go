@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+// syncUpgrade holds the web pods at the image and code reference they're
+// rolled out with until the backup taken before upgrading them succeeds. It
+// returns true while the upgrade is held. The code version is recorded once,
+// when the backups before upgrades are enabled.
+func (r *ReconcileWordpress) syncUpgrade(ctx context.Context, wp *wordpress.Wordpress) (bool, error) {
+	if !wp.BacksUpBeforeUpgrade() {
+		wp.Status.Upgrade = nil
+		wp.RemoveCondition(wordpressv1alpha1.UpgradeBackedUpCondition)
+
+		return false, nil
+	}
+
+	if wp.Status.Upgrade == nil {
+		wp.Status.Upgrade = &wordpressv1alpha1.UpgradeStatus{}
+	}
+
+	status := wp.Status.Upgrade
+
+	if status.Image == "" {
+		status.Image = wp.Spec.Image
+		status.GitRef = wp.GitRef()
+	}
+
+	if !wp.UpgradePending() {
+		return false, nil
+	}
+
+	backedUp, err := r.backupBeforeUpgrade(ctx, wp, wp.CodeVersion())
+	if err != nil {
+		return false, err
+	}
+
+	if !backedUp {
+		wp.HoldUpgrade()
+
+		return true, nil
+	}
+
+	status.Image = wp.Spec.Image
+	status.GitRef = wp.GitRef()
+
+	return false, nil
+}
+
+// backupBeforeUpgrade creates the WordpressBackup taken before upgrading the
+// site to the given version and returns true once it succeeded. The backup
+// is not owned by the Wordpress, so it outlives it. A failed backup holds the
+// upgrade until it's deleted.
+func (r *ReconcileWordpress) backupBeforeUpgrade(ctx context.Context, wp *wordpress.Wordpress, version string) (bool, error) {
+	backup, err := wp.UpgradeBackup(version)
+	if err != nil {
+		wp.SetCondition(wordpressv1alpha1.UpgradeBackedUpCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.UpgradeBackupFailedReason, err.Error())
+
+		return false, nil
+	}
+
+	err = r.Get(ctx, client.ObjectKeyFromObject(backup), backup)
+	if errors.IsNotFound(err) {
+		if err = r.Create(ctx, backup); err != nil {
+			return false, err
+		}
+
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.UpgradeBackupPendingReason,
+			fmt.Sprintf("created the %s backup before the upgrade to %s", backup.Name, version))
+	} else if err != nil {
+		return false, err
+	}
+
+	switch backup.Status.Phase {
+	case wordpressv1alpha1.BackupSucceeded:
+		wp.Status.Upgrade.LastBackup = backup.Name
+		wp.SetCondition(wordpressv1alpha1.UpgradeBackedUpCondition, corev1.ConditionTrue,
+			wordpressv1alpha1.UpgradeBackedUpReason, fmt.Sprintf("the %s backup succeeded", backup.Name))
+
+		return true, nil
+	case wordpressv1alpha1.BackupFailed:
+		wp.SetCondition(wordpressv1alpha1.UpgradeBackedUpCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.UpgradeBackupFailedReason,
+			fmt.Sprintf("the upgrade to %s is held as the %s backup failed, delete it to retry", version, backup.Name))
+	default:
+		wp.SetCondition(wordpressv1alpha1.UpgradeBackedUpCondition, corev1.ConditionFalse,
+			wordpressv1alpha1.UpgradeBackupPendingReason,
+			fmt.Sprintf("the upgrade to %s waits for the %s backup", version, backup.Name))
+	}
+
+	return false, nil
+}
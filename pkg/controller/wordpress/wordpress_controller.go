@@ -18,15 +18,25 @@ package wordpress
 
 import (
 	"context"
+	"net"
+	"sort"
+	"time"
 
 	"github.com/presslabs/controller-util/syncer"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -39,9 +49,26 @@ import (
 	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
 	"github.com/bitpoke/wordpress-operator/pkg/controller/wordpress/internal/sync"
 	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+	"github.com/bitpoke/wordpress-operator/pkg/tracing"
 )
 
-const controllerName = "wordpress-controller"
+const (
+	controllerName = "wordpress-controller"
+
+	pendingRequeueInterval      = 30 * time.Second
+	databaseDialTimeout         = 5 * time.Second
+	databaseHealthCheckInterval = time.Minute
+	coreUpdateRequeueInterval   = 5 * time.Minute
+	cronJobRequeueInterval      = 5 * time.Minute
+	tlsSecretCheckInterval      = time.Hour
+)
+
+const (
+	// configMapRefsField indexes the sites by the config maps they use
+	configMapRefsField = "spec.configMapRefs"
+	// secretRefsField indexes the sites by the secrets they use
+	secretRefsField = "spec.secretRefs"
+)
 
 // Add creates a new Wordpress Controller and adds it to the Manager with default RBAC. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
@@ -51,7 +78,12 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler.
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileWordpress{Client: mgr.GetClient(), scheme: mgr.GetScheme(), recorder: mgr.GetEventRecorderFor(controllerName)}
+	return &ReconcileWordpress{
+		Client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor(controllerName),
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler.
@@ -70,10 +102,43 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 
 	subresources := []client.Object{
 		&appsv1.Deployment{},
+		&appsv1.StatefulSet{},
 		&corev1.PersistentVolumeClaim{},
 		&corev1.Service{},
 		&corev1.Secret{},
+		&corev1.ServiceAccount{},
 		&netv1.Ingress{},
+		&policyv1.PodDisruptionBudget{},
+		&autoscalingv2beta2.HorizontalPodAutoscaler{},
+		&batchv1.Job{},
+	}
+
+	// index the sites by the config maps and secrets they use, so that the
+	// changes of the other ones are dropped without listing the sites
+	err = indexReferences(mgr, configMapRefsField, usedConfigMaps)
+	if err != nil {
+		return err
+	}
+
+	err = indexReferences(mgr, secretRefsField, usedSecrets)
+	if err != nil {
+		return err
+	}
+
+	// roll the web pods when the web server config or the referenced config maps change
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		return wordpressesUsing(mgr.GetClient(), obj, configMapRefsField)
+	}))
+	if err != nil {
+		return err
+	}
+
+	// validate the TLS secrets and roll the web pods when the referenced secrets change
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+		return wordpressesUsing(mgr.GetClient(), obj, secretRefsField)
+	}))
+	if err != nil {
+		return err
 	}
 
 	for _, subresource := range subresources {
@@ -94,16 +159,31 @@ var _ reconcile.Reconciler = &ReconcileWordpress{}
 // ReconcileWordpress reconciles a Wordpress object.
 type ReconcileWordpress struct {
 	client.Client
-	scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	// apiReader reads the objects which are not cached
+	apiReader client.Reader
+	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
 }
 
 // Automatically generate RBAC rules to allow the Controller to read and write Deployments
-// +kubebuilder:rbac:groups=core,resources=secrets;services;persistentvolumeclaims;events,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=secrets;services;serviceaccounts;persistentvolumeclaims;events,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=external-secrets.io,resources=externalsecrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=externaldns.k8s.io,resources=dnsendpoints,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=probes,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mysql.presslabs.org,resources=mysqlclusters;mysqldatabases;mysqlusers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices;destinationrules,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keda.sh,resources=scaledobjects,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=http.keda.sh,resources=httpscaledobjects,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpresses;wordpresses/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpressbackups,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile reads that state of the cluster for a Wordpress object and makes changes based on the state read
 // and what is in the Wordpress.Spec.
@@ -112,56 +192,204 @@ func (r *ReconcileWordpress) Reconcile(ctx context.Context, request reconcile.Re
 	wp := wordpress.New(&wordpressv1alpha1.Wordpress{})
 
 	err := r.Get(ctx, request.NamespacedName, wp.Unwrap())
+	if errors.IsNotFound(err) {
+		deleteReconcileMetrics(request.NamespacedName)
+		deleteBackupMetrics(request.NamespacedName)
+	}
+
 	if err != nil {
 		return reconcile.Result{}, ignoreNotFound(err)
 	}
 
-	if updated, needsMigration := r.maybeMigrate(wp.Unwrap()); needsMigration {
-		err = r.Update(ctx, updated)
+	ctx, span := tracing.StartReconcile(ctx, "Wordpress", request.NamespacedName)
 
-		return reconcile.Result{}, err
+	start := time.Now()
+	result, err := r.reconcileWordpress(ctx, wp)
+	reportReconcileMetrics(request.NamespacedName, time.Since(start), err)
+	tracing.End(span, err)
+
+	// the conflicts are retried right away, without anything to act upon
+	if err != nil && !errors.IsConflict(err) {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.ReconcileFailedReason, err.Error())
+	}
+
+	return result, err
+}
+
+// reconcileWordpress syncs the site's child resources and status.
+func (r *ReconcileWordpress) reconcileWordpress(ctx context.Context, wp *wordpress.Wordpress) (reconcile.Result, error) {
+	if updated, needsMigration := r.maybeMigrate(wp.Unwrap()); needsMigration {
+		return reconcile.Result{}, r.Update(ctx, updated)
 	}
 
 	r.scheme.Default(wp.Unwrap())
 	wp.SetDefaults()
 
-	secretSyncer := sync.NewSecretSyncer(wp, r.Client)
-	deploySyncer := sync.NewDeploymentSyncer(wp, secretSyncer.Object().(*corev1.Secret), r.Client)
-	syncers := []syncer.Interface{
-		secretSyncer,
-		deploySyncer,
-		sync.NewServiceSyncer(wp, r.Client),
-		sync.NewIngressSyncer(wp, r.Client),
-		// sync.NewDBUpgradeJobSyncer(wp, r.Client),
+	oldStatus := wp.Status.DeepCopy()
+
+	// a held upgrade renders the site with the code version it runs, so it goes before all the syncers
+	upgradePending, err := r.syncUpgrade(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
-	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil {
-		syncers = append(syncers, sync.NewCodePVCSyncer(wp, r.Client))
+	certificatePending, err := r.syncCertificate(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
-	if wp.Spec.MediaVolumeSpec != nil && wp.Spec.MediaVolumeSpec.PersistentVolumeClaim != nil {
-		syncers = append(syncers, sync.NewMediaPVCSyncer(wp, r.Client))
+	if err = r.syncTLSSecret(ctx, wp); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// the pods are not rendered with images whose signatures are not verified, so until they are, the web
+	// workload and the jobs are left as they are while the rest of the site is synced
+	imagesPending, imagesErr := r.syncImageVerification(ctx, wp)
+	if imagesErr != nil && !imagesPending {
+		return reconcile.Result{}, imagesErr
+	}
+
+	// the database's secret holds the passwords copied into the site's secret
+	databaseSyncers := r.databaseSyncers(wp)
+	secretSyncer := sync.NewSecretSyncer(wp, databaseSecret(databaseSyncers), r.Client)
+
+	syncers := append([]syncer.Interface{}, databaseSyncers...)
+	syncers = append(syncers, secretSyncer, sync.NewServiceSyncer(wp, r.Client))
+
+	for _, fn := range []func(context.Context, *wordpress.Wordpress) ([]syncer.Interface, error){
+		r.routingSyncers,
+		r.secretsProviderSyncers,
+		r.serviceAccountSyncers,
+		r.cacheSyncers,
+		r.mediaSyncers,
+		r.webServerConfigSyncers,
+		r.cronSyncers,
+		r.multisiteSyncers,
+		r.cleanupSyncers,
+		r.diagnosticsSyncers,
+		r.integrityCheckSyncers,
+		r.inventorySyncers,
+		r.backupsSyncers,
+		r.probeSyncers,
+	} {
+		var s []syncer.Interface
+
+		if s, err = fn(ctx, wp); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		syncers = append(syncers, s...)
+	}
+
+	config, err := r.webServerConfig(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	references, err := r.referencesVersion(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	workloadSyncers, err := r.workloadSyncers(ctx, wp, secretSyncer.Object().(*corev1.Secret), config, references)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if imagesPending {
+		syncers = withoutJobs(syncers)
+
+		// the web pods keep running the previously verified images
+		if err = r.getWorkload(ctx, workloadSyncers[0].Object().(client.Object)); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		syncers = append(syncers, workloadSyncers[1:]...)
+	} else {
+		syncers = append(syncers, workloadSyncers...)
+	}
+
+	scalingSyncers, err := r.scalingSyncers(ctx, wp)
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
+	syncers = append(syncers, scalingSyncers...)
+
 	if err = r.sync(ctx, syncers); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	oldStatus := wp.Status.DeepCopy()
-	wp.Status.Replicas = deploySyncer.Object().(*appsv1.Deployment).Status.Replicas
+	wp.Status.Replicas = webReplicas(workloadSyncers[0].Object())
+	syncRuntime(wp, workloadSyncers[0].Object())
+	syncBootstrapped(wp, workloadSyncers[0].Object())
+	syncCodeVersion(wp, workloadSyncers[0].Object())
+	restartDeferred := syncRestartDeferred(wp, workloadSyncers[0].Object(), config, references)
+
+	databasePending, err := r.syncDatabase(ctx, wp, databaseSyncers, secretSyncer.Object().(*corev1.Secret), workloadSyncers[0].Object())
+	if err != nil {
+		return reconcile.Result{}, err
+	}
 
-	if oldStatus.Replicas != wp.Status.Replicas {
-		if errUp := r.Status().Update(ctx, wp.Unwrap()); errUp != nil {
-			return reconcile.Result{}, errUp
+	// the canary and the maintenance Jobs run the site's images too
+	if !imagesPending {
+		if err = r.syncCanary(ctx, wp, secretSyncer.Object().(*corev1.Secret), config, references); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		if err = r.syncMaintenanceJobs(ctx, wp, workloadSyncers[0].Object()); err != nil {
+			return reconcile.Result{}, err
 		}
 	}
 
-	// remove old cron job if exists
-	if err = r.cleanupCronJob(ctx, wp); err != nil {
+	r.syncReadiness(wp, workloadSyncers[0].Object(), workloadSyncers)
+	r.recordChanges(wp, oldStatus)
+
+	if err = r.updateStatus(ctx, wp, oldStatus); err != nil {
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	// the certificate, the database and the upgrade's backup are not watched, so check back until they're
+	// ready, as well as for the maintenance window to open. The failed image verification fails the reconcile.
+	return requeueResult(wp, certificatePending, databasePending, upgradePending, restartDeferred), imagesErr
+}
+
+// requeueResult computes when the Wordpress is reconciled again, for the
+// pending dependencies and the periodic checks which are not watched.
+func requeueResult(wp *wordpress.Wordpress, pending ...bool) reconcile.Result {
+	for _, p := range pending {
+		if p {
+			return reconcile.Result{RequeueAfter: pendingRequeueInterval}
+		}
+	}
+
+	if wp.GetCondition(wordpressv1alpha1.DatabaseHealthyCondition) != nil {
+		return reconcile.Result{RequeueAfter: databaseHealthCheckInterval}
+	}
+
+	if wp.ChecksCoreUpdates() {
+		return reconcile.Result{RequeueAfter: coreUpdateRequeueInterval}
+	}
+
+	// the CronJobs' jobs are owned by them, so they're not watched
+	if wp.SchedulesCleanup() || wp.SchedulesDiagnostics() || wp.SchedulesIntegrityCheck() || wp.SchedulesInventory() || wp.SchedulesBackups() {
+		return reconcile.Result{RequeueAfter: cronJobRequeueInterval}
+	}
+
+	// the expiration of the TLS secret's certificate is checked periodically
+	if wp.Status.TLSSecret != nil {
+		return reconcile.Result{RequeueAfter: tlsSecretCheckInterval}
+	}
+
+	return reconcile.Result{}
+}
+
+func (r *ReconcileWordpress) updateStatus(ctx context.Context, wp *wordpress.Wordpress, oldStatus *wordpressv1alpha1.WordpressStatus) error {
+	if equality.Semantic.DeepEqual(oldStatus, &wp.Status) {
+		return nil
+	}
+
+	return r.Status().Update(ctx, wp.Unwrap())
 }
 
 func ignoreNotFound(err error) error {
@@ -172,9 +400,32 @@ func ignoreNotFound(err error) error {
 	return err
 }
 
+// withoutJobs returns the syncers which don't sync Jobs or CronJobs, as they
+// run the site's images.
+func withoutJobs(syncers []syncer.Interface) []syncer.Interface {
+	out := []syncer.Interface{}
+
+	for _, s := range syncers {
+		switch s.Object().(type) {
+		case *batchv1.Job, *batchv1.CronJob:
+			continue
+		}
+
+		out = append(out, s)
+	}
+
+	return out
+}
+
+// getWorkload reads the web workload as it is, for the status, instead of
+// syncing it. It's left empty if it doesn't exist yet.
+func (r *ReconcileWordpress) getWorkload(ctx context.Context, obj client.Object) error {
+	return ignoreNotFound(r.Get(ctx, client.ObjectKeyFromObject(obj), obj))
+}
+
 func (r *ReconcileWordpress) sync(ctx context.Context, syncers []syncer.Interface) error {
 	for _, s := range syncers {
-		if err := syncer.Sync(ctx, s, r.recorder); err != nil {
+		if err := tracing.Sync(ctx, &countedSyncer{Interface: s}, r.recorder); err != nil {
 			return err
 		}
 	}
@@ -202,23 +453,1173 @@ func (r *ReconcileWordpress) maybeMigrate(wp *wordpressv1alpha1.Wordpress) (*wor
 	return out, needsMigration
 }
 
-func (r *ReconcileWordpress) cleanupCronJob(ctx context.Context, wp *wordpress.Wordpress) error {
-	cronKey := types.NamespacedName{
-		Name:      wp.ComponentName(wordpress.WordpressCron),
-		Namespace: wp.Namespace,
+// syncCertificate reconciles the site's cert-manager Certificate and reflects
+// its status in the Wordpress status. It returns true while the certificate is not issued.
+func (r *ReconcileWordpress) syncCertificate(ctx context.Context, wp *wordpress.Wordpress) (bool, error) {
+	if wp.Spec.TLS == nil || wp.Spec.TLS.IssuerRef == nil {
+		wp.RemoveCondition(wordpressv1alpha1.CertificateReadyCondition)
+		wp.Status.CertificateNotAfter = nil
+
+		certificate := newUnstructured(sync.CertificateGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressCertificate)))
+
+		return false, r.deleteOwned(ctx, wp, certificate)
+	}
+
+	certificateSyncer := sync.NewCertificateSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{certificateSyncer}); err != nil {
+		return false, err
+	}
+
+	status, message, notAfter := sync.CertificateStatus(certificateSyncer.Object().(*unstructured.Unstructured))
+
+	reason := wordpressv1alpha1.CertificatePendingReason
+	if status == corev1.ConditionTrue {
+		reason = wordpressv1alpha1.CertificateIssuedReason
+	}
+
+	wp.SetCondition(wordpressv1alpha1.CertificateReadyCondition, status, reason, message)
+	wp.Status.CertificateNotAfter = notAfter
+
+	return status != corev1.ConditionTrue, nil
+}
+
+// databaseSyncers returns the syncers for the provisioned database or the
+// bootstrap job of the external one. The database is not removed when
+// provisioning gets disabled, to avoid data loss.
+func (r *ReconcileWordpress) databaseSyncers(wp *wordpress.Wordpress) []syncer.Interface {
+	if wp.BootstrapsDatabase() {
+		return []syncer.Interface{sync.NewDBBootstrapJobSyncer(wp, r.Client)}
+	}
+
+	if !wp.ProvisionsDatabase() {
+		return nil
+	}
+
+	syncers := []syncer.Interface{
+		sync.NewMysqlSecretSyncer(wp, r.Client),
+		sync.NewMysqlClusterSyncer(wp, r.Client),
+		sync.NewMysqlDatabaseSyncer(wp, r.Client),
+	}
+
+	for _, generation := range wp.DatabaseCredentialsGenerations() {
+		syncers = append(syncers, sync.NewMysqlUserSyncer(wp, generation, r.Client))
+	}
+
+	return syncers
+}
+
+// databaseSecret returns the secret of the provisioned database or an empty
+// secret if the database is not provisioned.
+func databaseSecret(syncers []syncer.Interface) *corev1.Secret {
+	for _, s := range syncers {
+		if secret, ok := s.Object().(*corev1.Secret); ok {
+			return secret
+		}
+	}
+
+	return &corev1.Secret{}
+}
+
+// syncDatabase rotates the credentials of the provisioned database and
+// reflects the database's status and health in the Wordpress status. It returns
+// true while the database is not ready or the credentials are being rotated.
+func (r *ReconcileWordpress) syncDatabase(ctx context.Context, wp *wordpress.Wordpress, syncers []syncer.Interface,
+	secret *corev1.Secret, workload interface{}) (bool, error) {
+	rotating, err := r.syncDatabaseCredentials(ctx, wp, syncers, secret, workload)
+	if err != nil {
+		return false, err
+	}
+
+	r.syncDatabaseHealth(ctx, wp)
+	pending := syncDatabaseStatus(wp, syncers)
+
+	return rotating || pending, nil
+}
+
+// syncDatabaseCredentials switches the site to the credentials requested by
+// spec.database.rotateCredentials once their user is ready, and revokes the
+// previous user once the web pods are rolled out with the new credentials
+// and none of the site's Jobs, which may have started with the previous ones,
+// is running. It returns true while a rotation is in progress.
+func (r *ReconcileWordpress) syncDatabaseCredentials(ctx context.Context, wp *wordpress.Wordpress, syncers []syncer.Interface,
+	secret *corev1.Secret, workload interface{}) (bool, error) {
+	if !wp.ProvisionsDatabase() {
+		return false, nil
+	}
+
+	if wp.Status.DatabaseCredentials == nil {
+		wp.Status.DatabaseCredentials = &wordpressv1alpha1.DatabaseCredentialsStatus{}
+	}
+
+	creds := wp.Status.DatabaseCredentials
+
+	if creds.PreviousGeneration != nil {
+		// the secret is updated the reconcile after the switch, which rolls the web pods
+		if string(secret.Data["DB_USER"]) != wp.DatabaseUser(creds.Generation) || !isWorkloadRolledOut(workload) {
+			return true, nil
+		}
+
+		running, err := r.hasRunningJobs(ctx, wp)
+		if err != nil || running {
+			return true, err
+		}
+
+		user := newUnstructured(sync.MysqlUserGVK, objectMeta(wp, wp.MysqlUserName(*creds.PreviousGeneration)))
+		if err := r.deleteOwned(ctx, wp, user); err != nil {
+			return true, err
+		}
+
+		creds.PreviousGeneration = nil
+	}
+
+	desired := wp.DesiredDatabaseCredentials()
+	if desired == creds.Generation {
+		return false, nil
+	}
+
+	if !isMysqlUserReady(syncers, wp.MysqlUserName(desired)) {
+		return true, nil
+	}
+
+	previous := creds.Generation
+	creds.Generation = desired
+	creds.PreviousGeneration = &previous
+
+	return true, nil
+}
+
+// hasRunningJobs returns true if any of the site's Jobs, including the ones of
+// its CronJobs and of its backups, is still running.
+func (r *ReconcileWordpress) hasRunningJobs(ctx context.Context, wp *wordpress.Wordpress) (bool, error) {
+	jobs := &batchv1.JobList{}
+
+	err := r.List(ctx, jobs, client.InNamespace(wp.Namespace), client.MatchingLabels(wp.Labels()))
+	if err != nil {
+		return false, err
+	}
+
+	for i := range jobs.Items {
+		if jobFinishedAt(&jobs.Items[i]) == nil {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func isMysqlUserReady(syncers []syncer.Interface, name string) bool {
+	for _, s := range syncers {
+		obj, ok := s.Object().(*unstructured.Unstructured)
+		if ok && obj.GetKind() == sync.MysqlUserGVK.Kind && obj.GetName() == name {
+			status, _ := sync.DatabaseStatus(obj)
+
+			return status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// syncDatabaseStatus reflects the readiness of the provisioned database or
+// the reachability of the external one in the Wordpress status. It returns
+// true while the database is not ready.
+func syncDatabaseStatus(wp *wordpress.Wordpress, syncers []syncer.Interface) bool {
+	var (
+		status  corev1.ConditionStatus
+		reason  string
+		message string
+	)
+
+	switch {
+	case wp.HasExternalDatabase():
+		status, reason, message = externalDatabaseStatus(wp, syncers)
+	case wp.ProvisionsDatabase():
+		status, reason, message = provisionedDatabaseStatus(wp, syncers)
+	default:
+		wp.RemoveCondition(wordpressv1alpha1.DatabaseReadyCondition)
+
+		return false
+	}
+
+	wp.SetCondition(wordpressv1alpha1.DatabaseReadyCondition, status, reason, message)
+
+	return status != corev1.ConditionTrue
+}
+
+// syncDatabaseHealth checks whether the site's database accepts connections
+// and records an event when it changes. The check is repeated periodically.
+func (r *ReconcileWordpress) syncDatabaseHealth(ctx context.Context, wp *wordpress.Wordpress) {
+	switch {
+	case wp.UsesCloudSQL(), !wp.HasExternalDatabase() && !wp.ProvisionsDatabase():
+		// the Cloud SQL Auth Proxy only listens within the site's pods
+		wp.RemoveCondition(wordpressv1alpha1.DatabaseHealthyCondition)
+
+		return
+	case wp.ProvisionsDatabase() && !wp.IsConditionTrue(wordpressv1alpha1.DatabaseReadyCondition):
+		// a database which is still being provisioned is not unhealthy
+		return
+	}
+
+	status, reason, message, eventType := corev1.ConditionTrue, wordpressv1alpha1.DatabaseHealthyReason,
+		"the database accepts connections", corev1.EventTypeNormal
+
+	dialer := net.Dialer{Timeout: databaseDialTimeout}
+
+	conn, err := dialer.DialContext(ctx, "tcp", wp.DatabaseAddress())
+	if err != nil {
+		status, reason, message, eventType = corev1.ConditionFalse, wordpressv1alpha1.DatabaseUnhealthyReason, err.Error(), corev1.EventTypeWarning
+	} else {
+		_ = conn.Close()
+	}
+
+	if cond := wp.GetCondition(wordpressv1alpha1.DatabaseHealthyCondition); cond == nil || cond.Status != status {
+		r.recorder.Event(wp.Unwrap(), eventType, reason, message)
+	}
+
+	wp.SetCondition(wordpressv1alpha1.DatabaseHealthyCondition, status, reason, message)
+}
+
+// provisionedDatabaseStatus checks that the provisioned database and the
+// user of the current credentials are ready.
+func provisionedDatabaseStatus(wp *wordpress.Wordpress, syncers []syncer.Interface) (corev1.ConditionStatus, string, string) {
+	objs := []*unstructured.Unstructured{}
+	currentUser := wp.MysqlUserName(wp.CurrentDatabaseCredentials())
+
+	for _, s := range syncers {
+		obj, ok := s.Object().(*unstructured.Unstructured)
+		if !ok || (obj.GetKind() == sync.MysqlUserGVK.Kind && obj.GetName() != currentUser) {
+			continue
+		}
+
+		objs = append(objs, obj)
+	}
+
+	status, message := sync.DatabaseStatus(objs...)
+	if status != corev1.ConditionTrue {
+		return status, wordpressv1alpha1.DatabasePendingReason, message
+	}
+
+	return status, wordpressv1alpha1.DatabaseProvisionedReason, message
+}
+
+// externalDatabaseStatus checks that the external database is healthy and,
+// if it gets bootstrapped, that the bootstrap job completed.
+func externalDatabaseStatus(wp *wordpress.Wordpress, syncers []syncer.Interface) (corev1.ConditionStatus, string, string) {
+	if cond := wp.GetCondition(wordpressv1alpha1.DatabaseHealthyCondition); cond != nil && cond.Status != corev1.ConditionTrue {
+		return corev1.ConditionFalse, wordpressv1alpha1.DatabaseUnreachableReason, cond.Message
+	}
+
+	for _, s := range syncers {
+		if job, ok := s.Object().(*batchv1.Job); ok && job.Status.Succeeded == 0 {
+			return corev1.ConditionFalse, wordpressv1alpha1.DatabasePendingReason, "the database bootstrap job has not completed yet"
+		}
+	}
+
+	return corev1.ConditionTrue, wordpressv1alpha1.DatabaseReachableReason, ""
+}
+
+// syncMaintenanceJobs runs the Jobs which keep the site in sync with its
+// spec and code and records the results of the scheduled ones.
+func (r *ReconcileWordpress) syncMaintenanceJobs(ctx context.Context, wp *wordpress.Wordpress, workload interface{}) error {
+	for _, fn := range []func(context.Context, *wordpress.Wordpress, interface{}) error{
+		withoutWorkload(r.syncSearchReplace),
+		withoutWorkload(r.syncDBUpgrade),
+		r.syncPlugins,
+		r.syncThemes,
+		r.syncOptions,
+		r.syncUsers,
+		r.syncSMTPTest,
+		withoutWorkload(r.syncStaticAssets),
+		r.syncCacheFlush,
+		r.syncCDNPurge,
+		withoutWorkload(r.syncCoreUpdates),
+		withoutWorkload(r.syncCleanup),
+		withoutWorkload(r.syncDiagnostics),
+		withoutWorkload(r.syncIntegrityCheck),
+		withoutWorkload(r.syncInventory),
+		withoutWorkload(r.syncBackups),
+		withoutWorkload(r.syncBackupVerification),
+		withoutWorkload(r.reportBackupMetrics),
+		withoutWorkload(r.syncDatabaseUsage),
+	} {
+		if err := fn(ctx, wp, workload); err != nil {
+			return err
+		}
 	}
 
-	cronJob := &batchv1.CronJob{}
+	return nil
+}
+
+// withoutWorkload adapts the syncs which don't depend on the web workload to
+// the signature of the maintenance Jobs' syncs.
+func withoutWorkload(fn func(context.Context, *wordpress.Wordpress) error) func(context.Context, *wordpress.Wordpress, interface{}) error {
+	return func(ctx context.Context, wp *wordpress.Wordpress, _ interface{}) error {
+		return fn(ctx, wp)
+	}
+}
+
+// syncDBUpgrade keeps track of the code version the database was upgraded
+// for and, if enabled, runs a wp core update-db Job when it changes.
+func (r *ReconcileWordpress) syncDBUpgrade(ctx context.Context, wp *wordpress.Wordpress) error {
+	version := wp.CodeVersion()
+
+	if !wp.Spec.UpgradeDatabaseOnCodeChange || wp.Status.DatabaseUpgradedFor == "" || wp.Status.DatabaseUpgradedFor == version {
+		wp.Status.DatabaseUpgradedFor = version
+
+		return nil
+	}
+
+	jobSyncer := sync.NewDBUpgradeJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		wp.Status.DatabaseUpgradedFor = version
+	}
+
+	// upgrade jobs are named after the code versions
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressDBUpgrade), job.Name)
+}
+
+// syncStaticAssets keeps track of the code version the static assets were
+// offloaded for and runs an upload Job when it changes. The web pods are
+// pointed to the offloaded assets once the Job completes.
+func (r *ReconcileWordpress) syncStaticAssets(ctx context.Context, wp *wordpress.Wordpress) error {
+	if !wp.OffloadsStaticAssets() {
+		wp.Status.StaticAssetsSyncedFor = ""
+
+		return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressStaticAssets), "")
+	}
+
+	version := wp.CodeVersion()
+
+	if wp.Status.StaticAssetsSyncedFor == version {
+		return nil
+	}
 
-	if err := r.Get(ctx, cronKey, cronJob); err != nil {
-		return ignoreNotFound(err)
+	jobSyncer := sync.NewStaticAssetsJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		wp.Status.StaticAssetsSyncedFor = version
 	}
 
-	if !isOwnedBy(cronJob.OwnerReferences, wp) {
+	// upload jobs are named after the code versions
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressStaticAssets), job.Name)
+}
+
+// syncCacheFlush keeps track of the code version the caches were flushed for
+// and, if enabled, runs a flush Job once the web pods are rolled out with a new one.
+func (r *ReconcileWordpress) syncCacheFlush(ctx context.Context, wp *wordpress.Wordpress, workload interface{}) error {
+	version := wp.CodeVersion()
+
+	if !wp.FlushesCacheOnRollout() || wp.Status.CacheFlushedFor == "" || wp.Status.CacheFlushedFor == version {
+		wp.Status.CacheFlushedFor = version
+
 		return nil
 	}
 
-	return r.Delete(ctx, cronJob)
+	if !isWorkloadRolledOut(workload) {
+		return nil
+	}
+
+	jobSyncer := sync.NewCacheFlushJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		wp.Status.CacheFlushedFor = version
+	}
+
+	// flush jobs are named after the code versions
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCacheFlush), job.Name)
+}
+
+// syncCDNPurge keeps track of the code version the CDN was purged for and,
+// if enabled, runs a purge Job once the web pods are rolled out with a new one.
+func (r *ReconcileWordpress) syncCDNPurge(ctx context.Context, wp *wordpress.Wordpress, workload interface{}) error {
+	if wp.Spec.CDN == nil {
+		wp.Status.CDNPurgedFor = ""
+
+		return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCDNPurge), "")
+	}
+
+	version := wp.CodeVersion()
+
+	if !wp.PurgesCDNOnRollout() || wp.Status.CDNPurgedFor == "" || wp.Status.CDNPurgedFor == version {
+		wp.Status.CDNPurgedFor = version
+
+		return nil
+	}
+
+	// the rollouts are watched, as the workload is owned by the Wordpress
+	if !isWorkloadRolledOut(workload) {
+		return nil
+	}
+
+	jobSyncer := sync.NewCDNPurgeJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		wp.Status.CDNPurgedFor = version
+	}
+
+	// purge jobs are named after the code versions
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCDNPurge), job.Name)
+}
+
+// syncSearchReplace keeps track of the home URL the site's content refers to
+// and, if enabled, runs a search-replace Job when it changes. The web pods
+// keep the previous home URL until the Job completes.
+func (r *ReconcileWordpress) syncSearchReplace(ctx context.Context, wp *wordpress.Wordpress) error {
+	homeURL := wp.HomeURL()
+
+	if !wp.SearchReplacePending() {
+		wp.Status.HomeURL = homeURL
+
+		return nil
+	}
+
+	jobSyncer := sync.NewSearchReplaceJobSyncer(wp, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+		return err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+	if job.Status.Succeeded > 0 {
+		wp.Status.HomeURL = homeURL
+	}
+
+	// search-replace jobs are named after the replaced URLs
+	return r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressSearchReplace), job.Name)
+}
+
+// routingSyncers returns the syncers for the resources routing traffic to the
+// site: ingresses, DNS records and service mesh routes.
+func (r *ReconcileWordpress) routingSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	syncers := []syncer.Interface{}
+
+	for _, fn := range []func(context.Context, *wordpress.Wordpress) ([]syncer.Interface, error){
+		r.ingressSyncers,
+		r.dnsSyncers,
+		r.meshSyncers,
+	} {
+		s, err := fn(ctx, wp)
+		if err != nil {
+			return nil, err
+		}
+
+		syncers = append(syncers, s...)
+	}
+
+	return syncers, nil
+}
+
+// ingressSyncers returns the syncers for the site's ingresses and removes the
+// ones which are no longer needed.
+func (r *ReconcileWordpress) ingressSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	syncers := []syncer.Interface{sync.NewIngressSyncer(wp, r.Client)}
+	stale := []client.Object{}
+
+	if len(wp.Spec.Aliases) > 0 {
+		syncers = append(syncers, sync.NewAliasesIngressSyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &netv1.Ingress{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressAliasesIngress))})
+	}
+
+	if wp.Spec.AdminAccess != nil {
+		syncers = append(syncers, sync.NewAdminIngressSyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &netv1.Ingress{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressAdminIngress))})
+	}
+
+	if wp.Spec.LoginProtection != nil {
+		syncers = append(syncers, sync.NewLoginIngressSyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &netv1.Ingress{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressLoginIngress))})
+	}
+
+	return syncers, r.deleteOwned(ctx, wp, stale...)
+}
+
+// dnsSyncers returns the syncers for the site's DNSEndpoint and removes it
+// when it's no longer needed.
+func (r *ReconcileWordpress) dnsSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.Spec.DNS != nil && wp.Spec.DNS.Endpoint != nil {
+		return []syncer.Interface{sync.NewDNSEndpointSyncer(wp, r.Client)}, nil
+	}
+
+	endpoint := newUnstructured(sync.DNSEndpointGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressDNSEndpoint)))
+
+	return nil, r.deleteOwned(ctx, wp, endpoint)
+}
+
+// probeSyncers returns the syncer for the Probe monitoring the site's domains
+// and removes it when they're no longer probed.
+func (r *ReconcileWordpress) probeSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.ProbesDomains() {
+		return []syncer.Interface{sync.NewProbeSyncer(wp, r.Client)}, nil
+	}
+
+	probe := newUnstructured(sync.ProbeGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressProbe)))
+
+	return nil, r.deleteOwned(ctx, wp, probe)
+}
+
+// meshSyncers returns the syncers for the site's Istio VirtualService and
+// DestinationRule and removes them when they're no longer needed.
+func (r *ReconcileWordpress) meshSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	mesh := wp.Spec.ServiceMesh
+	if mesh != nil && mesh.Provider == wordpressv1alpha1.ServiceMeshIstio && mesh.VirtualService != nil {
+		return []syncer.Interface{
+			sync.NewVirtualServiceSyncer(wp, r.Client),
+			sync.NewDestinationRuleSyncer(wp, r.Client),
+		}, nil
+	}
+
+	virtualService := newUnstructured(sync.VirtualServiceGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressVirtualService)))
+	destinationRule := newUnstructured(sync.DestinationRuleGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressDestinationRule)))
+
+	return nil, r.deleteOwned(ctx, wp, virtualService, destinationRule)
+}
+
+// secretsProviderSyncers returns the syncers for the site's ExternalSecret
+// and removes it when it's no longer needed.
+func (r *ReconcileWordpress) secretsProviderSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.UsesExternalSecret() {
+		return []syncer.Interface{sync.NewExternalSecretSyncer(wp, r.Client)}, nil
+	}
+
+	externalSecret := newUnstructured(sync.ExternalSecretGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressExternalSecret)))
+
+	return nil, r.deleteOwned(ctx, wp, externalSecret)
+}
+
+// serviceAccountSyncers returns the syncers for the site's ServiceAccount
+// and removes it when it's no longer needed.
+func (r *ReconcileWordpress) serviceAccountSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.CreatesServiceAccount() {
+		return []syncer.Interface{sync.NewServiceAccountSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &corev1.ServiceAccount{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressServiceAccount))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// cacheSyncers returns the syncers for the site's shared memcached, page
+// cache and cache warmup and removes them when they're no longer needed.
+func (r *ReconcileWordpress) cacheSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	syncers := []syncer.Interface{}
+	stale := []client.Object{}
+
+	if wp.SharesMemcached() {
+		syncers = append(syncers, sync.NewMemcachedDeploymentSyncer(wp, r.Client), sync.NewMemcachedServiceSyncer(wp, r.Client))
+	} else {
+		stale = append(stale,
+			&appsv1.Deployment{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressMemcachedDeployment))},
+			&corev1.Service{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressMemcachedService))},
+		)
+	}
+
+	if wp.UsesPageCache() {
+		syncers = append(syncers, sync.NewPageCacheDeploymentSyncer(wp, r.Client), sync.NewPageCacheServiceSyncer(wp, r.Client))
+	} else {
+		stale = append(stale,
+			&appsv1.Deployment{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressPageCacheDeployment))},
+			&corev1.Service{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressPageCacheService))},
+		)
+	}
+
+	if wp.WarmsUpCacheOnSchedule() {
+		syncers = append(syncers, sync.NewCacheWarmupCronJobSyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressCacheWarmup))})
+	}
+
+	return syncers, r.deleteOwned(ctx, wp, stale...)
+}
+
+// mediaSyncers returns the syncers for the CronJob optimizing the site's
+// media images and removes it when it's no longer needed.
+func (r *ReconcileWordpress) mediaSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.OptimizesImages() {
+		return []syncer.Interface{sync.NewImageOptimizationCronJobSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressImageOptimization))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// cronSyncers returns the syncers for the CronJob running the site's
+// scheduled events and removes it when it's no longer needed.
+func (r *ReconcileWordpress) cronSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.RunsCronJob() {
+		return []syncer.Interface{sync.NewWPCronSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressCron))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// cleanupSyncers returns the syncers for the CronJob cleaning up the site's
+// content and removes it when it's no longer needed.
+func (r *ReconcileWordpress) cleanupSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.SchedulesCleanup() {
+		return []syncer.Interface{sync.NewCleanupCronJobSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressCleanup))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// diagnosticsSyncers returns the syncers for the CronJob checking the site's
+// health and removes it when it's no longer needed.
+func (r *ReconcileWordpress) diagnosticsSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.SchedulesDiagnostics() {
+		return []syncer.Interface{sync.NewDiagnosticsCronJobSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressDiagnostics))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// integrityCheckSyncers returns the syncers for the CronJob verifying the core
+// and plugin files and removes it when it's no longer needed.
+func (r *ReconcileWordpress) integrityCheckSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.SchedulesIntegrityCheck() {
+		return []syncer.Interface{sync.NewIntegrityCheckCronJobSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressIntegrityCheck))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// inventorySyncers returns the syncers for the CronJob collecting the core,
+// plugin and theme versions and removes it when it's no longer needed.
+func (r *ReconcileWordpress) inventorySyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.SchedulesInventory() {
+		return []syncer.Interface{sync.NewInventoryCronJobSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressInventory))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// backupsSyncers returns the syncers for the CronJobs scheduling the site's
+// backups and verifying the last succeeded one, and removes them when they're
+// no longer needed.
+func (r *ReconcileWordpress) backupsSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	syncers := []syncer.Interface{}
+	stale := []client.Object{}
+
+	if wp.SchedulesBackups() {
+		syncers = append(syncers, sync.NewBackupsCronJobSyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressBackups))})
+	}
+
+	backup, err := r.verifiableBackup(ctx, wp)
+	if err != nil {
+		return nil, err
+	}
+
+	if backup != nil && wp.CanVerifyBackup(backup) == nil {
+		syncers = append(syncers, sync.NewBackupVerificationCronJobSyncer(wp, backup, r.Client))
+	} else {
+		stale = append(stale, &batchv1.CronJob{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressBackupVerification))})
+	}
+
+	return syncers, r.deleteOwned(ctx, wp, stale...)
+}
+
+// webServerConfigSyncers returns the syncers for the web server config
+// rendered by the operator and removes it when it's no longer needed.
+func (r *ReconcileWordpress) webServerConfigSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.ConfiguresCompression() {
+		return []syncer.Interface{sync.NewWebServerConfigMapSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &corev1.ConfigMap{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressWebServerConfig))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// multisiteSyncers returns the syncer for the sunrise drop-in mapping the
+// domains to the network's sub-sites, or removes it if no domains are mapped.
+func (r *ReconcileWordpress) multisiteSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	if wp.MapsDomains() {
+		return []syncer.Interface{sync.NewSunriseConfigMapSyncer(wp, r.Client)}, nil
+	}
+
+	stale := &corev1.ConfigMap{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressSunrise))}
+
+	return nil, r.deleteOwned(ctx, wp, stale)
+}
+
+// webServerConfig returns the site's web server config map or nil if it's not
+// configured or doesn't exist yet. Its creation triggers a reconcile, as config maps are watched.
+func (r *ReconcileWordpress) webServerConfig(ctx context.Context, wp *wordpress.Wordpress) (*corev1.ConfigMap, error) {
+	if wp.Spec.WebServerConfig == nil {
+		return nil, nil
+	}
+
+	config := &corev1.ConfigMap{}
+	key := client.ObjectKey{Name: wp.Spec.WebServerConfig.ConfigMapName, Namespace: wp.Namespace}
+
+	if err := r.Get(ctx, key, config); errors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// referencesVersion returns the version of the Secrets and ConfigMaps
+// referenced by the web pods' environment, or an empty string if the pods are
+// not rolled when they change. Their changes trigger a reconcile, as they're
+// watched.
+func (r *ReconcileWordpress) referencesVersion(ctx context.Context, wp *wordpress.Wordpress) (string, error) {
+	if !wp.RollsOnConfigChange() {
+		return "", nil
+	}
+
+	secrets := []corev1.Secret{}
+
+	for _, name := range wp.ReferencedSecrets() {
+		secret := corev1.Secret{}
+		key := client.ObjectKey{Name: name, Namespace: wp.Namespace}
+
+		if err := r.Get(ctx, key, &secret); ignoreNotFound(err) != nil {
+			return "", err
+		}
+
+		secret.Name = name
+		secrets = append(secrets, secret)
+	}
+
+	configMaps := []corev1.ConfigMap{}
+
+	for _, name := range wp.ReferencedConfigMaps() {
+		config := corev1.ConfigMap{}
+		key := client.ObjectKey{Name: name, Namespace: wp.Namespace}
+
+		if err := r.Get(ctx, key, &config); ignoreNotFound(err) != nil {
+			return "", err
+		}
+
+		config.Name = name
+		configMaps = append(configMaps, config)
+	}
+
+	return wordpress.ReferencesVersion(secrets, configMaps), nil
+}
+
+// workloadSyncers returns the syncers for the workload running the web pods,
+// the first one being the workload itself, and removes the one of the other kind.
+func (r *ReconcileWordpress) workloadSyncers(ctx context.Context, wp *wordpress.Wordpress, secret *corev1.Secret,
+	config *corev1.ConfigMap, references string) ([]syncer.Interface, error) {
+	if wp.IsStatefulSet() {
+		// the persistent volume claims are created from the statefulset's templates
+		syncers := []syncer.Interface{
+			sync.NewStatefulSetSyncer(wp, secret, config, references, r.Client),
+			sync.NewHeadlessServiceSyncer(wp, r.Client),
+		}
+
+		return syncers, r.deleteOwned(ctx, wp, &appsv1.Deployment{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressDeployment))})
+	}
+
+	syncers := []syncer.Interface{sync.NewDeploymentSyncer(wp, secret, config, references, r.Client)}
+	stale := []client.Object{&appsv1.StatefulSet{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressStatefulSet))}}
+
+	if wp.HasHeadlessService() {
+		syncers = append(syncers, sync.NewHeadlessServiceSyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &corev1.Service{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressHeadlessService))})
+	}
+
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil {
+		syncers = append(syncers, sync.NewCodePVCSyncer(wp, r.Client))
+	}
+
+	if wp.Spec.MediaVolumeSpec != nil && wp.Spec.MediaVolumeSpec.PersistentVolumeClaim != nil {
+		syncers = append(syncers, sync.NewMediaPVCSyncer(wp, r.Client))
+	}
+
+	return syncers, r.deleteOwned(ctx, wp, stale...)
+}
+
+// webReplicas returns the number of pods of the workload running the web pods.
+func webReplicas(workload interface{}) int32 {
+	switch obj := workload.(type) {
+	case *appsv1.Deployment:
+		return obj.Status.Replicas
+	case *appsv1.StatefulSet:
+		return obj.Status.Replicas
+	}
+
+	return 0
+}
+
+// syncBootstrapped records that the site is bootstrapped once the web pods are
+// rolled out, which means their bootstrap init containers completed.
+func syncBootstrapped(wp *wordpress.Wordpress, workload interface{}) {
+	if wp.Spec.WordpressBootstrapSpec == nil || wp.Status.Bootstrapped {
+		return
+	}
+
+	// a workload scaled to zero is rolled out without running any pod
+	wp.Status.Bootstrapped = webReplicas(workload) > 0 && isWorkloadRolledOut(workload)
+}
+
+// syncRestartDeferred sets the RestartDeferred condition while the web pods'
+// restart for changed Secrets and ConfigMaps waits for the maintenance window
+// and returns true in the meantime.
+func syncRestartDeferred(wp *wordpress.Wordpress, workload interface{}, config *corev1.ConfigMap, references string) bool {
+	var annotations map[string]string
+
+	switch obj := workload.(type) {
+	case *appsv1.Deployment:
+		annotations = obj.Spec.Template.Annotations
+	case *appsv1.StatefulSet:
+		annotations = obj.Spec.Template.Annotations
+	}
+
+	for k, v := range sync.DeferrableRestartAnnotations(config, references) {
+		if annotations[k] != v {
+			wp.SetCondition(wordpressv1alpha1.RestartDeferredCondition, corev1.ConditionTrue,
+				wordpressv1alpha1.MaintenanceWindowClosedReason,
+				"the web pods are restarted for the changed secrets or config maps once the maintenance window opens")
+
+			return true
+		}
+	}
+
+	wp.RemoveCondition(wordpressv1alpha1.RestartDeferredCondition)
+
+	return false
+}
+
+// scalingSyncers returns the syncers for the objects controlling the number
+// of web pods and removes the ones which are no longer needed.
+func (r *ReconcileWordpress) scalingSyncers(ctx context.Context, wp *wordpress.Wordpress) ([]syncer.Interface, error) {
+	syncers := []syncer.Interface{}
+	stale := []client.Object{}
+
+	// a disruption budget only makes sense when there is more than one replica
+	if wp.MinReplicas() > 1 {
+		syncers = append(syncers, sync.NewPDBSyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &policyv1.PodDisruptionBudget{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressPDB))})
+	}
+
+	if wp.Spec.Autoscaling != nil && wp.Spec.ScaleToZero == nil {
+		syncers = append(syncers, sync.NewHPASyncer(wp, r.Client))
+	} else {
+		stale = append(stale, &autoscalingv2beta2.HorizontalPodAutoscaler{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressHPA))})
+	}
+
+	scaledObject := newUnstructured(sync.ScaledObjectGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressScaledObject)))
+	httpScaledObject := newUnstructured(sync.HTTPScaledObjectGVK, objectMeta(wp, wp.ComponentName(wordpress.WordpressHTTPScaledObject)))
+	interceptorService := &corev1.Service{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressInterceptorService))}
+
+	switch {
+	case wp.Spec.ScaleToZero != nil && wp.Spec.ScaleToZero.HTTP != nil:
+		syncers = append(syncers, sync.NewHTTPScaledObjectSyncer(wp, r.Client), sync.NewInterceptorServiceSyncer(wp, r.Client))
+		stale = append(stale, scaledObject)
+	case wp.Spec.ScaleToZero != nil:
+		syncers = append(syncers, sync.NewScaledObjectSyncer(wp, r.Client))
+		stale = append(stale, httpScaledObject, interceptorService)
+	default:
+		stale = append(stale, scaledObject, httpScaledObject, interceptorService)
+	}
+
+	return syncers, r.deleteOwned(ctx, wp, stale...)
+}
+
+// syncCanary reconciles the canary release of the site. Traffic is routed to
+// the canary pods only after they are available and the smoke test passed.
+func (r *ReconcileWordpress) syncCanary(ctx context.Context, wp *wordpress.Wordpress, secret *corev1.Secret, config *corev1.ConfigMap,
+	references string) error {
+	if wp.Spec.Canary == nil || wp.IsStatefulSet() {
+		wp.Status.Canary = nil
+
+		return r.cleanupCanary(ctx, wp)
+	}
+
+	deploySyncer := sync.NewCanaryDeploymentSyncer(wp, secret, config, references, r.Client)
+	if err := r.sync(ctx, []syncer.Interface{deploySyncer, sync.NewCanaryServiceSyncer(wp, r.Client)}); err != nil {
+		return err
+	}
+
+	deploy := deploySyncer.Object().(*appsv1.Deployment)
+	status := &wordpressv1alpha1.CanaryStatus{
+		Revision:          wp.CanaryRevision(),
+		AvailableReplicas: deploy.Status.AvailableReplicas,
+	}
+
+	ready := deploy.Status.AvailableReplicas > 0 && isWorkloadRolledOut(deploy)
+
+	current := ""
+	if wp.Spec.Canary.SmokeTest != nil {
+		current = wp.JobName(wordpress.WordpressCanarySmokeTest)
+	}
+
+	// smoke test jobs are named after the canary revision
+	if err := r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCanarySmokeTest), current); err != nil {
+		return err
+	}
+
+	if ready && wp.Spec.Canary.SmokeTest != nil {
+		jobSyncer := sync.NewCanarySmokeTestJobSyncer(wp, r.Client)
+		if err := r.sync(ctx, []syncer.Interface{jobSyncer}); err != nil {
+			return err
+		}
+
+		status.SmokeTestSucceeded = jobSyncer.Object().(*batchv1.Job).Status.Succeeded > 0
+		ready = status.SmokeTestSucceeded
+	}
+
+	if ready {
+		status.Weight = wp.Spec.Canary.Weight
+	}
+
+	wp.Status.Canary = status
+
+	return r.sync(ctx, []syncer.Interface{sync.NewCanaryIngressSyncer(wp, status.Weight, r.Client)})
+}
+
+func (r *ReconcileWordpress) cleanupCanary(ctx context.Context, wp *wordpress.Wordpress) error {
+	stale := []client.Object{
+		&netv1.Ingress{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressCanaryIngress))},
+		&corev1.Service{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressCanaryService))},
+		&appsv1.Deployment{ObjectMeta: objectMeta(wp, wp.ComponentName(wordpress.WordpressCanaryDeployment))},
+	}
+
+	if err := r.cleanupJobs(ctx, wp, wp.ComponentLabels(wordpress.WordpressCanarySmokeTest), ""); err != nil {
+		return err
+	}
+
+	return r.deleteOwned(ctx, wp, stale...)
+}
+
+// cleanupJobs deletes the jobs matching the given labels, except the current one.
+func (r *ReconcileWordpress) cleanupJobs(ctx context.Context, wp *wordpress.Wordpress, jobLabels labels.Set, current string) error {
+	jobs := &batchv1.JobList{}
+
+	err := r.List(ctx, jobs,
+		client.InNamespace(wp.Namespace),
+		client.MatchingLabels(jobLabels),
+	)
+	if err != nil {
+		return err
+	}
+
+	for i := range jobs.Items {
+		if jobs.Items[i].Name == current || !isOwnedBy(jobs.Items[i].OwnerReferences, wp) {
+			continue
+		}
+
+		err = r.Delete(ctx, &jobs.Items[i], client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// finishedJobs returns the Jobs with the given labels, e.g. spawned by a
+// CronJob, which finished after the given time, in the order they finished.
+func (r *ReconcileWordpress) finishedJobs(ctx context.Context, wp *wordpress.Wordpress, jobLabels labels.Set, since *metav1.Time) ([]*batchv1.Job, error) {
+	jobs := &batchv1.JobList{}
+
+	err := r.List(ctx, jobs,
+		client.InNamespace(wp.Namespace),
+		client.MatchingLabels(jobLabels),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	finished := []*batchv1.Job{}
+
+	for i := range jobs.Items {
+		finishedAt := jobFinishedAt(&jobs.Items[i])
+		if finishedAt != nil && (since == nil || finishedAt.After(since.Time)) {
+			finished = append(finished, &jobs.Items[i])
+		}
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return jobFinishedAt(finished[i]).Before(jobFinishedAt(finished[j]))
+	})
+
+	return finished, nil
+}
+
+// jobFinishedAt returns the time the Job succeeded or failed at, or nil if
+// it's still running.
+func jobFinishedAt(job *batchv1.Job) *metav1.Time {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime
+	}
+
+	for i := range job.Status.Conditions {
+		cond := &job.Status.Conditions[i]
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return &cond.LastTransitionTime
+		}
+	}
+
+	return nil
+}
+
+// isWorkloadRolledOut returns true if all the web pods run the workload's
+// current pod template.
+func isWorkloadRolledOut(workload interface{}) bool {
+	switch obj := workload.(type) {
+	case *appsv1.Deployment:
+		replicas := int32(1)
+		if obj.Spec.Replicas != nil {
+			replicas = *obj.Spec.Replicas
+		}
+
+		return obj.Status.ObservedGeneration >= obj.Generation &&
+			obj.Status.Replicas == replicas &&
+			obj.Status.UpdatedReplicas == replicas &&
+			obj.Status.AvailableReplicas == replicas
+	case *appsv1.StatefulSet:
+		return obj.Status.ObservedGeneration >= obj.Generation &&
+			obj.Status.CurrentRevision == obj.Status.UpdateRevision
+	}
+
+	return false
+}
+
+// deleteOwned deletes the given objects if they exist and they are owned by the Wordpress.
+func (r *ReconcileWordpress) deleteOwned(ctx context.Context, wp *wordpress.Wordpress, objs ...client.Object) error {
+	for _, obj := range objs {
+		err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		// meta.IsNoMatchError means that the resource kind is not installed in the cluster
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !isOwnedBy(obj.GetOwnerReferences(), wp) {
+			continue
+		}
+
+		if err = r.Delete(ctx, obj); ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// usedConfigMaps returns the names of the config maps used by the site as
+// web server config or referenced by its web pods' environment.
+func usedConfigMaps(wp *wordpress.Wordpress) []string {
+	names := []string{}
+
+	if wp.Spec.WebServerConfig != nil && wp.Spec.WebServerConfig.ConfigMapName != "" {
+		names = append(names, wp.Spec.WebServerConfig.ConfigMapName)
+	}
+
+	if wp.RollsOnConfigChange() {
+		names = append(names, wp.ReferencedConfigMaps()...)
+	}
+
+	return names
+}
+
+// usedSecrets returns the names of the secrets used by the site as
+// TLSSecretRef, as the images' verification key or referenced by its web
+// pods' environment.
+func usedSecrets(wp *wordpress.Wordpress) []string {
+	names := []string{}
+
+	if wp.Spec.TLSSecretRef != "" {
+		names = append(names, string(wp.Spec.TLSSecretRef))
+	}
+
+	if spec := wp.Spec.ImageVerification; spec != nil && spec.PublicKeySecretRef != nil {
+		names = append(names, spec.PublicKeySecretRef.Name)
+	}
+
+	if wp.RollsOnConfigChange() {
+		names = append(names, wp.ReferencedSecrets()...)
+	}
+
+	return names
+}
+
+// indexReferences indexes the sites under field by the names returned by used.
+func indexReferences(mgr manager.Manager, field string, used func(*wordpress.Wordpress) []string) error {
+	return mgr.GetFieldIndexer().IndexField(context.TODO(), &wordpressv1alpha1.Wordpress{}, field, func(obj client.Object) []string {
+		// the object is the cached one, so it's defaulted on a copy
+		wp := wordpress.New(obj.(*wordpressv1alpha1.Wordpress).DeepCopy())
+		wp.SetDefaults()
+
+		return used(wp)
+	})
+}
+
+// wordpressesUsing returns the requests for reconciling the sites in the
+// object's namespace which are indexed under field by the object's name.
+func wordpressesUsing(c client.Client, obj client.Object, field string) []reconcile.Request {
+	wps := &wordpressv1alpha1.WordpressList{}
+
+	err := c.List(context.TODO(), wps, client.InNamespace(obj.GetNamespace()), client.MatchingFields{field: obj.GetName()})
+	if err != nil {
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+
+	for i := range wps.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: wps.Items[i].Name, Namespace: wps.Items[i].Namespace},
+		})
+	}
+
+	return requests
+}
+
+func objectMeta(wp *wordpress.Wordpress, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: wp.Namespace,
+	}
+}
+
+func newUnstructured(gvk schema.GroupVersionKind, objMeta metav1.ObjectMeta) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(objMeta.Name)
+	obj.SetNamespace(objMeta.Namespace)
+
+	return obj
 }
 
 func isOwnedBy(refs []metav1.OwnerReference, owner *wordpress.Wordpress) bool {
@@ -27,8 +27,11 @@ import (
 	logf "github.com/presslabs/controller-util/log"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	"k8s.io/klog/v2/klogr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	"sigs.k8s.io/controller-runtime/pkg/envtest/printer"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -89,3 +92,16 @@ func StartTestManager(mgr manager.Manager) context.CancelFunc {
 	}()
 	return stop
 }
+
+// newTestReconciler returns a ReconcileWordpress backed by a fake client
+// holding objs, for testing the reconciler's steps on their own.
+func newTestReconciler(objs ...client.Object) *ReconcileWordpress {
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs...).Build()
+
+	return &ReconcileWordpress{
+		Client:    c,
+		apiReader: c,
+		scheme:    scheme.Scheme,
+		recorder:  record.NewFakeRecorder(100),
+	}
+}
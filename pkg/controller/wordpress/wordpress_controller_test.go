@@ -29,6 +29,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	netv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -83,6 +84,7 @@ var _ = Describe("Wordpress controller", func() {
 			Entry("reconciles the ingress", "%s", &netv1.Ingress{}),
 			Entry("reconciles the code pvc", "%s-code", &corev1.PersistentVolumeClaim{}),
 			Entry("reconciles the media pvc", "%s-media", &corev1.PersistentVolumeClaim{}),
+			Entry("reconciles the pod disruption budget", "%s", &policyv1.PodDisruptionBudget{}),
 		}
 
 		BeforeEach(func() {
@@ -95,9 +97,12 @@ var _ = Describe("Wordpress controller", func() {
 			}
 			expectedRequest = reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
 
+			replicas := int32(2)
+
 			wp = &wordpressv1alpha1.Wordpress{
 				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
 				Spec: wordpressv1alpha1.WordpressSpec{
+					Replicas:        &replicas,
 					Routes:          routes,
 					CodeVolumeSpec:  &wordpressv1alpha1.CodeVolumeSpec{},
 					MediaVolumeSpec: &wordpressv1alpha1.MediaVolumeSpec{},
@@ -182,5 +187,47 @@ var _ = Describe("Wordpress controller", func() {
 			Expect(c.Get(context.TODO(), key, deploy)).To(Succeed())
 			Expect(deploy.Spec.Strategy.Type).To(Equal(appsv1.RecreateDeploymentStrategyType))
 		})
+
+		It("indexes the site by the secrets it uses", func() {
+			tls := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: wp.Name + "-tls", Namespace: wp.Namespace}}
+			other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: wp.Name + "-other", Namespace: wp.Namespace}}
+
+			wp.Spec.TLSSecretRef = wordpressv1alpha1.SecretRef(tls.Name)
+			Expect(c.Update(context.TODO(), wp)).To(Succeed())
+			Eventually(requests, timeout).Should(Receive(Equal(expectedRequest)))
+
+			Eventually(func() []reconcile.Request {
+				return wordpressesUsing(c, tls, secretRefsField)
+			}, timeout).Should(ConsistOf(expectedRequest))
+			Expect(wordpressesUsing(c, other, secretRefsField)).To(BeEmpty())
+			Expect(wordpressesUsing(c, tls, configMapRefsField)).To(BeEmpty())
+		})
+
+		It("sets the Ready condition from the site's conditions", func() {
+			key := types.NamespacedName{
+				Name:      wp.Name,
+				Namespace: wp.Namespace,
+			}
+
+			conditionStatus := func(condType wordpressv1alpha1.WordpressConditionType) corev1.ConditionStatus {
+				Expect(c.Get(context.TODO(), key, wp)).To(Succeed())
+
+				for _, cond := range wp.Status.Conditions {
+					if cond.Type == condType {
+						return cond.Status
+					}
+				}
+
+				return ""
+			}
+
+			// the deployment's pods are never rolled out, nor the pvcs bound, without their controllers
+			Eventually(func() corev1.ConditionStatus {
+				return conditionStatus(wordpressv1alpha1.ReadyCondition)
+			}, timeout).Should(Equal(corev1.ConditionFalse))
+			Expect(conditionStatus(wordpressv1alpha1.DeploymentReadyCondition)).To(Equal(corev1.ConditionFalse))
+			Expect(conditionStatus(wordpressv1alpha1.MediaReadyCondition)).To(Equal(corev1.ConditionFalse))
+			Expect(wp.Status.ObservedGeneration).To(Equal(wp.Generation))
+		})
 	})
 })
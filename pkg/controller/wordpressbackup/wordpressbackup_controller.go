@@ -0,0 +1,466 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpressbackup
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/appscode/mergo"
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	controllerName = "wordpress-backup-controller"
+
+	pendingRequeueInterval = 30 * time.Second
+)
+
+var controllerLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+}
+
+var jobSyncerNames = map[string]string{
+	wordpress.BackupDatabaseArtifact: "WordpressBackupDatabaseJob",
+	wordpress.BackupMediaArtifact:    "WordpressBackupMediaJob",
+}
+
+// Add creates a new WordpressBackup Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWordpressBackup{
+		Client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor(controllerName),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to WordpressBackup
+	err = c.Watch(&source.Kind{Type: &wordpressv1alpha1.WordpressBackup{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wordpressv1alpha1.WordpressBackup{},
+	})
+}
+
+var _ reconcile.Reconciler = &ReconcileWordpressBackup{}
+
+// ReconcileWordpressBackup reconciles a WordpressBackup object.
+type ReconcileWordpressBackup struct {
+	client.Client
+	// apiReader reads the objects which are not cached
+	apiReader client.Reader
+	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
+}
+
+// Reconcile runs the Jobs uploading the site's database dump and media files
+// and reports the uploaded artifacts in the WordpressBackup's status. The
+// backups which set deleteArtifacts delete them, in a Job, before they're gone.
+//
+// +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpressbackups;wordpressbackups/status,verbs=get;list;watch;create;update;patch;delete
+func (r *ReconcileWordpressBackup) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	backup := &wordpressv1alpha1.WordpressBackup{}
+
+	err := r.Get(ctx, request.NamespacedName, backup)
+	if err != nil {
+		return reconcile.Result{}, ignoreNotFound(err)
+	}
+
+	if !backup.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, r.purge(ctx, backup)
+	}
+
+	if backup.Spec.DeleteArtifacts && !controllerutil.ContainsFinalizer(backup, wordpressv1alpha1.BackupArtifactsFinalizer) {
+		controllerutil.AddFinalizer(backup, wordpressv1alpha1.BackupArtifactsFinalizer)
+
+		if err = r.Update(ctx, backup); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if isFinished(backup) {
+		return reconcile.Result{}, nil
+	}
+
+	oldStatus := backup.Status.DeepCopy()
+
+	if _, err = wordpress.BackupEncryptionMethod(backup); err != nil {
+		r.finish(backup, nil, wordpressv1alpha1.BackupFailed, err.Error())
+
+		return reconcile.Result{}, r.updateStatus(ctx, backup, oldStatus)
+	}
+
+	wp := wordpress.New(&wordpressv1alpha1.Wordpress{})
+
+	key := client.ObjectKey{Name: backup.Spec.SiteRef.Name, Namespace: backup.Namespace}
+	if err = r.Get(ctx, key, wp.Unwrap()); errors.IsNotFound(err) {
+		backup.Status.Phase = wordpressv1alpha1.BackupPending
+		backup.Status.Message = fmt.Sprintf("the %s Wordpress doesn't exist", key.Name)
+
+		// the Wordpress is not watched, so check back until it's created
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, r.updateStatus(ctx, backup, oldStatus)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.scheme.Default(wp.Unwrap())
+	wp.SetDefaults()
+
+	jobs := []*batchv1.Job{}
+	notes := []string{}
+
+	if isEnabled(backup.Spec.IncludeDatabase) {
+		template := wp.BackupDatabasePodTemplateSpec(backup)
+
+		job, err := r.syncJob(ctx, backup, wp, wordpress.BackupDatabaseArtifact, template)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	if isEnabled(backup.Spec.IncludeMedia) {
+		if wp.HasPersistentMedia() {
+			template := wp.BackupMediaPodTemplateSpec(backup)
+
+			job, err := r.syncJob(ctx, backup, wp, wordpress.BackupMediaArtifact, template)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+
+			jobs = append(jobs, job)
+		} else {
+			notes = append(notes, "the media is not stored in a shared volume or bucket, so it was not backed up")
+		}
+	}
+
+	if err = r.syncStatus(ctx, backup, wp, jobs, notes); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.updateStatus(ctx, backup, oldStatus)
+}
+
+// syncJob creates the Job uploading one of the backup's artifacts.
+func (r *ReconcileWordpressBackup) syncJob(ctx context.Context, backup *wordpressv1alpha1.WordpressBackup,
+	wp *wordpress.Wordpress, artifact string, template corev1.PodTemplateSpec) (*batchv1.Job, error) {
+	jobSyncer := newJobSyncer(backup, wp, r.Client, artifact, template)
+	if err := syncer.Sync(ctx, jobSyncer, r.recorder); err != nil {
+		return nil, err
+	}
+
+	return jobSyncer.Object().(*batchv1.Job), nil
+}
+
+// syncStatus sets the backup's phase from its Jobs and, once they all
+// succeeded, its artifacts from the upload containers' termination messages.
+func (r *ReconcileWordpressBackup) syncStatus(ctx context.Context, backup *wordpressv1alpha1.WordpressBackup,
+	wp *wordpress.Wordpress, jobs []*batchv1.Job, notes []string) error {
+	backup.Status.Message = strings.Join(notes, "; ")
+
+	if len(jobs) == 0 {
+		r.finish(backup, wp, wordpressv1alpha1.BackupFailed, "there is nothing to back up")
+
+		return nil
+	}
+
+	if backup.Status.StartTime == nil {
+		backup.Status.StartTime = &jobs[0].CreationTimestamp
+	}
+
+	succeeded := 0
+
+	for _, job := range jobs {
+		if isJobFailed(job) {
+			r.finish(backup, wp, wordpressv1alpha1.BackupFailed, fmt.Sprintf("the backup job %s failed", job.Name))
+
+			return nil
+		}
+
+		if job.Status.Succeeded > 0 {
+			succeeded++
+		}
+	}
+
+	if succeeded < len(jobs) {
+		backup.Status.Phase = wordpressv1alpha1.BackupRunning
+
+		return nil
+	}
+
+	artifacts := []wordpressv1alpha1.BackupArtifact{}
+
+	for _, job := range jobs {
+		report, err := r.uploadReport(ctx, job)
+		if err != nil {
+			return err
+		}
+
+		artifact, err := wordpress.ParseBackupArtifact(backup, report)
+		if err != nil {
+			r.finish(backup, wp, wordpressv1alpha1.BackupFailed, fmt.Sprintf("the backup job %s: %s", job.Name, err))
+
+			return nil
+		}
+
+		artifacts = append(artifacts, *artifact)
+	}
+
+	backup.Status.Artifacts = artifacts
+
+	r.finish(backup, wp, wordpressv1alpha1.BackupSucceeded, "")
+
+	return nil
+}
+
+// finish marks the backup as finished and records an event, on the backup
+// and, unless it's nil, on its site.
+func (r *ReconcileWordpressBackup) finish(backup *wordpressv1alpha1.WordpressBackup, wp *wordpress.Wordpress,
+	phase wordpressv1alpha1.WordpressBackupPhase, message string) {
+	now := metav1.Now()
+	backup.Status.Phase = phase
+	backup.Status.CompletionTime = &now
+
+	if message != "" {
+		backup.Status.Message = strings.TrimPrefix(backup.Status.Message+"; "+message, "; ")
+	}
+
+	if phase == wordpressv1alpha1.BackupFailed {
+		r.recorder.Event(backup, corev1.EventTypeWarning, wordpressv1alpha1.BackupFailedReason, message)
+
+		if wp != nil {
+			r.recorder.Event(wp.Unwrap(), corev1.EventTypeWarning, wordpressv1alpha1.BackupFailedReason,
+				fmt.Sprintf("the %s backup failed: %s", backup.Name, message))
+		}
+
+		return
+	}
+
+	names := []string{}
+	for _, artifact := range backup.Status.Artifacts {
+		names = append(names, artifact.Location)
+	}
+
+	r.recorder.Event(backup, corev1.EventTypeNormal, wordpressv1alpha1.BackupSucceededReason,
+		fmt.Sprintf("the backup uploaded %s", strings.Join(names, ", ")))
+
+	if wp != nil {
+		r.recorder.Event(wp.Unwrap(), corev1.EventTypeNormal, wordpressv1alpha1.BackupSucceededReason,
+			fmt.Sprintf("the %s backup uploaded %s", backup.Name, strings.Join(names, ", ")))
+	}
+}
+
+// uploadReport returns the termination message of the upload container of the
+// Job's last succeeded pod.
+func (r *ReconcileWordpressBackup) uploadReport(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+
+	// the pods are not cached
+	err := r.apiReader.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"controller-uid": string(job.UID)},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var out *corev1.ContainerStateTerminated
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name != wordpress.BackupUploadContainerName || terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+
+			if out == nil || out.FinishedAt.Before(&terminated.FinishedAt) {
+				out = terminated
+			}
+		}
+	}
+
+	if out == nil {
+		return "", nil
+	}
+
+	return out.Message, nil
+}
+
+// purge deletes the backup's artifacts, if it uploaded any, and then lets the
+// backup go. Artifacts which fail to be deleted are left behind.
+func (r *ReconcileWordpressBackup) purge(ctx context.Context, backup *wordpressv1alpha1.WordpressBackup) error {
+	if !controllerutil.ContainsFinalizer(backup, wordpressv1alpha1.BackupArtifactsFinalizer) {
+		return nil
+	}
+
+	if backup.Spec.DeleteArtifacts && backup.Status.StartTime != nil {
+		jobSyncer := newPurgeJobSyncer(backup, r.Client)
+		if err := syncer.Sync(ctx, jobSyncer, r.recorder); err != nil {
+			return err
+		}
+
+		job := jobSyncer.Object().(*batchv1.Job)
+
+		switch {
+		case isJobFailed(job):
+			r.recorder.Event(backup, corev1.EventTypeWarning, wordpressv1alpha1.BackupPurgeFailedReason,
+				fmt.Sprintf("the purge job %s failed, so the artifacts were left behind", job.Name))
+		case job.Status.Succeeded == 0:
+			return nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(backup, wordpressv1alpha1.BackupArtifactsFinalizer)
+
+	return r.Update(ctx, backup)
+}
+
+func (r *ReconcileWordpressBackup) updateStatus(ctx context.Context, backup *wordpressv1alpha1.WordpressBackup,
+	oldStatus *wordpressv1alpha1.WordpressBackupStatus) error {
+	if equality.Semantic.DeepEqual(oldStatus, &backup.Status) {
+		return nil
+	}
+
+	return r.Status().Update(ctx, backup)
+}
+
+// newJobSyncer returns a new sync.Interface for reconciling the Job uploading
+// one of the backup's artifacts. The Job is created once, as the backup is
+// taken once.
+func newJobSyncer(backup *wordpressv1alpha1.WordpressBackup, wp *wordpress.Wordpress, c client.Client,
+	artifact string, template corev1.PodTemplateSpec) syncer.Interface {
+	objLabels := wp.JobPodLabels()
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", backup.Name, artifact),
+			Namespace: backup.Namespace,
+		},
+	}
+
+	var backoffLimit int32 = 2
+
+	return syncer.NewObjectSyncer(jobSyncerNames[artifact], backup, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+
+		if !obj.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+// newPurgeJobSyncer returns a new sync.Interface for reconciling the Job
+// deleting the backup's artifacts.
+func newPurgeJobSyncer(backup *wordpressv1alpha1.WordpressBackup, c client.Client) syncer.Interface {
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-purge", backup.Name),
+			Namespace: backup.Namespace,
+		},
+	}
+
+	var backoffLimit int32 = 2
+
+	return syncer.NewObjectSyncer("WordpressBackupPurgeJob", backup, obj, c, func() error {
+		obj.Labels = labels.Merge(obj.Labels, controllerLabels)
+
+		if !obj.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+
+		template := wordpress.BackupPurgePodTemplateSpec(backup)
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+func isEnabled(include *bool) bool {
+	return include == nil || *include
+}
+
+func isFinished(backup *wordpressv1alpha1.WordpressBackup) bool {
+	return backup.Status.Phase == wordpressv1alpha1.BackupSucceeded || backup.Status.Phase == wordpressv1alpha1.BackupFailed
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
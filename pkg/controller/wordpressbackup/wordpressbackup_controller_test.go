@@ -0,0 +1,82 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpressbackup
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+var _ = Describe("The finished backups", func() {
+	var (
+		r        *ReconcileWordpressBackup
+		recorder *record.FakeRecorder
+		backup   *wordpressv1alpha1.WordpressBackup
+		wp       *wordpress.Wordpress
+	)
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		r = &ReconcileWordpressBackup{recorder: recorder}
+
+		backup = &wordpressv1alpha1.WordpressBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+		}
+		wp = wordpress.New(&wordpressv1alpha1.Wordpress{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		})
+	})
+
+	It("should record the succeeded backup on the backup and on the site", func() {
+		backup.Status.Artifacts = []wordpressv1alpha1.BackupArtifact{
+			{Name: "database", Location: "s3://bucket/nightly/database.sql.gz"},
+		}
+
+		r.finish(backup, wp, wordpressv1alpha1.BackupSucceeded, "")
+
+		Expect(backup.Status.Phase).To(Equal(wordpressv1alpha1.BackupSucceeded))
+		Expect(backup.Status.CompletionTime).NotTo(BeNil())
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal " + wordpressv1alpha1.BackupSucceededReason + " the backup uploaded s3://bucket/nightly/database.sql.gz")))
+		Expect(recorder.Events).To(Receive(Equal(
+			"Normal " + wordpressv1alpha1.BackupSucceededReason + " the nightly backup uploaded s3://bucket/nightly/database.sql.gz")))
+	})
+
+	It("should record the failed backup on the backup and on the site", func() {
+		r.finish(backup, wp, wordpressv1alpha1.BackupFailed, "the backup job nightly-db failed")
+
+		Expect(backup.Status.Phase).To(Equal(wordpressv1alpha1.BackupFailed))
+		Expect(backup.Status.Message).To(Equal("the backup job nightly-db failed"))
+		Expect(recorder.Events).To(Receive(Equal(
+			"Warning " + wordpressv1alpha1.BackupFailedReason + " the backup job nightly-db failed")))
+		Expect(recorder.Events).To(Receive(Equal(
+			"Warning " + wordpressv1alpha1.BackupFailedReason + " the nightly backup failed: the backup job nightly-db failed")))
+	})
+
+	It("should only record the failure on the backup without a site", func() {
+		r.finish(backup, nil, wordpressv1alpha1.BackupFailed, "unsupported encryption")
+
+		Expect(recorder.Events).To(Receive(HaveSuffix("unsupported encryption")))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+})
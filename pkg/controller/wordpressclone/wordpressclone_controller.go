@@ -0,0 +1,363 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpressclone
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/appscode/mergo"
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	controllerName = "wordpress-clone-controller"
+
+	pendingRequeueInterval = 30 * time.Second
+)
+
+var controllerLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+}
+
+// Add creates a new WordpressClone Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWordpressClone{
+		Client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor(controllerName),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to WordpressClone
+	err = c.Watch(&source.Kind{Type: &wordpressv1alpha1.WordpressClone{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wordpressv1alpha1.WordpressClone{},
+	})
+}
+
+var _ reconcile.Reconciler = &ReconcileWordpressClone{}
+
+// ReconcileWordpressClone reconciles a WordpressClone object.
+type ReconcileWordpressClone struct {
+	client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// Reconcile copies the source's database dump, with the target's URL, into a
+// claim and its media into the target's prefix, then creates the target
+// Wordpress, which imports the dump. Once the target is bootstrapped, the
+// dump is dropped.
+//
+// +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpressclones;wordpressclones/status,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpresses,verbs=get;list;watch;create;update;patch;delete
+func (r *ReconcileWordpressClone) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	clone := &wordpressv1alpha1.WordpressClone{}
+
+	err := r.Get(ctx, request.NamespacedName, clone)
+	if err != nil {
+		return reconcile.Result{}, ignoreNotFound(err)
+	}
+
+	if isFinished(clone) {
+		return reconcile.Result{}, nil
+	}
+
+	oldStatus := clone.Status.DeepCopy()
+
+	result, err := r.sync(ctx, clone)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return result, r.updateStatus(ctx, clone, oldStatus)
+}
+
+func (r *ReconcileWordpressClone) sync(ctx context.Context, clone *wordpressv1alpha1.WordpressClone) (reconcile.Result, error) {
+	target := &wordpressv1alpha1.Wordpress{}
+
+	err := r.Get(ctx, client.ObjectKey{Name: clone.Spec.TargetName, Namespace: clone.Namespace}, target)
+	if err == nil {
+		return r.syncTarget(ctx, clone, target)
+	} else if !errors.IsNotFound(err) {
+		return reconcile.Result{}, err
+	}
+
+	wp := wordpress.New(&wordpressv1alpha1.Wordpress{})
+
+	key := client.ObjectKey{Name: clone.Spec.SourceRef.Name, Namespace: clone.Namespace}
+	if err = r.Get(ctx, key, wp.Unwrap()); errors.IsNotFound(err) {
+		clone.Status.Phase = wordpressv1alpha1.ClonePending
+		clone.Status.Message = fmt.Sprintf("the %s Wordpress doesn't exist", key.Name)
+
+		// the Wordpress is not watched, so check back until it's created
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, nil
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.scheme.Default(wp.Unwrap())
+	wp.SetDefaults()
+
+	desired, err := wp.CloneTarget(clone)
+	if err != nil {
+		r.fail(clone, err.Error())
+
+		return reconcile.Result{}, nil
+	}
+
+	jobs, err := r.syncCopy(ctx, clone, wp, desired)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if clone.Status.StartTime == nil {
+		clone.Status.StartTime = &jobs[0].CreationTimestamp
+	}
+
+	clone.Status.Phase = wordpressv1alpha1.CloneRunning
+	clone.Status.Message = "copying the source"
+
+	for _, job := range jobs {
+		if isJobFailed(job) {
+			r.fail(clone, fmt.Sprintf("the clone job %s failed", job.Name))
+
+			return reconcile.Result{}, nil
+		}
+
+		if job.Status.Succeeded == 0 {
+			return reconcile.Result{}, nil
+		}
+	}
+
+	if err = r.Create(ctx, desired.Unwrap()); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	clone.Status.Message = "bootstrapping the target"
+
+	// the target is not watched, so check back until it's bootstrapped
+	return reconcile.Result{RequeueAfter: pendingRequeueInterval}, nil
+}
+
+// syncCopy creates the claim holding the database dump and the Jobs copying
+// the source's database and media.
+func (r *ReconcileWordpressClone) syncCopy(ctx context.Context, clone *wordpressv1alpha1.WordpressClone,
+	wp, target *wordpress.Wordpress) ([]*batchv1.Job, error) {
+	syncers := []syncer.Interface{
+		newDumpClaimSyncer(clone, r.Client),
+		newJobSyncer(clone, wp, r.Client, "CloneDumpJob", "dump", wp.CloneDumpPodTemplateSpec(clone, target)),
+	}
+
+	if wp.ClonesMedia() {
+		syncers = append(syncers,
+			newJobSyncer(clone, wp, r.Client, "CloneMediaJob", "media", wp.CloneMediaPodTemplateSpec(target)))
+	}
+
+	jobs := []*batchv1.Job{}
+
+	for _, s := range syncers {
+		if err := syncer.Sync(ctx, s, r.recorder); err != nil {
+			return nil, err
+		}
+
+		if job, ok := s.Object().(*batchv1.Job); ok {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// syncTarget waits for the target to be bootstrapped, then stops it from
+// importing the dump and drops the dump's claim.
+func (r *ReconcileWordpressClone) syncTarget(ctx context.Context, clone *wordpressv1alpha1.WordpressClone,
+	target *wordpressv1alpha1.Wordpress) (reconcile.Result, error) {
+	if target.Annotations[wordpressv1alpha1.CloneAnnotation] != clone.Name {
+		r.fail(clone, fmt.Sprintf("the %s Wordpress already exists", target.Name))
+
+		return reconcile.Result{}, nil
+	}
+
+	if !target.Status.Bootstrapped {
+		clone.Status.Phase = wordpressv1alpha1.CloneRunning
+		clone.Status.Message = "bootstrapping the target"
+
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, nil
+	}
+
+	if bootstrap := target.Spec.WordpressBootstrapSpec; bootstrap != nil && bootstrap.ImportFrom != nil {
+		bootstrap.ImportFrom = nil
+
+		if err := r.Update(ctx, target); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wordpress.CloneDumpClaimName(clone),
+			Namespace: clone.Namespace,
+		},
+	}
+
+	if err := r.Delete(ctx, claim); ignoreNotFound(err) != nil {
+		return reconcile.Result{}, err
+	}
+
+	now := metav1.Now()
+	clone.Status.Phase = wordpressv1alpha1.CloneSucceeded
+	clone.Status.Message = ""
+	clone.Status.CompletionTime = &now
+
+	r.recorder.Event(clone, corev1.EventTypeNormal, wordpressv1alpha1.CloneSucceededReason,
+		fmt.Sprintf("the %s Wordpress is cloned into %s", clone.Spec.SourceRef.Name, target.Name))
+
+	return reconcile.Result{}, nil
+}
+
+// fail marks the clone as failed and records an event.
+func (r *ReconcileWordpressClone) fail(clone *wordpressv1alpha1.WordpressClone, message string) {
+	now := metav1.Now()
+	clone.Status.Phase = wordpressv1alpha1.CloneFailed
+	clone.Status.Message = message
+	clone.Status.CompletionTime = &now
+
+	r.recorder.Event(clone, corev1.EventTypeWarning, wordpressv1alpha1.CloneFailedReason, message)
+}
+
+func (r *ReconcileWordpressClone) updateStatus(ctx context.Context, clone *wordpressv1alpha1.WordpressClone,
+	oldStatus *wordpressv1alpha1.WordpressCloneStatus) error {
+	if equality.Semantic.DeepEqual(oldStatus, &clone.Status) {
+		return nil
+	}
+
+	return r.Status().Update(ctx, clone)
+}
+
+// newDumpClaimSyncer returns a new sync.Interface for reconciling the claim
+// holding the database dump, until the target is bootstrapped.
+func newDumpClaimSyncer(clone *wordpressv1alpha1.WordpressClone, c client.Client) syncer.Interface {
+	obj := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wordpress.CloneDumpClaimName(clone),
+			Namespace: clone.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("CloneDumpPVC", clone, obj, c, func() error {
+		obj.Labels = labels.Merge(obj.Labels, controllerLabels)
+
+		// the claim's spec is immutable
+		if obj.CreationTimestamp.IsZero() {
+			obj.Spec = wordpress.CloneDumpVolumeClaimSpec(clone)
+		}
+
+		return nil
+	})
+}
+
+// newJobSyncer returns a new sync.Interface for reconciling one of the Jobs
+// copying the source. The Jobs are created once.
+func newJobSyncer(clone *wordpressv1alpha1.WordpressClone, wp *wordpress.Wordpress, c client.Client,
+	name, suffix string, template corev1.PodTemplateSpec) syncer.Interface {
+	objLabels := wp.JobPodLabels()
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", clone.Name, suffix),
+			Namespace: clone.Namespace,
+		},
+	}
+
+	var backoffLimit int32 = 2
+
+	return syncer.NewObjectSyncer(name, clone, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+
+		if !obj.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+func isFinished(clone *wordpressv1alpha1.WordpressClone) bool {
+	return clone.Status.Phase == wordpressv1alpha1.CloneSucceeded || clone.Status.Phase == wordpressv1alpha1.CloneFailed
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
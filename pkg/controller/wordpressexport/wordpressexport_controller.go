@@ -0,0 +1,311 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpressexport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/appscode/mergo"
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	controllerName = "wordpress-export-controller"
+
+	pendingRequeueInterval = 30 * time.Second
+)
+
+var controllerLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+}
+
+// Add creates a new WordpressExport Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWordpressExport{
+		Client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor(controllerName),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to WordpressExport
+	err = c.Watch(&source.Kind{Type: &wordpressv1alpha1.WordpressExport{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wordpressv1alpha1.WordpressExport{},
+	})
+}
+
+var _ reconcile.Reconciler = &ReconcileWordpressExport{}
+
+// ReconcileWordpressExport reconciles a WordpressExport object.
+type ReconcileWordpressExport struct {
+	client.Client
+	// apiReader reads the objects which are not cached
+	apiReader client.Reader
+	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
+}
+
+// Reconcile runs the Job exporting the site's content as WXR files and reports
+// the uploaded files in the WordpressExport's status.
+//
+// +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpressexports;wordpressexports/status,verbs=get;list;watch;create;update;patch;delete
+func (r *ReconcileWordpressExport) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	export := &wordpressv1alpha1.WordpressExport{}
+
+	err := r.Get(ctx, request.NamespacedName, export)
+	if err != nil {
+		return reconcile.Result{}, ignoreNotFound(err)
+	}
+
+	if isFinished(export) {
+		return reconcile.Result{}, nil
+	}
+
+	oldStatus := export.Status.DeepCopy()
+
+	wp := wordpress.New(&wordpressv1alpha1.Wordpress{})
+
+	key := client.ObjectKey{Name: export.Spec.SiteRef.Name, Namespace: export.Namespace}
+	if err = r.Get(ctx, key, wp.Unwrap()); errors.IsNotFound(err) {
+		export.Status.Phase = wordpressv1alpha1.ExportPending
+		export.Status.Message = fmt.Sprintf("the %s Wordpress doesn't exist", key.Name)
+
+		// the Wordpress is not watched, so check back until it's created
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, r.updateStatus(ctx, export, oldStatus)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.scheme.Default(wp.Unwrap())
+	wp.SetDefaults()
+
+	template, err := wp.ExportPodTemplateSpec(export)
+	if err != nil {
+		r.finish(export, wordpressv1alpha1.ExportFailed, err.Error())
+
+		return reconcile.Result{}, r.updateStatus(ctx, export, oldStatus)
+	}
+
+	jobSyncer := newJobSyncer(export, wp, r.Client, template)
+	if err = syncer.Sync(ctx, jobSyncer, r.recorder); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err = r.syncStatus(ctx, export, wp, jobSyncer.Object().(*batchv1.Job)); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.updateStatus(ctx, export, oldStatus)
+}
+
+// syncStatus sets the export's phase from its Job and, once it succeeded, the
+// uploaded files from the upload container's termination message.
+func (r *ReconcileWordpressExport) syncStatus(ctx context.Context, export *wordpressv1alpha1.WordpressExport,
+	wp *wordpress.Wordpress, job *batchv1.Job) error {
+	export.Status.Message = ""
+
+	if export.Status.StartTime == nil {
+		export.Status.StartTime = &job.CreationTimestamp
+	}
+
+	switch {
+	case isJobFailed(job):
+		r.finish(export, wordpressv1alpha1.ExportFailed, fmt.Sprintf("the export job %s failed", job.Name))
+
+		return nil
+	case job.Status.Succeeded == 0:
+		export.Status.Phase = wordpressv1alpha1.ExportRunning
+
+		return nil
+	}
+
+	report, err := r.uploadReport(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	size, files, err := wordpress.ParseExportReport(report)
+	if err != nil {
+		r.finish(export, wordpressv1alpha1.ExportFailed, fmt.Sprintf("the export job %s: %s", job.Name, err))
+
+		return nil
+	}
+
+	export.Status.Location = wp.ExportLocation(export)
+	export.Status.Files = files
+	export.Status.Size = size
+
+	r.finish(export, wordpressv1alpha1.ExportSucceeded, "")
+
+	return nil
+}
+
+// finish marks the export as finished and records an event.
+func (r *ReconcileWordpressExport) finish(export *wordpressv1alpha1.WordpressExport,
+	phase wordpressv1alpha1.WordpressExportPhase, message string) {
+	now := metav1.Now()
+	export.Status.Phase = phase
+	export.Status.CompletionTime = &now
+	export.Status.Message = message
+
+	if phase == wordpressv1alpha1.ExportFailed {
+		r.recorder.Event(export, corev1.EventTypeWarning, wordpressv1alpha1.ExportFailedReason, message)
+
+		return
+	}
+
+	r.recorder.Event(export, corev1.EventTypeNormal, wordpressv1alpha1.ExportSucceededReason,
+		fmt.Sprintf("the export uploaded %d files to %s", export.Status.Files, export.Status.Location))
+}
+
+// uploadReport returns the termination message of the upload container of the
+// Job's last succeeded pod.
+func (r *ReconcileWordpressExport) uploadReport(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+
+	// the pods are not cached
+	err := r.apiReader.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"controller-uid": string(job.UID)},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	var out *corev1.ContainerStateTerminated
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name != wordpress.ExportUploadContainerName || terminated == nil || terminated.ExitCode != 0 {
+				continue
+			}
+
+			if out == nil || out.FinishedAt.Before(&terminated.FinishedAt) {
+				out = terminated
+			}
+		}
+	}
+
+	if out == nil {
+		return "", nil
+	}
+
+	return out.Message, nil
+}
+
+func (r *ReconcileWordpressExport) updateStatus(ctx context.Context, export *wordpressv1alpha1.WordpressExport,
+	oldStatus *wordpressv1alpha1.WordpressExportStatus) error {
+	if equality.Semantic.DeepEqual(oldStatus, &export.Status) {
+		return nil
+	}
+
+	return r.Status().Update(ctx, export)
+}
+
+// newJobSyncer returns a new sync.Interface for reconciling the Job exporting
+// the site's content. The Job is created once, as the export runs once.
+func newJobSyncer(export *wordpressv1alpha1.WordpressExport, wp *wordpress.Wordpress, c client.Client,
+	template corev1.PodTemplateSpec) syncer.Interface {
+	objLabels := wp.JobPodLabels()
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-wxr", export.Name),
+			Namespace: export.Namespace,
+		},
+	}
+
+	var backoffLimit int32 = 2
+
+	return syncer.NewObjectSyncer("WordpressExportJob", export, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+
+		if !obj.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+func isFinished(export *wordpressv1alpha1.WordpressExport) bool {
+	return export.Status.Phase == wordpressv1alpha1.ExportSucceeded || export.Status.Phase == wordpressv1alpha1.ExportFailed
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
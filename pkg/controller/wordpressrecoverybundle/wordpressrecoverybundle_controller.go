@@ -0,0 +1,286 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpressrecoverybundle
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/presslabs/controller-util/syncer"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+)
+
+const (
+	controllerName = "wordpress-recovery-bundle-controller"
+
+	pendingRequeueInterval = 30 * time.Second
+)
+
+var controllerLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+}
+
+// Add creates a new WordpressRecoveryBundle Controller and adds it to the Manager. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWordpressRecoveryBundle{
+		Client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor(controllerName),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to WordpressRecoveryBundle
+	return c.Watch(&source.Kind{Type: &wordpressv1alpha1.WordpressRecoveryBundle{}}, &handler.EnqueueRequestForObject{})
+}
+
+var _ reconcile.Reconciler = &ReconcileWordpressRecoveryBundle{}
+
+// ReconcileWordpressRecoveryBundle reconciles a WordpressRecoveryBundle object.
+type ReconcileWordpressRecoveryBundle struct {
+	client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
+}
+
+// Reconcile packages the site's manifest, bootstrapped from its backup, along
+// with the backup's artifacts into the bundle's ConfigMap. The bundle is a
+// snapshot, so the ConfigMap is created once.
+//
+// +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wordpressrecoverybundles;wordpressrecoverybundles/status,verbs=get;list;watch;create;update;patch;delete
+func (r *ReconcileWordpressRecoveryBundle) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	bundle := &wordpressv1alpha1.WordpressRecoveryBundle{}
+
+	err := r.Get(ctx, request.NamespacedName, bundle)
+	if err != nil {
+		return reconcile.Result{}, ignoreNotFound(err)
+	}
+
+	if isFinished(bundle) {
+		return reconcile.Result{}, nil
+	}
+
+	oldStatus := bundle.Status.DeepCopy()
+
+	wp := wordpress.New(&wordpressv1alpha1.Wordpress{})
+
+	key := client.ObjectKey{Name: bundle.Spec.SiteRef.Name, Namespace: bundle.Namespace}
+	if err = r.Get(ctx, key, wp.Unwrap()); errors.IsNotFound(err) {
+		bundle.Status.Phase = wordpressv1alpha1.RecoveryBundlePending
+		bundle.Status.Message = fmt.Sprintf("the %s Wordpress doesn't exist", key.Name)
+
+		// the Wordpress is not watched, so check back until it's created
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, r.updateStatus(ctx, bundle, oldStatus)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	backup, err := r.bundledBackup(ctx, bundle, wp)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	switch {
+	case backup == nil:
+		bundle.Status.Phase = wordpressv1alpha1.RecoveryBundlePending
+		bundle.Status.Message = pendingMessage(bundle)
+
+		// the backups are not watched, so check back until one succeeds
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, r.updateStatus(ctx, bundle, oldStatus)
+	case backup.Spec.SiteRef.Name != wp.Name:
+		r.finish(bundle, wordpressv1alpha1.RecoveryBundleFailed,
+			fmt.Sprintf("the %s backup is not a backup of the %s Wordpress", backup.Name, wp.Name))
+
+		return reconcile.Result{}, r.updateStatus(ctx, bundle, oldStatus)
+	case backup.Status.Phase == wordpressv1alpha1.BackupFailed:
+		r.finish(bundle, wordpressv1alpha1.RecoveryBundleFailed, fmt.Sprintf("the %s backup failed", backup.Name))
+
+		return reconcile.Result{}, r.updateStatus(ctx, bundle, oldStatus)
+	case backup.Status.Phase != wordpressv1alpha1.BackupSucceeded:
+		bundle.Status.Phase = wordpressv1alpha1.RecoveryBundlePending
+		bundle.Status.Message = fmt.Sprintf("waiting for the %s backup to succeed", backup.Name)
+
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, r.updateStatus(ctx, bundle, oldStatus)
+	}
+
+	bundle.Status.Backup = backup.Name
+
+	data, err := wp.RecoveryBundleData(bundle, backup)
+	if err != nil {
+		r.finish(bundle, wordpressv1alpha1.RecoveryBundleFailed, err.Error())
+
+		return reconcile.Result{}, r.updateStatus(ctx, bundle, oldStatus)
+	}
+
+	configMapSyncer := newConfigMapSyncer(bundle, wp, r.Client, data)
+	if err = syncer.Sync(ctx, configMapSyncer, r.recorder); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	bundle.Status.ConfigMapName = configMapSyncer.Object().(*corev1.ConfigMap).Name
+
+	r.finish(bundle, wordpressv1alpha1.RecoveryBundleSucceeded, "")
+
+	return reconcile.Result{}, r.updateStatus(ctx, bundle, oldStatus)
+}
+
+// bundledBackup returns the bundle's backup, or the site's last succeeded
+// scheduled backup, or nil if there's none.
+func (r *ReconcileWordpressRecoveryBundle) bundledBackup(ctx context.Context,
+	bundle *wordpressv1alpha1.WordpressRecoveryBundle, wp *wordpress.Wordpress) (*wordpressv1alpha1.WordpressBackup, error) {
+	if bundle.Spec.Backup != "" {
+		backup := &wordpressv1alpha1.WordpressBackup{}
+
+		key := client.ObjectKey{Name: bundle.Spec.Backup, Namespace: bundle.Namespace}
+		if err := r.Get(ctx, key, backup); err != nil {
+			return nil, ignoreNotFound(err)
+		}
+
+		return backup, nil
+	}
+
+	backups := &wordpressv1alpha1.WordpressBackupList{}
+
+	err := r.List(ctx, backups,
+		client.InNamespace(wp.Namespace),
+		client.MatchingLabels(wp.ComponentLabels(wordpress.WordpressBackups)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var out *wordpressv1alpha1.WordpressBackup
+
+	for i := range backups.Items {
+		backup := &backups.Items[i]
+		if backup.DeletionTimestamp != nil || backup.Status.Phase != wordpressv1alpha1.BackupSucceeded ||
+			wordpress.DatabaseArtifact(backup) == nil {
+			continue
+		}
+
+		if out == nil || out.CreationTimestamp.Before(&backup.CreationTimestamp) {
+			out = backup
+		}
+	}
+
+	return out, nil
+}
+
+// pendingMessage returns the message of a bundle whose backup doesn't exist.
+func pendingMessage(bundle *wordpressv1alpha1.WordpressRecoveryBundle) string {
+	if bundle.Spec.Backup == "" {
+		return fmt.Sprintf("the %s Wordpress has no succeeded scheduled backup", bundle.Spec.SiteRef.Name)
+	}
+
+	return fmt.Sprintf("the %s backup doesn't exist", bundle.Spec.Backup)
+}
+
+// finish marks the bundle as finished and records an event.
+func (r *ReconcileWordpressRecoveryBundle) finish(bundle *wordpressv1alpha1.WordpressRecoveryBundle,
+	phase wordpressv1alpha1.WordpressRecoveryBundlePhase, message string) {
+	now := metav1.Now()
+	bundle.Status.Phase = phase
+	bundle.Status.CompletionTime = &now
+	bundle.Status.Message = message
+
+	if phase == wordpressv1alpha1.RecoveryBundleFailed {
+		r.recorder.Event(bundle, corev1.EventTypeWarning, wordpressv1alpha1.RecoveryBundleFailedReason, message)
+
+		return
+	}
+
+	r.recorder.Event(bundle, corev1.EventTypeNormal, wordpressv1alpha1.RecoveryBundleCreatedReason,
+		fmt.Sprintf("the %s backup was bundled into the %s ConfigMap", bundle.Status.Backup, bundle.Status.ConfigMapName))
+}
+
+func (r *ReconcileWordpressRecoveryBundle) updateStatus(ctx context.Context, bundle *wordpressv1alpha1.WordpressRecoveryBundle,
+	oldStatus *wordpressv1alpha1.WordpressRecoveryBundleStatus) error {
+	if equality.Semantic.DeepEqual(oldStatus, &bundle.Status) {
+		return nil
+	}
+
+	return r.Status().Update(ctx, bundle)
+}
+
+// newConfigMapSyncer returns a new sync.Interface for reconciling the
+// ConfigMap holding the bundle. The ConfigMap is created once, as the bundle
+// is a snapshot.
+func newConfigMapSyncer(bundle *wordpressv1alpha1.WordpressRecoveryBundle, wp *wordpress.Wordpress, c client.Client,
+	data map[string]string) syncer.Interface {
+	objLabels := wp.ComponentLabels(wordpress.WordpressRecoveryBundle)
+
+	obj := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bundle.Name,
+			Namespace: bundle.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("WordpressRecoveryBundleConfigMap", bundle, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+
+		if !obj.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		obj.Data = data
+
+		return nil
+	})
+}
+
+func isFinished(bundle *wordpressv1alpha1.WordpressRecoveryBundle) bool {
+	return bundle.Status.Phase == wordpressv1alpha1.RecoveryBundleSucceeded ||
+		bundle.Status.Phase == wordpressv1alpha1.RecoveryBundleFailed
+}
+
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
@@ -112,6 +112,17 @@ func (r *ReconcileWordpress) Reconcile(ctx context.Context, request reconcile.Re
 
 	log := r.Log.WithValues("key", request.NamespacedName)
 
+	// the scheduled events are run by the site's CronJob instead
+	if wp.RunsCronJob() {
+		if wp.GetCondition(wordpressv1alpha1.WPCronTriggeringCondition) == nil {
+			return reconcile.Result{}, nil
+		}
+
+		wp.RemoveCondition(wordpressv1alpha1.WPCronTriggeringCondition)
+
+		return reconcile.Result{}, r.Client.Status().Update(ctx, wp.Unwrap())
+	}
+
 	requeue := reconcile.Result{
 		Requeue:      true,
 		RequeueAfter: cronTriggerInterval,
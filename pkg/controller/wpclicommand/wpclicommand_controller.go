@@ -0,0 +1,312 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wpclicommand
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/appscode/mergo"
+	"github.com/presslabs/controller-util/mergo/transformers"
+	"github.com/presslabs/controller-util/syncer"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/internal/wordpress"
+	"github.com/bitpoke/wordpress-operator/pkg/tracing"
+)
+
+const (
+	controllerName = "wp-cli-command-controller"
+
+	pendingRequeueInterval = 30 * time.Second
+)
+
+var controllerLabels = map[string]string{
+	"app.kubernetes.io/managed-by": "wordpress-operator.presslabs.org",
+}
+
+// Add creates a new WPCliCommand Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler.
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWPCliCommand{
+		Client:    mgr.GetClient(),
+		apiReader: mgr.GetAPIReader(),
+		scheme:    mgr.GetScheme(),
+		recorder:  mgr.GetEventRecorderFor(controllerName),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to WPCliCommand
+	err = c.Watch(&source.Kind{Type: &wordpressv1alpha1.WPCliCommand{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wordpressv1alpha1.WPCliCommand{},
+	})
+}
+
+var _ reconcile.Reconciler = &ReconcileWPCliCommand{}
+
+// ReconcileWPCliCommand reconciles a WPCliCommand object.
+type ReconcileWPCliCommand struct {
+	client.Client
+	// apiReader reads the objects which are not cached
+	apiReader client.Reader
+	scheme    *runtime.Scheme
+	recorder  record.EventRecorder
+}
+
+// Reconcile runs the wp-cli command in a Job and reports its outcome in the
+// WPCliCommand's status. Finished commands are deleted after their TTL.
+//
+// +kubebuilder:rbac:groups=wordpress.presslabs.org,resources=wpclicommands;wpclicommands/status,verbs=get;list;watch;create;update;patch;delete
+func (r *ReconcileWPCliCommand) Reconcile(ctx context.Context, request reconcile.Request) (result reconcile.Result, err error) {
+	ctx, span := tracing.StartReconcile(ctx, "WPCliCommand", request.NamespacedName)
+	defer func() { tracing.End(span, err) }()
+
+	cmd := &wordpressv1alpha1.WPCliCommand{}
+
+	err = r.Get(ctx, request.NamespacedName, cmd)
+	if err != nil {
+		return reconcile.Result{}, ignoreNotFound(err)
+	}
+
+	if isFinished(cmd) {
+		return r.expire(ctx, cmd)
+	}
+
+	oldStatus := cmd.Status.DeepCopy()
+
+	wp := wordpress.New(&wordpressv1alpha1.Wordpress{})
+
+	key := client.ObjectKey{Name: cmd.Spec.SiteRef.Name, Namespace: cmd.Namespace}
+	if err = r.Get(ctx, key, wp.Unwrap()); errors.IsNotFound(err) {
+		cmd.Status.Phase = wordpressv1alpha1.WPCliCommandPending
+		cmd.Status.Message = fmt.Sprintf("the %s Wordpress doesn't exist", key.Name)
+
+		// the Wordpress is not watched, so check back until it's created
+		return reconcile.Result{RequeueAfter: pendingRequeueInterval}, r.updateStatus(ctx, cmd, oldStatus)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	r.scheme.Default(wp.Unwrap())
+	wp.SetDefaults()
+
+	jobSyncer := newJobSyncer(cmd, wp, r.Client)
+	if err = tracing.Sync(ctx, jobSyncer, r.recorder); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	job := jobSyncer.Object().(*batchv1.Job)
+
+	if err = r.syncStatus(ctx, cmd, job); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err = r.updateStatus(ctx, cmd, oldStatus); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if isFinished(cmd) {
+		return r.expire(ctx, cmd)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// syncStatus sets the command's phase from its Job and, once the Job is
+// finished, its exit code and output from the pod's termination message.
+func (r *ReconcileWPCliCommand) syncStatus(ctx context.Context, cmd *wordpressv1alpha1.WPCliCommand, job *batchv1.Job) error {
+	cmd.Status.JobName = job.Name
+	cmd.Status.Message = ""
+
+	if cmd.Status.StartTime == nil {
+		cmd.Status.StartTime = &job.CreationTimestamp
+	}
+
+	switch {
+	case job.Status.Succeeded > 0:
+		cmd.Status.Phase = wordpressv1alpha1.WPCliCommandSucceeded
+	case isJobFailed(job):
+		cmd.Status.Phase = wordpressv1alpha1.WPCliCommandFailed
+	default:
+		cmd.Status.Phase = wordpressv1alpha1.WPCliCommandRunning
+
+		return nil
+	}
+
+	now := metav1.Now()
+	cmd.Status.CompletionTime = &now
+
+	terminated, err := r.terminatedState(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	if terminated == nil {
+		cmd.Status.Message = "the command's pod was not found"
+
+		return nil
+	}
+
+	cmd.Status.ExitCode = &terminated.ExitCode
+	cmd.Status.Output = terminated.Message
+
+	return nil
+}
+
+// terminatedState returns the terminated state of the wp-cli container of the
+// Job's last pod, or nil if it can't be found.
+func (r *ReconcileWPCliCommand) terminatedState(ctx context.Context, job *batchv1.Job) (*corev1.ContainerStateTerminated, error) {
+	pods := &corev1.PodList{}
+
+	// the pods are not cached
+	err := r.apiReader.List(ctx, pods,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"controller-uid": string(job.UID)},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var out *corev1.ContainerStateTerminated
+
+	for i := range pods.Items {
+		for _, status := range pods.Items[i].Status.ContainerStatuses {
+			if status.Name != wordpress.WPCliContainerName || status.State.Terminated == nil {
+				continue
+			}
+
+			if out == nil || out.FinishedAt.Before(&status.State.Terminated.FinishedAt) {
+				out = status.State.Terminated
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// expire deletes the finished command, along with its Job, once its TTL passes.
+func (r *ReconcileWPCliCommand) expire(ctx context.Context, cmd *wordpressv1alpha1.WPCliCommand) (reconcile.Result, error) {
+	if cmd.Spec.TTLSecondsAfterFinished == nil || cmd.Status.CompletionTime == nil {
+		return reconcile.Result{}, nil
+	}
+
+	ttl := time.Duration(*cmd.Spec.TTLSecondsAfterFinished) * time.Second
+	if remaining := ttl - time.Since(cmd.Status.CompletionTime.Time); remaining > 0 {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	err := r.Delete(ctx, cmd, client.PropagationPolicy(metav1.DeletePropagationBackground))
+
+	return reconcile.Result{}, ignoreNotFound(err)
+}
+
+func (r *ReconcileWPCliCommand) updateStatus(ctx context.Context, cmd *wordpressv1alpha1.WPCliCommand,
+	oldStatus *wordpressv1alpha1.WPCliCommandStatus) error {
+	if equality.Semantic.DeepEqual(oldStatus, &cmd.Status) {
+		return nil
+	}
+
+	return r.Status().Update(ctx, cmd)
+}
+
+// newJobSyncer returns a new sync.Interface for reconciling the Job running
+// the command. The Job is created once and is not retried, as the commands
+// are not expected to be idempotent.
+func newJobSyncer(cmd *wordpressv1alpha1.WPCliCommand, wp *wordpress.Wordpress, c client.Client) syncer.Interface {
+	objLabels := wp.JobPodLabels()
+
+	obj := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-wp-cli", cmd.Name),
+			Namespace: cmd.Namespace,
+		},
+	}
+
+	var backoffLimit int32
+
+	return syncer.NewObjectSyncer("WPCliCommandJob", cmd, obj, c, func() error {
+		obj.Labels = labels.Merge(labels.Merge(obj.Labels, objLabels), controllerLabels)
+
+		if !obj.CreationTimestamp.IsZero() {
+			return nil
+		}
+
+		obj.Spec.BackoffLimit = &backoffLimit
+
+		template := wp.WPCliCommandPodTemplateSpec(cmd.Spec.Args)
+
+		obj.Spec.Template.ObjectMeta = template.ObjectMeta
+
+		return mergo.Merge(&obj.Spec.Template.Spec, template.Spec, mergo.WithTransformers(transformers.PodSpec))
+	})
+}
+
+func isFinished(cmd *wordpressv1alpha1.WPCliCommand) bool {
+	return cmd.Status.Phase == wordpressv1alpha1.WPCliCommandSucceeded || cmd.Status.Phase == wordpressv1alpha1.WPCliCommandFailed
+}
+
+func isJobFailed(job *batchv1.Job) bool {
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
+}
+
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
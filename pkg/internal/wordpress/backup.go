@@ -0,0 +1,533 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// BackupUploadContainerName is the name of the container uploading the
+	// backup's artifacts, which reports them through its termination message.
+	BackupUploadContainerName = "upload"
+
+	// BackupDatabaseArtifact is the name of the database artifact.
+	BackupDatabaseArtifact = "database"
+	// BackupMediaArtifact is the name of the media artifact.
+	BackupMediaArtifact = "media"
+
+	backupVolumeName     = "backup"
+	backupMountPath      = "/backup"
+	backupMediaMountPath = "/media"
+	backupDatabaseFile   = "database.sql.gz"
+	backupMediaArchive   = "media.tar.gz"
+)
+
+// backupDumpScript dumps the database into the backup volume, signaling the
+// upload container once it's done or it failed.
+const backupDumpScript = `
+if wp db export /backup/database.sql && gzip -f /backup/database.sql; then
+    touch /backup/.done
+else
+    touch /backup/.failed
+    exit 1
+fi
+`
+
+// backupUploadDatabaseScript waits for the database dump, encrypts it if
+// needed, uploads it and reports it through the termination message, as
+// name|size|objects|checksum|keys.
+const backupUploadDatabaseScript = `
+set -e
+
+while [ ! -f /backup/.done ]; do
+    [ ! -f /backup/.failed ] || exit 1
+    sleep 1
+done
+
+file=/backup/database.sql.gz
+if [ -n "$BACKUP_ENCRYPTION" ]; then
+    encrypt "$file" "$file$BACKUP_EXTENSION"
+    file="$file$BACKUP_EXTENSION"
+fi
+
+size=$(stat -c %s "$file")
+checksum=$(sha256sum "$file" | cut -d ' ' -f 1)
+
+rclone copyto "$file" "$BACKUP_REMOTE/$(basename "$file")"
+
+printf 'database|%s|1|%s|%s\n' "$size" "$checksum" "$keys" | tee /dev/termination-log
+`
+
+// backupUploadMediaScript copies the media files and reports them through the
+// termination message, as name|size|objects|checksum.
+const backupUploadMediaScript = `
+set -e
+
+rclone copy "$MEDIA_SOURCE" "$BACKUP_REMOTE/media"
+
+rclone size --json "$BACKUP_REMOTE/media" > /tmp/size.json
+count=$(sed -E 's/.*"count":([0-9]+).*/\1/' /tmp/size.json)
+bytes=$(sed -E 's/.*"bytes":([0-9]+).*/\1/' /tmp/size.json)
+
+printf 'media|%s|%s|\n' "$bytes" "$count" | tee /dev/termination-log
+`
+
+// backupUploadEncryptedMediaScript archives the media files, as they can't be
+// encrypted one by one, then encrypts and uploads the archive. The media
+// stored in a bucket is downloaded first.
+const backupUploadEncryptedMediaScript = `
+set -e
+
+source="$MEDIA_SOURCE"
+if [ "$source" != /media ]; then
+    rclone copy "$source" /backup/media
+    source=/backup/media
+fi
+
+count=$(find "$source" -type f | wc -l)
+tar -C "$source" -czf /backup/media.tar.gz .
+rm -rf /backup/media
+
+file="/backup/media.tar.gz$BACKUP_EXTENSION"
+encrypt /backup/media.tar.gz "$file"
+
+size=$(stat -c %s "$file")
+checksum=$(sha256sum "$file" | cut -d ' ' -f 1)
+
+rclone copyto "$file" "$BACKUP_REMOTE/$(basename "$file")"
+
+printf 'media|%s|%s|%s|%s\n' "$size" "$count" "$checksum" "$keys" | tee /dev/termination-log
+`
+
+// backupEncryptScript installs the encryption tools and defines encrypt,
+// which encrypts a file into another one, removing it, and sets the keys it's
+// encrypted to.
+const backupEncryptScript = `
+set -e
+
+apk add --no-cache age gnupg > /dev/null
+
+encrypt() {
+    case "$BACKUP_ENCRYPTION" in
+    age)
+        printf '%s\n' "$BACKUP_AGE_RECIPIENTS" > /tmp/recipients
+        age --encrypt -R /tmp/recipients -o "$2" "$1"
+        keys=$(paste -s -d , /tmp/recipients)
+        ;;
+    gpg)
+        printf '%s\n' "$BACKUP_GPG_PUBLIC_KEY" > /tmp/key.asc
+        gpg --batch --trust-model always --recipient-file /tmp/key.asc --output "$2" --encrypt "$1"
+        keys=$(gpg --show-keys --with-colons /tmp/key.asc | awk -F: '/^fpr/ { print $10; exit }')
+        ;;
+    passphrase)
+        printf '%s' "$BACKUP_PASSPHRASE" > /tmp/passphrase
+        gpg --batch --pinentry-mode loopback --passphrase-file /tmp/passphrase \
+            --symmetric --cipher-algo AES256 --output "$2" "$1"
+        keys=
+        ;;
+    esac
+    rm -f "$1"
+}
+`
+
+// backupPurgeScript deletes the backup's artifacts, succeeding if they're
+// already gone.
+const backupPurgeScript = `
+rclone purge "$BACKUP_REMOTE" || ! rclone lsf "$BACKUP_REMOTE" > /dev/null 2>&1
+`
+
+// SchedulesBackups returns true if the site is backed up on a schedule.
+func (wp *Wordpress) SchedulesBackups() bool {
+	return wp.Spec.Backups != nil
+}
+
+// BackupsTriggerPodTemplateSpec generates the pod template spec of the jobs of
+// the backups CronJob. They only mark the schedule, as the backups are taken
+// by the WordpressBackups which the controller creates when they're scheduled.
+func (wp *Wordpress) BackupsTriggerPodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressBackups)
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:    "trigger",
+			Image:   options.RcloneImage,
+			Command: []string{"/bin/true"},
+		},
+	}
+
+	return out
+}
+
+// ScheduledBackup returns the WordpressBackup scheduled at the given time. It's
+// named after the time, so it's created once.
+func (wp *Wordpress) ScheduledBackup(scheduledAt time.Time) *wordpressv1alpha1.WordpressBackup {
+	spec := wp.Spec.Backups
+
+	return &wordpressv1alpha1.WordpressBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", wp.ComponentName(WordpressBackups), scheduledAt.Unix()/60),
+			Namespace: wp.Namespace,
+			Labels:    wp.ComponentLabels(WordpressBackups),
+		},
+		Spec: wordpressv1alpha1.WordpressBackupSpec{
+			SiteRef:         corev1.LocalObjectReference{Name: wp.Name},
+			Destination:     *spec.Destination.DeepCopy(),
+			IncludeDatabase: spec.IncludeDatabase,
+			IncludeMedia:    spec.IncludeMedia,
+			DeleteArtifacts: spec.Retention != nil,
+			Encryption:      spec.Encryption.DeepCopy(),
+		},
+	}
+}
+
+// HasPersistentMedia returns true if the site's media files are stored in a
+// persistent volume or in a bucket, so they can be backed up. The claims of
+// the StatefulSet's pods are not reachable.
+func (wp *Wordpress) HasPersistentMedia() bool {
+	media := wp.Spec.MediaVolumeSpec
+	if media == nil {
+		return false
+	}
+
+	return wp.hasMediaBucket() || media.HostPath != nil || (media.PersistentVolumeClaim != nil && !wp.IsStatefulSet())
+}
+
+// BackupDatabasePodTemplateSpec generates the pod template spec of the job
+// which dumps the site's database and uploads it to the backup's destination.
+func (wp *Wordpress) BackupDatabasePodTemplateSpec(backup *wordpressv1alpha1.WordpressBackup) corev1.PodTemplateSpec {
+	mount := corev1.VolumeMount{Name: backupVolumeName, MountPath: backupMountPath}
+
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", backupDumpScript)
+	out.Spec.Containers[0].VolumeMounts = append(out.Spec.Containers[0].VolumeMounts, mount)
+	out.Spec.Volumes = append(out.Spec.Volumes, corev1.Volume{
+		Name:         backupVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+
+	remote, env := backupRclone(backup)
+	env = append(env, corev1.EnvVar{Name: "BACKUP_REMOTE", Value: remote})
+	env = append(env, backupEncryptionEnv(backup)...)
+
+	out.Spec.Containers = append(out.Spec.Containers, corev1.Container{
+		Name:         BackupUploadContainerName,
+		Image:        options.RcloneImage,
+		Command:      []string{"/bin/sh", "-c", backupScript(backup, backupUploadDatabaseScript)},
+		Env:          env,
+		VolumeMounts: []corev1.VolumeMount{mount},
+	})
+
+	return out
+}
+
+// BackupMediaPodTemplateSpec generates the pod template spec of the job which
+// copies the site's media files, from their volume or bucket, to the backup's
+// destination.
+func (wp *Wordpress) BackupMediaPodTemplateSpec(backup *wordpressv1alpha1.WordpressBackup) (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.JobPodLabels()
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	remote, env := backupRclone(backup)
+	env = append(env, corev1.EnvVar{Name: "BACKUP_REMOTE", Value: remote})
+	env = append(env, backupEncryptionEnv(backup)...)
+
+	upload := corev1.Container{
+		Name:    BackupUploadContainerName,
+		Image:   options.RcloneImage,
+		Command: []string{"/bin/sh", "-c", backupUploadMediaScript},
+	}
+
+	// the media is archived in the backup volume before it's encrypted
+	if backup.Spec.Encryption != nil {
+		upload.Command = []string{"/bin/sh", "-c", backupScript(backup, backupUploadEncryptedMediaScript)}
+		upload.VolumeMounts = []corev1.VolumeMount{{Name: backupVolumeName, MountPath: backupMountPath}}
+		out.Spec.Volumes = []corev1.Volume{
+			{
+				Name:         backupVolumeName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			},
+		}
+	}
+
+	media := wp.Spec.MediaVolumeSpec
+
+	if wp.hasMediaBucket() {
+		source, mediaEnv := bucketRclone("media", media.S3VolumeSource, media.GCSVolumeSource)
+		env = append(env, mediaEnv...)
+		env = append(env, corev1.EnvVar{Name: "MEDIA_SOURCE", Value: source})
+	} else {
+		env = append(env, corev1.EnvVar{Name: "MEDIA_SOURCE", Value: backupMediaMountPath})
+		upload.VolumeMounts = append(upload.VolumeMounts, corev1.VolumeMount{
+			Name:      mediaVolumeName,
+			MountPath: backupMediaMountPath,
+			SubPath:   media.ContentSubPath,
+			ReadOnly:  true,
+		})
+		out.Spec.Volumes = append(out.Spec.Volumes, wp.mediaVolume())
+	}
+
+	upload.Env = env
+	out.Spec.Containers = []corev1.Container{upload}
+
+	return out
+}
+
+// BackupPurgePodTemplateSpec generates the pod template spec of the job which
+// deletes the backup's artifacts from its destination.
+func BackupPurgePodTemplateSpec(backup *wordpressv1alpha1.WordpressBackup) (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	remote, env := backupRclone(backup)
+	env = append(env, corev1.EnvVar{Name: "BACKUP_REMOTE", Value: remote})
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:    "purge",
+			Image:   options.RcloneImage,
+			Command: []string{"/bin/sh", "-c", backupPurgeScript},
+			Env:     env,
+		},
+	}
+
+	return out
+}
+
+var errBackupEncryptionMethod = errors.New(
+	"the encryption must set exactly one of recipients, gpgPublicKeySecretRef and passphraseSecretRef")
+
+// BackupEncryptionMethod returns the method the backup's artifacts are
+// encrypted with, or an empty one if they're not encrypted.
+func BackupEncryptionMethod(backup *wordpressv1alpha1.WordpressBackup) (wordpressv1alpha1.BackupEncryptionMethod, error) {
+	enc := backup.Spec.Encryption
+	if enc == nil {
+		return "", nil
+	}
+
+	methods := []wordpressv1alpha1.BackupEncryptionMethod{}
+
+	if len(enc.Recipients) > 0 {
+		methods = append(methods, wordpressv1alpha1.BackupEncryptionAge)
+	}
+
+	if enc.GPGPublicKeySecretRef != nil {
+		methods = append(methods, wordpressv1alpha1.BackupEncryptionGPG)
+	}
+
+	if enc.PassphraseSecretRef != nil {
+		methods = append(methods, wordpressv1alpha1.BackupEncryptionPassphrase)
+	}
+
+	if len(methods) != 1 {
+		return "", errBackupEncryptionMethod
+	}
+
+	return methods[0], nil
+}
+
+// backupEncryptionExtension returns the extension of the encrypted artifacts.
+func backupEncryptionExtension(method wordpressv1alpha1.BackupEncryptionMethod) string {
+	if method == wordpressv1alpha1.BackupEncryptionAge {
+		return ".age"
+	}
+
+	return ".gpg"
+}
+
+// backupEncryptionEnv returns the env vars configuring the encryption of the
+// backup's artifacts.
+func backupEncryptionEnv(backup *wordpressv1alpha1.WordpressBackup) []corev1.EnvVar {
+	method, err := BackupEncryptionMethod(backup)
+	if err != nil || method == "" {
+		return nil
+	}
+
+	enc := backup.Spec.Encryption
+	env := []corev1.EnvVar{
+		{Name: "BACKUP_ENCRYPTION", Value: string(method)},
+		{Name: "BACKUP_EXTENSION", Value: backupEncryptionExtension(method)},
+	}
+
+	switch method {
+	case wordpressv1alpha1.BackupEncryptionAge:
+		env = append(env, corev1.EnvVar{Name: "BACKUP_AGE_RECIPIENTS", Value: strings.Join(enc.Recipients, "\n")})
+	case wordpressv1alpha1.BackupEncryptionGPG:
+		env = append(env, corev1.EnvVar{
+			Name:      "BACKUP_GPG_PUBLIC_KEY",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: enc.GPGPublicKeySecretRef},
+		})
+	case wordpressv1alpha1.BackupEncryptionPassphrase:
+		env = append(env, corev1.EnvVar{
+			Name:      "BACKUP_PASSPHRASE",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: enc.PassphraseSecretRef},
+		})
+	}
+
+	return env
+}
+
+// backupScript prepends the encryption tools to the upload script, if the
+// backup is encrypted.
+func backupScript(backup *wordpressv1alpha1.WordpressBackup, script string) string {
+	if backup.Spec.Encryption == nil {
+		return script
+	}
+
+	return backupEncryptScript + script
+}
+
+// BackupLocation returns the URL of a backup's artifact.
+func BackupLocation(backup *wordpressv1alpha1.WordpressBackup, artifact string) string {
+	method, _ := BackupEncryptionMethod(backup)
+
+	switch {
+	case artifact == BackupDatabaseArtifact && method != "":
+		artifact = backupDatabaseFile + backupEncryptionExtension(method)
+	case artifact == BackupDatabaseArtifact:
+		artifact = backupDatabaseFile
+	case artifact == BackupMediaArtifact && method != "":
+		artifact = backupMediaArchive + backupEncryptionExtension(method)
+	}
+
+	dest := backup.Spec.Destination
+	if dest.S3 != nil {
+		return "s3://" + path.Join(dest.S3.Bucket, dest.S3.PathPrefix, BackupPath(backup), artifact)
+	}
+
+	return "gs://" + path.Join(dest.GCS.Bucket, dest.GCS.PathPrefix, BackupPath(backup), artifact)
+}
+
+// ParseBackupArtifact parses an artifact reported by a backup job.
+func ParseBackupArtifact(backup *wordpressv1alpha1.WordpressBackup, report string) (*wordpressv1alpha1.BackupArtifact, error) {
+	parts := strings.Split(strings.TrimSpace(report), "|")
+	if len(parts) != 4 && len(parts) != 5 {
+		return nil, fmt.Errorf("invalid backup artifact report: %q", report)
+	}
+
+	size, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := &wordpressv1alpha1.BackupArtifact{
+		Name:     parts[0],
+		Location: BackupLocation(backup, parts[0]),
+		Size:     size,
+		Objects:  objects,
+		Checksum: parts[3],
+	}
+
+	// the keys are reported by the encrypted artifacts, e.g. the GPG key's fingerprint
+	if method, _ := BackupEncryptionMethod(backup); method != "" {
+		artifact.Encryption = &wordpressv1alpha1.BackupArtifactEncryption{Method: method}
+
+		if len(parts) == 5 && parts[4] != "" {
+			artifact.Encryption.Keys = strings.Split(parts[4], ",")
+		}
+	}
+
+	return artifact, nil
+}
+
+// backupDownloadScript downloads a database artifact into the download
+// directory, as database.sql.gz, decrypting it if needed.
+const backupDownloadScript = `
+set -e
+
+file="$DOWNLOAD_DIR/$BACKUP_ARTIFACT"
+rclone copyto "$BACKUP_REMOTE/$BACKUP_ARTIFACT" "$file"
+
+case "$BACKUP_ENCRYPTION" in
+age)
+    apk add --no-cache age > /dev/null
+    printf '%s\n' "$BACKUP_DECRYPTION_KEY" > /tmp/identity
+    age --decrypt -i /tmp/identity -o "$DOWNLOAD_DIR/database.sql.gz" "$file"
+    ;;
+gpg)
+    apk add --no-cache gnupg > /dev/null
+    printf '%s\n' "$BACKUP_DECRYPTION_KEY" | gpg --batch --import
+    gpg --batch --output "$DOWNLOAD_DIR/database.sql.gz" --decrypt "$file"
+    ;;
+passphrase)
+    apk add --no-cache gnupg > /dev/null
+    printf '%s' "$BACKUP_DECRYPTION_KEY" > /tmp/passphrase
+    gpg --batch --pinentry-mode loopback --passphrase-file /tmp/passphrase \
+        --output "$DOWNLOAD_DIR/database.sql.gz" --decrypt "$file"
+    ;;
+esac
+`
+
+// backupDownloadContainer returns the container downloading the database
+// artifact, from the rclone remote of its prefix, into the volume. The env
+// configures the remote and, for the encrypted artifacts, sets
+// BACKUP_ENCRYPTION and BACKUP_DECRYPTION_KEY.
+func backupDownloadContainer(remote, artifact string, env []corev1.EnvVar, mount corev1.VolumeMount) corev1.Container {
+	env = append(env,
+		corev1.EnvVar{Name: "BACKUP_REMOTE", Value: remote},
+		corev1.EnvVar{Name: "BACKUP_ARTIFACT", Value: artifact},
+		corev1.EnvVar{Name: "DOWNLOAD_DIR", Value: mount.MountPath},
+	)
+
+	return corev1.Container{
+		Name:         "download",
+		Image:        options.RcloneImage,
+		Command:      []string{"/bin/sh", "-c", backupDownloadScript},
+		Env:          env,
+		VolumeMounts: []corev1.VolumeMount{mount},
+	}
+}
+
+// backupRclone returns the rclone remote of the backup's prefix within its
+// destination, along with the env vars configuring it.
+func backupRclone(backup *wordpressv1alpha1.WordpressBackup) (string, []corev1.EnvVar) {
+	dest := backup.Spec.Destination
+	remote, env := bucketRclone("backup", dest.S3, dest.GCS)
+
+	return path.Join(remote, BackupPath(backup)), env
+}
+
+// BackupPath returns the prefix of the backup's artifacts within its
+// destination. It's namespaced, as the destinations may be shared by the
+// backups of several namespaces.
+func BackupPath(backup *wordpressv1alpha1.WordpressBackup) string {
+	return path.Join(backup.Namespace, backup.Name)
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+// backupGCOrphans is the awk program listing the orphaned backup prefixes,
+// given the kept ones and the destination's files, as their modification
+// time and path, separated by '|'. The backups are uploaded under their
+// namespace and name, e.g. default/mysite-backups-28000000/database.sql.gz.
+// A prefix is orphaned if it holds nothing but backup artifacts, it's not
+// kept and it was not written to since the cutoff, e.g. as a backup is being
+// uploaded.
+const backupGCOrphans = `
+NR == FNR { keep[$0] = 1; next }
+{
+    if (split($2, parts, "/") < 3) next
+
+    prefix = parts[1] "/" parts[2]
+    file = substr($2, length(prefix) + 2)
+
+    if (!(prefix in newest) || $1 > newest[prefix]) newest[prefix] = $1
+    if (file !~ /^(database\.sql|media\.tar)\.gz(\.age|\.gpg)?$/ && file !~ /^media\//) foreign[prefix] = 1
+}
+END {
+    for (p in newest) if (!(p in keep) && !(p in foreign) && newest[p] < cutoff) print p
+}
+`
+
+// backupGCScript deletes the orphaned backup prefixes of a destination.
+const backupGCScript = `
+set -e
+
+cutoff=$(date -u -d "@$(( $(date +%s) - GC_GRACE_SECONDS ))" '+%Y-%m-%d %H:%M:%S')
+printf '%s\n' "$GC_KEEP" > /tmp/keep
+
+rclone lsf -R --files-only --format tp --separator '|' "$GC_REMOTE" > /tmp/files
+
+awk -F '|' -v cutoff="$cutoff" '` + backupGCOrphans + `' /tmp/keep /tmp/files > /tmp/orphans
+
+while read -r prefix; do
+    echo "deleting the orphaned $prefix backup"
+    rclone purge "$GC_REMOTE/$prefix"
+done < /tmp/orphans
+`
+
+// BackupDestinationURL returns the URL of the prefix the backups are uploaded
+// under, within the destination.
+func BackupDestinationURL(dest *wordpressv1alpha1.BackupDestination) string {
+	if dest.S3 != nil {
+		return "s3://" + path.Join(dest.S3.Bucket, dest.S3.PathPrefix)
+	}
+
+	return "gs://" + path.Join(dest.GCS.Bucket, dest.GCS.PathPrefix)
+}
+
+// BackupGCPodTemplateSpec generates the pod template spec of the job which
+// deletes, from the destination, the artifacts of the backups which are not
+// kept, e.g. as they were deleted along with their namespace. Only the backup
+// prefixes left untouched for the grace period are deleted.
+func BackupGCPodTemplateSpec(dest *wordpressv1alpha1.BackupDestination, keep []string,
+	grace time.Duration) (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	names := append([]string{}, keep...)
+	sort.Strings(names)
+
+	remote, env := bucketRclone("backup", dest.S3, dest.GCS)
+	env = append(env,
+		corev1.EnvVar{Name: "GC_REMOTE", Value: remote},
+		corev1.EnvVar{Name: "GC_KEEP", Value: strings.Join(names, "\n")},
+		corev1.EnvVar{Name: "GC_GRACE_SECONDS", Value: strconv.Itoa(int(grace.Seconds()))},
+	)
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:    "gc",
+			Image:   options.RcloneImage,
+			Command: []string{"/bin/sh", "-c", backupGCScript},
+			Env:     env,
+		},
+	}
+
+	return out
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2019 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("The backup GC orphans program", func() {
+	var (
+		dir  string
+		keep []string
+	)
+
+	orphans := func(cutoff string, files ...string) []string {
+		awk, err := exec.LookPath("awk")
+		if err != nil {
+			Skip("awk is not available")
+		}
+
+		keepFile := filepath.Join(dir, "keep")
+		Expect(os.WriteFile(keepFile, []byte(strings.Join(keep, "\n")+"\n"), 0o600)).To(Succeed())
+
+		filesFile := filepath.Join(dir, "files")
+		Expect(os.WriteFile(filesFile, []byte(strings.Join(files, "\n")+"\n"), 0o600)).To(Succeed())
+
+		out, err := exec.Command(awk, "-F", "|", "-v", "cutoff="+cutoff, backupGCOrphans, keepFile, filesFile).Output()
+		Expect(err).NotTo(HaveOccurred())
+
+		return strings.Fields(string(out))
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "backup-gc")
+		Expect(err).NotTo(HaveOccurred())
+
+		keep = []string{"default/nightly"}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should list the backups which are not kept", func() {
+		Expect(orphans("2021-10-02 00:00:00",
+			"2021-10-01 00:00:00|default/nightly/database.sql.gz",
+			"2021-10-01 00:00:00|default/weekly/database.sql.gz.age",
+			"2021-10-01 00:00:00|default/weekly/media/2021/10/a.jpg",
+		)).To(ConsistOf("default/weekly"))
+	})
+
+	It("should tell apart the same-named backups of several namespaces", func() {
+		Expect(orphans("2021-10-02 00:00:00",
+			"2021-10-01 00:00:00|default/nightly/database.sql.gz",
+			"2021-10-01 00:00:00|other/nightly/database.sql.gz",
+		)).To(ConsistOf("other/nightly"))
+	})
+
+	It("should not list the backups written to within the grace period", func() {
+		Expect(orphans("2021-10-02 00:00:00",
+			"2021-10-01 00:00:00|default/weekly/database.sql.gz",
+			"2021-10-02 12:00:00|default/weekly/media.tar.gz",
+		)).To(BeEmpty())
+	})
+
+	It("should not list the prefixes holding other files", func() {
+		Expect(orphans("2021-10-02 00:00:00",
+			"2021-10-01 00:00:00|default/weekly/database.sql.gz",
+			"2021-10-01 00:00:00|default/weekly/notes.txt",
+			"2021-10-01 00:00:00|default/database.sql.gz",
+			"2021-10-01 00:00:00|database.sql.gz",
+		)).To(BeEmpty())
+	})
+})
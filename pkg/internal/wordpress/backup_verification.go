@@ -0,0 +1,255 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"errors"
+	"path"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// VerifiedBackupAnnotation records, on the verification jobs, the name
+	// of the WordpressBackup they restore.
+	VerifiedBackupAnnotation = "wordpress.presslabs.org/verified-backup"
+
+	verificationVolumeName = "verification"
+	verificationMountPath  = "/verify"
+	verificationHomeURL    = "http://127.0.0.1:8080"
+)
+
+var errBackupDecryptionKey = errors.New("the backups are encrypted to a public key, so the verification's decryptionKeySecretRef must be set")
+
+// verificationDatabaseScript runs the throwaway database, listening only
+// within the pod, until the checks are done.
+const verificationDatabaseScript = `
+docker-entrypoint.sh mysqld --bind-address=127.0.0.1 &
+
+while [ ! -f /verify/.done ]; do
+    sleep 1
+done
+
+kill $!
+`
+
+// verificationScript restores the database dump into the throwaway database
+// and smoke checks the restored site, served by PHP's built-in server. It
+// reports the results through the termination message, one check|pass or
+// check|fail|message per line. A failed check doesn't fail the job.
+const verificationScript = `
+trap 'touch /verify/.done' EXIT
+
+report=$(mktemp)
+
+until wp db query 'SELECT 1' > /dev/null 2>&1; do
+    sleep 2
+done
+
+if out=$(gunzip -c /verify/database.sql.gz | wp db query 2>&1); then
+    echo "restore|pass" >> "$report"
+
+    if [ "$VERIFY_HOMEPAGE" = "true" ]; then
+        docroot=$(dirname "$(wp eval 'echo WP_CONTENT_DIR;')")
+        php -S 127.0.0.1:8080 -t "$docroot" > /dev/null 2>&1 &
+        sleep 2
+
+        code=$(curl -s -o /dev/null -w '%{http_code}' "$WP_HOME/")
+        if [ "$code" = "200" ]; then
+            echo "homepage|pass" >> "$report"
+        else
+            echo "homepage|fail|the homepage responded with $code" >> "$report"
+        fi
+    fi
+
+    if out=$(wp core verify-checksums 2>&1); then
+        echo "core-checksums|pass" >> "$report"
+    else
+        count=$(echo "$out" | grep -c '^Warning:')
+        echo "core-checksums|fail|$count core files don't match their checksums" >> "$report"
+    fi
+else
+    echo "restore|fail|$(echo "$out" | tail -n 1)" >> "$report"
+fi
+
+cat "$report"
+cp "$report" /dev/termination-log
+`
+
+// VerifiesBackups returns true if the scheduled backups are verified.
+func (wp *Wordpress) VerifiesBackups() bool {
+	return wp.SchedulesBackups() && wp.Spec.Backups.Verification != nil
+}
+
+// DatabaseArtifact returns the backup's database artifact or nil if it has
+// none.
+func DatabaseArtifact(backup *wordpressv1alpha1.WordpressBackup) *wordpressv1alpha1.BackupArtifact {
+	for i := range backup.Status.Artifacts {
+		if backup.Status.Artifacts[i].Name == BackupDatabaseArtifact {
+			return &backup.Status.Artifacts[i]
+		}
+	}
+
+	return nil
+}
+
+// CanVerifyBackup returns an error if the backup can't be decrypted by the
+// verification.
+func (wp *Wordpress) CanVerifyBackup(backup *wordpressv1alpha1.WordpressBackup) error {
+	method, err := BackupEncryptionMethod(backup)
+	if err != nil {
+		return err
+	}
+
+	if method != "" && method != wordpressv1alpha1.BackupEncryptionPassphrase && wp.Spec.Backups.Verification.DecryptionKeySecretRef == nil {
+		return errBackupDecryptionKey
+	}
+
+	return nil
+}
+
+// verificationEnv returns the env of the wp-cli container pointed to the
+// throwaway database and to the built-in server, without the object cache
+// and the page cache purging, so that the restored site doesn't touch the
+// live one. The network's homepage is not checked, as its sites are mapped
+// to their domains.
+func (wp *Wordpress) verificationEnv(env []corev1.EnvVar) []corev1.EnvVar {
+	overrides := []corev1.EnvVar{
+		{Name: "DB_HOST", Value: "127.0.0.1"},
+		{Name: "DB_PORT", Value: "3306"},
+		{Name: "DB_USER", Value: "root"},
+		{Name: "DB_PASSWORD", Value: ""},
+		{Name: "DB_NAME", Value: "wordpress"},
+		{Name: "WP_HOME", Value: verificationHomeURL},
+		{Name: "WP_SITEURL", Value: verificationHomeURL + strings.TrimPrefix(wp.SiteURL(), wp.HomeURL())},
+		{Name: "VERIFY_HOMEPAGE", Value: strconv.FormatBool(!wp.IsMultisite())},
+	}
+
+	dropped := map[string]bool{"MEMCACHED_HOST": true, "PAGE_CACHE_PURGE_URL": true}
+	for _, e := range overrides {
+		dropped[e.Name] = true
+	}
+
+	out := []corev1.EnvVar{}
+
+	for _, e := range env {
+		if !dropped[e.Name] {
+			out = append(out, e)
+		}
+	}
+
+	return append(out, overrides...)
+}
+
+// verificationDecryptionEnv returns the env vars decrypting the backup's
+// database artifact.
+func (wp *Wordpress) verificationDecryptionEnv(backup *wordpressv1alpha1.WordpressBackup) []corev1.EnvVar {
+	method, err := BackupEncryptionMethod(backup)
+	if err != nil || method == "" {
+		return nil
+	}
+
+	key := wp.Spec.Backups.Verification.DecryptionKeySecretRef
+	if method == wordpressv1alpha1.BackupEncryptionPassphrase {
+		key = backup.Spec.Encryption.PassphraseSecretRef
+	}
+
+	return []corev1.EnvVar{
+		{Name: "BACKUP_ENCRYPTION", Value: string(method)},
+		{Name: "BACKUP_DECRYPTION_KEY", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: key}},
+	}
+}
+
+// BackupVerificationPodTemplateSpec generates the pod template spec of the
+// jobs which restore the backup's database into a throwaway database, run
+// within the pod, and smoke check the restored site. Only the init
+// containers providing the code are run, as the live database is not
+// touched.
+func (wp *Wordpress) BackupVerificationPodTemplateSpec(backup *wordpressv1alpha1.WordpressBackup) corev1.PodTemplateSpec {
+	mount := corev1.VolumeMount{Name: verificationVolumeName, MountPath: verificationMountPath}
+
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", verificationScript)
+
+	wpcli := &out.Spec.Containers[0]
+	wpcli.Env = wp.verificationEnv(wpcli.Env)
+	wpcli.VolumeMounts = append(wpcli.VolumeMounts, mount)
+
+	out.Spec.Volumes = append(out.Spec.Volumes, corev1.Volume{
+		Name:         verificationVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+
+	artifact := ""
+	if a := DatabaseArtifact(backup); a != nil {
+		artifact = path.Base(a.Location)
+	}
+
+	remote, env := backupRclone(backup)
+	env = append(env, wp.verificationDecryptionEnv(backup)...)
+
+	out.Spec.InitContainers = append(wp.codeInitContainers(), backupDownloadContainer(remote, artifact, env, mount))
+
+	image := wp.Spec.Backups.Verification.DatabaseImage
+	if image == "" {
+		image = options.BackupVerificationDatabaseImage
+	}
+
+	out.Spec.Containers = append(out.Spec.Containers, corev1.Container{
+		Name:    "database",
+		Image:   image,
+		Command: []string{"/bin/sh", "-c", verificationDatabaseScript},
+		Env: []corev1.EnvVar{
+			{Name: "MYSQL_ALLOW_EMPTY_PASSWORD", Value: "yes"},
+			{Name: "MYSQL_DATABASE", Value: "wordpress"},
+			{Name: "MARIADB_ALLOW_EMPTY_ROOT_PASSWORD", Value: "yes"},
+			{Name: "MARIADB_DATABASE", Value: "wordpress"},
+		},
+		VolumeMounts: []corev1.VolumeMount{mount},
+	})
+
+	return out
+}
+
+// ParseBackupVerification parses the results reported by a verification job.
+func ParseBackupVerification(report []string) []wordpressv1alpha1.BackupVerificationCheckResult {
+	results := []wordpressv1alpha1.BackupVerificationCheckResult{}
+
+	for _, line := range report {
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) < 2 {
+			continue
+		}
+
+		result := wordpressv1alpha1.BackupVerificationCheckResult{
+			Name:   parts[0],
+			Passed: parts[1] == "pass",
+		}
+
+		if len(parts) == 3 {
+			result.Message = parts[2]
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
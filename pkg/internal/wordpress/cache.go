@@ -0,0 +1,430 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// MemcachedPort is the port memcached listens on.
+	MemcachedPort = 11211
+
+	// PageCacheHTTPPort is the port Varnish listens on.
+	PageCacheHTTPPort = 8080
+
+	// PageCachePurgeTokenKey is the key of the page cache's purge token
+	// within the site's secret.
+	PageCachePurgeTokenKey = "PAGE_CACHE_PURGE_TOKEN"
+
+	defaultPageCacheTTL           = int32(120)
+	defaultCacheWarmupConcurrency = int32(4)
+)
+
+var (
+	defaultMemcachedMemory = resource.MustParse("64Mi")
+	defaultPageCacheMemory = resource.MustParse("256Mi")
+)
+
+// varnish is configured through the env vars, so the purge token is read
+// from the site's secret. The cached objects keep the host and url they were
+// fetched for, to be banned by PURGE requests.
+const pageCacheScript = `#!/bin/sh
+set -e
+
+cat > /tmp/default.vcl <<EOF
+vcl 4.1;
+
+backend default {
+    .host = "$BACKEND_HOST";
+    .port = "$BACKEND_PORT";
+}
+
+sub vcl_recv {
+    if (req.method == "PURGE") {
+        if (req.http.X-Purge-Token != "$PURGE_TOKEN") {
+            return (synth(403, "Forbidden"));
+        }
+        if (req.http.X-Purge-Method == "regex") {
+            ban("obj.http.x-host == " + req.http.host + " && obj.http.x-url ~ " + req.url);
+        } else {
+            ban("obj.http.x-host == " + req.http.host + " && obj.http.x-url == " + req.url);
+        }
+        return (synth(200, "Purged"));
+    }
+
+    if (req.method != "GET" && req.method != "HEAD") {
+        return (pass);
+    }
+
+    if (req.url ~ "wp-admin|wp-login\.php|wp-cron\.php|xmlrpc\.php|preview=true") {
+        return (pass);
+    }
+
+    if (req.http.Cookie ~ "wordpress_logged_in_|wordpress_sec_|wp-postpass_|comment_author_|woocommerce_") {
+        return (pass);
+    }
+
+    unset req.http.Cookie;
+    return (hash);
+}
+
+sub vcl_backend_response {
+    set beresp.http.x-host = bereq.http.host;
+    set beresp.http.x-url = bereq.url;
+}
+
+sub vcl_deliver {
+    unset resp.http.x-host;
+    unset resp.http.x-url;
+}
+EOF
+
+exec varnishd -F -f /tmp/default.vcl -a ":$HTTP_PORT" -s "malloc,$CACHE_SIZE" -t "$DEFAULT_TTL"
+`
+
+// UsesMemcached returns true if the site's object cache is stored in memcached.
+func (wp *Wordpress) UsesMemcached() bool {
+	return wp.Spec.Cache != nil && wp.Spec.Cache.Memcached != nil
+}
+
+// SharesMemcached returns true if memcached runs as a Deployment shared by
+// all the site's pods, instead of a sidecar of each web pod.
+func (wp *Wordpress) SharesMemcached() bool {
+	return wp.UsesMemcached() && wp.Spec.Cache.Memcached.Mode == wordpressv1alpha1.MemcachedShared
+}
+
+// the page cache is purged for each of the site's domains
+const cacheFlushScript = `
+set -e
+
+wp cache flush
+
+if [ -n "$PAGE_CACHE_PURGE_URL" ]; then
+    for host in $PAGE_CACHE_HOSTS; do
+        curl -fsS -o /dev/null -X PURGE -H "Host: $host" -H "X-Purge-Token: $PAGE_CACHE_PURGE_TOKEN" -H "X-Purge-Method: regex" "$PAGE_CACHE_PURGE_URL/.*"
+    done
+fi
+`
+
+// the pages listed by the sitemap are fetched in parallel. The sitemaps listed
+// by a sitemap index are followed, one level deep.
+const cacheWarmupScript = `
+set -e
+
+locs() {
+    curl -fsSL "$1" | grep -o '<loc>[^<]*</loc>' | sed -e 's|<loc>||' -e 's|</loc>||'
+}
+
+for url in $(locs "$SITEMAP_URL"); do
+    case "$url" in
+        *.xml) locs "$url" ;;
+        *) echo "$url" ;;
+    esac
+done | xargs -n 1 -P "$CONCURRENCY" curl -s -o /dev/null
+`
+
+// FlushesCacheOnRollout returns true if the caches are flushed once the web
+// pods are rolled out with a new code version.
+func (wp *Wordpress) FlushesCacheOnRollout() bool {
+	return wp.Spec.Cache != nil && wp.Spec.Cache.FlushOnRollout
+}
+
+// WarmsUpCacheOnSchedule returns true if the caches are warmed up on a schedule.
+func (wp *Wordpress) WarmsUpCacheOnSchedule() bool {
+	return wp.Spec.Cache != nil && wp.Spec.Cache.Warmup != nil && wp.Spec.Cache.Warmup.Schedule != ""
+}
+
+func (wp *Wordpress) cacheWarmupEnv() []corev1.EnvVar {
+	warmup := wp.Spec.Cache.Warmup
+
+	sitemapURL := warmup.SitemapURL
+	if sitemapURL == "" {
+		sitemapURL = wp.HomeURL("wp-sitemap.xml")
+	}
+
+	concurrency := defaultCacheWarmupConcurrency
+	if warmup.Concurrency != nil {
+		concurrency = *warmup.Concurrency
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "SITEMAP_URL",
+			Value: sitemapURL,
+		},
+		{
+			Name:  "CONCURRENCY",
+			Value: strconv.Itoa(int(concurrency)),
+		},
+	}
+}
+
+// CacheFlushPodTemplateSpec generates the pod template spec of the job which
+// flushes the object cache, purges the page cache and warms them up.
+func (wp *Wordpress) CacheFlushPodTemplateSpec() corev1.PodTemplateSpec {
+	script := cacheFlushScript
+	env := []corev1.EnvVar{
+		{
+			Name:  "PAGE_CACHE_HOSTS",
+			Value: strings.Join(wp.Domains(), " "),
+		},
+	}
+
+	if wp.Spec.Cache.Warmup != nil {
+		script += cacheWarmupScript
+		env = append(env, wp.cacheWarmupEnv()...)
+	}
+
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", script)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env, env...)
+
+	return out
+}
+
+// CacheWarmupPodTemplateSpec generates the pod template spec of the job which
+// warms up the caches. The pages are fetched using the runtime image's curl.
+func (wp *Wordpress) CacheWarmupPodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressCacheWarmup)
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+	out.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:    "cache-warmup",
+			Image:   wp.Spec.Image,
+			Command: []string{"/bin/sh", "-c", cacheWarmupScript},
+			Env:     wp.cacheWarmupEnv(),
+		},
+	}
+
+	return out
+}
+
+// UsesPageCache returns true if the site is served through the full-page cache.
+func (wp *Wordpress) UsesPageCache() bool {
+	return wp.Spec.Cache != nil && wp.Spec.Cache.Page != nil
+}
+
+// cacheEnv points the runtime to the shared memcached and to the page
+// cache's purge endpoint, which are reachable from the jobs too. The purge
+// token is set into the site's secret.
+func (wp *Wordpress) cacheEnv() []corev1.EnvVar {
+	out := []corev1.EnvVar{}
+
+	if wp.SharesMemcached() {
+		out = append(out, corev1.EnvVar{
+			Name:  "MEMCACHED_HOST",
+			Value: fmt.Sprintf("%s.%s:%d", wp.ComponentName(WordpressMemcachedService), wp.Namespace, MemcachedPort),
+		})
+	}
+
+	if wp.UsesPageCache() {
+		out = append(out, corev1.EnvVar{
+			Name:  "PAGE_CACHE_PURGE_URL",
+			Value: fmt.Sprintf("http://%s.%s", wp.ComponentName(WordpressPageCacheService), wp.Namespace),
+		})
+	}
+
+	return out
+}
+
+// webCacheEnv points the wordpress container of the web pods to the memcached sidecar.
+func (wp *Wordpress) webCacheEnv() []corev1.EnvVar {
+	if !wp.UsesMemcached() || wp.SharesMemcached() {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "MEMCACHED_HOST",
+			Value: fmt.Sprintf("127.0.0.1:%d", MemcachedPort),
+		},
+	}
+}
+
+// megabytes returns the given quantity in megabytes, rounded down to at least one.
+func megabytes(q resource.Quantity) int64 {
+	mb := q.Value() >> 20
+	if mb < 1 {
+		return 1
+	}
+
+	return mb
+}
+
+func (wp *Wordpress) memcachedContainer(listen string) corev1.Container {
+	spec := wp.Spec.Cache.Memcached
+
+	image := spec.Image
+	if image == "" {
+		image = options.MemcachedImage
+	}
+
+	memory := defaultMemcachedMemory
+	if spec.Memory != nil {
+		memory = *spec.Memory
+	}
+
+	return corev1.Container{
+		Name:  "memcached",
+		Image: image,
+		Args: []string{
+			"--memory-limit=" + strconv.FormatInt(megabytes(memory), 10),
+			"--port=" + strconv.Itoa(MemcachedPort),
+			"--listen=" + listen,
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "memcached",
+				ContainerPort: MemcachedPort,
+			},
+		},
+		Resources: spec.Resources,
+	}
+}
+
+// memcachedContainers returns the memcached sidecar of the web pods, which
+// only listens on the loopback interface.
+func (wp *Wordpress) memcachedContainers() []corev1.Container {
+	if !wp.UsesMemcached() || wp.SharesMemcached() {
+		return nil
+	}
+
+	return []corev1.Container{wp.memcachedContainer("127.0.0.1")}
+}
+
+// MemcachedPodTemplateSpec generates the pod template spec of the shared memcached.
+func (wp *Wordpress) MemcachedPodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressMemcachedDeployment)
+
+	container := wp.memcachedContainer("0.0.0.0")
+	container.ReadinessProbe = &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{
+				Port: intstr.FromString("memcached"),
+			},
+		},
+	}
+
+	out.Spec.Containers = []corev1.Container{container}
+	out.Spec.NodeSelector = wp.Spec.NodeSelector
+	out.Spec.Tolerations = wp.Spec.Tolerations
+
+	return out
+}
+
+// pageCacheBackend returns the address of the service the page cache fetches
+// the pages from. When scaling on HTTP traffic, the requests are routed
+// through the KEDA interceptor.
+func (wp *Wordpress) pageCacheBackend() (string, int) {
+	if wp.Spec.ScaleToZero != nil && wp.Spec.ScaleToZero.HTTP != nil {
+		return wp.ComponentName(WordpressInterceptorService), options.KEDAHTTPInterceptorPort
+	}
+
+	return wp.ComponentName(WordpressService), 80
+}
+
+// PageCachePodTemplateSpec generates the pod template spec of the page cache.
+func (wp *Wordpress) PageCachePodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressPageCacheDeployment)
+
+	spec := wp.Spec.Cache.Page
+
+	image := spec.Image
+	if image == "" {
+		image = options.VarnishImage
+	}
+
+	memory := defaultPageCacheMemory
+	if spec.Memory != nil {
+		memory = *spec.Memory
+	}
+
+	ttl := defaultPageCacheTTL
+	if spec.TTLSeconds != nil {
+		ttl = *spec.TTLSeconds
+	}
+
+	host, port := wp.pageCacheBackend()
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:  "varnish",
+			Image: image,
+			Args:  []string{"/bin/sh", "-c", pageCacheScript},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "BACKEND_HOST",
+					Value: host,
+				},
+				{
+					Name:  "BACKEND_PORT",
+					Value: strconv.Itoa(port),
+				},
+				{
+					Name:  "HTTP_PORT",
+					Value: strconv.Itoa(PageCacheHTTPPort),
+				},
+				{
+					Name:  "CACHE_SIZE",
+					Value: strconv.FormatInt(megabytes(memory), 10) + "M",
+				},
+				{
+					Name:  "DEFAULT_TTL",
+					Value: strconv.Itoa(int(ttl)),
+				},
+				secretKeyEnvVar("PURGE_TOKEN", wp.ComponentName(WordpressSecret), PageCachePurgeTokenKey),
+			},
+			Ports: []corev1.ContainerPort{
+				{
+					Name:          "http",
+					ContainerPort: PageCacheHTTPPort,
+				},
+			},
+			ReadinessProbe: &corev1.Probe{
+				Handler: corev1.Handler{
+					TCPSocket: &corev1.TCPSocketAction{
+						Port: intstr.FromString("http"),
+					},
+				},
+			},
+			Resources: spec.Resources,
+		},
+	}
+	out.Spec.NodeSelector = wp.Spec.NodeSelector
+	out.Spec.Tolerations = wp.Spec.Tolerations
+
+	return out
+}
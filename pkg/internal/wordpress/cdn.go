@@ -0,0 +1,120 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+// the scripts purging the whole CDN, using the credentials from the CDN's
+// secret, passed as env vars
+var cdnPurgeScripts = map[wordpressv1alpha1.CDNProvider]string{
+	wordpressv1alpha1.CDNProviderCloudflare: `curl -fsS -X POST "https://api.cloudflare.com/client/v4/zones/$CDN_ZONE/purge_cache" ` +
+		`-H "Authorization: Bearer $API_TOKEN" -H "Content-Type: application/json" --data '{"purge_everything":true}'`,
+	wordpressv1alpha1.CDNProviderFastly:     `curl -fsS -X POST "https://api.fastly.com/service/$CDN_ZONE/purge_all" -H "Fastly-Key: $API_TOKEN"`,
+	wordpressv1alpha1.CDNProviderCloudFront: `aws cloudfront create-invalidation --distribution-id "$CDN_ZONE" --paths "/*"`,
+}
+
+// PurgesCDNOnRollout returns true if the CDN is purged once the web pods are
+// rolled out with a new code version.
+func (wp *Wordpress) PurgesCDNOnRollout() bool {
+	return wp.Spec.CDN != nil && (wp.Spec.CDN.PurgeOnRollout == nil || *wp.Spec.CDN.PurgeOnRollout)
+}
+
+// cdnEnv returns the env vars pointing the runtime to the site's CDN.
+func (wp *Wordpress) cdnEnv() []corev1.EnvVar {
+	if wp.Spec.CDN == nil {
+		return nil
+	}
+
+	out := []corev1.EnvVar{
+		{
+			Name:  "CDN_PROVIDER",
+			Value: string(wp.Spec.CDN.Provider),
+		},
+		{
+			Name:  "CDN_ZONE",
+			Value: wp.Spec.CDN.Zone,
+		},
+	}
+
+	if wp.Spec.CDN.Host != "" {
+		out = append(out, corev1.EnvVar{
+			Name:  "CDN_HOST",
+			Value: wp.Spec.CDN.Host,
+		})
+	}
+
+	return out
+}
+
+// cdnEnvFrom returns the CDN's credentials, with the given prefix.
+func (wp *Wordpress) cdnEnvFrom(prefix string) []corev1.EnvFromSource {
+	if wp.Spec.CDN == nil || len(wp.Spec.CDN.CredentialsSecretRef) == 0 {
+		return nil
+	}
+
+	return []corev1.EnvFromSource{
+		{
+			Prefix: prefix,
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: string(wp.Spec.CDN.CredentialsSecretRef),
+				},
+			},
+		},
+	}
+}
+
+// CDNPurgePodTemplateSpec generates the pod template spec of the job which
+// purges the whole CDN. Cloudflare and Fastly are purged using the runtime
+// image's curl, while CloudFront is purged using the AWS CLI.
+func (wp *Wordpress) CDNPurgePodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressCDNPurge)
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+	out.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
+	out.Spec.ServiceAccountName = wp.ServiceAccountName()
+
+	image := wp.Spec.Image
+	if wp.Spec.CDN.Provider == wordpressv1alpha1.CDNProviderCloudFront {
+		image = options.AWSCLIImage
+	}
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:    "cdn-purge",
+			Image:   image,
+			Command: []string{"/bin/sh", "-c", cdnPurgeScripts[wp.Spec.CDN.Provider]},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "CDN_ZONE",
+					Value: wp.Spec.CDN.Zone,
+				},
+			},
+			EnvFrom: wp.cdnEnvFrom(""),
+		},
+	}
+
+	return out
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const defaultCleanupSchedule = "0 3 * * *"
+
+// cleanupScript runs the tasks listed in CLEANUP_TASKS and reports the number
+// of the items deleted by each of them through the termination message, one
+// task=count per line.
+const cleanupScript = `
+set -e
+
+report=$(mktemp)
+prefix=$(wp db prefix)
+
+for task in $CLEANUP_TASKS; do
+    case "$task" in
+    spam-comments)
+        ids=$(wp comment list --status=spam --format=ids)
+        count=0
+        if [ -n "$ids" ]; then
+            count=$(echo $ids | wc -w)
+            wp comment delete $ids --force
+        fi
+        ;;
+    expired-transients)
+        count=$(wp db query --skip-column-names \
+            "SELECT COUNT(*) FROM ${prefix}options WHERE option_name LIKE '\_transient\_timeout\_%' AND option_value < UNIX_TIMESTAMP()")
+        wp transient delete --expired
+        ;;
+    orphaned-postmeta)
+        count=$(wp db query --skip-column-names \
+            "SELECT COUNT(*) FROM ${prefix}postmeta pm LEFT JOIN ${prefix}posts p ON p.ID = pm.post_id WHERE p.ID IS NULL")
+        wp db query "DELETE pm FROM ${prefix}postmeta pm LEFT JOIN ${prefix}posts p ON p.ID = pm.post_id WHERE p.ID IS NULL"
+        ;;
+    esac
+
+    echo "$task=$count" >> "$report"
+done
+
+tee /dev/termination-log < "$report"
+`
+
+// SchedulesCleanup returns true if the site's content is cleaned up by a CronJob.
+func (wp *Wordpress) SchedulesCleanup() bool {
+	return wp.Spec.Cleanup != nil
+}
+
+// CleanupSchedule returns the schedule of the CronJob cleaning up the site's content.
+func (wp *Wordpress) CleanupSchedule() string {
+	if wp.Spec.Cleanup.Schedule == "" {
+		return defaultCleanupSchedule
+	}
+
+	return wp.Spec.Cleanup.Schedule
+}
+
+// CleanupTasks returns the tasks run by the cleanup.
+func (wp *Wordpress) CleanupTasks() []wordpressv1alpha1.CleanupTask {
+	if len(wp.Spec.Cleanup.Tasks) == 0 {
+		return []wordpressv1alpha1.CleanupTask{
+			wordpressv1alpha1.CleanupSpamComments,
+			wordpressv1alpha1.CleanupExpiredTransients,
+			wordpressv1alpha1.CleanupOrphanedPostMeta,
+		}
+	}
+
+	return wp.Spec.Cleanup.Tasks
+}
+
+// CleanupPodTemplateSpec generates the pod template spec of the job which
+// cleans up the site's content.
+func (wp *Wordpress) CleanupPodTemplateSpec() corev1.PodTemplateSpec {
+	tasks := []string{}
+	for _, task := range wp.CleanupTasks() {
+		tasks = append(tasks, string(task))
+	}
+
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", cleanupScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "CLEANUP_TASKS", Value: strings.Join(tasks, " ")},
+	)
+
+	return out
+}
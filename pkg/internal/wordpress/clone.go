@@ -0,0 +1,224 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	cloneVolumeName = "clone"
+	cloneMountPath  = "/clone"
+	cloneDumpFile   = "database.sql.gz"
+
+	defaultCloneDumpSize = "10Gi"
+)
+
+// cloneDumpScript exports the database into the dump volume, replacing the
+// source's URL with the target's. The source's database is left untouched.
+const cloneDumpScript = `
+set -e
+
+wp search-replace "$SOURCE_URL" "$TARGET_URL" --all-tables-with-prefix --export=/clone/database.sql
+gzip -f /clone/database.sql
+`
+
+// CloneDumpClaimName returns the name of the claim holding the clone's
+// database dump.
+func CloneDumpClaimName(clone *wordpressv1alpha1.WordpressClone) string {
+	return fmt.Sprintf("%s-dump", clone.Name)
+}
+
+// CloneDumpVolumeClaimSpec returns the spec of the claim holding the clone's
+// database dump.
+func CloneDumpVolumeClaimSpec(clone *wordpressv1alpha1.WordpressClone) corev1.PersistentVolumeClaimSpec {
+	if clone.Spec.DumpVolumeClaim != nil {
+		return *clone.Spec.DumpVolumeClaim.DeepCopy()
+	}
+
+	return corev1.PersistentVolumeClaimSpec{
+		AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse(defaultCloneDumpSize),
+			},
+		},
+	}
+}
+
+// ClonesMedia returns true if the site's media files are copied by its
+// clones. Only the media stored in a bucket is copied.
+func (wp *Wordpress) ClonesMedia() bool {
+	return wp.hasMediaBucket()
+}
+
+// CloneTarget returns the Wordpress created by the clone: a copy of the site,
+// answering to the clone's routes, importing the dump of the site's database
+// into its own database and discouraging the search engines from indexing it.
+// The integrations which would act on the site's resources (e.g. the CDN) are
+// dropped.
+func (wp *Wordpress) CloneTarget(clone *wordpressv1alpha1.WordpressClone) (*Wordpress, error) {
+	spec := wp.Spec.DeepCopy()
+
+	spec.Domains = nil
+	spec.Routes = append([]wordpressv1alpha1.RouteSpec{}, clone.Spec.Routes...)
+	spec.TLSSecretRef = ""
+	spec.CDN = nil
+	spec.Canary = nil
+	spec.Backups = nil
+
+	if spec.Multisite != nil {
+		for i := range spec.Multisite.Sites {
+			spec.Multisite.Sites[i].Domains = nil
+		}
+	}
+
+	switch {
+	case clone.Spec.Database != nil:
+		spec.Database = clone.Spec.Database.DeepCopy()
+	case wp.BootstrapsDatabase():
+		spec.Database.Name = strings.ReplaceAll(clone.Spec.TargetName, "-", "_")
+	case !wp.ProvisionsDatabase():
+		return nil, fmt.Errorf("the %s Wordpress's database is not provisioned nor bootstrapped by the operator, "+
+			"so the clone's database must be set", wp.Name)
+	}
+
+	if wp.hasMediaBucket() {
+		prefix := clone.Spec.MediaPrefix
+		if prefix == "" {
+			prefix = clone.Spec.TargetName
+		}
+
+		if spec.MediaVolumeSpec.S3VolumeSource != nil {
+			spec.MediaVolumeSpec.S3VolumeSource.PathPrefix = prefix
+		} else {
+			spec.MediaVolumeSpec.GCSVolumeSource.PathPrefix = prefix
+		}
+	}
+
+	if spec.WordpressBootstrapSpec == nil {
+		spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{}
+	}
+
+	// the content comes along with the source's database
+	spec.WordpressBootstrapSpec.ContentImport = nil
+
+	spec.WordpressBootstrapSpec.ImportFrom = &wordpressv1alpha1.DatabaseImportSource{
+		PersistentVolumeClaim: &wordpressv1alpha1.DatabaseImportVolumeSource{
+			ClaimName: CloneDumpClaimName(clone),
+			Path:      cloneDumpFile,
+		},
+	}
+
+	if spec.Options == nil {
+		spec.Options = map[string]wordpressv1alpha1.OptionValue{}
+	}
+
+	spec.Options["blog_public"] = wordpressv1alpha1.OptionValue{Value: "0"}
+
+	objLabels := map[string]string{}
+	for k, v := range wp.ObjectMeta.Labels {
+		objLabels[k] = v
+	}
+
+	target := New(&wordpressv1alpha1.Wordpress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        clone.Spec.TargetName,
+			Namespace:   clone.Namespace,
+			Labels:      objLabels,
+			Annotations: map[string]string{wordpressv1alpha1.CloneAnnotation: clone.Name},
+		},
+		Spec: *spec,
+	})
+
+	return target, nil
+}
+
+// CloneDumpPodTemplateSpec generates the pod template spec of the job which
+// exports the site's database into the clone's dump volume, with the target's
+// URL.
+func (wp *Wordpress) CloneDumpPodTemplateSpec(clone *wordpressv1alpha1.WordpressClone, target *Wordpress) corev1.PodTemplateSpec {
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", cloneDumpScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "SOURCE_URL", Value: schemelessURL(wp.HomeURL())},
+		corev1.EnvVar{Name: "TARGET_URL", Value: schemelessURL(target.HomeURL())},
+	)
+	out.Spec.Containers[0].VolumeMounts = append(out.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{Name: cloneVolumeName, MountPath: cloneMountPath},
+	)
+	out.Spec.Volumes = append(out.Spec.Volumes, corev1.Volume{
+		Name: cloneVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: CloneDumpClaimName(clone),
+			},
+		},
+	})
+
+	return out
+}
+
+// CloneMediaPodTemplateSpec generates the pod template spec of the job which
+// copies the site's media files to the target's prefix.
+func (wp *Wordpress) CloneMediaPodTemplateSpec(target *Wordpress) (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.JobPodLabels()
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	media, targetMedia := wp.Spec.MediaVolumeSpec, target.Spec.MediaVolumeSpec
+
+	source, env := bucketRclone("source", media.S3VolumeSource, media.GCSVolumeSource)
+	dest, destEnv := bucketRclone("target", targetMedia.S3VolumeSource, targetMedia.GCSVolumeSource)
+
+	env = append(env, destEnv...)
+	env = append(env,
+		corev1.EnvVar{Name: "SOURCE_MEDIA", Value: source},
+		corev1.EnvVar{Name: "TARGET_MEDIA", Value: dest},
+	)
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:    "rclone",
+			Image:   options.RcloneImage,
+			Command: []string{"rclone", "copy", "$(SOURCE_MEDIA)", "$(TARGET_MEDIA)"},
+			Env:     env,
+		},
+	}
+
+	return out
+}
+
+// schemelessURL strips the scheme of the URL, so that both the http and the
+// https URLs are replaced.
+func schemelessURL(url string) string {
+	if i := strings.Index(url, "//"); i >= 0 {
+		return url[i:]
+	}
+
+	return url
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	cloudSQLCredentialsVolumeName = "cloudsql-credentials"
+	cloudSQLCredentialsMountPath  = "/var/run/presslabs.org/cloudsql"
+	cloudSQLCredentialsKey        = "credentials.json"
+)
+
+// the proxy doesn't exit on its own, so the wp-cli Jobs stop it once the
+// command completes. The command waits for the proxy to start.
+const cloudSQLJobScript = `
+for i in $(seq 30); do
+    curl -fsS -o /dev/null http://127.0.0.1:9090/startup && break
+    sleep 1
+done
+
+"$@"
+status=$?
+
+curl -fsS -o /dev/null -X POST http://127.0.0.1:9091/quitquitquit
+exit $status
+`
+
+// UsesCloudSQL returns true if the site connects to a Cloud SQL instance
+// through the Cloud SQL Auth Proxy sidecar.
+func (wp *Wordpress) UsesCloudSQL() bool {
+	return wp.HasExternalDatabase() && wp.Spec.Database.CloudSQL != nil
+}
+
+func (wp *Wordpress) hasCloudSQLCredentials() bool {
+	return wp.UsesCloudSQL() && len(wp.Spec.Database.CloudSQL.CredentialsSecretRef) > 0
+}
+
+// cloudSQLContainers returns the Cloud SQL Auth Proxy sidecar. Within Jobs,
+// the proxy can be stopped once their command completes.
+func (wp *Wordpress) cloudSQLContainers(job bool) []corev1.Container {
+	if !wp.UsesCloudSQL() {
+		return nil
+	}
+
+	spec := wp.Spec.Database.CloudSQL
+
+	image := spec.Image
+	if image == "" {
+		image = options.CloudSQLProxyImage
+	}
+
+	args := []string{
+		"--port=" + strconv.Itoa(int(*wp.Spec.Database.Port)),
+		"--structured-logs",
+	}
+
+	if wp.hasCloudSQLCredentials() {
+		args = append(args, "--credentials-file="+cloudSQLCredentialsMountPath+"/"+cloudSQLCredentialsKey)
+	}
+
+	if spec.PrivateIP {
+		args = append(args, "--private-ip")
+	}
+
+	if job {
+		args = append(args, "--health-check", "--quitquitquit", "--exit-zero-on-sigterm")
+	}
+
+	container := corev1.Container{
+		Name:      "cloudsql-proxy",
+		Image:     image,
+		Args:      append(args, spec.InstanceConnectionName),
+		Resources: spec.Resources,
+	}
+
+	if wp.hasCloudSQLCredentials() {
+		container.VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      cloudSQLCredentialsVolumeName,
+				MountPath: cloudSQLCredentialsMountPath,
+				ReadOnly:  true,
+			},
+		}
+	}
+
+	return []corev1.Container{container}
+}
+
+func (wp *Wordpress) cloudSQLVolumes() []corev1.Volume {
+	if !wp.hasCloudSQLCredentials() {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: cloudSQLCredentialsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: string(wp.Spec.Database.CloudSQL.CredentialsSecretRef),
+					Items: []corev1.KeyToPath{
+						{
+							Key:  cloudSQLCredentialsKey,
+							Path: cloudSQLCredentialsKey,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// jobArgs returns the arguments of the wp-cli Jobs' container, which loads
+// the secrets rendered by the Vault Agent and stops the Cloud SQL Auth Proxy
+// sidecar once the command completes.
+func (wp *Wordpress) jobArgs(cmd []string) []string {
+	if wp.UsesCloudSQL() {
+		cmd = append([]string{"/bin/sh", "-c", cloudSQLJobScript, "--"}, cmd...)
+	}
+
+	if wp.UsesVault() {
+		cmd = append([]string{"/bin/sh", "-c", vaultJobScript, "--"}, cmd...)
+	}
+
+	return cmd
+}
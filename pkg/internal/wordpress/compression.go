@@ -0,0 +1,115 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// CompressionConfigKey is the key of the rendered compression config,
+	// within the config map generated for the site.
+	CompressionConfigKey = "compression.conf"
+
+	defaultGzipLevel            = int32(5)
+	defaultBrotliLevel          = int32(5)
+	defaultCompressionMinLength = int32(256)
+)
+
+var defaultCompressionTypes = []string{
+	"text/css", "text/plain", "text/xml",
+	"application/javascript", "application/json", "application/xml",
+	"application/rss+xml", "application/atom+xml",
+	"image/svg+xml", "image/x-icon",
+	"font/ttf", "font/otf", "application/vnd.ms-fontobject",
+}
+
+// ConfiguresCompression returns true if the operator renders the web
+// server's compression config.
+func (wp *Wordpress) ConfiguresCompression() bool {
+	return wp.Spec.Compression != nil
+}
+
+// CompressionConfig renders the nginx directives configuring the compression
+// of the site's responses.
+func (wp *Wordpress) CompressionConfig() string {
+	spec := wp.Spec.Compression
+
+	level := func(l, def int32) int32 {
+		if l == 0 {
+			return def
+		}
+
+		return l
+	}
+
+	minLength := spec.MinLength
+	if minLength == 0 {
+		minLength = defaultCompressionMinLength
+	}
+
+	// text/html is always compressed and nginx warns if it's listed
+	types := []string{}
+	for _, t := range spec.Types {
+		if t != "text/html" {
+			types = append(types, t)
+		}
+	}
+
+	if len(spec.Types) == 0 {
+		types = defaultCompressionTypes
+	}
+
+	var b strings.Builder
+
+	if spec.Gzip == nil || *spec.Gzip {
+		b.WriteString("gzip on;\n")
+		fmt.Fprintf(&b, "gzip_comp_level %d;\n", level(spec.GzipLevel, defaultGzipLevel))
+		fmt.Fprintf(&b, "gzip_min_length %d;\n", minLength)
+		b.WriteString("gzip_proxied any;\n")
+		b.WriteString("gzip_vary on;\n")
+		writeTypes(&b, "gzip_types", types)
+	} else {
+		b.WriteString("gzip off;\n")
+	}
+
+	if spec.Brotli {
+		b.WriteString("brotli on;\n")
+		fmt.Fprintf(&b, "brotli_comp_level %d;\n", level(spec.BrotliLevel, defaultBrotliLevel))
+		fmt.Fprintf(&b, "brotli_min_length %d;\n", minLength)
+		writeTypes(&b, "brotli_types", types)
+	}
+
+	return b.String()
+}
+
+func writeTypes(b *strings.Builder, directive string, types []string) {
+	if len(types) > 0 {
+		fmt.Fprintf(b, "%s %s;\n", directive, strings.Join(types, " "))
+	}
+}
+
+// webServerConfigMountPath returns the directory the web server config
+// snippets are mounted into.
+func (wp *Wordpress) webServerConfigMountPath() string {
+	if wp.Spec.WebServerConfig != nil {
+		return wp.Spec.WebServerConfig.MountPath
+	}
+
+	return defaultWebServerConfigMountPath
+}
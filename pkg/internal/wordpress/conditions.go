@@ -0,0 +1,87 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+// GetCondition returns the condition of the given type or nil if it is not set.
+func (wp *Wordpress) GetCondition(condType wordpressv1alpha1.WordpressConditionType) *wordpressv1alpha1.WordpressCondition {
+	for i := range wp.Status.Conditions {
+		if wp.Status.Conditions[i].Type == condType {
+			return &wp.Status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of the given type is set and true.
+func (wp *Wordpress) IsConditionTrue(condType wordpressv1alpha1.WordpressConditionType) bool {
+	cond := wp.GetCondition(condType)
+
+	return cond != nil && cond.Status == corev1.ConditionTrue
+}
+
+// SetCondition sets the condition of the given type. The condition is left
+// untouched if neither its status, reason nor message changed.
+func (wp *Wordpress) SetCondition(condType wordpressv1alpha1.WordpressConditionType, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+
+	cond := wp.GetCondition(condType)
+	if cond == nil {
+		wp.Status.Conditions = append(wp.Status.Conditions, wordpressv1alpha1.WordpressCondition{
+			Type:               condType,
+			LastTransitionTime: now,
+		})
+		cond = &wp.Status.Conditions[len(wp.Status.Conditions)-1]
+	}
+
+	if cond.Status == status && cond.Reason == reason && cond.Message == message {
+		return
+	}
+
+	if cond.Status != status {
+		cond.LastTransitionTime = now
+	}
+
+	cond.Status = status
+	cond.Reason = reason
+	cond.Message = message
+	cond.LastUpdateTime = now
+}
+
+// RemoveCondition removes the condition of the given type.
+func (wp *Wordpress) RemoveCondition(condType wordpressv1alpha1.WordpressConditionType) {
+	if wp.GetCondition(condType) == nil {
+		return
+	}
+
+	conditions := []wordpressv1alpha1.WordpressCondition{}
+
+	for _, cond := range wp.Status.Conditions {
+		if cond.Type != condType {
+			conditions = append(conditions, cond)
+		}
+	}
+
+	wp.Status.Conditions = conditions
+}
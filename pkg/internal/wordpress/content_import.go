@@ -0,0 +1,160 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	contentImportVolumeName = "content-import"
+	contentImportMountPath  = "/var/run/presslabs.org/content"
+
+	// contentImportedOption records that the content was imported, so that
+	// it's imported once.
+	contentImportedOption = "wordpress_operator_content_imported"
+)
+
+// contentImportScript imports the WXR file with the WordPress Importer,
+// installed if missing. The author mapping is passed as old,new lines and
+// written into the CSV file read by wp import.
+const contentImportScript = `
+set -e
+
+if [ "$(wp option get ` + contentImportedOption + ` 2>/dev/null || true)" = "1" ]; then
+    echo "The content is already imported, skipping the content import"
+    exit 0
+fi
+
+if [ -n "$IMPORT_URL" ]; then
+    IMPORT_FILE=/tmp/content.xml
+    curl -fsSL -o "$IMPORT_FILE" "$IMPORT_URL"
+fi
+
+if ! wp plugin is-installed wordpress-importer; then
+    wp plugin install wordpress-importer
+fi
+wp plugin activate wordpress-importer
+
+authors=create
+if [ -n "$IMPORT_AUTHORS" ]; then
+    authors=/tmp/authors.csv
+    printf 'old_user_login,new_user_login\n%s\n' "$IMPORT_AUTHORS" > "$authors"
+fi
+
+skip=""
+if [ "$IMPORT_ATTACHMENTS" != "true" ]; then
+    skip="--skip=attachment"
+fi
+
+wp import "$IMPORT_FILE" --authors="$authors" $skip
+wp option update ` + contentImportedOption + ` 1
+`
+
+// importsContent returns true if a WXR file is imported at bootstrap.
+func (wp *Wordpress) importsContent() bool {
+	return wp.Spec.WordpressBootstrapSpec != nil && wp.Spec.WordpressBootstrapSpec.ContentImport != nil
+}
+
+// contentImportAuthors returns the author mapping as old,new lines, sorted so
+// that the pod template is stable.
+func (wp *Wordpress) contentImportAuthors() string {
+	mapping := wp.Spec.WordpressBootstrapSpec.ContentImport.AuthorMapping
+	lines := make([]string, 0, len(mapping))
+
+	for old, user := range mapping {
+		lines = append(lines, fmt.Sprintf("%s,%s", old, user))
+	}
+
+	sort.Strings(lines)
+
+	return strings.Join(lines, "\n")
+}
+
+// importContentContainer imports the WXR file once WordPress is installed.
+func (wp *Wordpress) importContentContainer() corev1.Container {
+	source := wp.Spec.WordpressBootstrapSpec.ContentImport
+
+	fetchAttachments := source.FetchAttachments == nil || *source.FetchAttachments
+
+	env := append(wp.env(), wp.Spec.WordpressBootstrapSpec.Env...)
+	env = append(env,
+		corev1.EnvVar{Name: "IMPORT_AUTHORS", Value: wp.contentImportAuthors()},
+		corev1.EnvVar{Name: "IMPORT_ATTACHMENTS", Value: strconv.FormatBool(fetchAttachments)},
+	)
+	mounts := wp.volumeMounts()
+
+	switch {
+	case source.URL != "":
+		env = append(env, corev1.EnvVar{
+			Name:  "IMPORT_URL",
+			Value: source.URL,
+		})
+	case source.SecretKeyRef != nil:
+		env = append(env, corev1.EnvVar{
+			Name:  "IMPORT_FILE",
+			Value: path.Join(contentImportMountPath, source.SecretKeyRef.Key),
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      contentImportVolumeName,
+			MountPath: contentImportMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return corev1.Container{
+		Name:            "import-content",
+		Image:           wp.Spec.Image,
+		VolumeMounts:    mounts,
+		Env:             env,
+		EnvFrom:         append(wp.envFrom(), wp.Spec.WordpressBootstrapSpec.EnvFrom...),
+		Resources:       wp.Spec.Resources,
+		SecurityContext: wp.securityContext(),
+		Command:         []string{"/bin/sh", "-c", contentImportScript},
+	}
+}
+
+func (wp *Wordpress) contentImportVolumes() []corev1.Volume {
+	if !wp.importsContent() || wp.Spec.WordpressBootstrapSpec.ContentImport.SecretKeyRef == nil {
+		return nil
+	}
+
+	ref := wp.Spec.WordpressBootstrapSpec.ContentImport.SecretKeyRef
+
+	return []corev1.Volume{
+		{
+			Name: contentImportVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: ref.Name,
+					Items: []corev1.KeyToPath{
+						{
+							Key:  ref.Key,
+							Path: ref.Key,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const (
+	defaultCoreUpdateCheckInterval = 12 * time.Hour
+	defaultMaintenanceTimezone     = "Etc/UTC"
+	maintenanceWindowTimeFormat    = "15:04"
+)
+
+// coreUpdateCheckScript reports the installed core version and the newest
+// one allowed by CORE_UPDATE_POLICY, if any, through the termination message.
+const coreUpdateCheckScript = `
+set -e
+
+flags=""
+if [ "$CORE_UPDATE_POLICY" = "minor" ]; then
+    flags="--minor"
+fi
+
+current=$(wp core version)
+available=$(wp core check-update $flags --field=version | grep -E '^[0-9]+(\.[0-9]+)+$' | head -n 1)
+
+printf '%s\n%s\n' "$current" "$available" | tee /dev/termination-log
+`
+
+// coreUpdateScript updates the core to VERSION, along with its database, and
+// reports the previous and the installed versions through the termination
+// message.
+const coreUpdateScript = `
+set -e
+
+previous=$(wp core version)
+wp core update --version="$VERSION"
+wp core update-db
+
+printf '%s\n%s\n' "$previous" "$(wp core version)" | tee /dev/termination-log
+`
+
+// ChecksCoreUpdates returns true if the operator checks for core updates.
+func (wp *Wordpress) ChecksCoreUpdates() bool {
+	return wp.Spec.Updates != nil && wp.Spec.Updates.Core != nil
+}
+
+// CoreUpdatePolicy returns the policy selecting the core updates which are applied.
+func (wp *Wordpress) CoreUpdatePolicy() wordpressv1alpha1.CoreUpdatePolicy {
+	if wp.Spec.Updates.Core.Policy == "" {
+		return wordpressv1alpha1.CoreUpdateNone
+	}
+
+	return wp.Spec.Updates.Core.Policy
+}
+
+// CoreUpdateCheckInterval returns the interval between the core update checks.
+func (wp *Wordpress) CoreUpdateCheckInterval() time.Duration {
+	if wp.Spec.Updates.Core.CheckIntervalSeconds == nil {
+		return defaultCoreUpdateCheckInterval
+	}
+
+	return time.Duration(*wp.Spec.Updates.Core.CheckIntervalSeconds) * time.Second
+}
+
+// InCoreUpdateWindow returns true if the given time is within the core
+// updates maintenance window, or if there's no window.
+func (wp *Wordpress) InCoreUpdateWindow(now time.Time) (bool, error) {
+	window := wp.Spec.Updates.Core.Window
+	if window == nil {
+		return true, nil
+	}
+
+	return inMaintenanceWindow(window, now)
+}
+
+func inMaintenanceWindow(window *wordpressv1alpha1.MaintenanceWindow, now time.Time) (bool, error) {
+	timezone := window.Timezone
+	if timezone == "" {
+		timezone = defaultMaintenanceTimezone
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return false, err
+	}
+
+	start, err := time.Parse(maintenanceWindowTimeFormat, window.Start)
+	if err != nil {
+		return false, err
+	}
+
+	end, err := time.Parse(maintenanceWindowTimeFormat, window.End)
+	if err != nil {
+		return false, err
+	}
+
+	now = now.In(loc)
+
+	// check the window started today and the one started yesterday, which
+	// may end today
+	for _, day := range []time.Time{now, now.AddDate(0, 0, -1)} {
+		from := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		to := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+
+		if !to.After(from) {
+			to = to.AddDate(0, 0, 1)
+		}
+
+		if startsOn(window, from.Weekday()) && !now.Before(from) && now.Before(to) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func startsOn(window *wordpressv1alpha1.MaintenanceWindow, day time.Weekday) bool {
+	if len(window.Days) == 0 {
+		return true
+	}
+
+	for _, d := range window.Days {
+		if string(d) == day.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CoreUpdateCheckPodTemplateSpec generates the pod template spec of the job
+// which checks for core updates.
+func (wp *Wordpress) CoreUpdateCheckPodTemplateSpec() corev1.PodTemplateSpec {
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", coreUpdateCheckScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "CORE_UPDATE_POLICY", Value: string(wp.CoreUpdatePolicy())},
+	)
+
+	return out
+}
+
+// CoreUpdatePodTemplateSpec generates the pod template spec of the job which
+// updates the core to the available version.
+func (wp *Wordpress) CoreUpdatePodTemplateSpec() corev1.PodTemplateSpec {
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", coreUpdateScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "VERSION", Value: wp.AvailableCoreVersion()},
+	)
+
+	return out
+}
+
+// AvailableCoreVersion returns the core version found by the last check, if
+// it differs from the installed one.
+func (wp *Wordpress) AvailableCoreVersion() string {
+	status := wp.Status.CoreUpdate
+	if status == nil || status.AvailableVersion == status.Version {
+		return ""
+	}
+
+	return status.AvailableVersion
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultCronSchedule = "*/5 * * * *"
+
+// RunsCronJob returns true if the site's scheduled events are run from a
+// CronJob instead of the pseudo-cron.
+func (wp *Wordpress) RunsCronJob() bool {
+	return wp.Spec.Cron != nil && (wp.Spec.Cron.Enabled == nil || *wp.Spec.Cron.Enabled)
+}
+
+// CronSchedule returns the schedule of the CronJob running the site's scheduled events.
+func (wp *Wordpress) CronSchedule() string {
+	if wp.Spec.Cron.Schedule == "" {
+		return defaultCronSchedule
+	}
+
+	return wp.Spec.Cron.Schedule
+}
+
+// CronPodTemplateSpec generates the pod template spec of the job which runs
+// the due scheduled events.
+func (wp *Wordpress) CronPodTemplateSpec() corev1.PodTemplateSpec {
+	return wp.JobPodTemplateSpec("wp", "cron", "event", "run", "--due-now")
+}
+
+// cronEnv disables the pseudo-cron, as the events are run by the CronJob.
+func (wp *Wordpress) cronEnv() []corev1.EnvVar {
+	if !wp.RunsCronJob() {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "DISABLE_WP_CRON",
+			Value: "true",
+		},
+	}
+}
@@ -0,0 +1,715 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+// the statements are idempotent, so the job can be safely retried.
+const databaseBootstrapScript = `#!/bin/sh
+set -e
+
+# shellcheck disable=SC2086
+mysql --host="$DB_HOST" --port="$DB_PORT" --user="$ADMIN_USER" $DB_SSL_ARGS <<EOF
+CREATE DATABASE IF NOT EXISTS ` + "`$DB_NAME`" + `;
+CREATE USER IF NOT EXISTS '$DB_USER'@'%' IDENTIFIED BY '$DB_PASSWORD';
+ALTER USER '$DB_USER'@'%' IDENTIFIED BY '$DB_PASSWORD';
+GRANT ALL PRIVILEGES ON ` + "`$DB_NAME`" + `.* TO '$DB_USER'@'%';
+EOF
+`
+
+// the size is reported through the container's termination message.
+const databaseUsageScript = `#!/bin/sh
+set -e
+
+# shellcheck disable=SC2086
+size=$(mysql --host="$DB_HOST" --port="$DB_PORT" --user="$DB_USER" $DB_SSL_ARGS --batch --skip-column-names \
+    -e "SELECT COALESCE(SUM(data_length + index_length), 0) FROM information_schema.tables WHERE table_schema = '$DB_NAME'")
+
+printf "%s" "$size" > /dev/termination-log
+`
+
+// proxysql reads its configuration from a file, which is generated from the
+// database credentials passed as env vars.
+const proxySQLScript = `#!/bin/sh
+set -e
+
+cat > /tmp/proxysql.cnf <<EOF
+datadir="/tmp"
+admin_variables={
+    mysql_ifaces="127.0.0.1:6032"
+}
+mysql_variables={
+    interfaces="127.0.0.1:$PROXYSQL_PORT"
+    monitor_enabled=false
+    ssl_p2s_ca="$DB_SSL_CA"
+}
+mysql_servers=({
+    address="$DB_SERVER_HOST"
+    port=$DB_SERVER_PORT
+    hostgroup=0
+    max_connections=$MAX_CONNECTIONS
+    use_ssl=$DB_USE_SSL
+})
+mysql_users=({
+    username="$DB_USER"
+    password="$DB_PASSWORD"
+    default_hostgroup=0
+})
+EOF
+
+exec proxysql -f --idle-threads -c /tmp/proxysql.cnf
+`
+
+// waitForDatabaseScript polls the database at $DB_HOST until it accepts
+// connections, for at most $DB_TIMEOUT seconds. The host may include the port.
+const waitForDatabaseScript = `#!/bin/sh
+deadline=$(( $(date +%s) + DB_TIMEOUT ))
+
+until php -r '
+    $host = getenv("DB_HOST");
+    $port = 3306;
+    if (preg_match("/^(.+):(\d+)$/", $host, $m)) {
+        $host = $m[1];
+        $port = (int) $m[2];
+    }
+    exit(@fsockopen(trim($host, "[]"), $port, $errno, $errstr, 2) ? 0 : 1);
+'; do
+    if [ "$(date +%s)" -ge "$deadline" ]; then
+        echo "timed out waiting for the database at $DB_HOST"
+        exit 1
+    fi
+    echo "waiting for the database at $DB_HOST"
+    sleep 2
+done
+`
+
+// importDatabaseScript imports the SQL dump at $IMPORT_FILE or downloaded
+// from $IMPORT_URL, unless WordPress is already installed.
+const importDatabaseScript = `#!/bin/sh
+set -e
+
+if wp core is-installed >/dev/null 2>&1; then
+    echo "WordPress is already installed, skipping the database import"
+    exit 0
+fi
+
+if [ -n "$IMPORT_URL" ]; then
+    IMPORT_FILE=/tmp/dump
+    curl -fsSL -o "$IMPORT_FILE" "$IMPORT_URL"
+fi
+
+if gzip -t "$IMPORT_FILE" 2>/dev/null; then
+    gunzip -c "$IMPORT_FILE" > /tmp/dump.sql
+    IMPORT_FILE=/tmp/dump.sql
+fi
+
+wp db import "$IMPORT_FILE"
+`
+
+const (
+	defaultDatabaseTimeoutSeconds = int32(300)
+	defaultDatabaseUsageInterval  = time.Hour
+
+	databaseImportVolumeName = "db-import"
+	databaseImportMountPath  = "/var/run/presslabs.org/import"
+
+	proxySQLPort                 = 6033
+	defaultPoolingMaxConnections = int32(10)
+
+	databaseCAVolumeName = "db-ca"
+	databaseCAMountPath  = "/var/run/presslabs.org/db"
+	databaseCAFile       = databaseCAMountPath + "/ca.crt"
+
+	databaseUser = "wordpress"
+)
+
+// BootstrapsDatabase returns true if the operator creates the site's
+// database and user within the external database. Databases reached through
+// the Cloud SQL Auth Proxy are not bootstrapped, as the proxy doesn't run
+// within the bootstrap Job.
+func (wp *Wordpress) BootstrapsDatabase() bool {
+	return wp.HasExternalDatabase() && !wp.UsesCloudSQL() && !wp.UsesPostgres() &&
+		len(wp.Spec.Database.CredentialsSecretRef) > 0 &&
+		len(wp.Spec.Database.AdminCredentialsSecretRef) > 0
+}
+
+// UsesPostgres returns true if the site runs on the experimental PostgreSQL
+// engine, through the pg4wp compatibility layer.
+func (wp *Wordpress) UsesPostgres() bool {
+	return wp.Spec.Database != nil && wp.Spec.Database.Engine == wordpressv1alpha1.DatabaseEnginePostgres
+}
+
+// UsesDatabaseTLS returns true if the connections to the database are encrypted.
+func (wp *Wordpress) UsesDatabaseTLS() bool {
+	return wp.Spec.Database != nil && wp.Spec.Database.TLS != nil && !wp.UsesPostgres()
+}
+
+func (wp *Wordpress) hasDatabaseCA() bool {
+	return wp.UsesDatabaseTLS() && len(wp.Spec.Database.TLS.CASecretRef) > 0
+}
+
+// databaseTLSEnv returns the env vars configuring WordPress to connect to
+// the database over TLS.
+func (wp *Wordpress) databaseTLSEnv() []corev1.EnvVar {
+	if !wp.UsesDatabaseTLS() {
+		return nil
+	}
+
+	flags := "MYSQLI_CLIENT_SSL"
+	if wp.Spec.Database.TLS.VerifyMode == wordpressv1alpha1.DatabaseTLSSkipVerify {
+		flags = "MYSQLI_CLIENT_SSL_DONT_VERIFY_SERVER_CERT"
+	}
+
+	out := []corev1.EnvVar{
+		{
+			Name:  "MYSQL_CLIENT_FLAGS",
+			Value: flags,
+		},
+	}
+
+	if wp.hasDatabaseCA() {
+		out = append(out, corev1.EnvVar{
+			Name:  "MYSQL_SSL_CA",
+			Value: databaseCAFile,
+		})
+	}
+
+	return out
+}
+
+// databaseSSLArgs returns the TLS arguments of the mysql client.
+func (wp *Wordpress) databaseSSLArgs() string {
+	switch {
+	case !wp.UsesDatabaseTLS():
+		return ""
+	case wp.hasDatabaseCA() && wp.Spec.Database.TLS.VerifyMode != wordpressv1alpha1.DatabaseTLSSkipVerify:
+		return "--ssl-mode=VERIFY_CA --ssl-ca=" + databaseCAFile
+	default:
+		return "--ssl-mode=REQUIRED"
+	}
+}
+
+func (wp *Wordpress) databaseCAVolumes() []corev1.Volume {
+	if !wp.hasDatabaseCA() {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: databaseCAVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: string(wp.Spec.Database.TLS.CASecretRef),
+					Items: []corev1.KeyToPath{
+						{
+							Key:  "ca.crt",
+							Path: "ca.crt",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (wp *Wordpress) databaseCAVolumeMounts() []corev1.VolumeMount {
+	if !wp.hasDatabaseCA() {
+		return nil
+	}
+
+	return []corev1.VolumeMount{
+		{
+			Name:      databaseCAVolumeName,
+			MountPath: databaseCAMountPath,
+			ReadOnly:  true,
+		},
+	}
+}
+
+// DesiredDatabaseCredentials returns the generation of the provisioned
+// database's credentials requested through spec.database.rotateCredentials.
+// The initial credentials have the empty generation.
+func (wp *Wordpress) DesiredDatabaseCredentials() string {
+	if wp.Spec.Database == nil || wp.Spec.Database.RotateCredentials == "" {
+		return ""
+	}
+
+	return hash(wp.Spec.Database.RotateCredentials)
+}
+
+// CurrentDatabaseCredentials returns the generation of the provisioned
+// database's credentials used by the site.
+func (wp *Wordpress) CurrentDatabaseCredentials() string {
+	if wp.Status.DatabaseCredentials == nil {
+		return ""
+	}
+
+	return wp.Status.DatabaseCredentials.Generation
+}
+
+// DatabaseCredentialsGenerations returns the generations of the provisioned
+// database's credentials which must exist: the current one, the one being
+// rotated to and the one waiting to be revoked.
+func (wp *Wordpress) DatabaseCredentialsGenerations() []string {
+	current := wp.CurrentDatabaseCredentials()
+	out := []string{current}
+
+	if desired := wp.DesiredDatabaseCredentials(); desired != current {
+		out = append(out, desired)
+	}
+
+	if creds := wp.Status.DatabaseCredentials; creds != nil && creds.PreviousGeneration != nil {
+		previous := *creds.PreviousGeneration
+		if previous != current && previous != wp.DesiredDatabaseCredentials() {
+			out = append(out, previous)
+		}
+	}
+
+	return out
+}
+
+// DatabaseUser returns the name of the provisioned database's user for the
+// given generation of credentials.
+func (wp *Wordpress) DatabaseUser(generation string) string {
+	if generation == "" {
+		return databaseUser
+	}
+
+	return databaseUser + "_" + generation
+}
+
+// MysqlUserName returns the name of the MysqlUser for the given generation
+// of credentials.
+func (wp *Wordpress) MysqlUserName(generation string) string {
+	if generation == "" {
+		return wp.ComponentName(WordpressMysqlUser)
+	}
+
+	return wp.ComponentName(WordpressMysqlUser) + "-" + generation
+}
+
+// PoolsDatabaseConnections returns true if the web pods connect to the
+// database through a ProxySQL sidecar.
+func (wp *Wordpress) PoolsDatabaseConnections() bool {
+	if wp.Spec.Database == nil || wp.Spec.Database.Pooling == nil || wp.UsesPostgres() {
+		return false
+	}
+
+	return wp.ProvisionsDatabase() || (wp.HasExternalDatabase() && len(wp.Spec.Database.CredentialsSecretRef) > 0)
+}
+
+// databaseServer returns the host and the port of the site's database server.
+func (wp *Wordpress) databaseServer() (string, int32) {
+	if wp.UsesCloudSQL() {
+		return "127.0.0.1", *wp.Spec.Database.Port
+	}
+
+	if wp.HasExternalDatabase() {
+		return wp.Spec.Database.Host, *wp.Spec.Database.Port
+	}
+
+	return wp.DatabaseHost(), defaultDatabasePort
+}
+
+// DatabaseAddress returns the host:port address of the site's database server.
+func (wp *Wordpress) DatabaseAddress() string {
+	host, port := wp.databaseServer()
+
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// databaseCredentials returns the secret holding the credentials of the
+// site's database user and the keys of the user and password within it.
+func (wp *Wordpress) databaseCredentials() (string, string, string) {
+	if wp.HasExternalDatabase() {
+		return string(wp.Spec.Database.CredentialsSecretRef), "USER", "PASSWORD"
+	}
+
+	return wp.ComponentName(WordpressSecret), "DB_USER", "DB_PASSWORD"
+}
+
+// webDatabaseEnv points the wordpress container of the web pods to the
+// ProxySQL sidecar, which encrypts the connections to the database if needed.
+// The init containers and the jobs connect directly to the database, since
+// the sidecar isn't running for them.
+func (wp *Wordpress) webDatabaseEnv() []corev1.EnvVar {
+	if !wp.PoolsDatabaseConnections() {
+		return nil
+	}
+
+	out := []corev1.EnvVar{
+		{
+			Name:  "DB_HOST",
+			Value: fmt.Sprintf("127.0.0.1:%d", proxySQLPort),
+		},
+	}
+
+	if wp.UsesDatabaseTLS() {
+		out = append(out, corev1.EnvVar{
+			Name:  "MYSQL_CLIENT_FLAGS",
+			Value: "0",
+		})
+	}
+
+	return out
+}
+
+func (wp *Wordpress) proxySQLContainers() []corev1.Container {
+	if !wp.PoolsDatabaseConnections() {
+		return nil
+	}
+
+	pooling := wp.Spec.Database.Pooling
+
+	image := pooling.Image
+	if image == "" {
+		image = options.ProxySQLImage
+	}
+
+	maxConnections := defaultPoolingMaxConnections
+	if pooling.MaxConnections != nil {
+		maxConnections = *pooling.MaxConnections
+	}
+
+	host, port := wp.databaseServer()
+	secret, userKey, passwordKey := wp.databaseCredentials()
+
+	useSSL, ca := "0", ""
+	if wp.UsesDatabaseTLS() {
+		useSSL = "1"
+	}
+
+	if wp.hasDatabaseCA() && wp.Spec.Database.TLS.VerifyMode != wordpressv1alpha1.DatabaseTLSSkipVerify {
+		ca = databaseCAFile
+	}
+
+	return []corev1.Container{
+		{
+			Name:      "proxysql",
+			Image:     image,
+			Args:      []string{"/bin/sh", "-c", proxySQLScript},
+			Resources: pooling.Resources,
+			Env: []corev1.EnvVar{
+				{
+					Name:  "PROXYSQL_PORT",
+					Value: strconv.Itoa(proxySQLPort),
+				},
+				{
+					Name:  "DB_SERVER_HOST",
+					Value: host,
+				},
+				{
+					Name:  "DB_SERVER_PORT",
+					Value: strconv.Itoa(int(port)),
+				},
+				{
+					Name:  "MAX_CONNECTIONS",
+					Value: strconv.Itoa(int(maxConnections)),
+				},
+				{
+					Name:  "DB_USE_SSL",
+					Value: useSSL,
+				},
+				{
+					Name:  "DB_SSL_CA",
+					Value: ca,
+				},
+				secretKeyEnvVar("DB_USER", secret, userKey),
+				secretKeyEnvVar("DB_PASSWORD", secret, passwordKey),
+			},
+			VolumeMounts: wp.databaseCAVolumeMounts(),
+		},
+	}
+}
+
+func secretKeyEnvVar(name, secret, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secret},
+				Key:                  key,
+			},
+		},
+	}
+}
+
+// MeasuresDatabaseUsage returns true if the size of the site's database is
+// measured periodically, which requires the credentials of its user.
+func (wp *Wordpress) MeasuresDatabaseUsage() bool {
+	if wp.Spec.Database == nil || wp.Spec.Database.Usage == nil || wp.UsesCloudSQL() || wp.UsesPostgres() {
+		return false
+	}
+
+	return wp.ProvisionsDatabase() || (wp.HasExternalDatabase() && len(wp.Spec.Database.CredentialsSecretRef) > 0)
+}
+
+// DatabaseUsageInterval returns the interval between the database's size measurements.
+func (wp *Wordpress) DatabaseUsageInterval() time.Duration {
+	if wp.Spec.Database.Usage.IntervalSeconds == nil {
+		return defaultDatabaseUsageInterval
+	}
+
+	return time.Duration(*wp.Spec.Database.Usage.IntervalSeconds) * time.Second
+}
+
+// DatabaseUsagePodTemplateSpec generates the pod template spec of the job
+// which measures the size of the site's database.
+func (wp *Wordpress) DatabaseUsagePodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressDBUsage)
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	host, port := wp.databaseServer()
+	secret, userKey, passwordKey := wp.databaseCredentials()
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:  "db-usage",
+			Image: options.MysqlClientImage,
+			Args:  []string{"/bin/sh", "-c", databaseUsageScript},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "DB_HOST",
+					Value: host,
+				},
+				{
+					Name:  "DB_PORT",
+					Value: strconv.Itoa(int(port)),
+				},
+				{
+					Name:  "DB_NAME",
+					Value: wp.Spec.Database.Name,
+				},
+				{
+					Name:  "DB_SSL_ARGS",
+					Value: wp.databaseSSLArgs(),
+				},
+				secretKeyEnvVar("DB_USER", secret, userKey),
+				// read by the mysql client
+				secretKeyEnvVar("MYSQL_PWD", secret, passwordKey),
+			},
+			VolumeMounts: wp.databaseCAVolumeMounts(),
+		},
+	}
+
+	out.Spec.Volumes = wp.databaseCAVolumes()
+
+	if len(wp.Spec.NodeSelector) > 0 {
+		out.Spec.NodeSelector = wp.Spec.NodeSelector
+	}
+
+	if len(wp.Spec.Tolerations) > 0 {
+		out.Spec.Tolerations = wp.Spec.Tolerations
+	}
+
+	return out
+}
+
+// DatabaseBootstrapPodTemplateSpec generates the pod template spec of the
+// job which creates the site's database and user within the external database.
+func (wp *Wordpress) DatabaseBootstrapPodTemplateSpec() (out corev1.PodTemplateSpec) {
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressDBBootstrap)
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	db := wp.Spec.Database
+	credentials := string(db.CredentialsSecretRef)
+	adminCredentials := string(db.AdminCredentialsSecretRef)
+
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:  "db-bootstrap",
+			Image: options.MysqlClientImage,
+			Args:  []string{"/bin/sh", "-c", databaseBootstrapScript},
+			Env: []corev1.EnvVar{
+				{
+					Name:  "DB_HOST",
+					Value: db.Host,
+				},
+				{
+					Name:  "DB_PORT",
+					Value: strconv.Itoa(int(*db.Port)),
+				},
+				{
+					Name:  "DB_NAME",
+					Value: db.Name,
+				},
+				{
+					Name:  "DB_SSL_ARGS",
+					Value: wp.databaseSSLArgs(),
+				},
+				secretKeyEnvVar("DB_USER", credentials, "USER"),
+				secretKeyEnvVar("DB_PASSWORD", credentials, "PASSWORD"),
+				secretKeyEnvVar("ADMIN_USER", adminCredentials, "USER"),
+				// read by the mysql client
+				secretKeyEnvVar("MYSQL_PWD", adminCredentials, "PASSWORD"),
+			},
+			VolumeMounts: wp.databaseCAVolumeMounts(),
+		},
+	}
+
+	out.Spec.Volumes = wp.databaseCAVolumes()
+
+	if len(wp.Spec.NodeSelector) > 0 {
+		out.Spec.NodeSelector = wp.Spec.NodeSelector
+	}
+
+	if len(wp.Spec.Tolerations) > 0 {
+		out.Spec.Tolerations = wp.Spec.Tolerations
+	}
+
+	return out
+}
+
+// importDatabaseContainer imports the SQL dump the site is bootstrapped from.
+func (wp *Wordpress) importDatabaseContainer() corev1.Container {
+	source := wp.Spec.WordpressBootstrapSpec.ImportFrom
+
+	env := append(wp.env(), wp.Spec.WordpressBootstrapSpec.Env...)
+	mounts := wp.volumeMounts()
+	file := ""
+
+	switch {
+	case source.URL != "":
+		env = append(env, corev1.EnvVar{
+			Name:  "IMPORT_URL",
+			Value: source.URL,
+		})
+	case source.SecretKeyRef != nil:
+		file = path.Join(databaseImportMountPath, source.SecretKeyRef.Key)
+	case source.PersistentVolumeClaim != nil:
+		file = path.Join(databaseImportMountPath, source.PersistentVolumeClaim.Path)
+	case source.Backup != nil && source.Backup.Encryption != "":
+		file = path.Join(databaseImportMountPath, backupDatabaseFile)
+	case source.Backup != nil:
+		file = path.Join(databaseImportMountPath, path.Base(source.Backup.Path))
+	}
+
+	if file != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "IMPORT_FILE",
+			Value: file,
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      databaseImportVolumeName,
+			MountPath: databaseImportMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return corev1.Container{
+		Name:            "import-db",
+		Image:           wp.Spec.Image,
+		VolumeMounts:    mounts,
+		Env:             env,
+		EnvFrom:         append(wp.envFrom(), wp.Spec.WordpressBootstrapSpec.EnvFrom...),
+		Resources:       wp.Spec.Resources,
+		SecurityContext: wp.securityContext(),
+		Command:         []string{"/bin/sh", "-c", importDatabaseScript},
+	}
+}
+
+// downloadBackupContainer downloads, and decrypts if needed, the backup's
+// database artifact the site is bootstrapped from into the import volume.
+func (wp *Wordpress) downloadBackupContainer() corev1.Container {
+	source := wp.Spec.WordpressBootstrapSpec.ImportFrom.Backup
+
+	remote, env := bucketRclone("backup", source.Destination.S3, source.Destination.GCS)
+
+	if source.Encryption != "" {
+		env = append(env,
+			corev1.EnvVar{Name: "BACKUP_ENCRYPTION", Value: string(source.Encryption)},
+			corev1.EnvVar{
+				Name:      "BACKUP_DECRYPTION_KEY",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: source.DecryptionKeySecretRef},
+			},
+		)
+	}
+
+	mount := corev1.VolumeMount{Name: databaseImportVolumeName, MountPath: databaseImportMountPath}
+
+	return backupDownloadContainer(path.Join(remote, path.Dir(source.Path)), path.Base(source.Path), env, mount)
+}
+
+func (wp *Wordpress) databaseImportVolumes() []corev1.Volume {
+	if wp.Spec.WordpressBootstrapSpec == nil || wp.Spec.WordpressBootstrapSpec.ImportFrom == nil {
+		return nil
+	}
+
+	source := wp.Spec.WordpressBootstrapSpec.ImportFrom
+
+	switch {
+	case source.URL != "":
+		return nil
+	case source.SecretKeyRef != nil:
+		return []corev1.Volume{
+			{
+				Name: databaseImportVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName: source.SecretKeyRef.Name,
+						Items: []corev1.KeyToPath{
+							{
+								Key:  source.SecretKeyRef.Key,
+								Path: source.SecretKeyRef.Key,
+							},
+						},
+					},
+				},
+			},
+		}
+	case source.PersistentVolumeClaim != nil:
+		return []corev1.Volume{
+			{
+				Name: databaseImportVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: source.PersistentVolumeClaim.ClaimName,
+						ReadOnly:  true,
+					},
+				},
+			},
+		}
+	case source.Backup != nil:
+		return []corev1.Volume{
+			{
+				Name:         databaseImportVolumeName,
+				VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			},
+		}
+	}
+
+	return nil
+}
@@ -22,7 +22,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
-	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
 )
 
 const (
@@ -37,11 +37,20 @@ const (
 	mediaSubPath          = "uploads"
 	defaultMediaMountPath = defaultCodeMountPath + "/" + mediaSubPath
 
+	defaultWebServerConfigMountPath = "/etc/nginx/conf.d"
+
 	knativeVarLogVolume    = "knative-var-log"
 	knativeVarLogMountPath = "/var/log"
 
 	knativeInternalVolume    = "knative-internal"
 	knativeInternalMountPath = "/var/knative-internal"
+
+	// one year, as recommended for HSTS preloading
+	defaultHSTSMaxAge = int64(31536000)
+
+	defaultDatabaseName = "wordpress"
+	defaultDatabasePort = int32(3306)
+	defaultPostgresPort = int32(5432)
 )
 
 var varLogSizeLimit = resource.MustParse("1Gi")
@@ -49,13 +58,64 @@ var varLogSizeLimit = resource.MustParse("1Gi")
 // SetDefaults sets Wordpress field defaults.
 func (wp *Wordpress) SetDefaults() {
 	if len(wp.Spec.Image) == 0 {
-		wp.Spec.Image = options.WordpressRuntimeImage
+		wp.Spec.Image = wp.defaultImage()
 	}
 
 	if len(wp.Spec.ImagePullPolicy) == 0 {
 		wp.Spec.ImagePullPolicy = corev1.PullAlways
 	}
 
+	if len(wp.Spec.WorkloadType) == 0 {
+		wp.Spec.WorkloadType = wordpressv1alpha1.WorkloadTypeDeployment
+	}
+
+	wp.setVolumeDefaults()
+	wp.setDatabaseDefaults()
+	wp.setSizePresetDefaults()
+
+	if wp.Spec.Cache != nil && wp.Spec.Cache.Memcached != nil && wp.Spec.Cache.Memcached.Mode == "" {
+		wp.Spec.Cache.Memcached.Mode = wordpressv1alpha1.MemcachedSidecar
+	}
+
+	if wp.Spec.WebServerConfig != nil && wp.Spec.WebServerConfig.MountPath == "" {
+		wp.Spec.WebServerConfig.MountPath = defaultWebServerConfigMountPath
+	}
+
+	if wp.Spec.WordpressPathPrefix == "" {
+		wp.Spec.WordpressPathPrefix = "/wp"
+	}
+}
+
+// setDatabaseDefaults sets the engine, the name and the port of the site's database.
+func (wp *Wordpress) setDatabaseDefaults() {
+	if wp.Spec.Database == nil {
+		return
+	}
+
+	if wp.Spec.Database.Engine == "" {
+		wp.Spec.Database.Engine = wordpressv1alpha1.DatabaseEngineMySQL
+	}
+
+	if wp.Spec.Database.Name == "" {
+		wp.Spec.Database.Name = defaultDatabaseName
+	}
+
+	if wp.Spec.Database.Port == nil {
+		port := defaultDatabasePort
+		if wp.UsesPostgres() {
+			port = defaultPostgresPort
+		}
+
+		wp.Spec.Database.Port = &port
+	}
+
+	if wp.Spec.Database.TLS != nil && wp.Spec.Database.TLS.VerifyMode == "" {
+		wp.Spec.Database.TLS.VerifyMode = wordpressv1alpha1.DatabaseTLSVerifyCA
+	}
+}
+
+// setVolumeDefaults sets the mount paths and sub paths of the code and media volumes.
+func (wp *Wordpress) setVolumeDefaults() {
 	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.MountPath == "" {
 		wp.Spec.CodeVolumeSpec.MountPath = defaultCodeMountPath
 	}
@@ -75,8 +135,4 @@ func (wp *Wordpress) SetDefaults() {
 			wp.Spec.MediaVolumeSpec.MountPath = defaultMediaMountPath
 		}
 	}
-
-	if wp.Spec.WordpressPathPrefix == "" {
-		wp.Spec.WordpressPathPrefix = "/wp"
-	}
 }
@@ -0,0 +1,133 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const defaultDiagnosticsSchedule = "0 */6 * * *"
+
+// diagnosticsScript runs the checks listed in DIAGNOSTICS_CHECKS and reports
+// their results through the termination message, one check|pass or
+// check|fail|message per line. A failed check doesn't fail the job.
+const diagnosticsScript = `
+report=$(mktemp)
+
+for check in $DIAGNOSTICS_CHECKS; do
+    case "$check" in
+    core-checksums)
+        if out=$(wp core verify-checksums 2>&1); then
+            echo "$check|pass" >> "$report"
+        else
+            count=$(echo "$out" | grep -c '^Warning:')
+            echo "$check|fail|$count core files don't match their checksums" >> "$report"
+        fi
+        ;;
+    plugin-updates)
+        plugins=$(wp plugin list --update=available --field=name | tr '\n' ' ' | sed 's/ $//')
+        if [ -z "$plugins" ]; then
+            echo "$check|pass" >> "$report"
+        else
+            echo "$check|fail|updates are available for: $plugins" >> "$report"
+        fi
+        ;;
+    file-permissions)
+        count=$(find "$(wp eval 'echo WP_CONTENT_DIR;')" -perm -o+w ! -type l 2>/dev/null | wc -l)
+        if [ "$count" -eq 0 ]; then
+            echo "$check|pass" >> "$report"
+        else
+            echo "$check|fail|$count files are world-writable" >> "$report"
+        fi
+        ;;
+    esac
+done
+
+tail -c 4096 "$report" | tee /dev/termination-log
+`
+
+// SchedulesDiagnostics returns true if the site's health is checked by a CronJob.
+func (wp *Wordpress) SchedulesDiagnostics() bool {
+	return wp.Spec.Diagnostics != nil
+}
+
+// DiagnosticsSchedule returns the schedule of the CronJob checking the site's health.
+func (wp *Wordpress) DiagnosticsSchedule() string {
+	if wp.Spec.Diagnostics.Schedule == "" {
+		return defaultDiagnosticsSchedule
+	}
+
+	return wp.Spec.Diagnostics.Schedule
+}
+
+// DiagnosticChecks returns the checks run by the diagnostics.
+func (wp *Wordpress) DiagnosticChecks() []wordpressv1alpha1.DiagnosticCheck {
+	if len(wp.Spec.Diagnostics.Checks) == 0 {
+		return []wordpressv1alpha1.DiagnosticCheck{
+			wordpressv1alpha1.DiagnosticCoreChecksums,
+			wordpressv1alpha1.DiagnosticPluginUpdates,
+			wordpressv1alpha1.DiagnosticFilePermissions,
+		}
+	}
+
+	return wp.Spec.Diagnostics.Checks
+}
+
+// DiagnosticsPodTemplateSpec generates the pod template spec of the job which
+// checks the site's health.
+func (wp *Wordpress) DiagnosticsPodTemplateSpec() corev1.PodTemplateSpec {
+	checks := []string{}
+	for _, check := range wp.DiagnosticChecks() {
+		checks = append(checks, string(check))
+	}
+
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", diagnosticsScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "DIAGNOSTICS_CHECKS", Value: strings.Join(checks, " ")},
+	)
+
+	return out
+}
+
+// ParseDiagnostics parses the results reported by the diagnostics job.
+func ParseDiagnostics(report []string) []wordpressv1alpha1.DiagnosticCheckResult {
+	results := []wordpressv1alpha1.DiagnosticCheckResult{}
+
+	for _, line := range report {
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) < 2 {
+			continue
+		}
+
+		result := wordpressv1alpha1.DiagnosticCheckResult{
+			Name:   wordpressv1alpha1.DiagnosticCheck(parts[0]),
+			Passed: parts[1] == "pass",
+		}
+
+		if len(parts) == 3 {
+			result.Message = parts[2]
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
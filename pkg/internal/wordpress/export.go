@@ -0,0 +1,189 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// ExportUploadContainerName is the name of the container uploading the
+	// export's files, which reports them through its termination message.
+	ExportUploadContainerName = "upload"
+
+	exportVolumeName = "export"
+	exportMountPath  = "/export"
+	exportsPrefix    = "exports"
+)
+
+var errExportDestination = errors.New("the export has no destination and the site's media is not stored in a bucket")
+
+// exportScript exports the site's content into the export volume, signaling
+// the upload container once it's done or it failed.
+const exportScript = `
+if wp export --dir=/export "$@"; then
+    touch /export/.done
+else
+    touch /export/.failed
+    exit 1
+fi
+`
+
+// exportUploadScript waits for the export, uploads the WXR files and reports
+// them through the termination message, as size|files.
+const exportUploadScript = `
+set -e
+
+while [ ! -f /export/.done ]; do
+    [ ! -f /export/.failed ] || exit 1
+    sleep 1
+done
+
+files=$(find /export -name '*.xml' | wc -l)
+size=$(cat /export/*.xml | wc -c)
+
+rclone copy /export "$EXPORT_REMOTE" --include '*.xml'
+
+printf '%s|%s\n' "$size" "$files" | tee /dev/termination-log
+`
+
+// exportDestination returns the bucket the export is uploaded to and the
+// prefix within it, defaulting to the media bucket's exports prefix.
+func (wp *Wordpress) exportDestination(export *wordpressv1alpha1.WordpressExport) (
+	*wordpressv1alpha1.S3VolumeSource, *wordpressv1alpha1.GCSVolumeSource, string, error) {
+	if dest := export.Spec.Destination; dest != nil {
+		return dest.S3, dest.GCS, export.Name, nil
+	}
+
+	if !wp.hasMediaBucket() {
+		return nil, nil, "", errExportDestination
+	}
+
+	media := wp.Spec.MediaVolumeSpec
+
+	return media.S3VolumeSource, media.GCSVolumeSource, path.Join(exportsPrefix, export.Name), nil
+}
+
+// exportArgs returns the wp export arguments selecting the exported content.
+func exportArgs(export *wordpressv1alpha1.WordpressExport) []string {
+	spec := export.Spec
+	args := []string{}
+
+	if len(spec.PostTypes) > 0 {
+		args = append(args, "--post_type="+strings.Join(spec.PostTypes, ","))
+	}
+
+	if spec.StartDate != "" {
+		args = append(args, "--start_date="+spec.StartDate)
+	}
+
+	if spec.EndDate != "" {
+		args = append(args, "--end_date="+spec.EndDate)
+	}
+
+	if spec.Author != "" {
+		args = append(args, "--author="+spec.Author)
+	}
+
+	if spec.SkipComments {
+		args = append(args, "--skip_comments")
+	}
+
+	if spec.MaxFileSizeMB != nil {
+		args = append(args, "--max_file_size="+strconv.Itoa(int(*spec.MaxFileSizeMB)))
+	}
+
+	return args
+}
+
+// ExportPodTemplateSpec generates the pod template spec of the job which
+// exports the site's content with wp export and uploads the WXR files to the
+// export's destination.
+func (wp *Wordpress) ExportPodTemplateSpec(export *wordpressv1alpha1.WordpressExport) (corev1.PodTemplateSpec, error) {
+	s3, gcs, prefix, err := wp.exportDestination(export)
+	if err != nil {
+		return corev1.PodTemplateSpec{}, err
+	}
+
+	mount := corev1.VolumeMount{Name: exportVolumeName, MountPath: exportMountPath}
+
+	// the arguments are passed to the script as positional parameters
+	cmd := append([]string{"/bin/sh", "-c", exportScript, "export"}, exportArgs(export)...)
+
+	out := wp.JobPodTemplateSpec(cmd...)
+	out.Spec.Containers[0].VolumeMounts = append(out.Spec.Containers[0].VolumeMounts, mount)
+	out.Spec.Volumes = append(out.Spec.Volumes, corev1.Volume{
+		Name:         exportVolumeName,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+
+	remote, env := bucketRclone("export", s3, gcs)
+	env = append(env, corev1.EnvVar{Name: "EXPORT_REMOTE", Value: path.Join(remote, prefix)})
+
+	out.Spec.Containers = append(out.Spec.Containers, corev1.Container{
+		Name:         ExportUploadContainerName,
+		Image:        options.RcloneImage,
+		Command:      []string{"/bin/sh", "-c", exportUploadScript},
+		Env:          env,
+		VolumeMounts: []corev1.VolumeMount{mount},
+	})
+
+	return out, nil
+}
+
+// ExportLocation returns the URL of the prefix the export's files are
+// uploaded to.
+func (wp *Wordpress) ExportLocation(export *wordpressv1alpha1.WordpressExport) string {
+	s3, gcs, prefix, err := wp.exportDestination(export)
+
+	switch {
+	case err != nil:
+		return ""
+	case s3 != nil:
+		return "s3://" + path.Join(s3.Bucket, s3.PathPrefix, prefix)
+	default:
+		return "gs://" + path.Join(gcs.Bucket, gcs.PathPrefix, prefix)
+	}
+}
+
+// ParseExportReport parses the size and the number of the files reported by
+// an export job.
+func ParseExportReport(report string) (size, files int64, err error) {
+	parts := strings.Split(strings.TrimSpace(report), "|")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid export report: %q", report)
+	}
+
+	if size, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return 0, 0, err
+	}
+
+	if files, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return 0, 0, err
+	}
+
+	return size, files, nil
+}
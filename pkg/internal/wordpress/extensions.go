@@ -0,0 +1,110 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// extensionsScript syncs the plugins or the themes, as set by EXTENSION.
+// They are passed one per line, as name|version|source|state, on a separate
+// file descriptor, as wp-cli may read its standard input. The missing or
+// mismatched ones are installed and, unless INSTALL_ONLY is set, activated
+// or deactivated. Themes are only activated, as activating one deactivates
+// the others. The drift found is reported through the termination message,
+// one difference per line.
+const extensionsScript = `
+set -e
+
+drift=$(mktemp)
+
+while IFS='|' read -r name version source state <&3; do
+    [ -n "$name" ] || continue
+
+    current=""
+    if wp "$EXTENSION" is-installed "$name"; then
+        current=$(wp "$EXTENSION" get "$name" --field=version)
+    fi
+
+    if [ -z "$current" ]; then
+        echo "$name: not installed" >> "$drift"
+    elif [ -n "$version" ] && [ "$current" != "$version" ]; then
+        echo "$name: version $current, expected $version" >> "$drift"
+    else
+        current="ok"
+    fi
+
+    if [ "$current" != "ok" ]; then
+        if [ -n "$source" ]; then
+            wp "$EXTENSION" install "$source" --force
+        else
+            wp "$EXTENSION" install "$name" ${version:+--version="$version"} --force
+        fi
+    fi
+
+    [ "$INSTALL_ONLY" != "true" ] || continue
+
+    status=$(wp "$EXTENSION" get "$name" --field=status)
+    if [ "$state" = "active" ] && [ "${status#active}" = "$status" ]; then
+        echo "$name: $status, expected active" >> "$drift"
+        wp "$EXTENSION" activate "$name"
+    elif [ "$state" = "inactive" ] && [ "$EXTENSION" = "plugin" ] && [ "${status#active}" != "$status" ]; then
+        echo "$name: $status, expected inactive" >> "$drift"
+        wp "$EXTENSION" deactivate "$name"
+    fi
+done 3<<EOF
+$EXTENSIONS
+EOF
+
+if [ "$INSTALL_ONLY" != "true" ]; then
+    cat "$drift"
+    tail -c 4096 "$drift" > /dev/termination-log
+fi
+`
+
+// installExtensionsContainer returns the init container installing the
+// missing plugins or themes into the web pod's code volume, as it may not be
+// shared with the Job syncing them.
+func (wp *Wordpress) installExtensionsContainer(extension, list string) corev1.Container {
+	return corev1.Container{
+		Name:            "install-" + extension + "s",
+		Image:           wp.Spec.Image,
+		ImagePullPolicy: wp.Spec.ImagePullPolicy,
+		VolumeMounts:    wp.volumeMounts(),
+		Env: append(wp.env(),
+			corev1.EnvVar{Name: "EXTENSION", Value: extension},
+			corev1.EnvVar{Name: "EXTENSIONS", Value: list},
+			corev1.EnvVar{Name: "INSTALL_ONLY", Value: "true"},
+		),
+		EnvFrom:         wp.envFrom(),
+		Resources:       wp.Spec.Resources,
+		SecurityContext: wp.securityContext(),
+		Command:         []string{"/bin/sh", "-c", extensionsScript},
+	}
+}
+
+// extensionsPodTemplateSpec generates the pod template spec of the job which
+// syncs the plugins or the themes with the spec.
+func (wp *Wordpress) extensionsPodTemplateSpec(extension, list string) corev1.PodTemplateSpec {
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", extensionsScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "EXTENSION", Value: extension},
+		corev1.EnvVar{Name: "EXTENSIONS", Value: list},
+	)
+
+	return out
+}
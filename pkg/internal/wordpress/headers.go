@@ -0,0 +1,71 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+// Header is an HTTP header.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// ResponseHeaderNames are the names of all the response headers which can be
+// set by the operator.
+var ResponseHeaderNames = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Frame-Options",
+	"Referrer-Policy",
+	"X-Content-Type-Options",
+	"Permissions-Policy",
+}
+
+// ResponseHeaders returns the response headers of the site: HSTS and the
+// configured security headers.
+func (wp *Wordpress) ResponseHeaders() []Header {
+	headers := []Header{}
+
+	if hsts := wp.HSTSHeader(); len(hsts) > 0 {
+		headers = append(headers, Header{Name: "Strict-Transport-Security", Value: hsts})
+	}
+
+	spec := wp.Spec.SecurityHeaders
+	if spec == nil {
+		return headers
+	}
+
+	if len(spec.ContentSecurityPolicy) > 0 {
+		headers = append(headers, Header{Name: "Content-Security-Policy", Value: spec.ContentSecurityPolicy})
+	}
+
+	if len(spec.FrameOptions) > 0 {
+		headers = append(headers, Header{Name: "X-Frame-Options", Value: spec.FrameOptions})
+	}
+
+	if len(spec.ReferrerPolicy) > 0 {
+		headers = append(headers, Header{Name: "Referrer-Policy", Value: spec.ReferrerPolicy})
+	}
+
+	if spec.ContentTypeNosniff {
+		headers = append(headers, Header{Name: "X-Content-Type-Options", Value: "nosniff"})
+	}
+
+	if len(spec.PermissionsPolicy) > 0 {
+		headers = append(headers, Header{Name: "Permissions-Policy", Value: spec.PermissionsPolicy})
+	}
+
+	return headers
+}
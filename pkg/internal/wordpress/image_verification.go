@@ -0,0 +1,199 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const imageVerificationKeyEnv = "COSIGN_PUBLIC_KEY"
+
+var (
+	errImageVerificationPolicy = errors.New("the images' signatures are verified, but neither a key nor a keyless certificate identity are set")
+	errImageVerificationIssuer = errors.New("the keyless signatures are verified, but the certificates' OIDC issuer is not set")
+	errImageNotPinned          = errors.New("the images whose signatures are verified must be pinned by digest")
+)
+
+// verifiedImage is an image whose signature is verified, along with the name
+// of the container verifying it.
+type verifiedImage struct {
+	container string
+	image     string
+}
+
+// VerifiesImages returns true if the signatures of the site's images are
+// verified before its pods are rendered with them.
+func (wp *Wordpress) VerifiesImages() bool {
+	return options.VerifyImageSignatures || wp.Spec.ImageVerification != nil
+}
+
+// verifiedImages returns the runtime images and, if used by the site, the
+// git clone and rclone images.
+func (wp *Wordpress) verifiedImages() []verifiedImage {
+	images := []verifiedImage{{container: "verify-runtime", image: wp.Spec.Image}}
+
+	if wp.Spec.Canary != nil && len(wp.Spec.Canary.Image) > 0 {
+		images = append(images, verifiedImage{container: "verify-canary", image: wp.Spec.Canary.Image})
+	}
+
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.GitDir != nil {
+		images = append(images, verifiedImage{container: "verify-git", image: options.GitCloneImage})
+	}
+
+	if wp.hasMediaBucket() || wp.SchedulesBackups() {
+		images = append(images, verifiedImage{container: "verify-rclone", image: options.RcloneImage})
+	}
+
+	return images
+}
+
+// VerifiedImage returns the image verified by the given container of the
+// verification job or an empty string if there is none.
+func (wp *Wordpress) VerifiedImage(container string) string {
+	for _, image := range wp.verifiedImages() {
+		if image.container == container {
+			return image.image
+		}
+	}
+
+	return ""
+}
+
+// imageVerificationArgs returns the cosign verify arguments selecting the key
+// or the keyless identity the signatures are verified with, along with the
+// env var holding the public key of PublicKeySecretRef. The site's policy
+// takes precedence over the operator's one.
+func (wp *Wordpress) imageVerificationArgs() ([]string, []corev1.EnvVar, error) {
+	spec := wp.Spec.ImageVerification
+	if spec == nil {
+		spec = &wordpressv1alpha1.ImageVerificationSpec{}
+	}
+
+	issuer := spec.CertificateOIDCIssuer
+	if issuer == "" {
+		issuer = options.ImageSignatureOIDCIssuer
+	}
+
+	switch {
+	case spec.PublicKeySecretRef != nil:
+		return []string{"--key", "env://" + imageVerificationKeyEnv}, []corev1.EnvVar{
+			{
+				Name:      imageVerificationKeyEnv,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: spec.PublicKeySecretRef},
+			},
+		}, nil
+	case spec.Key != "":
+		return []string{"--key", spec.Key}, nil, nil
+	case spec.CertificateIdentity != "":
+		return keylessVerificationArgs(spec.CertificateIdentity, issuer)
+	case options.ImageSignatureKey != "":
+		return []string{"--key", options.ImageSignatureKey}, nil, nil
+	case options.ImageSignatureIdentity != "":
+		return keylessVerificationArgs(options.ImageSignatureIdentity, issuer)
+	}
+
+	return nil, nil, errImageVerificationPolicy
+}
+
+func keylessVerificationArgs(identity, issuer string) ([]string, []corev1.EnvVar, error) {
+	if issuer == "" {
+		return nil, nil, errImageVerificationIssuer
+	}
+
+	return []string{"--certificate-identity-regexp", identity, "--certificate-oidc-issuer", issuer}, nil, nil
+}
+
+// CanVerifyImages returns an error if the verification policy sets neither a
+// key nor a keyless identity, or if any of the verified images is not pinned
+// by digest, as its tag could be moved to an unverified image once verified.
+func (wp *Wordpress) CanVerifyImages() error {
+	if _, _, err := wp.imageVerificationArgs(); err != nil {
+		return err
+	}
+
+	for _, image := range wp.verifiedImages() {
+		if !strings.Contains(image.image, "@sha256:") {
+			return fmt.Errorf("%w: %s", errImageNotPinned, image.image)
+		}
+	}
+
+	return nil
+}
+
+// SetImageVerificationKeyVersion sets the resource version of the secret
+// holding the public key the signatures are verified with, for the images to
+// be verified again when the key changes.
+func (wp *Wordpress) SetImageVerificationKeyVersion(version string) {
+	wp.imageVerificationKeyVersion = version
+}
+
+// ImageVerificationVersion identifies the images and the verification
+// policy the images' signatures are verified for, including the version of
+// the public key secret.
+func (wp *Wordpress) ImageVerificationVersion() string {
+	args, env, _ := wp.imageVerificationArgs()
+
+	values := []interface{}{args}
+	for _, image := range wp.verifiedImages() {
+		values = append(values, image.image)
+	}
+
+	for _, e := range env {
+		values = append(values, e.ValueFrom.SecretKeyRef.Name, e.ValueFrom.SecretKeyRef.Key, wp.imageVerificationKeyVersion)
+	}
+
+	return hash(values...)
+}
+
+// ImageVerificationPodTemplateSpec generates the pod template spec of the
+// job which verifies the signatures of the site's images, one container per
+// image. The cosign image has no shell, so the verification errors are
+// reported through the termination messages, from the logs.
+func (wp *Wordpress) ImageVerificationPodTemplateSpec() (out corev1.PodTemplateSpec) {
+	args, env, _ := wp.imageVerificationArgs()
+
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressImageVerification)
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
+	if name := wp.ServiceAccountName(); len(name) > 0 {
+		out.Spec.ServiceAccountName = name
+	}
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	for _, image := range wp.verifiedImages() {
+		out.Spec.Containers = append(out.Spec.Containers, corev1.Container{
+			Name:                     image.container,
+			Image:                    options.CosignImage,
+			Args:                     append(append([]string{"verify"}, args...), image.image),
+			Env:                      env,
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		})
+	}
+
+	return out
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	imagesMountPath = "/mnt/media"
+
+	defaultImageOptimizationConcurrency = int32(2)
+)
+
+// the images are optimized losslessly, unless a maximum JPEG quality is set,
+// and keep their modification time
+const optimizeImagesScript = `
+set -e
+
+if ! command -v jpegoptim >/dev/null || ! command -v optipng >/dev/null; then
+    apk add --no-cache jpegoptim optipng findutils
+fi
+
+cd "$MEDIA_DIR"
+
+filters=""
+if [ -n "$MAX_AGE_MINUTES" ]; then
+    filters="$filters -mmin -$MAX_AGE_MINUTES"
+fi
+if [ -n "$MAX_SIZE_KB" ]; then
+    filters="$filters -size -${MAX_SIZE_KB}k"
+fi
+
+jpegoptim_args="--preserve --quiet"
+if [ -n "$JPEG_QUALITY" ]; then
+    jpegoptim_args="$jpegoptim_args --max=$JPEG_QUALITY"
+fi
+
+find . -type f \( -iname '*.jpg' -o -iname '*.jpeg' \) $filters -print0 | xargs -0 -r -n 16 -P "$CONCURRENCY" jpegoptim $jpegoptim_args
+find . -type f -iname '*.png' $filters -print0 | xargs -0 -r -n 16 -P "$CONCURRENCY" optipng -quiet -preserve -o2
+`
+
+// the offloaded static assets are left out
+const downloadImagesScript = `
+set -e
+
+set -- --ignore-case --filter "- /static/**" --filter "+ *.{jpg,jpeg,png}" --filter "- *"
+if [ -n "$MAX_AGE_MINUTES" ]; then
+    set -- "$@" --max-age "${MAX_AGE_MINUTES}m"
+fi
+if [ -n "$MAX_SIZE_KB" ]; then
+    set -- "$@" --max-size "${MAX_SIZE_KB}k"
+fi
+
+rclone copy "$@" "$MEDIA_REMOTE" "$MEDIA_DIR"
+`
+
+// only the optimized images differ in size, so only they are uploaded. The
+// images replaced in the meantime are newer, so they are skipped.
+const uploadImagesScript = `
+rclone copy --update "$MEDIA_DIR" "$MEDIA_REMOTE"
+`
+
+// OptimizesImages returns true if the media images are optimized on a
+// schedule. The images of persistent volume claims are reachable only if the
+// claims are shared by all the web pods.
+func (wp *Wordpress) OptimizesImages() bool {
+	media := wp.Spec.MediaVolumeSpec
+	if media == nil || media.ImageOptimization == nil {
+		return false
+	}
+
+	return wp.hasMediaBucket() || media.HostPath != nil || (media.PersistentVolumeClaim != nil && !wp.IsStatefulSet())
+}
+
+func (wp *Wordpress) imageOptimizationEnv() []corev1.EnvVar {
+	spec := wp.Spec.MediaVolumeSpec.ImageOptimization
+
+	concurrency := defaultImageOptimizationConcurrency
+	if spec.Concurrency != nil {
+		concurrency = *spec.Concurrency
+	}
+
+	out := []corev1.EnvVar{
+		{
+			Name:  "MEDIA_DIR",
+			Value: imagesMountPath,
+		},
+		{
+			Name:  "CONCURRENCY",
+			Value: strconv.Itoa(int(concurrency)),
+		},
+	}
+
+	if spec.MaxAge != nil {
+		out = append(out, corev1.EnvVar{
+			Name:  "MAX_AGE_MINUTES",
+			Value: strconv.Itoa(int(spec.MaxAge.Minutes())),
+		})
+	}
+
+	if spec.MaxFileSize != nil {
+		out = append(out, corev1.EnvVar{
+			Name:  "MAX_SIZE_KB",
+			Value: strconv.FormatInt(spec.MaxFileSize.Value()>>10, 10),
+		})
+	}
+
+	if spec.JPEGQuality != nil {
+		out = append(out, corev1.EnvVar{
+			Name:  "JPEG_QUALITY",
+			Value: strconv.Itoa(int(*spec.JPEGQuality)),
+		})
+	}
+
+	return out
+}
+
+// ImageOptimizationPodTemplateSpec generates the pod template spec of the job
+// which optimizes the media images. The images in buckets are downloaded and
+// uploaded back by rclone, around the optimizer.
+func (wp *Wordpress) ImageOptimizationPodTemplateSpec() (out corev1.PodTemplateSpec) {
+	spec := wp.Spec.MediaVolumeSpec.ImageOptimization
+
+	image := spec.Image
+	if image == "" {
+		image = options.ImageOptimizerImage
+	}
+
+	out = corev1.PodTemplateSpec{}
+
+	out.ObjectMeta.Labels = wp.ComponentLabels(WordpressImageOptimization)
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+
+	out.Spec.RestartPolicy = corev1.RestartPolicyNever
+
+	optimize := corev1.Container{
+		Name:      "optimize-images",
+		Image:     image,
+		Command:   []string{"/bin/sh", "-c", optimizeImagesScript},
+		Env:       wp.imageOptimizationEnv(),
+		Resources: spec.Resources,
+	}
+
+	if !wp.hasMediaBucket() {
+		optimize.VolumeMounts = []corev1.VolumeMount{
+			{
+				Name:      mediaVolumeName,
+				MountPath: imagesMountPath,
+				SubPath:   wp.Spec.MediaVolumeSpec.ContentSubPath,
+			},
+		}
+
+		out.Spec.Containers = []corev1.Container{optimize}
+		out.Spec.Volumes = []corev1.Volume{wp.mediaVolume()}
+
+		return out
+	}
+
+	remote, env := wp.mediaRclone()
+	env = append(env, wp.imageOptimizationEnv()...)
+	env = append(env, corev1.EnvVar{Name: "MEDIA_REMOTE", Value: remote})
+
+	mount := corev1.VolumeMount{
+		Name:      mediaVolumeName,
+		MountPath: imagesMountPath,
+	}
+	optimize.VolumeMounts = []corev1.VolumeMount{mount}
+
+	out.Spec.InitContainers = []corev1.Container{
+		{
+			Name:         "download-images",
+			Image:        options.RcloneImage,
+			Command:      []string{"/bin/sh", "-c", downloadImagesScript},
+			Env:          env,
+			VolumeMounts: []corev1.VolumeMount{mount},
+		},
+		optimize,
+	}
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:         "upload-images",
+			Image:        options.RcloneImage,
+			Command:      []string{"/bin/sh", "-c", uploadImagesScript},
+			Env:          env,
+			VolumeMounts: []corev1.VolumeMount{mount},
+		},
+	}
+	out.Spec.Volumes = []corev1.Volume{
+		{
+			Name: mediaVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+	}
+
+	return out
+}
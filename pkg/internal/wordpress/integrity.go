@@ -0,0 +1,103 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const defaultIntegrityCheckSchedule = "0 4 * * *"
+
+// integrityCheckScript verifies the core and the plugin files against the
+// checksums published on wordpress.org and reports the mismatches through the
+// termination message, one component|path|message per line. The plugins
+// whose checksums are not published are skipped by wp-cli. The job fails only
+// if the checksums couldn't be verified at all.
+const integrityCheckScript = `
+report=$(mktemp)
+
+if ! out=$(wp core verify-checksums 2>&1); then
+    files=$(echo "$out" | sed -n 's/^Warning: \(.*\): \(.*\)$/core|\2|\1/p')
+    if [ -z "$files" ]; then
+        echo "$out" >&2
+        exit 1
+    fi
+    echo "$files" >> "$report"
+fi
+
+if ! out=$(wp plugin verify-checksums --all --format=csv ${INTEGRITY_SKIP_PLUGINS:+--exclude="$INTEGRITY_SKIP_PLUGINS"}); then
+    files=$(echo "$out" | tail -n +2 | tr -d '"' | sed -n 's/^\([^,]*\),\([^,]*\),\(.*\)$/\1|\2|\3/p')
+    if [ -z "$files" ]; then
+        exit 1
+    fi
+    echo "$files" >> "$report"
+fi
+
+head -c 4096 "$report" | tee /dev/termination-log
+`
+
+// SchedulesIntegrityCheck returns true if the core and plugin files are
+// verified against their checksums by a CronJob.
+func (wp *Wordpress) SchedulesIntegrityCheck() bool {
+	return wp.Spec.IntegrityCheck != nil
+}
+
+// IntegrityCheckSchedule returns the schedule of the CronJob verifying the
+// core and plugin files.
+func (wp *Wordpress) IntegrityCheckSchedule() string {
+	if wp.Spec.IntegrityCheck.Schedule == "" {
+		return defaultIntegrityCheckSchedule
+	}
+
+	return wp.Spec.IntegrityCheck.Schedule
+}
+
+// IntegrityCheckPodTemplateSpec generates the pod template spec of the job
+// which verifies the core and plugin files against their checksums.
+func (wp *Wordpress) IntegrityCheckPodTemplateSpec() corev1.PodTemplateSpec {
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", integrityCheckScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "INTEGRITY_SKIP_PLUGINS", Value: strings.Join(wp.Spec.IntegrityCheck.SkipPlugins, ",")},
+	)
+
+	return out
+}
+
+// ParseIntegrityCheck parses the modified files reported by the integrity
+// check job.
+func ParseIntegrityCheck(report []string) []wordpressv1alpha1.ModifiedFile {
+	files := []wordpressv1alpha1.ModifiedFile{}
+
+	for _, line := range report {
+		parts := strings.SplitN(line, "|", 3)
+		if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+
+		files = append(files, wordpressv1alpha1.ModifiedFile{
+			Component: parts[0],
+			Path:      parts[1],
+			Message:   parts[2],
+		})
+	}
+
+	return files
+}
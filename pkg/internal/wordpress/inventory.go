@@ -0,0 +1,96 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const defaultInventorySchedule = "30 3 * * *"
+
+// inventoryScript reports the core, plugin and theme versions through the
+// termination message, one core|version or plugin|name|version|status|update
+// and theme|name|version|status|update per line. The report is cut at the
+// last line fitting into the termination message.
+const inventoryScript = `
+set -e
+
+core=$(wp core version)
+plugins=$(wp plugin list --fields=name,version,status,update_version --format=csv)
+themes=$(wp theme list --fields=name,version,status,update_version --format=csv)
+
+{
+    echo "core|$core"
+    echo "$plugins" | tail -n +2 | tr -d '"' | sed 's/,/|/g; s/^/plugin|/'
+    echo "$themes" | tail -n +2 | tr -d '"' | sed 's/,/|/g; s/^/theme|/'
+} | awk '{ n += length($0) + 1; if (n > 4096) exit; print }' | tee /dev/termination-log
+`
+
+// SchedulesInventory returns true if the core, plugin and theme versions are
+// collected by a CronJob.
+func (wp *Wordpress) SchedulesInventory() bool {
+	return wp.Spec.Inventory != nil
+}
+
+// InventorySchedule returns the schedule of the CronJob collecting the
+// versions.
+func (wp *Wordpress) InventorySchedule() string {
+	if wp.Spec.Inventory.Schedule == "" {
+		return defaultInventorySchedule
+	}
+
+	return wp.Spec.Inventory.Schedule
+}
+
+// InventoryPodTemplateSpec generates the pod template spec of the job which
+// collects the core, plugin and theme versions.
+func (wp *Wordpress) InventoryPodTemplateSpec() corev1.PodTemplateSpec {
+	return wp.JobPodTemplateSpec("/bin/sh", "-c", inventoryScript)
+}
+
+// ParseInventory parses the versions reported by the inventory job.
+func ParseInventory(report []string) wordpressv1alpha1.InventoryStatus {
+	inventory := wordpressv1alpha1.InventoryStatus{}
+
+	for _, line := range report {
+		parts := strings.Split(line, "|")
+
+		switch {
+		case parts[0] == "core" && len(parts) == 2:
+			inventory.WordpressVersion = parts[1]
+		case parts[0] == "plugin" && len(parts) == 5 && parts[1] != "":
+			inventory.Plugins = append(inventory.Plugins, installedExtension(parts[1:]))
+		case parts[0] == "theme" && len(parts) == 5 && parts[1] != "":
+			inventory.Themes = append(inventory.Themes, installedExtension(parts[1:]))
+		}
+	}
+
+	return inventory
+}
+
+func installedExtension(parts []string) wordpressv1alpha1.InstalledExtension {
+	return wordpressv1alpha1.InstalledExtension{
+		Name:          parts[0],
+		Version:       parts[1],
+		Status:        parts[2],
+		UpdateVersion: parts[3],
+	}
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	logsVolumeName = "logs"
+	logsMountPath  = "/var/log/stack"
+
+	logShipperOutputVolumeName = "log-shipper-output"
+	logShipperOutputMountPath  = "/fluent-bit/etc/output"
+	logShipperParsersFile      = "/fluent-bit/etc/parsers.conf"
+
+	defaultLogShipperOutputKey = "output.conf"
+)
+
+// LogFormat returns the format of the runtime's access and error logs.
+func (wp *Wordpress) LogFormat() wordpressv1alpha1.LogFormat {
+	if wp.Spec.Logging == nil || wp.Spec.Logging.Format == "" {
+		return wordpressv1alpha1.TextLogFormat
+	}
+
+	return wp.Spec.Logging.Format
+}
+
+// ShipsLogs returns true if the web pods run a fluent-bit sidecar shipping
+// the runtime's logs.
+func (wp *Wordpress) ShipsLogs() bool {
+	return wp.Spec.Logging != nil && wp.Spec.Logging.Shipper != nil
+}
+
+// LogShipperOutputKey returns the config map's key holding the fluent-bit outputs.
+func (wp *Wordpress) LogShipperOutputKey() string {
+	if wp.Spec.Logging.Shipper.OutputKey == "" {
+		return defaultLogShipperOutputKey
+	}
+
+	return wp.Spec.Logging.Shipper.OutputKey
+}
+
+func (wp *Wordpress) loggingEnv() []corev1.EnvVar {
+	if wp.Spec.Logging == nil || wp.Spec.Logging.Format == "" {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "STACK_LOG_FORMAT",
+			Value: string(wp.LogFormat()),
+		},
+	}
+}
+
+// webLoggingEnv returns the env vars making the runtime write its logs into
+// the volume shared with the log shipper, instead of its standard output.
+func (wp *Wordpress) webLoggingEnv() []corev1.EnvVar {
+	if !wp.ShipsLogs() {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{
+			Name:  "STACK_ACCESS_LOG",
+			Value: path.Join(logsMountPath, "access.log"),
+		},
+		{
+			Name:  "STACK_ERROR_LOG",
+			Value: path.Join(logsMountPath, "error.log"),
+		},
+	}
+}
+
+func (wp *Wordpress) logsVolumeMounts() []corev1.VolumeMount {
+	if !wp.ShipsLogs() {
+		return nil
+	}
+
+	return []corev1.VolumeMount{
+		{
+			Name:      logsVolumeName,
+			MountPath: logsMountPath,
+		},
+	}
+}
+
+// logShipperArgs returns the fluent-bit args tailing the access and error
+// logs, tagged as such, and adding the site to their records. The outputs are
+// read from the config map.
+func (wp *Wordpress) logShipperArgs() []string {
+	args := []string{"-R", logShipperParsersFile}
+
+	for _, log := range []string{"access", "error"} {
+		args = append(args,
+			"-i", "tail",
+			"-p", "path="+path.Join(logsMountPath, log+".log"),
+			"-p", "tag="+log,
+		)
+
+		if wp.LogFormat() == wordpressv1alpha1.JSONLogFormat {
+			args = append(args, "-p", "parser=json")
+		}
+	}
+
+	return append(args,
+		"-F", "record_modifier", "-m", "*",
+		"-p", "Record=site "+wp.Name,
+		"-p", "Record=namespace "+wp.Namespace,
+		"-c", path.Join(logShipperOutputMountPath, wp.LogShipperOutputKey()),
+	)
+}
+
+func (wp *Wordpress) logShipperContainers() []corev1.Container {
+	if !wp.ShipsLogs() {
+		return nil
+	}
+
+	spec := wp.Spec.Logging.Shipper
+
+	image := spec.Image
+	if image == "" {
+		image = options.LogShipperImage
+	}
+
+	return []corev1.Container{
+		{
+			Name:      "log-shipper",
+			Image:     image,
+			Command:   []string{"/fluent-bit/bin/fluent-bit"},
+			Args:      wp.logShipperArgs(),
+			Resources: spec.Resources,
+			VolumeMounts: []corev1.VolumeMount{
+				{
+					Name:      logsVolumeName,
+					MountPath: logsMountPath,
+					ReadOnly:  true,
+				},
+				{
+					Name:      logShipperOutputVolumeName,
+					MountPath: logShipperOutputMountPath,
+					ReadOnly:  true,
+				},
+			},
+		},
+	}
+}
+
+func (wp *Wordpress) logShipperVolumes() []corev1.Volume {
+	if !wp.ShipsLogs() {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: logsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+		{
+			Name: logShipperOutputVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.Spec.Logging.Shipper.OutputConfigMapName,
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// ExporterMetricsPort is the port the exporter sidecar serves the metrics on.
+	ExporterMetricsPort = 9253
+	// ExporterMetricsPortName is the name of the exporter's port, on the web
+	// pods and on the site's service, selected by the scrape configs.
+	ExporterMetricsPortName = "metrics"
+
+	defaultPHPFPMStatusURI = "tcp://127.0.0.1:9000/status"
+	defaultApacheStatusURI = "http://127.0.0.1:8080/server-status?auto"
+	defaultNginxStatusURI  = "http://127.0.0.1:8080/stub_status"
+)
+
+// RunsExporter returns true if the web pods run a Prometheus exporter sidecar.
+func (wp *Wordpress) RunsExporter() bool {
+	return wp.Spec.Monitoring != nil && wp.Spec.Monitoring.Exporter != nil
+}
+
+// ExporterType returns the server whose metrics are exported.
+func (wp *Wordpress) ExporterType() wordpressv1alpha1.ExporterType {
+	if wp.Spec.Monitoring.Exporter.Type == "" {
+		return wordpressv1alpha1.PHPFPMExporter
+	}
+
+	return wp.Spec.Monitoring.Exporter.Type
+}
+
+// ExporterStatusURI returns the runtime's status page scraped by the exporter.
+func (wp *Wordpress) ExporterStatusURI() string {
+	if wp.Spec.Monitoring.Exporter.StatusURI != "" {
+		return wp.Spec.Monitoring.Exporter.StatusURI
+	}
+
+	switch wp.ExporterType() {
+	case wordpressv1alpha1.ApacheExporter:
+		return defaultApacheStatusURI
+	case wordpressv1alpha1.NginxExporter:
+		return defaultNginxStatusURI
+	case wordpressv1alpha1.PHPFPMExporter:
+	}
+
+	return defaultPHPFPMStatusURI
+}
+
+// exporterArgs returns the image and the args of the exporter, each exporter
+// having its own flags for the scraped URI and the listen address.
+func (wp *Wordpress) exporterArgs() (string, []string) {
+	listenAddress := fmt.Sprintf(":%d", ExporterMetricsPort)
+
+	switch wp.ExporterType() {
+	case wordpressv1alpha1.ApacheExporter:
+		return options.ApacheExporterImage, []string{
+			"--scrape_uri=" + wp.ExporterStatusURI(),
+			"--web.listen-address=" + listenAddress,
+		}
+	case wordpressv1alpha1.NginxExporter:
+		return options.NginxExporterImage, []string{
+			"-nginx.scrape-uri=" + wp.ExporterStatusURI(),
+			"-web.listen-address=" + listenAddress,
+		}
+	case wordpressv1alpha1.PHPFPMExporter:
+	}
+
+	return options.PHPFPMExporterImage, []string{
+		"server",
+		"--phpfpm.scrape-uri=" + wp.ExporterStatusURI(),
+		"--web.listen-address=" + listenAddress,
+	}
+}
+
+func (wp *Wordpress) exporterContainers() []corev1.Container {
+	if !wp.RunsExporter() {
+		return nil
+	}
+
+	spec := wp.Spec.Monitoring.Exporter
+
+	image, args := wp.exporterArgs()
+	if spec.Image != "" {
+		image = spec.Image
+	}
+
+	return []corev1.Container{
+		{
+			Name:      "exporter",
+			Image:     image,
+			Args:      args,
+			Resources: spec.Resources,
+			Ports: []corev1.ContainerPort{
+				{
+					Name:          ExporterMetricsPortName,
+					ContainerPort: ExporterMetricsPort,
+				},
+			},
+		},
+	}
+}
+
+// ProbesDomains returns true if the availability of the site's domains is
+// monitored by a Probe, for which a prober must be set.
+func (wp *Wordpress) ProbesDomains() bool {
+	return wp.Spec.Monitoring != nil && wp.ProbeProberURL() != ""
+}
+
+// ProbeProberURL returns the address of the blackbox exporter probing the
+// site's domains.
+func (wp *Wordpress) ProbeProberURL() string {
+	if wp.Spec.Monitoring.Probe != nil && wp.Spec.Monitoring.Probe.ProberURL != "" {
+		return wp.Spec.Monitoring.Probe.ProberURL
+	}
+
+	return options.ProbeProberURL
+}
+
+// ProbeModule returns the blackbox exporter module the site's domains are
+// probed with.
+func (wp *Wordpress) ProbeModule() string {
+	if wp.Spec.Monitoring.Probe != nil && wp.Spec.Monitoring.Probe.Module != "" {
+		return wp.Spec.Monitoring.Probe.Module
+	}
+
+	return options.ProbeModule
+}
+
+// ProbeTargets returns the URLs of the site's routes and aliases, probed over
+// https for the domains served over TLS.
+func (wp *Wordpress) ProbeTargets() []string {
+	targets := []string{}
+	seen := map[string]bool{}
+
+	add := func(domain, p string) {
+		scheme := "http"
+		if len(wp.DomainTLSSecretName(domain)) > 0 {
+			scheme = "https"
+		}
+
+		target := fmt.Sprintf("%s://%s%s", scheme, domain, p)
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+
+	for _, route := range wp.Routes() {
+		add(route.Domain, path.Join("/", wp.RoutePath(route)))
+	}
+
+	for _, alias := range wp.Spec.Aliases {
+		add(alias, "/")
+	}
+
+	return targets
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const (
+	// SunriseKey is the key of the sunrise drop-in within the sunrise config map.
+	SunriseKey = "sunrise.php"
+
+	sunriseVolumeName = "sunrise"
+)
+
+// multisiteInstallScript installs the network, unless it's installed, and
+// creates the missing sub-sites. The network is installed without the
+// multisite constants, which are set once it's installed. The sub-sites are
+// passed one per line, as slug|url, on a separate file descriptor, as wp-cli
+// may read its standard input.
+const multisiteInstallScript = `
+set -e
+
+if ! wp core is-installed --network > /dev/null 2>&1; then
+    flags=""
+    if [ "$SUBDOMAIN_INSTALL" = "true" ]; then
+        flags="--subdomains"
+    fi
+
+    env -u MULTISITE -u SUNRISE wp core multisite-install --skip-config --skip-email $flags \
+        --title="$1" --url="$2" --base="$PATH_CURRENT_SITE" \
+        --admin_user="$3" --admin_password="$4" --admin_email="$5"
+fi
+
+while IFS='|' read -r slug url <&3; do
+    [ -n "$slug" ] || continue
+
+    if ! wp site list --field=url | grep -qxF "$url"; then
+        wp site create --slug="$slug"
+    fi
+done 3<<EOF
+$MULTISITE_SITES
+EOF
+`
+
+// sunriseTemplate maps the domains, given as a PHP array of domain => sub-site
+// domain and path, to the network's sub-sites.
+const sunriseTemplate = `<?php
+// Rendered by the wordpress-operator. Maps the domains to the network's sub-sites.
+
+$wordpress_operator_domains = array(
+%s);
+
+$wordpress_operator_host = strtolower( preg_replace( '/:\d+$/', '', $_SERVER['HTTP_HOST'] ?? '' ) );
+
+if ( isset( $wordpress_operator_domains[ $wordpress_operator_host ] ) ) {
+	list( $wordpress_operator_domain, $wordpress_operator_path ) = $wordpress_operator_domains[ $wordpress_operator_host ];
+
+	$current_blog = get_site_by_path( $wordpress_operator_domain, $wordpress_operator_path );
+
+	if ( $current_blog ) {
+		$current_blog->domain = $wordpress_operator_host;
+		$current_blog->path   = '/';
+
+		$blog_id      = $current_blog->blog_id;
+		$site_id      = $current_blog->site_id;
+		$current_site = get_network( $site_id );
+
+		define( 'COOKIE_DOMAIN', $wordpress_operator_host );
+
+		$wordpress_operator_url = function ( $url ) use ( $wordpress_operator_host ) {
+			return set_url_scheme( 'http://' . $wordpress_operator_host . wp_parse_url( $url, PHP_URL_PATH ) );
+		};
+
+		add_filter( 'option_home', $wordpress_operator_url );
+		add_filter( 'option_siteurl', $wordpress_operator_url );
+	}
+}
+`
+
+// phpEscaper escapes the values of single-quoted PHP strings.
+var phpEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// IsMultisite returns true if the site is run as a WordPress network.
+func (wp *Wordpress) IsMultisite() bool {
+	return wp.Spec.Multisite != nil
+}
+
+func (wp *Wordpress) isSubdomainInstall() bool {
+	return wp.Spec.Multisite.Mode == wordpressv1alpha1.MultisiteSubdomain
+}
+
+// pathCurrentSite returns the network's path, with a trailing slash.
+func (wp *Wordpress) pathCurrentSite() string {
+	return strings.TrimSuffix(wp.mainPath(), "/") + "/"
+}
+
+// subsiteLocation returns the domain and the path of a sub-site, as recorded
+// by WordPress.
+func (wp *Wordpress) subsiteLocation(site wordpressv1alpha1.SubsiteSpec) (string, string) {
+	if wp.isSubdomainInstall() {
+		return fmt.Sprintf("%s.%s", site.Slug, wp.MainDomain()), wp.pathCurrentSite()
+	}
+
+	return wp.MainDomain(), wp.pathCurrentSite() + site.Slug + "/"
+}
+
+// multisiteRoutes returns the routes of the network's sub-sites: their
+// subdomains and their mapped domains.
+func (wp *Wordpress) multisiteRoutes() []wordpressv1alpha1.RouteSpec {
+	routes := []wordpressv1alpha1.RouteSpec{}
+
+	if !wp.IsMultisite() {
+		return routes
+	}
+
+	for _, site := range wp.Spec.Multisite.Sites {
+		if wp.isSubdomainInstall() {
+			domain, _ := wp.subsiteLocation(site)
+			routes = append(routes, wordpressv1alpha1.RouteSpec{Domain: domain, Path: wp.mainPath()})
+		}
+
+		for _, domain := range site.Domains {
+			routes = append(routes, wordpressv1alpha1.RouteSpec{Domain: domain, Path: "/"})
+		}
+	}
+
+	return routes
+}
+
+// MapsDomains returns true if domains are mapped to any of the network's sub-sites.
+func (wp *Wordpress) MapsDomains() bool {
+	if !wp.IsMultisite() {
+		return false
+	}
+
+	for _, site := range wp.Spec.Multisite.Sites {
+		if len(site.Domains) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sunrise returns the sunrise drop-in mapping the domains to the network's sub-sites.
+func (wp *Wordpress) Sunrise() string {
+	domains := ""
+
+	for _, site := range wp.Spec.Multisite.Sites {
+		domain, p := wp.subsiteLocation(site)
+
+		for _, d := range site.Domains {
+			domains += fmt.Sprintf("\t'%s' => array( '%s', '%s' ),\n",
+				phpEscaper.Replace(strings.ToLower(d)), phpEscaper.Replace(domain), phpEscaper.Replace(p))
+		}
+	}
+
+	return fmt.Sprintf(sunriseTemplate, domains)
+}
+
+func (wp *Wordpress) multisiteEnv() []corev1.EnvVar {
+	if !wp.IsMultisite() {
+		return nil
+	}
+
+	return []corev1.EnvVar{
+		{Name: "MULTISITE", Value: "true"},
+		{Name: "SUBDOMAIN_INSTALL", Value: fmt.Sprintf("%t", wp.isSubdomainInstall())},
+		{Name: "DOMAIN_CURRENT_SITE", Value: wp.MainDomain()},
+		{Name: "PATH_CURRENT_SITE", Value: wp.pathCurrentSite()},
+		{Name: "SITE_ID_CURRENT_SITE", Value: "1"},
+		{Name: "BLOG_ID_CURRENT_SITE", Value: "1"},
+		{Name: "SUNRISE", Value: fmt.Sprintf("%t", wp.MapsDomains())},
+	}
+}
+
+// multisiteSites returns the sub-sites in the format read by the multisite
+// install script.
+func (wp *Wordpress) multisiteSites() string {
+	lines := make([]string, len(wp.Spec.Multisite.Sites))
+
+	for i, site := range wp.Spec.Multisite.Sites {
+		domain, p := wp.subsiteLocation(site)
+		lines[i] = fmt.Sprintf("%s|%s://%s%s", site.Slug, wp.Scheme(), domain, p)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (wp *Wordpress) sunriseVolumes() []corev1.Volume {
+	if !wp.MapsDomains() {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: sunriseVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.ComponentName(WordpressSunrise),
+					},
+				},
+			},
+		},
+	}
+}
+
+// sunriseVolumeMounts mounts the sunrise drop-in within wp-content. It's
+// mounted by its sub-path, so the web pods are rolled when it changes.
+func (wp *Wordpress) sunriseVolumeMounts() []corev1.VolumeMount {
+	if !wp.MapsDomains() {
+		return nil
+	}
+
+	contentDir := defaultCodeMountPath
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.MountPath != "" {
+		contentDir = wp.Spec.CodeVolumeSpec.MountPath
+	}
+
+	return []corev1.VolumeMount{
+		{
+			Name:      sunriseVolumeName,
+			MountPath: path.Join(contentDir, SunriseKey),
+			SubPath:   SunriseKey,
+			ReadOnly:  true,
+		},
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// optionsScript updates the options which differ from the spec. They are
+// passed one per line, as name|variable, the variable holding the option's
+// value, on a separate file descriptor, as wp-cli may read its standard
+// input. The names of the updated options are reported through the
+// termination message, one per line.
+const optionsScript = `
+set -e
+
+drift=$(mktemp)
+
+while IFS='|' read -r name variable <&3; do
+    [ -n "$name" ] || continue
+
+    value=$(printenv "$variable" || true)
+    current=$(wp option get "$name" 2>/dev/null || true)
+
+    if [ "$current" != "$value" ]; then
+        echo "$name" >> "$drift"
+        wp option update "$name" "$value"
+    fi
+done 3<<EOF
+$OPTIONS
+EOF
+
+cat "$drift"
+tail -c 4096 "$drift" > /dev/termination-log
+`
+
+// ManagesOptions returns true if the site's options are managed by the operator.
+func (wp *Wordpress) ManagesOptions() bool {
+	return len(wp.Spec.Options) > 0
+}
+
+// OptionsVersion identifies the options spec and the code version the
+// options are synced for.
+func (wp *Wordpress) OptionsVersion() string {
+	names := wp.optionNames()
+	values := make([]string, len(names))
+
+	for i, name := range names {
+		option := wp.Spec.Options[name]
+		if option.SecretKeyRef != nil {
+			values[i] = fmt.Sprintf("%s<-%s/%s", name, option.SecretKeyRef.Name, option.SecretKeyRef.Key)
+		} else {
+			values[i] = fmt.Sprintf("%s=%s", name, option.Value)
+		}
+	}
+
+	return hash(wp.CodeVersion(), strings.Join(values, "\n"))
+}
+
+// optionNames returns the names of the options, sorted so that the list and
+// the environment of the job are stable.
+func (wp *Wordpress) optionNames() []string {
+	names := make([]string, 0, len(wp.Spec.Options))
+	for name := range wp.Spec.Options {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// optionsEnv returns the list of the options, in the format read by the
+// options script, and the variables holding their values.
+func (wp *Wordpress) optionsEnv() (string, []corev1.EnvVar) {
+	names := wp.optionNames()
+	lines := make([]string, len(names))
+	env := make([]corev1.EnvVar, len(names))
+
+	for i, name := range names {
+		option := wp.Spec.Options[name]
+		variable := fmt.Sprintf("WP_OPTION_%d", i)
+
+		lines[i] = fmt.Sprintf("%s|%s", name, variable)
+		env[i] = corev1.EnvVar{Name: variable, Value: option.Value}
+
+		if option.SecretKeyRef != nil {
+			env[i] = corev1.EnvVar{
+				Name:      variable,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: option.SecretKeyRef},
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n"), env
+}
+
+// OptionsPodTemplateSpec generates the pod template spec of the job which
+// updates the options which differ from the spec.
+func (wp *Wordpress) OptionsPodTemplateSpec() corev1.PodTemplateSpec {
+	list, env := wp.optionsEnv()
+
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", optionsScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env, env...)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env, corev1.EnvVar{Name: "OPTIONS", Value: list})
+
+	return out
+}
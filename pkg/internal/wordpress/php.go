@@ -0,0 +1,124 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// phpSize formats a quantity using PHP's shorthand notation, in megabytes.
+func phpSize(q *resource.Quantity) string {
+	return strconv.FormatInt(megabytes(*q), 10) + "M"
+}
+
+// phpEnv returns the env vars tuning the runtime's PHP-FPM pool and PHP settings.
+func (wp *Wordpress) phpEnv() []corev1.EnvVar {
+	php := wp.Spec.PHP
+	if php == nil {
+		return wp.opcacheEnv()
+	}
+
+	out := []corev1.EnvVar{}
+
+	if php.PM != "" {
+		out = append(out, corev1.EnvVar{Name: "PHP_PM", Value: string(php.PM)})
+	}
+
+	// the env vars are kept in order, to avoid rolling the pods
+	for _, v := range []struct {
+		name  string
+		value *int32
+	}{
+		{"PHP_PM_MAX_CHILDREN", php.MaxChildren},
+		{"PHP_PM_MAX_REQUESTS", php.MaxRequests},
+		{"PHP_MAX_EXECUTION_TIME", php.MaxExecutionTime},
+	} {
+		if v.value != nil {
+			out = append(out, corev1.EnvVar{Name: v.name, Value: strconv.Itoa(int(*v.value))})
+		}
+	}
+
+	for _, v := range []struct {
+		name  string
+		value *resource.Quantity
+	}{
+		{"PHP_MEMORY_LIMIT", php.MemoryLimit},
+		{"PHP_UPLOAD_MAX_FILESIZE", php.UploadMaxFilesize},
+		{"PHP_POST_MAX_SIZE", php.PostMaxSize},
+	} {
+		if v.value != nil {
+			out = append(out, corev1.EnvVar{Name: v.name, Value: phpSize(v.value)})
+		}
+	}
+
+	return append(out, wp.opcacheEnv()...)
+}
+
+// opcacheValidatesTimestamps returns whether the OPcache checks the scripts
+// for updates, or nil to keep the image's default.
+func (wp *Wordpress) opcacheValidatesTimestamps() *bool {
+	if wp.Spec.PHP != nil && wp.Spec.PHP.Opcache != nil && wp.Spec.PHP.Opcache.ValidateTimestamps != nil {
+		return wp.Spec.PHP.Opcache.ValidateTimestamps
+	}
+
+	// the code cloned into a read-only volume doesn't change during the pods' lifetime
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.GitDir != nil && wp.Spec.CodeVolumeSpec.ReadOnly {
+		validate := false
+
+		return &validate
+	}
+
+	return nil
+}
+
+// opcacheEnv returns the env vars tuning the PHP OPcache.
+func (wp *Wordpress) opcacheEnv() []corev1.EnvVar {
+	out := []corev1.EnvVar{}
+
+	if validate := wp.opcacheValidatesTimestamps(); validate != nil {
+		value := "0"
+		if *validate {
+			value = "1"
+		}
+
+		out = append(out, corev1.EnvVar{Name: "PHP_OPCACHE_VALIDATE_TIMESTAMPS", Value: value})
+	}
+
+	if wp.Spec.PHP == nil || wp.Spec.PHP.Opcache == nil {
+		return out
+	}
+
+	opcache := wp.Spec.PHP.Opcache
+
+	// opcache.memory_consumption is set in megabytes
+	if opcache.Memory != nil {
+		out = append(out, corev1.EnvVar{Name: "PHP_OPCACHE_MEMORY_CONSUMPTION", Value: strconv.FormatInt(megabytes(*opcache.Memory), 10)})
+	}
+
+	if opcache.RevalidateFreq != nil {
+		out = append(out, corev1.EnvVar{Name: "PHP_OPCACHE_REVALIDATE_FREQ", Value: strconv.Itoa(int(*opcache.RevalidateFreq))})
+	}
+
+	if opcache.Preload != "" {
+		out = append(out, corev1.EnvVar{Name: "PHP_OPCACHE_PRELOAD", Value: opcache.Preload})
+	}
+
+	return out
+}
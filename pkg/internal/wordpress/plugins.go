@@ -0,0 +1,68 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+// ManagesPlugins returns true if the site's plugins are managed by the operator.
+func (wp *Wordpress) ManagesPlugins() bool {
+	return len(wp.Spec.Plugins) > 0
+}
+
+// PluginsVersion identifies the plugins spec and the code version the
+// plugins are synced for.
+func (wp *Wordpress) PluginsVersion() string {
+	return hash(wp.CodeVersion(), wp.pluginsList())
+}
+
+// pluginsList returns the plugins in the format read by the extensions script.
+func (wp *Wordpress) pluginsList() string {
+	lines := make([]string, len(wp.Spec.Plugins))
+
+	for i, p := range wp.Spec.Plugins {
+		state := p.State
+		if state == "" {
+			state = wordpressv1alpha1.PluginActive
+		}
+
+		lines[i] = fmt.Sprintf("%s|%s|%s|%s", p.Name, p.Version, p.Source, state)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// installPluginsContainer installs the missing plugins into the web pods.
+func (wp *Wordpress) installPluginsContainer() []corev1.Container {
+	if !wp.ManagesPlugins() {
+		return nil
+	}
+
+	return []corev1.Container{wp.installExtensionsContainer("plugin", wp.pluginsList())}
+}
+
+// PluginsPodTemplateSpec generates the pod template spec of the job which
+// syncs the plugins with the spec.
+func (wp *Wordpress) PluginsPodTemplateSpec() corev1.PodTemplateSpec {
+	return wp.extensionsPodTemplateSpec("plugin", wp.pluginsList())
+}
@@ -20,13 +20,16 @@ import (
 	"bytes"
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"text/template"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
 	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
 )
 
@@ -37,9 +40,12 @@ const (
 	MetricsExporterPort = 9145
 	codeVolumeName      = "code"
 	mediaVolumeName     = "media"
+	webServerConfigName = "web-server-config"
 	s3Prefix            = "s3"
 	gcsPrefix           = "gs"
 
+	hostnameTopologyKey = "kubernetes.io/hostname"
+
 	prepareVolumesImage = "gcr.io/google-containers/busybox@sha256:545e6a6310a27636260920bc07b994a299b6708a1b26910cfefd335fdfb60d2b"
 )
 
@@ -63,6 +69,16 @@ if [ -z "$GIT_CLONE_URL" ] ; then
     exit 1
 fi
 
+# the clone of a restarted pod is only updated
+if [ "$(git -C "$SRC_DIR" config --get remote.origin.url 2>/dev/null)" = "$GIT_CLONE_URL" ] ; then
+    set -x
+    cd "$SRC_DIR"
+    git fetch origin
+    git checkout -f -B "$GIT_CLONE_REF" "origin/$GIT_CLONE_REF"
+    git clean -fdx
+    exit 0
+fi
+
 find "$SRC_DIR" -maxdepth 1 -mindepth 1 -print0 | xargs -0 /bin/rm -rf
 
 set -x
@@ -145,24 +161,27 @@ func (wp *Wordpress) routes() []string {
 		return []string{wp.MainDomain()}
 	}
 
-	out := make([]string, len(wp.Spec.Routes))
+	routes := wp.Routes()
+	out := make([]string, len(routes))
 
-	for i, r := range wp.Spec.Routes {
-		out[i] = path.Join(r.Domain, r.Path)
+	for i, r := range routes {
+		out[i] = path.Join(r.Domain, wp.RoutePath(r))
 	}
 
 	return out
 }
 
 func (wp *Wordpress) env() []corev1.EnvVar {
+	homeURL, siteURL := wp.rolledOutURLs()
+
 	out := append([]corev1.EnvVar{
 		{
 			Name:  "WP_HOME",
-			Value: wp.HomeURL(),
+			Value: homeURL,
 		},
 		{
 			Name:  "WP_SITEURL",
-			Value: wp.SiteURL(),
+			Value: siteURL,
 		},
 		{
 			Name:  "WP_CORE_DIRECTORY",
@@ -180,13 +199,92 @@ func (wp *Wordpress) env() []corev1.EnvVar {
 			Name:  "STACK_SITE_NAMESPACE",
 			Value: wp.Namespace,
 		},
-	}, wp.Spec.Env...)
+	}, wp.databaseEnv()...)
+
+	out = append(out, wp.cacheEnv()...)
+	out = append(out, wp.phpEnv()...)
+	out = append(out, wp.cdnEnv()...)
+	out = append(out, wp.cronEnv()...)
+	out = append(out, wp.smtpEnv()...)
+	out = append(out, wp.loggingEnv()...)
+	out = append(out, wp.multisiteEnv()...)
+	out = append(out, wp.Spec.Env...)
+
+	if (wp.Spec.HonorForwardedHeaders || wp.RuntimeProxyProtocol()) && len(wp.Spec.TrustedProxies) > 0 {
+		out = append(out, corev1.EnvVar{
+			Name:  "STACK_TRUSTED_PROXIES",
+			Value: strings.Join(wp.Spec.TrustedProxies, ","),
+		})
+	}
+
+	if wp.RuntimeProxyProtocol() {
+		out = append(out, corev1.EnvVar{
+			Name:  "STACK_PROXY_PROTOCOL",
+			Value: "true",
+		})
+	}
+
+	if wp.RedirectToHTTPS() {
+		out = append(out, corev1.EnvVar{
+			Name:  "FORCE_SSL_ADMIN",
+			Value: "true",
+		})
+	}
 
 	out = append(out, wp.mediaEnv()...)
+	out = append(out, wp.staticAssetsEnv()...)
 
 	return out
 }
 
+// databaseEnv returns the env vars pointing the runtime to the external
+// database and configuring TLS and the table prefix. The credentials of a
+// provisioned database are set into the site's secret.
+func (wp *Wordpress) databaseEnv() []corev1.EnvVar {
+	out := wp.databaseTLSEnv()
+
+	// read by pg4wp
+	if wp.UsesPostgres() {
+		out = append(out, corev1.EnvVar{
+			Name:  "DB_DRIVER",
+			Value: "pgsql",
+		})
+	}
+
+	if wp.Spec.Database != nil && wp.Spec.Database.TablePrefix != "" {
+		out = append(out, corev1.EnvVar{
+			Name:  "DB_TABLE_PREFIX",
+			Value: wp.Spec.Database.TablePrefix,
+		})
+	}
+
+	if !wp.HasExternalDatabase() {
+		return out
+	}
+
+	out = append(out, []corev1.EnvVar{
+		{
+			Name:  "DB_HOST",
+			Value: wp.DatabaseHost(),
+		},
+		{
+			Name:  "DB_NAME",
+			Value: wp.Spec.Database.Name,
+		},
+	}...)
+
+	if len(wp.Spec.Database.CredentialsSecretRef) == 0 {
+		return out
+	}
+
+	credentials := string(wp.Spec.Database.CredentialsSecretRef)
+
+	return append(out,
+		secretKeyEnvVar("DB_USER", credentials, "USER"),
+		secretKeyEnvVar("DB_PASSWORD", credentials, "PASSWORD"),
+	)
+}
+
 func (wp *Wordpress) envFrom() []corev1.EnvFromSource {
 	out := []corev1.EnvFromSource{
 		{
@@ -198,6 +296,8 @@ func (wp *Wordpress) envFrom() []corev1.EnvFromSource {
 		},
 	}
 
+	out = append(out, wp.cdnEnvFrom("CDN_")...)
+	out = append(out, wp.secretsProviderEnvFrom()...)
 	out = append(out, wp.Spec.EnvFrom...)
 
 	return out
@@ -274,7 +374,18 @@ func (wp *Wordpress) volumeMounts() []corev1.VolumeMount {
 		out = append(out, v)
 	}
 
-	return out
+	if wp.Spec.WebServerConfig != nil || wp.ConfiguresCompression() {
+		out = append(out, corev1.VolumeMount{
+			MountPath: wp.webServerConfigMountPath(),
+			Name:      webServerConfigName,
+			ReadOnly:  true,
+		})
+	}
+
+	out = append(out, wp.sunriseVolumeMounts()...)
+	out = append(out, wp.secretsProviderVolumeMounts()...)
+
+	return append(out, wp.databaseCAVolumeMounts()...)
 }
 
 func (wp *Wordpress) codeVolume() corev1.Volume {
@@ -376,7 +487,94 @@ func (wp *Wordpress) volumes() []corev1.Volume {
 		volumes = append(volumes, wp.mediaVolume())
 	}
 
-	return volumes
+	if wp.Spec.WebServerConfig != nil || wp.ConfiguresCompression() {
+		volumes = append(volumes, wp.webServerConfigVolume())
+	}
+
+	volumes = append(volumes, wp.sunriseVolumes()...)
+	volumes = append(volumes, wp.secretsProviderVolumes()...)
+	volumes = append(volumes, wp.databaseCAVolumes()...)
+	volumes = append(volumes, wp.databaseImportVolumes()...)
+	volumes = append(volumes, wp.contentImportVolumes()...)
+
+	return append(volumes, wp.cloudSQLVolumes()...)
+}
+
+// webServerConfigVolume returns the volume holding the user's web server
+// config snippets. The config rendered by the operator is projected along them.
+func (wp *Wordpress) webServerConfigVolume() corev1.Volume {
+	if !wp.ConfiguresCompression() {
+		return corev1.Volume{
+			Name: webServerConfigName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.Spec.WebServerConfig.ConfigMapName,
+					},
+				},
+			},
+		}
+	}
+
+	sources := []corev1.VolumeProjection{
+		{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: wp.ComponentName(WordpressWebServerConfig),
+				},
+			},
+		},
+	}
+
+	if wp.Spec.WebServerConfig != nil {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: wp.Spec.WebServerConfig.ConfigMapName,
+				},
+			},
+		})
+	}
+
+	return corev1.Volume{
+		Name: webServerConfigName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: sources,
+			},
+		},
+	}
+}
+
+// VolumeClaimTemplates returns the persistent volume claims created for each
+// web pod, when running as a StatefulSet. Their names match the volumes they replace.
+func (wp *Wordpress) VolumeClaimTemplates() []corev1.PersistentVolumeClaim {
+	claims := []corev1.PersistentVolumeClaim{}
+
+	// the git source takes precedence over the persistent volume claim (see codeVolume)
+	if wp.hasCodeMounts() && wp.Spec.CodeVolumeSpec.GitDir == nil && wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil {
+		claims = append(claims, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        codeVolumeName,
+				Labels:      labels.Merge(wp.Spec.CodeVolumeSpec.Labels, wp.ComponentLabels(WordpressCodePVC)),
+				Annotations: wp.Spec.CodeVolumeSpec.Annotations,
+			},
+			Spec: *wp.Spec.CodeVolumeSpec.PersistentVolumeClaim.DeepCopy(),
+		})
+	}
+
+	if wp.hasMediaMounts() && wp.Spec.MediaVolumeSpec.PersistentVolumeClaim != nil {
+		claims = append(claims, corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        mediaVolumeName,
+				Labels:      labels.Merge(wp.Spec.MediaVolumeSpec.Labels, wp.ComponentLabels(WordpressMediaPVC)),
+				Annotations: wp.Spec.MediaVolumeSpec.Annotations,
+			},
+			Spec: *wp.Spec.MediaVolumeSpec.PersistentVolumeClaim.DeepCopy(),
+		})
+	}
+
+	return claims
 }
 
 func (wp *Wordpress) securityContext() *corev1.SecurityContext {
@@ -479,30 +677,94 @@ func (wp *Wordpress) prepareVolumesContainer() corev1.Container {
 	return c
 }
 
-func (wp *Wordpress) installWPContainer() []corev1.Container {
-	if wp.Spec.WordpressBootstrapSpec == nil {
+// waitForDatabaseContainer waits for the database to accept connections, so
+// that install-wp doesn't crash loop while the database starts. The Cloud SQL
+// Auth Proxy doesn't run within init containers, so there is nothing to wait for.
+func (wp *Wordpress) waitForDatabaseContainer() []corev1.Container {
+	if wp.UsesCloudSQL() {
 		return []corev1.Container{}
 	}
 
+	timeout := defaultDatabaseTimeoutSeconds
+	if wp.Spec.WordpressBootstrapSpec.DatabaseTimeoutSeconds != nil {
+		timeout = *wp.Spec.WordpressBootstrapSpec.DatabaseTimeoutSeconds
+	}
+
+	env := append(wp.env(), wp.Spec.WordpressBootstrapSpec.Env...)
+	env = append(env, corev1.EnvVar{
+		Name:  "DB_TIMEOUT",
+		Value: strconv.Itoa(int(timeout)),
+	})
+
 	return []corev1.Container{
 		{
-			Name:            "install-wp",
+			Name:            "wait-for-db",
 			Image:           wp.Spec.Image,
-			VolumeMounts:    wp.volumeMounts(),
-			Env:             append(wp.env(), wp.Spec.WordpressBootstrapSpec.Env...),
+			Env:             env,
 			EnvFrom:         append(wp.envFrom(), wp.Spec.WordpressBootstrapSpec.EnvFrom...),
-			Resources:       wp.Spec.Resources,
 			SecurityContext: wp.securityContext(),
-			Command:         []string{"wp-install"},
-			Args: []string{
-				"$(WORDPRESS_BOOTSTRAP_TITLE)",
-				wp.HomeURL(),
-				"$(WORDPRESS_BOOTSTRAP_USER)",
-				"$(WORDPRESS_BOOTSTRAP_PASSWORD)",
-				"$(WORDPRESS_BOOTSTRAP_EMAIL)",
-			},
+			Command:         []string{"/bin/sh", "-c", waitForDatabaseScript},
+		},
+	}
+}
+
+func (wp *Wordpress) installWPContainer() []corev1.Container {
+	if wp.Spec.WordpressBootstrapSpec == nil {
+		return []corev1.Container{}
+	}
+
+	if wp.Spec.WordpressBootstrapSpec.RunOnce && wp.Status.Bootstrapped {
+		return []corev1.Container{}
+	}
+
+	containers := wp.waitForDatabaseContainer()
+
+	// the site is bootstrapped from a SQL dump instead
+	if source := wp.Spec.WordpressBootstrapSpec.ImportFrom; source != nil {
+		if source.Backup != nil {
+			containers = append(containers, wp.downloadBackupContainer())
+		}
+
+		containers = append(containers, wp.importDatabaseContainer())
+	} else {
+		containers = append(containers, wp.installContainer())
+	}
+
+	// the content is imported into the installed site
+	if wp.importsContent() {
+		containers = append(containers, wp.importContentContainer())
+	}
+
+	return containers
+}
+
+// installContainer installs WordPress, or the network of a multisite.
+func (wp *Wordpress) installContainer() corev1.Container {
+	install := corev1.Container{
+		Name:            "install-wp",
+		Image:           wp.Spec.Image,
+		VolumeMounts:    wp.volumeMounts(),
+		Env:             append(wp.env(), wp.Spec.WordpressBootstrapSpec.Env...),
+		EnvFrom:         append(wp.envFrom(), wp.Spec.WordpressBootstrapSpec.EnvFrom...),
+		Resources:       wp.Spec.Resources,
+		SecurityContext: wp.securityContext(),
+		Command:         []string{"wp-install"},
+		Args: []string{
+			"$(WORDPRESS_BOOTSTRAP_TITLE)",
+			wp.HomeURL(),
+			"$(WORDPRESS_BOOTSTRAP_USER)",
+			"$(WORDPRESS_BOOTSTRAP_PASSWORD)",
+			"$(WORDPRESS_BOOTSTRAP_EMAIL)",
 		},
 	}
+
+	// the network is installed, and its sub-sites created, by wp-cli
+	if wp.IsMultisite() {
+		install.Command = []string{"/bin/sh", "-c", multisiteInstallScript, "--"}
+		install.Env = append(install.Env, corev1.EnvVar{Name: "MULTISITE_SITES", Value: wp.multisiteSites()})
+	}
+
+	return install
 }
 
 func (wp *Wordpress) initContainers() []corev1.Container {
@@ -524,6 +786,29 @@ func (wp *Wordpress) initContainers() []corev1.Container {
 	return containers
 }
 
+// jobInitContainers returns the init containers of the job pods. In the
+// lightweight mode, only the ones providing the code are kept, as the volumes
+// are prepared and the site is bootstrapped by the web pods.
+func (wp *Wordpress) jobInitContainers() []corev1.Container {
+	if wp.Spec.JobPolicy == nil || !wp.Spec.JobPolicy.Lightweight {
+		return wp.initContainers()
+	}
+
+	return wp.codeInitContainers()
+}
+
+// codeInitContainers returns the site's own init containers and the one
+// cloning the code, if it's deployed from git.
+func (wp *Wordpress) codeInitContainers() []corev1.Container {
+	containers := append([]corev1.Container{}, wp.Spec.InitContainers...)
+
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.GitDir != nil {
+		containers = append(containers, wp.gitCloneContainer())
+	}
+
+	return containers
+}
+
 func (wp *Wordpress) readinessProbe() *corev1.Probe {
 	// If the HTTPGetAction doesn't have any Host parameter it will use pod's IP address as Host.
 	// This is helpful because Wordpress may not be installed and in this case it will redirect to
@@ -541,7 +826,7 @@ func (wp *Wordpress) readinessProbe() *corev1.Probe {
 	return &corev1.Probe{
 		Handler: corev1.Handler{
 			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/",
+				Path: wp.mainPath(),
 				Port: intstr.FromInt(InternalHTTPPort),
 				HTTPHeaders: []corev1.HTTPHeader{
 					{
@@ -579,6 +864,77 @@ func (wp *Wordpress) livenessProbe() *corev1.Probe {
 	}
 }
 
+func (wp *Wordpress) webPodAffinity() *corev1.Affinity {
+	var affinity *corev1.Affinity
+	if wp.Spec.Affinity != nil {
+		affinity = wp.Spec.Affinity.DeepCopy()
+	}
+
+	preset := wp.Spec.PodAntiAffinityPreset
+	if preset == "" || preset == wordpressv1alpha1.PodAntiAffinityPresetNone {
+		return affinity
+	}
+
+	if affinity == nil {
+		affinity = &corev1.Affinity{}
+	}
+
+	// an explicitly set pod anti-affinity takes precedence over the preset
+	if affinity.PodAntiAffinity != nil {
+		return affinity
+	}
+
+	term := corev1.PodAffinityTerm{
+		LabelSelector: metav1.SetAsLabelSelector(wp.WebPodLabels()),
+		TopologyKey:   hostnameTopologyKey,
+	}
+
+	switch preset {
+	case wordpressv1alpha1.PodAntiAffinityPresetSoft:
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+				{Weight: 100, PodAffinityTerm: term},
+			},
+		}
+	case wordpressv1alpha1.PodAntiAffinityPresetHard:
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{term},
+		}
+	}
+
+	return affinity
+}
+
+func (wp *Wordpress) injectMeshSidecar() bool {
+	mesh := wp.Spec.ServiceMesh
+
+	return mesh != nil && (mesh.SidecarInjection == nil || *mesh.SidecarInjection)
+}
+
+// meshSidecarAnnotations sets the annotations enabling or disabling the
+// service mesh sidecar injection.
+func (wp *Wordpress) meshSidecarAnnotations(annotations map[string]string, inject bool) map[string]string {
+	if wp.Spec.ServiceMesh == nil {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	switch wp.Spec.ServiceMesh.Provider {
+	case wordpressv1alpha1.ServiceMeshIstio:
+		annotations["sidecar.istio.io/inject"] = strconv.FormatBool(inject)
+	case wordpressv1alpha1.ServiceMeshLinkerd:
+		annotations["linkerd.io/inject"] = "disabled"
+		if inject {
+			annotations["linkerd.io/inject"] = "enabled"
+		}
+	}
+
+	return annotations
+}
+
 // WebPodTemplateSpec generates a pod template spec suitable for use in Wordpress deployment.
 // nolint: funlen
 func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
@@ -589,19 +945,51 @@ func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
 	}
 
 	out.ObjectMeta.Labels = labels.Merge(out.ObjectMeta.Labels, wp.WebPodLabels())
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, wp.injectMeshSidecar())
+	out.ObjectMeta.Annotations = wp.veleroAnnotations(out.ObjectMeta.Annotations)
+	out.ObjectMeta.Annotations = wp.vaultAnnotations(out.ObjectMeta.Annotations, false)
+
+	// the pods are selected along with the site's objects, so that Velero runs their hooks
+	if wp.UsesVelero() {
+		out.ObjectMeta.Labels[wordpressv1alpha1.SiteLabel] = wp.Name
+	}
+
+	// the web pods are rolled when the rendered compression config changes
+	if wp.ConfiguresCompression() {
+		if out.ObjectMeta.Annotations == nil {
+			out.ObjectMeta.Annotations = make(map[string]string)
+		}
+
+		out.ObjectMeta.Annotations["wordpress.presslabs.org/compressionConfigVersion"] = hash(wp.CompressionConfig())
+	}
+
+	// the sunrise drop-in is mounted by its sub-path, so it's not updated in place
+	if wp.MapsDomains() {
+		if out.ObjectMeta.Annotations == nil {
+			out.ObjectMeta.Annotations = make(map[string]string)
+		}
+
+		out.ObjectMeta.Annotations["wordpress.presslabs.org/sunriseVersion"] = hash(wp.Sunrise())
+	}
 
 	out.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
-	if len(wp.Spec.ServiceAccountName) > 0 {
-		out.Spec.ServiceAccountName = wp.Spec.ServiceAccountName
+	if name := wp.ServiceAccountName(); len(name) > 0 {
+		out.Spec.ServiceAccountName = name
 	}
 
-	out.Spec.InitContainers = wp.initContainers()
+	out.Spec.InitContainers = append(wp.initContainers(), wp.installPluginsContainer()...)
+	out.Spec.InitContainers = append(out.Spec.InitContainers, wp.installThemesContainer()...)
+
+	env := append(wp.env(), wp.webDatabaseEnv()...)
+	env = append(env, wp.webCacheEnv()...)
+	env = append(env, wp.webLoggingEnv()...)
+
 	wordpressContainer := corev1.Container{
 		Name:            "wordpress",
 		Image:           wp.Spec.Image,
 		ImagePullPolicy: wp.Spec.ImagePullPolicy,
-		VolumeMounts:    wp.volumeMounts(),
-		Env:             wp.env(),
+		VolumeMounts:    append(wp.volumeMounts(), wp.logsVolumeMounts()...),
+		Env:             env,
 		EnvFrom:         wp.envFrom(),
 		Resources:       wp.Spec.Resources,
 		Ports: []corev1.ContainerPort{
@@ -637,8 +1025,15 @@ func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
 		LivenessProbe:  wp.livenessProbe(),
 	}
 	out.Spec.Containers = append([]corev1.Container{wordpressContainer}, wp.Spec.Sidecars...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.proxySQLContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.cloudSQLContainers(false)...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.memcachedContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.wafContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.exporterContainers()...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.logShipperContainers()...)
 
-	out.Spec.Volumes = wp.volumes()
+	out.Spec.Volumes = append(wp.volumes(), wp.wafVolumes()...)
+	out.Spec.Volumes = append(out.Spec.Volumes, wp.logShipperVolumes()...)
 
 	if len(wp.Spec.NodeSelector) > 0 {
 		out.Spec.NodeSelector = wp.Spec.NodeSelector
@@ -648,7 +1043,12 @@ func (wp *Wordpress) WebPodTemplateSpec() (out corev1.PodTemplateSpec) {
 		out.Spec.Tolerations = wp.Spec.Tolerations
 	}
 
-	out.Spec.Affinity = wp.Spec.Affinity
+	out.Spec.Affinity = wp.webPodAffinity()
+
+	// the pods of a statefulset get their DNS records through its service name
+	if wp.HasHeadlessService() && !wp.IsStatefulSet() {
+		out.Spec.Subdomain = wp.ComponentName(WordpressHeadlessService)
+	}
 
 	if len(wp.Spec.PriorityClassName) > 0 {
 		out.Spec.PriorityClassName = wp.Spec.PriorityClassName
@@ -666,26 +1066,29 @@ func (wp *Wordpress) JobPodTemplateSpec(cmd ...string) (out corev1.PodTemplateSp
 	}
 
 	out.ObjectMeta.Labels = labels.Merge(out.ObjectMeta.Labels, wp.JobPodLabels())
+	out.ObjectMeta.Annotations = wp.meshSidecarAnnotations(out.ObjectMeta.Annotations, false)
+	out.ObjectMeta.Annotations = wp.vaultAnnotations(out.ObjectMeta.Annotations, true)
 
 	out.Spec.ImagePullSecrets = wp.Spec.ImagePullSecrets
-	if len(wp.Spec.ServiceAccountName) > 0 {
-		out.Spec.ServiceAccountName = wp.Spec.ServiceAccountName
+	if name := wp.ServiceAccountName(); len(name) > 0 {
+		out.Spec.ServiceAccountName = name
 	}
 
 	out.Spec.RestartPolicy = corev1.RestartPolicyNever
 
-	out.Spec.InitContainers = wp.initContainers()
+	out.Spec.InitContainers = wp.jobInitContainers()
 	wordpressContainer := corev1.Container{
-		Name:            "wp-cli",
+		Name:            WPCliContainerName,
 		Image:           wp.Spec.Image,
 		ImagePullPolicy: wp.Spec.ImagePullPolicy,
-		Args:            cmd,
+		Args:            wp.jobArgs(cmd),
 		VolumeMounts:    wp.volumeMounts(),
 		Env:             wp.env(),
 		EnvFrom:         wp.envFrom(),
 		SecurityContext: wp.securityContext(),
 	}
 	out.Spec.Containers = append([]corev1.Container{wordpressContainer}, wp.Spec.Sidecars...)
+	out.Spec.Containers = append(out.Spec.Containers, wp.cloudSQLContainers(true)...)
 
 	out.Spec.Volumes = wp.volumes()
 
@@ -707,9 +1110,36 @@ func (wp *Wordpress) JobPodTemplateSpec(cmd ...string) (out corev1.PodTemplateSp
 		FSGroup: &wwwDataUserID,
 	}
 
+	wp.applyJobPodOverrides(&out)
+
 	return out
 }
 
+// applyJobPodOverrides overrides the scheduling and the resources the job pods
+// inherit from the web pods.
+func (wp *Wordpress) applyJobPodOverrides(out *corev1.PodTemplateSpec) {
+	overrides := wp.Spec.JobPodOverrides
+	if overrides == nil {
+		return
+	}
+
+	if len(overrides.NodeSelector) > 0 {
+		out.Spec.NodeSelector = overrides.NodeSelector
+	}
+
+	if len(overrides.Tolerations) > 0 {
+		out.Spec.Tolerations = overrides.Tolerations
+	}
+
+	if overrides.Affinity != nil {
+		out.Spec.Affinity = overrides.Affinity
+	}
+
+	if overrides.Resources != nil {
+		out.Spec.Containers[0].Resources = *overrides.Resources
+	}
+}
+
 func (wp *Wordpress) hasMediaMounts() bool {
 	if wp.Spec.MediaVolumeSpec == nil {
 		return false
@@ -745,3 +1175,29 @@ func (wp *Wordpress) hasCodeMounts() bool {
 
 	return false
 }
+
+func hasAccessMode(spec *corev1.PersistentVolumeClaimSpec, mode corev1.PersistentVolumeAccessMode) bool {
+	for _, m := range spec.AccessModes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasReadWriteOnceVolumes returns true if the code or media volumes are
+// ReadWriteOnce persistent volume claims.
+func (wp *Wordpress) HasReadWriteOnceVolumes() bool {
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.PersistentVolumeClaim != nil &&
+		hasAccessMode(wp.Spec.CodeVolumeSpec.PersistentVolumeClaim, corev1.ReadWriteOnce) {
+		return true
+	}
+
+	if wp.Spec.MediaVolumeSpec != nil && wp.Spec.MediaVolumeSpec.PersistentVolumeClaim != nil &&
+		hasAccessMode(wp.Spec.MediaVolumeSpec.PersistentVolumeClaim, corev1.ReadWriteOnce) {
+		return true
+	}
+
+	return false
+}
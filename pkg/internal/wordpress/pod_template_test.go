@@ -17,14 +17,18 @@ limitations under the License.
 package wordpress
 
 import (
+	"crypto/x509"
 	"fmt"
 	"math/rand"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -100,9 +104,10 @@ var _ = Describe("Web pod spec", func() {
 			w.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{}
 			containers := podSpec().Spec.InitContainers
 
-			Expect(containers).To(HaveLen(1))
-			Expect(containers[0].Name).To(Equal("install-wp"))
-			Expect(containers[0].Image).To(Equal(w.Spec.Image))
+			Expect(containers).To(HaveLen(2))
+			Expect(containers[0].Name).To(Equal("wait-for-db"))
+			Expect(containers[1].Name).To(Equal("install-wp"))
+			Expect(containers[1].Image).To(Equal(w.Spec.Image))
 		},
 		Entry("for web pod", func() (func() corev1.PodTemplateSpec, *Wordpress) {
 			return wp.WebPodTemplateSpec, wp
@@ -170,6 +175,98 @@ var _ = Describe("Web pod spec", func() {
 		Expect(wp.MainDomain()).To(Equal(fmt.Sprintf("%s.default.svc", wp.Name)))
 	})
 
+	It("should give me the minimum number of replicas", func() {
+		Expect(wp.MinReplicas()).To(Equal(int32(1)))
+
+		replicas := int32(3)
+		wp.Spec.Replicas = &replicas
+		Expect(wp.MinReplicas()).To(Equal(int32(3)))
+
+		wp.Spec.Autoscaling = &wordpressv1alpha1.AutoscalingSpec{MaxReplicas: 5}
+		Expect(wp.MinReplicas()).To(Equal(int32(1)))
+
+		minReplicas := int32(2)
+		wp.Spec.Autoscaling.MinReplicas = &minReplicas
+		Expect(wp.MinReplicas()).To(Equal(int32(2)))
+	})
+
+	It("should use the certificate secret once the certificate is issued", func() {
+		wp.Spec.TLSSecretRef = "custom-tls"
+		Expect(wp.TLSSecretName()).To(Equal("custom-tls"))
+
+		wp.Spec.TLS = &wordpressv1alpha1.TLSSpec{
+			IssuerRef: &wordpressv1alpha1.CertificateIssuerReference{Name: "letsencrypt"},
+		}
+		wp.SetCondition(wordpressv1alpha1.CertificateReadyCondition, corev1.ConditionFalse, "CertificatePending", "")
+		Expect(wp.TLSSecretName()).To(Equal("custom-tls"))
+
+		wp.SetCondition(wordpressv1alpha1.CertificateReadyCondition, corev1.ConditionTrue, "CertificateIssued", "")
+		Expect(wp.TLSSecretName()).To(Equal(fmt.Sprintf("%s-tls", wp.Name)))
+		Expect(wp.HomeURL()).To(Equal("https://test.com"))
+		Expect(wp.Status.Conditions).To(HaveLen(1))
+
+		wp.RemoveCondition(wordpressv1alpha1.CertificateReadyCondition)
+		Expect(wp.Status.Conditions).To(BeEmpty())
+	})
+
+	It("should report the domains the TLS secret's certificate doesn't cover", func() {
+		wp.Spec.TLSSecretRef = "custom-tls"
+		wp.Spec.Aliases = []string{"www.test.com", "shop.test.com", "other.com"}
+		wp.Spec.TLS = &wordpressv1alpha1.TLSSpec{
+			Secrets: []wordpressv1alpha1.DomainTLSSecret{{Domains: []string{"other.com"}, SecretName: "other-tls"}},
+		}
+		Expect(wp.ValidatesTLSSecret()).To(BeTrue())
+
+		cert := &x509.Certificate{DNSNames: []string{"test.com", "*.test.com"}}
+		Expect(wp.UncoveredDomains(cert)).To(BeEmpty())
+
+		cert.DNSNames = []string{"test.com"}
+		Expect(wp.UncoveredDomains(cert)).To(Equal([]string{"www.test.com", "shop.test.com"}))
+
+		_, err := ParseTLSCertificate(&corev1.Secret{Data: map[string][]byte{"tls.crt": []byte("invalid")}})
+		Expect(err).To(HaveOccurred())
+
+		wp.Spec.TLSSecretRef = ""
+		Expect(wp.ValidatesTLSSecret()).To(BeFalse())
+	})
+
+	It("should give me the unique domains of the routes and aliases", func() {
+		wp.Spec.Routes = append(wp.Spec.Routes, wordpressv1alpha1.RouteSpec{Domain: "test.com", Path: "/blog"})
+		wp.Spec.Aliases = []string{"www.test.com", "test.com"}
+
+		Expect(wp.Domains()).To(Equal([]string{"test.com", "www.test.com"}))
+	})
+
+	It("should name the search-replace job after the replaced URLs", func() {
+		wp.Status.HomeURL = "http://old.test.com"
+		name := wp.JobName(WordpressSearchReplace)
+		Expect(name).To(HavePrefix(fmt.Sprintf("%s-search-replace-", wp.Name)))
+
+		wp.Spec.Routes[0].Domain = "new.test.com"
+		Expect(wp.JobName(WordpressSearchReplace)).ToNot(Equal(name))
+	})
+
+	It("should keep the previous home URL in the web pods until the search-replace completes", func() {
+		wp.Spec.WordpressPathPrefix = "/wp"
+		wp.Status.HomeURL = wp.HomeURL()
+		oldHomeURL := wp.HomeURL()
+
+		wp.Spec.Routes[0].Domain = "new.test.com"
+		Expect(wp.SearchReplacePending()).To(BeFalse())
+
+		wp.Spec.SearchReplaceOnDomainChange = true
+		Expect(wp.SearchReplacePending()).To(BeTrue())
+
+		env := wp.WebPodTemplateSpec().Spec.Containers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "WP_HOME", Value: oldHomeURL}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "WP_SITEURL", Value: oldHomeURL + "/wp"}))
+
+		wp.Status.HomeURL = wp.HomeURL()
+		env = wp.WebPodTemplateSpec().Spec.Containers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "WP_HOME", Value: wp.HomeURL()}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "WP_SITEURL", Value: wp.SiteURL()}))
+	})
+
 	It("should give me right home URL, without trailing slash", func() {
 		// WP_HOME and WP_SITEURL should not contain a trailing slash,
 		// as per: https://wordpress.org/support/article/changing-the-site-url/
@@ -186,6 +283,1799 @@ var _ = Describe("Web pod spec", func() {
 		Expect(wp.HomeURL()).To(Equal("http://test.com/subpath"))
 	})
 
+	It("should set the pods subdomain when the headless service is enabled", func() {
+		Expect(wp.WebPodTemplateSpec().Spec.Subdomain).To(BeEmpty())
+
+		wp.Spec.Service = &wordpressv1alpha1.ServiceSpec{Headless: true}
+		Expect(wp.WebPodTemplateSpec().Spec.Subdomain).To(Equal(wp.ComponentName(WordpressHeadlessService)))
+
+		wp.Spec.WorkloadType = wordpressv1alpha1.WorkloadTypeStatefulSet
+		Expect(wp.WebPodTemplateSpec().Spec.Subdomain).To(BeEmpty())
+	})
+
+	It("should point the runtime to the external database", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{Host: "mysql.example.com", CredentialsSecretRef: "db-credentials"}
+		wp.SetDefaults()
+
+		e, found := lookupEnvVar("DB_HOST", wp.env())
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("mysql.example.com:3306"))
+
+		e, found = lookupEnvVar("DB_NAME", wp.env())
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("wordpress"))
+
+		e, found = lookupEnvVar("DB_PASSWORD", wp.env())
+		Expect(found).To(BeTrue())
+		Expect(e.ValueFrom.SecretKeyRef.Name).To(Equal("db-credentials"))
+		Expect(e.ValueFrom.SecretKeyRef.Key).To(Equal("PASSWORD"))
+
+		wp.Spec.Database.Provision = true
+		_, found = lookupEnvVar("DB_HOST", wp.env())
+		Expect(found).To(BeFalse())
+	})
+
+	It("should bootstrap the external database only when given admin credentials", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{Host: "mysql.example.com", CredentialsSecretRef: "db-credentials"}
+		wp.SetDefaults()
+		Expect(wp.BootstrapsDatabase()).To(BeFalse())
+
+		wp.Spec.Database.AdminCredentialsSecretRef = "db-admin"
+		Expect(wp.BootstrapsDatabase()).To(BeTrue())
+
+		env := wp.DatabaseBootstrapPodTemplateSpec().Spec.Containers[0].Env
+
+		e, found := lookupEnvVar("DB_PORT", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("3306"))
+
+		e, found = lookupEnvVar("MYSQL_PWD", env)
+		Expect(found).To(BeTrue())
+		Expect(e.ValueFrom.SecretKeyRef.Name).To(Equal("db-admin"))
+	})
+
+	It("should measure the database usage with the site's credentials", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			Provision: true,
+			Usage:     &wordpressv1alpha1.DatabaseUsageSpec{},
+		}
+		wp.SetDefaults()
+		Expect(wp.MeasuresDatabaseUsage()).To(BeTrue())
+		Expect(wp.DatabaseUsageInterval()).To(Equal(time.Hour))
+
+		env := wp.DatabaseUsagePodTemplateSpec().Spec.Containers[0].Env
+
+		e, found := lookupEnvVar("DB_HOST", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal(wp.DatabaseHost()))
+
+		e, found = lookupEnvVar("MYSQL_PWD", env)
+		Expect(found).To(BeTrue())
+		Expect(e.ValueFrom.SecretKeyRef.Name).To(Equal(wp.ComponentName(WordpressSecret)))
+		Expect(e.ValueFrom.SecretKeyRef.Key).To(Equal("DB_PASSWORD"))
+
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			Host:  "mysql.example.com",
+			Usage: &wordpressv1alpha1.DatabaseUsageSpec{},
+		}
+		Expect(wp.MeasuresDatabaseUsage()).To(BeFalse())
+	})
+
+	It("should run memcached within the web pods", func() {
+		wp.Spec.Cache = &wordpressv1alpha1.CacheSpec{Memcached: &wordpressv1alpha1.MemcachedSpec{}}
+		wp.SetDefaults()
+		Expect(wp.SharesMemcached()).To(BeFalse())
+
+		spec := wp.WebPodTemplateSpec().Spec
+		Expect(spec.Containers[len(spec.Containers)-1].Name).To(Equal("memcached"))
+		Expect(spec.Containers[len(spec.Containers)-1].Args).To(ContainElement("--memory-limit=64"))
+
+		e, found := lookupEnvVar("MEMCACHED_HOST", spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("127.0.0.1:11211"))
+
+		// the jobs can't reach the sidecar
+		_, found = lookupEnvVar("MEMCACHED_HOST", wp.JobPodTemplateSpec("true").Spec.Containers[0].Env)
+		Expect(found).To(BeFalse())
+	})
+
+	It("should point the pods to the shared memcached", func() {
+		memory := resource.MustParse("1Gi")
+		wp.Spec.Cache = &wordpressv1alpha1.CacheSpec{Memcached: &wordpressv1alpha1.MemcachedSpec{
+			Mode:   wordpressv1alpha1.MemcachedShared,
+			Memory: &memory,
+		}}
+		wp.SetDefaults()
+
+		spec := wp.WebPodTemplateSpec().Spec
+		for _, c := range spec.Containers {
+			Expect(c.Name).ToNot(Equal("memcached"))
+		}
+
+		host := fmt.Sprintf("%s-memcached.default:11211", wp.Name)
+
+		e, found := lookupEnvVar("MEMCACHED_HOST", spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal(host))
+
+		e, found = lookupEnvVar("MEMCACHED_HOST", wp.JobPodTemplateSpec("true").Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal(host))
+
+		Expect(wp.MemcachedPodTemplateSpec().Spec.Containers[0].Args).To(ContainElement("--memory-limit=1024"))
+	})
+
+	It("should point the pods to the page cache's purge endpoint", func() {
+		wp.Spec.Cache = &wordpressv1alpha1.CacheSpec{Page: &wordpressv1alpha1.PageCacheSpec{}}
+		wp.SetDefaults()
+
+		e, found := lookupEnvVar("PAGE_CACHE_PURGE_URL", wp.WebPodTemplateSpec().Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal(fmt.Sprintf("http://%s-page-cache.default", wp.Name)))
+
+		env := wp.PageCachePodTemplateSpec().Spec.Containers[0].Env
+
+		e, found = lookupEnvVar("BACKEND_HOST", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal(wp.ComponentName(WordpressService)))
+
+		e, found = lookupEnvVar("CACHE_SIZE", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("256M"))
+
+		e, found = lookupEnvVar("PURGE_TOKEN", env)
+		Expect(found).To(BeTrue())
+		Expect(e.ValueFrom.SecretKeyRef.Name).To(Equal(wp.ComponentName(WordpressSecret)))
+		Expect(e.ValueFrom.SecretKeyRef.Key).To(Equal(PageCachePurgeTokenKey))
+	})
+
+	It("should tune the runtime's PHP settings", func() {
+		maxChildren := int32(10)
+		memoryLimit := resource.MustParse("256Mi")
+		wp.Spec.PHP = &wordpressv1alpha1.PHPSpec{
+			PM:          wordpressv1alpha1.PHPProcessManagerStatic,
+			MaxChildren: &maxChildren,
+			MemoryLimit: &memoryLimit,
+		}
+
+		Expect(wp.phpEnv()).To(Equal([]corev1.EnvVar{
+			{Name: "PHP_PM", Value: "static"},
+			{Name: "PHP_PM_MAX_CHILDREN", Value: "10"},
+			{Name: "PHP_MEMORY_LIMIT", Value: "256M"},
+		}))
+
+		e, found := lookupEnvVar("PHP_MEMORY_LIMIT", wp.JobPodTemplateSpec("true").Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("256M"))
+	})
+
+	It("should stop validating the timestamps of read-only code cloned from git", func() {
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			ReadOnly: true,
+			GitDir:   &wordpressv1alpha1.GitVolumeSource{Repository: "https://github.com/example/site.git"},
+		}
+
+		Expect(wp.phpEnv()).To(Equal([]corev1.EnvVar{{Name: "PHP_OPCACHE_VALIDATE_TIMESTAMPS", Value: "0"}}))
+
+		validate := true
+		memory := resource.MustParse("128Mi")
+		wp.Spec.PHP = &wordpressv1alpha1.PHPSpec{
+			Opcache: &wordpressv1alpha1.PHPOpcacheSpec{
+				ValidateTimestamps: &validate,
+				Memory:             &memory,
+			},
+		}
+
+		Expect(wp.phpEnv()).To(Equal([]corev1.EnvVar{
+			{Name: "PHP_OPCACHE_VALIDATE_TIMESTAMPS", Value: "1"},
+			{Name: "PHP_OPCACHE_MEMORY_CONSUMPTION", Value: "128"},
+		}))
+
+		wp.Spec.CodeVolumeSpec.ReadOnly = false
+		wp.Spec.PHP = nil
+		Expect(wp.phpEnv()).To(BeEmpty())
+	})
+
+	It("should flush the caches of each domain", func() {
+		wp.Spec.Aliases = []string{"www.test.com"}
+		wp.Spec.Cache = &wordpressv1alpha1.CacheSpec{FlushOnRollout: true}
+		Expect(wp.FlushesCacheOnRollout()).To(BeTrue())
+
+		env := wp.CacheFlushPodTemplateSpec().Spec.Containers[0].Env
+
+		e, found := lookupEnvVar("PAGE_CACHE_HOSTS", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("test.com www.test.com"))
+
+		_, found = lookupEnvVar("SITEMAP_URL", env)
+		Expect(found).To(BeFalse())
+	})
+
+	It("should warm up the caches from the site's sitemap", func() {
+		wp.Spec.Cache = &wordpressv1alpha1.CacheSpec{
+			FlushOnRollout: true,
+			Warmup:         &wordpressv1alpha1.CacheWarmupSpec{},
+		}
+		Expect(wp.WarmsUpCacheOnSchedule()).To(BeFalse())
+
+		for _, env := range [][]corev1.EnvVar{
+			wp.CacheFlushPodTemplateSpec().Spec.Containers[0].Env,
+			wp.CacheWarmupPodTemplateSpec().Spec.Containers[0].Env,
+		} {
+			e, found := lookupEnvVar("SITEMAP_URL", env)
+			Expect(found).To(BeTrue())
+			Expect(e.Value).To(Equal("http://test.com/wp-sitemap.xml"))
+
+			e, found = lookupEnvVar("CONCURRENCY", env)
+			Expect(found).To(BeTrue())
+			Expect(e.Value).To(Equal("4"))
+		}
+	})
+
+	It("should point the pods to the CDN and purge it with its credentials", func() {
+		wp.Spec.CDN = &wordpressv1alpha1.CDNSpec{
+			Provider:             wordpressv1alpha1.CDNProviderCloudFront,
+			Zone:                 "E2QWRUHAPOMQZL",
+			Host:                 "cdn.example.com",
+			CredentialsSecretRef: "cdn",
+		}
+
+		container := wp.WebPodTemplateSpec().Spec.Containers[0]
+
+		e, found := lookupEnvVar("CDN_HOST", container.Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("cdn.example.com"))
+
+		Expect(container.EnvFrom).To(ContainElement(corev1.EnvFromSource{
+			Prefix:    "CDN_",
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "cdn"}},
+		}))
+
+		purge := wp.CDNPurgePodTemplateSpec().Spec.Containers[0]
+		Expect(purge.Image).To(Equal(options.AWSCLIImage))
+		Expect(purge.EnvFrom[0].Prefix).To(BeEmpty())
+		Expect(wp.PurgesCDNOnRollout()).To(BeTrue())
+	})
+
+	It("should offload the static assets and point the pods to them once uploaded", func() {
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			S3VolumeSource: &wordpressv1alpha1.S3VolumeSource{
+				Bucket:     "media",
+				PathPrefix: "test",
+				Env: []corev1.EnvVar{
+					{Name: "AWS_ACCESS_KEY_ID", Value: "key"},
+				},
+			},
+			StaticAssets: &wordpressv1alpha1.StaticAssetsSpec{
+				URL: "https://cdn.example.com/",
+			},
+		}
+		Expect(wp.OffloadsStaticAssets()).To(BeTrue())
+
+		_, found := lookupEnvVar("STACK_STATIC_ASSETS_URL", wp.WebPodTemplateSpec().Spec.Containers[0].Env)
+		Expect(found).To(BeFalse())
+
+		spec := wp.StaticAssetsPodTemplateSpec()
+		collect := spec.Spec.InitContainers[len(spec.Spec.InitContainers)-1]
+		Expect(collect.Name).To(Equal("collect-static-assets"))
+		Expect(collect.Image).To(Equal(wp.Spec.Image))
+
+		upload := spec.Spec.Containers[0]
+		Expect(upload.Image).To(Equal(options.RcloneImage))
+
+		e, found := lookupEnvVar("STATIC_ASSETS_REMOTE", upload.Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal(":s3:media/test/static/" + wp.StaticAssetsVersion()))
+
+		e, found = lookupEnvVar("RCLONE_S3_ACCESS_KEY_ID", upload.Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("key"))
+
+		wp.Status.StaticAssetsSyncedFor = wp.CodeVersion()
+
+		e, found = lookupEnvVar("STACK_STATIC_ASSETS_URL", wp.WebPodTemplateSpec().Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("https://cdn.example.com/" + wp.StaticAssetsVersion()))
+	})
+
+	It("should expand the size preset, keeping the fields set explicitly", func() {
+		replicas := int32(5)
+		wp.Spec.SizePreset = wordpressv1alpha1.SizePresetMedium
+		wp.Spec.Replicas = &replicas
+		wp.Spec.Resources.Limits = corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		}
+		wp.SetDefaults()
+
+		Expect(*wp.Spec.Replicas).To(Equal(int32(5)))
+		Expect(wp.Spec.Resources.Requests.Cpu().String()).To(Equal("500m"))
+		Expect(wp.Spec.Resources.Requests.Memory().String()).To(Equal("1Gi"))
+		Expect(wp.Spec.Resources.Limits.Memory().String()).To(Equal("4Gi"))
+
+		env := wp.WebPodTemplateSpec().Spec.Containers[0].Env
+
+		e, found := lookupEnvVar("PHP_PM_MAX_CHILDREN", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("10"))
+
+		e, found = lookupEnvVar("PHP_MEMORY_LIMIT", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("128M"))
+	})
+
+	It("should run the runtime image of the PHP version and variant", func() {
+		wp.Spec.Image = ""
+		wp.Spec.Runtime = &wordpressv1alpha1.RuntimeSpec{PHPVersion: "8.0", Variant: wordpressv1alpha1.RuntimeVariantApache}
+		wp.SetDefaults()
+		Expect(wp.Spec.Image).To(Equal(options.WordpressRuntimeImage + "-php-8.0-apache"))
+
+		// the unsupported combinations fall back to the default image
+		wp.Spec.Image = ""
+		wp.Spec.Runtime = &wordpressv1alpha1.RuntimeSpec{PHPVersion: "8.1", Variant: wordpressv1alpha1.RuntimeVariantApache}
+		wp.SetDefaults()
+		Expect(wp.Spec.Image).To(Equal(options.WordpressRuntimeImage))
+
+		_, err := wp.RuntimeImage()
+		Expect(err).To(MatchError(ErrUnsupportedRuntime))
+	})
+
+	It("should optimize the images of the media bucket around rclone", func() {
+		maxFileSize := resource.MustParse("10Mi")
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			GCSVolumeSource: &wordpressv1alpha1.GCSVolumeSource{
+				Bucket: "media",
+			},
+			ImageOptimization: &wordpressv1alpha1.ImageOptimizationSpec{
+				Schedule:    "0 3 * * *",
+				MaxAge:      &metav1.Duration{Duration: 24 * time.Hour},
+				MaxFileSize: &maxFileSize,
+			},
+		}
+		Expect(wp.OptimizesImages()).To(BeTrue())
+
+		spec := wp.ImageOptimizationPodTemplateSpec()
+		Expect(spec.Spec.InitContainers).To(HaveLen(2))
+		Expect(spec.Spec.InitContainers[0].Image).To(Equal(options.RcloneImage))
+		Expect(spec.Spec.InitContainers[1].Image).To(Equal(options.ImageOptimizerImage))
+		Expect(spec.Spec.Containers[0].Image).To(Equal(options.RcloneImage))
+
+		env := spec.Spec.Containers[0].Env
+
+		e, found := lookupEnvVar("MEDIA_REMOTE", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal(":gcs:media"))
+
+		e, found = lookupEnvVar("MAX_AGE_MINUTES", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("1440"))
+
+		e, found = lookupEnvVar("MAX_SIZE_KB", env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("10240"))
+	})
+
+	It("should optimize the images of shared media volumes in place", func() {
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{},
+			ImageOptimization: &wordpressv1alpha1.ImageOptimizationSpec{
+				Schedule: "0 3 * * *",
+			},
+		}
+		Expect(wp.OptimizesImages()).To(BeTrue())
+
+		spec := wp.ImageOptimizationPodTemplateSpec()
+		Expect(spec.Spec.InitContainers).To(BeEmpty())
+		Expect(spec.Spec.Containers[0].VolumeMounts[0].Name).To(Equal("media"))
+		Expect(spec.Spec.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal(wp.ComponentName(WordpressMediaPVC)))
+
+		// the claims of the StatefulSet's pods are not reachable
+		wp.Spec.WorkloadType = wordpressv1alpha1.WorkloadTypeStatefulSet
+		Expect(wp.OptimizesImages()).To(BeFalse())
+	})
+
+	It("should pool the database connections of the web pods", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			Host:                 "mysql.example.com",
+			CredentialsSecretRef: "db-credentials",
+			Pooling:              &wordpressv1alpha1.DatabasePoolingSpec{},
+		}
+		wp.SetDefaults()
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(2))
+		Expect(spec.Spec.Containers[1].Name).To(Equal("proxysql"))
+
+		e, found := lookupEnvVar("DB_SERVER_HOST", spec.Spec.Containers[1].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("mysql.example.com"))
+
+		e, found = lookupEnvVar("DB_HOST", spec.Spec.Containers[0].Env[len(spec.Spec.Containers[0].Env)-1:])
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("127.0.0.1:6033"))
+
+		// the jobs connect directly to the database
+		e, found = lookupEnvVar("DB_HOST", wp.JobPodTemplateSpec().Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("mysql.example.com:3306"))
+	})
+
+	It("should configure pg4wp for the experimental postgres engine", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			Engine:  wordpressv1alpha1.DatabaseEnginePostgres,
+			Host:    "postgres.example.com",
+			Pooling: &wordpressv1alpha1.DatabasePoolingSpec{},
+		}
+		wp.SetDefaults()
+		Expect(wp.PoolsDatabaseConnections()).To(BeFalse())
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(1))
+
+		e, found := lookupEnvVar("DB_DRIVER", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("pgsql"))
+
+		e, found = lookupEnvVar("DB_HOST", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("postgres.example.com:5432"))
+	})
+
+	It("should pass the table prefix to the runtime and the bootstrap", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{TablePrefix: "site_"}
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{}
+		wp.SetDefaults()
+
+		spec := wp.WebPodTemplateSpec()
+		for _, c := range []corev1.Container{spec.Spec.Containers[0], spec.Spec.InitContainers[1]} {
+			e, found := lookupEnvVar("DB_TABLE_PREFIX", c.Env)
+			Expect(found).To(BeTrue())
+			Expect(e.Value).To(Equal("site_"))
+		}
+	})
+
+	It("should import the database instead of installing WordPress", func() {
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{
+			ImportFrom: &wordpressv1alpha1.DatabaseImportSource{
+				PersistentVolumeClaim: &wordpressv1alpha1.DatabaseImportVolumeSource{
+					ClaimName: "dumps",
+					Path:      "mysite/dump.sql.gz",
+				},
+			},
+		}
+
+		spec := wp.WebPodTemplateSpec()
+		containers := spec.Spec.InitContainers
+		Expect(containers).To(HaveLen(2))
+		Expect(containers[1].Name).To(Equal("import-db"))
+
+		e, found := lookupEnvVar("IMPORT_FILE", containers[1].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("/var/run/presslabs.org/import/mysite/dump.sql.gz"))
+
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "db-import",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "dumps", ReadOnly: true},
+			},
+		}))
+	})
+
+	It("should import the content after installing WordPress", func() {
+		fetch := false
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{
+			ContentImport: &wordpressv1alpha1.ContentImportSpec{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "demo"},
+					Key:                  "content.xml",
+				},
+				AuthorMapping:    map[string]string{"jane": "admin", "bob": "editor"},
+				FetchAttachments: &fetch,
+			},
+		}
+
+		spec := wp.WebPodTemplateSpec()
+		containers := spec.Spec.InitContainers
+		Expect(containers).To(HaveLen(3))
+		Expect(containers[1].Name).To(Equal("install-wp"))
+		Expect(containers[2].Name).To(Equal("import-content"))
+
+		Expect(containers[2].Env).To(ContainElement(corev1.EnvVar{
+			Name: "IMPORT_FILE", Value: "/var/run/presslabs.org/content/content.xml",
+		}))
+		Expect(containers[2].Env).To(ContainElement(corev1.EnvVar{Name: "IMPORT_AUTHORS", Value: "bob,editor\njane,admin"}))
+		Expect(containers[2].Env).To(ContainElement(corev1.EnvVar{Name: "IMPORT_ATTACHMENTS", Value: "false"}))
+
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "content-import",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: "demo",
+					Items:      []corev1.KeyToPath{{Key: "content.xml", Path: "content.xml"}},
+				},
+			},
+		}))
+	})
+
+	It("should download the backup's database artifact before importing it", func() {
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{
+			ImportFrom: &wordpressv1alpha1.DatabaseImportSource{
+				Backup: &wordpressv1alpha1.BackupImportSource{
+					Destination: wordpressv1alpha1.BackupDestination{
+						S3: &wordpressv1alpha1.S3VolumeSource{Bucket: "backups", PathPrefix: "mysite"},
+					},
+					Path:       "default/mysite-backups-28000000/database.sql.gz.age",
+					Encryption: wordpressv1alpha1.BackupEncryptionAge,
+					DecryptionKeySecretRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "mysite-backups-key"},
+						Key:                  "identity",
+					},
+				},
+			},
+		}
+
+		spec := wp.WebPodTemplateSpec()
+		containers := spec.Spec.InitContainers
+		Expect(containers).To(HaveLen(3))
+		Expect(containers[1].Name).To(Equal("download"))
+		Expect(containers[2].Name).To(Equal("import-db"))
+
+		Expect(containers[1].Env).To(ContainElement(corev1.EnvVar{
+			Name: "BACKUP_REMOTE", Value: "backup:backups/mysite/default/mysite-backups-28000000",
+		}))
+		Expect(containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "BACKUP_ARTIFACT", Value: "database.sql.gz.age"}))
+		Expect(containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "BACKUP_ENCRYPTION", Value: "age"}))
+		Expect(containers[2].Env).To(ContainElement(corev1.EnvVar{
+			Name: "IMPORT_FILE", Value: "/var/run/presslabs.org/import/database.sql.gz",
+		}))
+
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name:         "db-import",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}))
+	})
+
+	It("should wait for the database before installing WordPress", func() {
+		timeout := int32(60)
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{DatabaseTimeoutSeconds: &timeout}
+
+		containers := wp.WebPodTemplateSpec().Spec.InitContainers
+		Expect(containers[0].Name).To(Equal("wait-for-db"))
+
+		e, found := lookupEnvVar("DB_TIMEOUT", containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("60"))
+
+		// the Cloud SQL Auth Proxy doesn't run within init containers
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			CloudSQL: &wordpressv1alpha1.CloudSQLSpec{InstanceConnectionName: "project:region:instance"},
+		}
+		wp.SetDefaults()
+
+		containers = wp.WebPodTemplateSpec().Spec.InitContainers
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0].Name).To(Equal("install-wp"))
+	})
+
+	It("should stop bootstrapping the site once it's bootstrapped, if it runs once", func() {
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{}
+		wp.Status.Bootstrapped = true
+		Expect(wp.WebPodTemplateSpec().Spec.InitContainers).To(HaveLen(2))
+
+		wp.Spec.WordpressBootstrapSpec.RunOnce = true
+		Expect(wp.WebPodTemplateSpec().Spec.InitContainers).To(BeEmpty())
+		Expect(wp.JobPodTemplateSpec().Spec.InitContainers).To(BeEmpty())
+	})
+
+	It("should only clone the code in the lightweight job pods", func() {
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{}
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			GitDir: &wordpressv1alpha1.GitVolumeSource{},
+		}
+		Expect(wp.JobPodTemplateSpec().Spec.InitContainers).To(HaveLen(4))
+
+		wp.Spec.JobPolicy = &wordpressv1alpha1.JobPolicySpec{Lightweight: true}
+		containers := wp.JobPodTemplateSpec().Spec.InitContainers
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0].Name).To(Equal("git"))
+
+		Expect(wp.WebPodTemplateSpec().Spec.InitContainers).To(HaveLen(4))
+	})
+
+	It("should schedule the job pods by the job pod overrides", func() {
+		wp.Spec.NodeSelector = map[string]string{"pool": "web"}
+		wp.Spec.JobPodOverrides = &wordpressv1alpha1.JobPodOverridesSpec{
+			NodeSelector: map[string]string{"pool": "batch"},
+			Tolerations: []corev1.Toleration{
+				{Key: "batch", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule},
+			},
+			Resources: &corev1.ResourceRequirements{
+				Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		}
+
+		job := wp.JobPodTemplateSpec("wp", "db", "export")
+		Expect(job.Spec.NodeSelector).To(Equal(map[string]string{"pool": "batch"}))
+		Expect(job.Spec.Tolerations).To(HaveLen(1))
+		Expect(job.Spec.Containers[0].Resources.Limits.Memory().String()).To(Equal("1Gi"))
+
+		web := wp.WebPodTemplateSpec()
+		Expect(web.Spec.NodeSelector).To(Equal(map[string]string{"pool": "web"}))
+		Expect(web.Spec.Tolerations).To(BeEmpty())
+	})
+
+	It("should run all the cleanup tasks by default", func() {
+		wp.Spec.Cleanup = &wordpressv1alpha1.CleanupSpec{}
+		Expect(wp.CleanupSchedule()).To(Equal("0 3 * * *"))
+
+		spec := wp.CleanupPodTemplateSpec()
+		Expect(spec.Spec.Containers[0].Args).To(Equal([]string{"/bin/sh", "-c", cleanupScript}))
+		Expect(spec.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+			Name:  "CLEANUP_TASKS",
+			Value: "spam-comments expired-transients orphaned-postmeta",
+		}))
+
+		wp.Spec.Cleanup.Tasks = []wordpressv1alpha1.CleanupTask{wordpressv1alpha1.CleanupExpiredTransients}
+		Expect(wp.CleanupPodTemplateSpec().Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+			Name:  "CLEANUP_TASKS",
+			Value: "expired-transients",
+		}))
+	})
+
+	It("should parse the results of the diagnostics", func() {
+		wp.Spec.Diagnostics = &wordpressv1alpha1.DiagnosticsSpec{}
+		Expect(wp.DiagnosticsPodTemplateSpec().Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+			Name:  "DIAGNOSTICS_CHECKS",
+			Value: "core-checksums plugin-updates file-permissions",
+		}))
+
+		Expect(ParseDiagnostics([]string{
+			"core-checksums|pass",
+			"plugin-updates|fail|updates are available for: akismet hello|dolly",
+			"garbage",
+		})).To(Equal([]wordpressv1alpha1.DiagnosticCheckResult{
+			{Name: wordpressv1alpha1.DiagnosticCoreChecksums, Passed: true},
+			{Name: wordpressv1alpha1.DiagnosticPluginUpdates, Message: "updates are available for: akismet hello|dolly"},
+		}))
+	})
+
+	It("should parse the files found modified by the integrity check", func() {
+		wp.Spec.IntegrityCheck = &wordpressv1alpha1.IntegrityCheckSpec{SkipPlugins: []string{"akismet", "premium"}}
+		Expect(wp.IntegrityCheckPodTemplateSpec().Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{
+			Name:  "INTEGRITY_SKIP_PLUGINS",
+			Value: "akismet,premium",
+		}))
+
+		Expect(ParseIntegrityCheck([]string{
+			"core|wp-includes/version.php|File doesn't verify against checksum",
+			"hello-dolly|hello.php|Checksum does not match",
+			"core|",
+			"garbage",
+		})).To(Equal([]wordpressv1alpha1.ModifiedFile{
+			{Component: "core", Path: "wp-includes/version.php", Message: "File doesn't verify against checksum"},
+			{Component: "hello-dolly", Path: "hello.php", Message: "Checksum does not match"},
+		}))
+	})
+
+	It("should parse the versions collected by the inventory", func() {
+		wp.Spec.Inventory = &wordpressv1alpha1.InventorySpec{}
+		Expect(wp.InventorySchedule()).To(Equal("30 3 * * *"))
+		Expect(wp.InventoryPodTemplateSpec().Spec.Containers[0].Args).To(Equal([]string{"/bin/sh", "-c", inventoryScript}))
+
+		Expect(ParseInventory([]string{
+			"core|6.3.1",
+			"plugin|akismet|5.2|active|5.3",
+			"plugin|hello|1.7.2|inactive|",
+			"theme|twentytwentythree|1.2|active|",
+			"plugin||1.0|active|",
+			"garbage",
+		})).To(Equal(wordpressv1alpha1.InventoryStatus{
+			WordpressVersion: "6.3.1",
+			Plugins: []wordpressv1alpha1.InstalledExtension{
+				{Name: "akismet", Version: "5.2", Status: "active", UpdateVersion: "5.3"},
+				{Name: "hello", Version: "1.7.2", Status: "inactive"},
+			},
+			Themes: []wordpressv1alpha1.InstalledExtension{
+				{Name: "twentytwentythree", Version: "1.2", Status: "active"},
+			},
+		}))
+	})
+
+	It("should back up the database and the media bucket to the destination", func() {
+		backup := &wordpressv1alpha1.WordpressBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+			Spec: wordpressv1alpha1.WordpressBackupSpec{
+				Destination: wordpressv1alpha1.BackupDestination{
+					S3: &wordpressv1alpha1.S3VolumeSource{
+						Bucket:     "backups",
+						PathPrefix: "sites",
+						Env:        []corev1.EnvVar{{Name: "AWS_ACCESS_KEY_ID", Value: "backup"}},
+					},
+				},
+			},
+		}
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			GCSVolumeSource: &wordpressv1alpha1.GCSVolumeSource{Bucket: "media"},
+		}
+		Expect(wp.HasPersistentMedia()).To(BeTrue())
+
+		spec := wp.BackupDatabasePodTemplateSpec(backup)
+		Expect(spec.Spec.Containers).To(HaveLen(2))
+		Expect(spec.Spec.Containers[1].Name).To(Equal(BackupUploadContainerName))
+		Expect(spec.Spec.Containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "BACKUP_REMOTE", Value: "backup:backups/sites/default/nightly"}))
+		Expect(spec.Spec.Containers[1].Env).To(ContainElement(corev1.EnvVar{Name: "RCLONE_CONFIG_BACKUP_ACCESS_KEY_ID", Value: "backup"}))
+
+		env := wp.BackupMediaPodTemplateSpec(backup).Spec.Containers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "MEDIA_SOURCE", Value: "media:media"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "RCLONE_CONFIG_MEDIA_TYPE", Value: "google cloud storage"}))
+
+		artifact, err := ParseBackupArtifact(backup, "database|1024|1|abc\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*artifact).To(Equal(wordpressv1alpha1.BackupArtifact{
+			Name:     "database",
+			Location: "s3://backups/sites/default/nightly/database.sql.gz",
+			Size:     1024,
+			Objects:  1,
+			Checksum: "abc",
+		}))
+
+		// the claims of the StatefulSet's pods are not reachable
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{}}
+		wp.Spec.WorkloadType = wordpressv1alpha1.WorkloadTypeStatefulSet
+		Expect(wp.HasPersistentMedia()).To(BeFalse())
+	})
+
+	It("should keep apart the backups of several namespaces sharing a destination", func() {
+		dest := wordpressv1alpha1.BackupDestination{
+			S3: &wordpressv1alpha1.S3VolumeSource{Bucket: "backups", PathPrefix: "sites"},
+		}
+		wp.Spec.Backups = &wordpressv1alpha1.BackupsSpec{Schedule: "@daily", Destination: dest}
+		backup := wp.ScheduledBackup(time.Unix(600, 0))
+
+		other := backup.DeepCopy()
+		other.Namespace = "other"
+		Expect(other.Name).To(Equal(backup.Name))
+
+		Expect(BackupLocation(backup, BackupDatabaseArtifact)).To(Equal(
+			fmt.Sprintf("s3://backups/sites/%s/%s/database.sql.gz", wp.Namespace, backup.Name)))
+		Expect(BackupLocation(other, BackupDatabaseArtifact)).To(Equal(
+			fmt.Sprintf("s3://backups/sites/other/%s/database.sql.gz", backup.Name)))
+
+		remote, _ := lookupEnvVar("BACKUP_REMOTE", wp.BackupDatabasePodTemplateSpec(backup).Spec.Containers[1].Env)
+		otherRemote, _ := lookupEnvVar("BACKUP_REMOTE", wp.BackupDatabasePodTemplateSpec(other).Spec.Containers[1].Env)
+		Expect(remote.Value).To(Equal(fmt.Sprintf("backup:backups/sites/%s/%s", wp.Namespace, backup.Name)))
+		Expect(otherRemote.Value).To(Equal(fmt.Sprintf("backup:backups/sites/other/%s", backup.Name)))
+	})
+
+	It("should encrypt the backup's artifacts", func() {
+		backup := &wordpressv1alpha1.WordpressBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly"},
+			Spec: wordpressv1alpha1.WordpressBackupSpec{
+				Destination: wordpressv1alpha1.BackupDestination{
+					GCS: &wordpressv1alpha1.GCSVolumeSource{Bucket: "backups"},
+				},
+				Encryption: &wordpressv1alpha1.BackupEncryption{
+					Recipients: []string{"age1one", "age1two"},
+				},
+			},
+		}
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			GCSVolumeSource: &wordpressv1alpha1.GCSVolumeSource{Bucket: "media"},
+		}
+
+		upload := wp.BackupDatabasePodTemplateSpec(backup).Spec.Containers[1]
+		Expect(upload.Command[2]).To(ContainSubstring("apk add --no-cache age gnupg"))
+		Expect(upload.Env).To(ContainElement(corev1.EnvVar{Name: "BACKUP_ENCRYPTION", Value: "age"}))
+		Expect(upload.Env).To(ContainElement(corev1.EnvVar{Name: "BACKUP_AGE_RECIPIENTS", Value: "age1one\nage1two"}))
+
+		// the media is archived in the backup volume before it's encrypted
+		media := wp.BackupMediaPodTemplateSpec(backup).Spec
+		Expect(media.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "backup", MountPath: "/backup"}))
+
+		artifact, err := ParseBackupArtifact(backup, "media|2048|10|abc|age1one,age1two\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifact.Location).To(Equal("gs://backups/nightly/media.tar.gz.age"))
+		Expect(artifact.Encryption).To(Equal(&wordpressv1alpha1.BackupArtifactEncryption{
+			Method: wordpressv1alpha1.BackupEncryptionAge,
+			Keys:   []string{"age1one", "age1two"},
+		}))
+
+		// a passphrase is used by GPG, and isn't reported
+		backup.Spec.Encryption = &wordpressv1alpha1.BackupEncryption{
+			PassphraseSecretRef: &corev1.SecretKeySelector{Key: "passphrase"},
+		}
+		artifact, err = ParseBackupArtifact(backup, "database|1024|1|abc|\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(artifact.Location).To(Equal("gs://backups/nightly/database.sql.gz.gpg"))
+		Expect(artifact.Encryption.Keys).To(BeEmpty())
+
+		backup.Spec.Encryption.Recipients = []string{"age1one"}
+		_, err = BackupEncryptionMethod(backup)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should name the scheduled backups after their schedule time", func() {
+		var keepLast int32 = 7
+
+		wp.Spec.Backups = &wordpressv1alpha1.BackupsSpec{
+			Schedule: "0 2 * * *",
+			Destination: wordpressv1alpha1.BackupDestination{
+				GCS: &wordpressv1alpha1.GCSVolumeSource{Bucket: "backups"},
+			},
+		}
+		Expect(wp.SchedulesBackups()).To(BeTrue())
+
+		scheduledAt := time.Date(2021, time.October, 1, 2, 0, 0, 0, time.UTC)
+
+		backup := wp.ScheduledBackup(scheduledAt)
+		Expect(backup.Name).To(Equal(fmt.Sprintf("%s-backups-%d", wp.Name, scheduledAt.Unix()/60)))
+		Expect(backup.Labels).To(HaveKeyWithValue("app.kubernetes.io/component", "backups"))
+		Expect(backup.Spec.SiteRef.Name).To(Equal(wp.Name))
+		Expect(backup.Spec.Destination.GCS.Bucket).To(Equal("backups"))
+		Expect(backup.Spec.DeleteArtifacts).To(BeFalse())
+
+		// the pruned backups take their artifacts along
+		wp.Spec.Backups.Retention = &wordpressv1alpha1.BackupRetentionSpec{KeepLast: &keepLast}
+		Expect(wp.ScheduledBackup(scheduledAt).Spec.DeleteArtifacts).To(BeTrue())
+	})
+
+	It("should hold the upgrade until it's backed up", func() {
+		wp.Spec.Updates = &wordpressv1alpha1.UpdatesSpec{
+			BackupBeforeUpgrade: &wordpressv1alpha1.UpgradeBackupSpec{},
+		}
+		Expect(wp.BacksUpBeforeUpgrade()).To(BeTrue())
+
+		// the destination defaults to the scheduled backups' one
+		_, err := wp.UpgradeBackup(wp.CodeVersion())
+		Expect(err).To(HaveOccurred())
+
+		wp.Spec.Backups = &wordpressv1alpha1.BackupsSpec{
+			Schedule: "0 2 * * *",
+			Destination: wordpressv1alpha1.BackupDestination{
+				GCS: &wordpressv1alpha1.GCSVolumeSource{Bucket: "backups"},
+			},
+		}
+		backup, err := wp.UpgradeBackup(wp.CodeVersion())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(backup.Name).To(HavePrefix(wp.Name + "-upgrade-backup-"))
+		Expect(backup.Spec.Destination.GCS.Bucket).To(Equal("backups"))
+
+		wp.Status.Upgrade = &wordpressv1alpha1.UpgradeStatus{Image: wp.Spec.Image}
+		Expect(wp.UpgradePending()).To(BeFalse())
+
+		previous := wp.Spec.Image
+		wp.Spec.Image = "docker.io/bitpoke/wordpress-runtime:next"
+		Expect(wp.UpgradePending()).To(BeTrue())
+
+		wp.HoldUpgrade()
+		Expect(wp.Spec.Image).To(Equal(previous))
+		Expect(wp.WebPodTemplateSpec().Spec.Containers[0].Image).To(Equal(previous))
+	})
+
+	It("should clone the site with the target's routes and its own database", func() {
+		clone := &wordpressv1alpha1.WordpressClone{
+			ObjectMeta: metav1.ObjectMeta{Name: "staging", Namespace: wp.Namespace},
+			Spec: wordpressv1alpha1.WordpressCloneSpec{
+				SourceRef:  corev1.LocalObjectReference{Name: wp.Name},
+				TargetName: "staging",
+				Routes:     []wordpressv1alpha1.RouteSpec{{Domain: "staging.test.com"}},
+			},
+		}
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{Provision: true}
+		wp.Spec.CDN = &wordpressv1alpha1.CDNSpec{}
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			S3VolumeSource: &wordpressv1alpha1.S3VolumeSource{Bucket: "media", PathPrefix: "production"},
+		}
+		wp.SetDefaults()
+
+		target, err := wp.CloneTarget(clone)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target.Name).To(Equal("staging"))
+		Expect(target.Annotations).To(HaveKeyWithValue(wordpressv1alpha1.CloneAnnotation, "staging"))
+		Expect(target.Spec.Routes).To(Equal(clone.Spec.Routes))
+		Expect(target.Spec.CDN).To(BeNil())
+		Expect(target.Spec.MediaVolumeSpec.S3VolumeSource.PathPrefix).To(Equal("staging"))
+		Expect(target.Spec.Options).To(HaveKeyWithValue("blog_public", wordpressv1alpha1.OptionValue{Value: "0"}))
+		Expect(target.Spec.WordpressBootstrapSpec.ImportFrom.PersistentVolumeClaim.ClaimName).To(Equal("staging-dump"))
+
+		// the source is left alone
+		Expect(wp.Spec.MediaVolumeSpec.S3VolumeSource.PathPrefix).To(Equal("production"))
+		Expect(wp.Spec.Options).To(BeEmpty())
+
+		env := wp.CloneDumpPodTemplateSpec(clone, target).Spec.Containers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "SOURCE_URL", Value: "//test.com"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "TARGET_URL", Value: "//staging.test.com"}))
+
+		// the target would share an external database which it can't bootstrap
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{Host: "mysql.example.com"}
+		_, err = wp.CloneTarget(clone)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should export the site's content to the media bucket", func() {
+		export := &wordpressv1alpha1.WordpressExport{
+			ObjectMeta: metav1.ObjectMeta{Name: "posts", Namespace: wp.Namespace},
+			Spec: wordpressv1alpha1.WordpressExportSpec{
+				SiteRef:      corev1.LocalObjectReference{Name: wp.Name},
+				PostTypes:    []string{"post", "page"},
+				SkipComments: true,
+			},
+		}
+
+		// there's nowhere to upload the files to
+		_, err := wp.ExportPodTemplateSpec(export)
+		Expect(err).To(HaveOccurred())
+
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			S3VolumeSource: &wordpressv1alpha1.S3VolumeSource{Bucket: "media", PathPrefix: "production"},
+		}
+
+		pod, err := wp.ExportPodTemplateSpec(export)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Spec.Containers[0].Args).To(ContainElement("--post_type=post,page"))
+		Expect(pod.Spec.Containers[0].Args).To(ContainElement("--skip_comments"))
+
+		upload := pod.Spec.Containers[len(pod.Spec.Containers)-1]
+		Expect(upload.Name).To(Equal(ExportUploadContainerName))
+		Expect(upload.Env).To(ContainElement(corev1.EnvVar{
+			Name: "EXPORT_REMOTE", Value: "export:media/production/exports/posts",
+		}))
+		Expect(wp.ExportLocation(export)).To(Equal("s3://media/production/exports/posts"))
+
+		size, files, err := ParseExportReport("2048|2\n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(size).To(Equal(int64(2048)))
+		Expect(files).To(Equal(int64(2)))
+	})
+
+	It("should collect the orphaned backups of a destination", func() {
+		dest := &wordpressv1alpha1.BackupDestination{
+			S3: &wordpressv1alpha1.S3VolumeSource{Bucket: "backups", PathPrefix: "sites"},
+		}
+		Expect(BackupDestinationURL(dest)).To(Equal("s3://backups/sites"))
+
+		pod := BackupGCPodTemplateSpec(dest, []string{"default/mysite-backups-2", "default/mysite-backups-1"}, 24*time.Hour)
+		Expect(pod.Spec.Containers).To(HaveLen(1))
+
+		env := pod.Spec.Containers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "GC_REMOTE", Value: "backup:backups/sites"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "GC_KEEP", Value: "default/mysite-backups-1\ndefault/mysite-backups-2"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "GC_GRACE_SECONDS", Value: "86400"}))
+	})
+
+	It("should restore the backup into a throwaway database to verify it", func() {
+		wp.Spec.Backups = &wordpressv1alpha1.BackupsSpec{
+			Schedule: "@daily",
+			Destination: wordpressv1alpha1.BackupDestination{
+				S3: &wordpressv1alpha1.S3VolumeSource{Bucket: "backups"},
+			},
+			Verification: &wordpressv1alpha1.BackupVerificationSpec{Schedule: "@weekly"},
+		}
+		backup := wp.ScheduledBackup(time.Unix(600, 0))
+		backup.Status.Artifacts = []wordpressv1alpha1.BackupArtifact{
+			{Name: BackupDatabaseArtifact, Location: BackupLocation(backup, BackupDatabaseArtifact)},
+		}
+
+		pod := wp.BackupVerificationPodTemplateSpec(backup)
+
+		download := pod.Spec.InitContainers[len(pod.Spec.InitContainers)-1]
+		Expect(download.Name).To(Equal("download"))
+		Expect(download.Env).To(ContainElement(corev1.EnvVar{Name: "BACKUP_ARTIFACT", Value: "database.sql.gz"}))
+
+		Expect(pod.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "DB_HOST", Value: "127.0.0.1"}))
+		_, found := lookupEnvVar("MEMCACHED_HOST", pod.Spec.Containers[0].Env)
+		Expect(found).To(BeFalse())
+
+		database := pod.Spec.Containers[len(pod.Spec.Containers)-1]
+		Expect(database.Name).To(Equal("database"))
+		Expect(database.Command[2]).To(ContainSubstring("--bind-address=127.0.0.1"))
+
+		// the backups encrypted to a public key need the private one
+		backup.Spec.Encryption = &wordpressv1alpha1.BackupEncryption{Recipients: []string{"age1test"}}
+		Expect(wp.CanVerifyBackup(backup)).To(HaveOccurred())
+
+		Expect(ParseBackupVerification([]string{"restore|pass", "homepage|fail|the homepage responded with 500"})).To(Equal(
+			[]wordpressv1alpha1.BackupVerificationCheckResult{
+				{Name: "restore", Passed: true},
+				{Name: "homepage", Message: "the homepage responded with 500"},
+			},
+		))
+	})
+
+	It("should annotate the web pods with the Velero backup hooks", func() {
+		Expect(wp.WebPodTemplateSpec().Annotations).NotTo(HaveKey("pre.hook.backup.velero.io/command"))
+
+		wp.Spec.Velero = &wordpressv1alpha1.VeleroSpec{LockDatabase: true, BackupVolumes: true}
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{},
+		}
+
+		pod := wp.WebPodTemplateSpec()
+		Expect(pod.Labels).To(HaveKeyWithValue(wordpressv1alpha1.SiteLabel, wp.Name))
+		Expect(pod.Annotations).To(HaveKeyWithValue("pre.hook.backup.velero.io/container", "wordpress"))
+		Expect(pod.Annotations["pre.hook.backup.velero.io/command"]).To(ContainSubstring("FLUSH TABLES WITH READ LOCK"))
+		Expect(pod.Annotations["post.hook.backup.velero.io/command"]).To(ContainSubstring("KILL"))
+		Expect(pod.Annotations).To(HaveKeyWithValue("backup.velero.io/backup-volumes", "media"))
+
+		// without the lock, there's nothing to release after the backup
+		wp.Spec.Velero.LockDatabase = false
+		pod = wp.WebPodTemplateSpec()
+		Expect(pod.Annotations["pre.hook.backup.velero.io/command"]).NotTo(ContainSubstring("LOCK"))
+		Expect(pod.Annotations).NotTo(HaveKey("post.hook.backup.velero.io/command"))
+	})
+
+	It("should connect to Cloud SQL through the proxy sidecar", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			CloudSQL: &wordpressv1alpha1.CloudSQLSpec{
+				InstanceConnectionName: "project:region:instance",
+				CredentialsSecretRef:   "cloudsql-credentials",
+			},
+			CredentialsSecretRef:      "db-credentials",
+			AdminCredentialsSecretRef: "db-admin",
+		}
+		wp.SetDefaults()
+		Expect(wp.HasExternalDatabase()).To(BeTrue())
+		Expect(wp.BootstrapsDatabase()).To(BeFalse())
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Containers).To(HaveLen(2))
+		Expect(spec.Spec.Containers[1].Name).To(Equal("cloudsql-proxy"))
+		Expect(spec.Spec.Containers[1].Args).To(Equal([]string{
+			"--port=3306",
+			"--structured-logs",
+			"--credentials-file=/var/run/presslabs.org/cloudsql/credentials.json",
+			"project:region:instance",
+		}))
+
+		e, found := lookupEnvVar("DB_HOST", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("127.0.0.1:3306"))
+
+		// the jobs stop the proxy once their command completes
+		job := wp.JobPodTemplateSpec("wp", "core", "update-db")
+		Expect(job.Spec.Containers).To(HaveLen(2))
+		Expect(job.Spec.Containers[1].Args).To(ContainElement("--quitquitquit"))
+		args := job.Spec.Containers[0].Args
+		Expect(args[len(args)-4:]).To(Equal([]string{"--", "wp", "core", "update-db"}))
+	})
+
+	It("should connect to the database over TLS", func() {
+		wp.Spec.Database = &wordpressv1alpha1.DatabaseSpec{
+			Host: "mysql.example.com",
+			TLS:  &wordpressv1alpha1.DatabaseTLSSpec{CASecretRef: "db-ca"},
+		}
+		wp.SetDefaults()
+
+		spec := wp.WebPodTemplateSpec()
+
+		e, found := lookupEnvVar("MYSQL_CLIENT_FLAGS", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("MYSQLI_CLIENT_SSL"))
+
+		e, found = lookupEnvVar("MYSQL_SSL_CA", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("/var/run/presslabs.org/db/ca.crt"))
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "db-ca",
+			MountPath: "/var/run/presslabs.org/db",
+			ReadOnly:  true,
+		}))
+		Expect(spec.Spec.Volumes[len(spec.Spec.Volumes)-1].Secret.SecretName).To(Equal("db-ca"))
+
+		wp.Spec.Database.TLS.VerifyMode = wordpressv1alpha1.DatabaseTLSSkipVerify
+		e, _ = lookupEnvVar("MYSQL_CLIENT_FLAGS", wp.env())
+		Expect(e.Value).To(Equal("MYSQLI_CLIENT_SSL_DONT_VERIFY_SERVER_CERT"))
+	})
+
+	It("should name the database upgrade job after the code version", func() {
+		wp.Spec.Image = "bitpoke/wordpress-runtime:5.8.2"
+		Expect(wp.CodeVersion()).To(Equal("bitpoke/wordpress-runtime:5.8.2"))
+		name := wp.JobName(WordpressDBUpgrade)
+
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			GitDir: &wordpressv1alpha1.GitVolumeSource{Repository: "https://github.com/example/site", GitRef: "v2"},
+		}
+		Expect(wp.CodeVersion()).To(Equal("bitpoke/wordpress-runtime:5.8.2@v2"))
+		Expect(wp.JobName(WordpressDBUpgrade)).ToNot(Equal(name))
+		Expect(wp.JobName(WordpressDBUpgrade)).To(HavePrefix(wp.Name + "-upgrade-for-"))
+		Expect(wp.ComponentName(WordpressDBUpgrade)).To(Equal(wp.Name + "-upgrade"))
+	})
+
+	It("should pass the trusted proxies only when honoring forwarded headers", func() {
+		wp.Spec.TrustedProxies = []string{"10.0.0.0/8", "192.168.0.0/16"}
+		_, found := lookupEnvVar("STACK_TRUSTED_PROXIES", wp.env())
+		Expect(found).To(BeFalse())
+
+		wp.Spec.HonorForwardedHeaders = true
+		e, found := lookupEnvVar("STACK_TRUSTED_PROXIES", wp.env())
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("10.0.0.0/8,192.168.0.0/16"))
+	})
+
+	It("should accept the PROXY protocol from the trusted proxies", func() {
+		wp.Spec.TrustedProxies = []string{"10.0.0.0/8"}
+		_, found := lookupEnvVar("STACK_PROXY_PROTOCOL", wp.env())
+		Expect(found).To(BeFalse())
+
+		wp.Spec.ProxyProtocol = &wordpressv1alpha1.ProxyProtocolSpec{Runtime: true}
+		e, found := lookupEnvVar("STACK_PROXY_PROTOCOL", wp.env())
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("true"))
+
+		e, found = lookupEnvVar("STACK_TRUSTED_PROXIES", wp.env())
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("10.0.0.0/8"))
+	})
+
+	It("should install the site under the site's path", func() {
+		wp.Spec.Path = "/blog"
+		Expect(wp.HomeURL()).To(Equal("http://test.com/blog"))
+		Expect(wp.SiteURL()).To(Equal("http://test.com/blog/wp"))
+		Expect(wp.WebPodTemplateSpec().Spec.Containers[0].ReadinessProbe.HTTPGet.Path).To(Equal("/blog"))
+
+		e, _ := lookupEnvVar("STACK_ROUTES", wp.env())
+		Expect(e.Value).To(Equal("test.com/blog"))
+
+		// the route's path takes precedence
+		wp.Spec.Routes[0].Path = "/subpath"
+		Expect(wp.HomeURL()).To(Equal("http://test.com/subpath"))
+	})
+
+	It("shouldn't generate a pod anti-affinity by default", func() {
+		Expect(wp.WebPodTemplateSpec().Spec.Affinity).To(BeNil())
+
+		wp.Spec.PodAntiAffinityPreset = wordpressv1alpha1.PodAntiAffinityPresetNone
+		Expect(wp.WebPodTemplateSpec().Spec.Affinity).To(BeNil())
+	})
+
+	It("should generate a soft pod anti-affinity", func() {
+		wp.Spec.PodAntiAffinityPreset = wordpressv1alpha1.PodAntiAffinityPresetSoft
+		affinity := wp.WebPodTemplateSpec().Spec.Affinity
+
+		Expect(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+		Expect(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+
+		term := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm
+		Expect(term.TopologyKey).To(Equal("kubernetes.io/hostname"))
+		Expect(term.LabelSelector.MatchLabels).To(Equal(map[string]string(wp.WebPodLabels())))
+	})
+
+	It("should generate a hard pod anti-affinity, keeping the other affinities", func() {
+		wp.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+		wp.Spec.PodAntiAffinityPreset = wordpressv1alpha1.PodAntiAffinityPresetHard
+		affinity := wp.WebPodTemplateSpec().Spec.Affinity
+
+		Expect(affinity.NodeAffinity).ToNot(BeNil())
+		Expect(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		Expect(wp.Spec.Affinity.PodAntiAffinity).To(BeNil())
+	})
+
+	It("should prefer the pod anti-affinity specified in the Wordpress resource", func() {
+		antiAffinity := &corev1.PodAntiAffinity{}
+		wp.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: antiAffinity}
+		wp.Spec.PodAntiAffinityPreset = wordpressv1alpha1.PodAntiAffinityPresetHard
+
+		Expect(wp.WebPodTemplateSpec().Spec.Affinity.PodAntiAffinity).To(Equal(antiAffinity))
+	})
+
+	It("should generate volume claim templates for persistent volume claims", func() {
+		Expect(wp.VolumeClaimTemplates()).To(BeEmpty())
+
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			GitDir:                &wordpressv1alpha1.GitVolumeSource{},
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{},
+		}
+		wp.Spec.MediaVolumeSpec = &wordpressv1alpha1.MediaVolumeSpec{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimSpec{
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			},
+		}
+
+		claims := wp.VolumeClaimTemplates()
+		Expect(claims).To(HaveLen(1))
+		Expect(claims[0].Name).To(Equal("media"))
+		Expect(claims[0].Spec).To(Equal(*wp.Spec.MediaVolumeSpec.PersistentVolumeClaim))
+	})
+
+	It("should run the canary image and code", func() {
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			GitDir: &wordpressv1alpha1.GitVolumeSource{GitRef: "v1"},
+		}
+		wp.Spec.Canary = &wordpressv1alpha1.CanarySpec{Image: "test-image", GitRef: "v2"}
+
+		canary := wp.Canary()
+		Expect(canary.WebPodTemplateSpec().Spec.Containers[0].Image).To(Equal("test-image"))
+		Expect(canary.Spec.CodeVolumeSpec.GitDir.GitRef).To(Equal("v2"))
+		Expect(wp.Spec.CodeVolumeSpec.GitDir.GitRef).To(Equal("v1"))
+	})
+
+	It("should change the canary revision only when the canary release changes", func() {
+		Expect(wp.CanaryRevision()).To(BeEmpty())
+
+		wp.Spec.Canary = &wordpressv1alpha1.CanarySpec{Image: "test-image", Weight: 10}
+		revision := wp.CanaryRevision()
+		Expect(revision).To(HaveLen(8))
+		Expect(wp.JobName(WordpressCanarySmokeTest)).To(Equal(fmt.Sprintf("%s-smoke-test-%s", wp.Name, revision)))
+
+		wp.Spec.Canary.Weight = 50
+		Expect(wp.CanaryRevision()).To(Equal(revision))
+
+		wp.Spec.Canary.SmokeTest = &wordpressv1alpha1.SmokeTestSpec{Image: "curlimages/curl"}
+		Expect(wp.CanaryRevision()).ToNot(Equal(revision))
+	})
+
+	It("should annotate the pods for the Vault Agent Injector", func() {
+		wp.Spec.Vault = &wordpressv1alpha1.VaultSpec{
+			Role: "mysite",
+			Env: []wordpressv1alpha1.VaultEnvVar{
+				{Name: "DB_PASSWORD", Path: "secret/data/mysite/db", Key: "password"},
+				{Name: "AUTH_KEY", Path: "secret/data/mysite/salts", Key: "auth-key"},
+			},
+			Annotations: map[string]string{
+				"vault.hashicorp.com/namespace": "sites",
+				"vault.hashicorp.com/role":      "other",
+			},
+		}
+
+		web := wp.WebPodTemplateSpec().Annotations
+		Expect(web).To(HaveKeyWithValue("vault.hashicorp.com/agent-inject", "true"))
+		Expect(web).To(HaveKeyWithValue("vault.hashicorp.com/role", "mysite"))
+		Expect(web).To(HaveKeyWithValue("vault.hashicorp.com/namespace", "sites"))
+		Expect(web).To(HaveKeyWithValue("vault.hashicorp.com/agent-inject-secret-wordpress.env", "secret/data/mysite/db"))
+		Expect(web).To(HaveKeyWithValue("vault.hashicorp.com/agent-inject-template-wordpress.env",
+			`{{ with secret "secret/data/mysite/db" }}export DB_PASSWORD='{{ index .Data.data "password" | replaceAll "'" "'\\''" }}'{{ end }}`+"\n"+
+				`{{ with secret "secret/data/mysite/salts" }}export AUTH_KEY='{{ index .Data.data "auth-key" | replaceAll "'" "'\\''" }}'{{ end }}`+"\n"))
+		Expect(web).NotTo(HaveKey("vault.hashicorp.com/agent-pre-populate-only"))
+
+		job := wp.JobPodTemplateSpec("wp", "cron", "event", "run", "--due-now")
+		Expect(job.Annotations).To(HaveKeyWithValue("vault.hashicorp.com/agent-pre-populate-only", "true"))
+		Expect(job.Spec.Containers[0].Args).To(Equal([]string{
+			"/bin/sh", "-c", ". /vault/secrets/wordpress.env && exec \"$@\"", "--", "wp", "cron", "event", "run", "--due-now",
+		}))
+	})
+
+	It("should annotate web pods for sidecar injection and opt jobs out", func() {
+		wp.Spec.ServiceMesh = &wordpressv1alpha1.ServiceMeshSpec{Provider: wordpressv1alpha1.ServiceMeshIstio}
+		Expect(wp.WebPodTemplateSpec().Annotations).To(HaveKeyWithValue("sidecar.istio.io/inject", "true"))
+		Expect(wp.JobPodTemplateSpec().Annotations).To(HaveKeyWithValue("sidecar.istio.io/inject", "false"))
+
+		wp.Spec.ServiceMesh = &wordpressv1alpha1.ServiceMeshSpec{Provider: wordpressv1alpha1.ServiceMeshLinkerd}
+		Expect(wp.WebPodTemplateSpec().Annotations).To(HaveKeyWithValue("linkerd.io/inject", "enabled"))
+		Expect(wp.JobPodTemplateSpec().Annotations).To(HaveKeyWithValue("linkerd.io/inject", "disabled"))
+	})
+
+	It("should mount the web server config", func() {
+		wp.Spec.WebServerConfig = &wordpressv1alpha1.WebServerConfigSpec{ConfigMapName: "nginx"}
+		wp.SetDefaults()
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "web-server-config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "nginx"},
+				},
+			},
+		}))
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "web-server-config",
+			MountPath: "/etc/nginx/conf.d",
+			ReadOnly:  true,
+		}))
+	})
+
+	It("should add the secrets provider's secrets to the environment", func() {
+		wp.Spec.SecretsProvider = &wordpressv1alpha1.SecretsProviderSpec{
+			CSI: &wordpressv1alpha1.CSISecretsProviderSpec{SecretProviderClass: "vault", SecretName: "vault-db"},
+			ExternalSecret: &wordpressv1alpha1.ExternalSecretSpec{
+				SecretStoreRef: wordpressv1alpha1.ExternalSecretStoreRef{Name: "aws"},
+			},
+		}
+		wp.Spec.EnvFrom = []corev1.EnvFromSource{secretEnvFrom("custom")}
+
+		spec := wp.WebPodTemplateSpec()
+		readOnly := true
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "secrets-store",
+			VolumeSource: corev1.VolumeSource{
+				CSI: &corev1.CSIVolumeSource{
+					Driver:           "secrets-store.csi.k8s.io",
+					ReadOnly:         &readOnly,
+					VolumeAttributes: map[string]string{"secretProviderClass": "vault"},
+				},
+			},
+		}))
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "secrets-store",
+			MountPath: "/var/run/secrets/wordpress",
+			ReadOnly:  true,
+		}))
+
+		// the provided secrets take precedence over the site's secret, but not over spec.envFrom
+		Expect(spec.Spec.Containers[0].EnvFrom).To(Equal([]corev1.EnvFromSource{
+			secretEnvFrom(wp.ComponentName(WordpressSecret)),
+			secretEnvFrom("vault-db"),
+			secretEnvFrom(wp.ComponentName(WordpressExternalSecret)),
+			secretEnvFrom("custom"),
+		}))
+		Expect(wp.ExternalSecretStoreKind()).To(Equal("SecretStore"))
+		Expect(wp.ExternalSecretRefreshInterval()).To(Equal(time.Hour))
+	})
+
+	It("should version the secrets and config maps referenced by the web pods' environment", func() {
+		Expect(wp.ReferencedSecrets()).To(BeEmpty())
+		Expect(ReferencesVersion(nil, nil)).To(BeEmpty())
+
+		wp.Spec.Env = []corev1.EnvVar{
+			{
+				Name: "DB_PASSWORD",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "db"},
+						Key:                  "PASSWORD",
+					},
+				},
+			},
+		}
+		wp.Spec.EnvFrom = []corev1.EnvFromSource{
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}}},
+			secretEnvFrom("api"),
+		}
+
+		Expect(wp.RollsOnConfigChange()).To(BeTrue())
+		Expect(wp.ReferencedSecrets()).To(Equal([]string{"api", "db"}))
+		Expect(wp.ReferencedConfigMaps()).To(Equal([]string{"settings"}))
+
+		secret := corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db"}, Data: map[string][]byte{"PASSWORD": []byte("a")}}
+		version := ReferencesVersion([]corev1.Secret{secret}, nil)
+		Expect(version).NotTo(BeEmpty())
+
+		secret.Data["PASSWORD"] = []byte("b")
+		Expect(ReferencesVersion([]corev1.Secret{secret}, nil)).NotTo(Equal(version))
+
+		wp.Spec.RollOnConfigChangeExclusions = []string{"api", "settings"}
+		Expect(wp.ReferencedSecrets()).To(Equal([]string{"db"}))
+		Expect(wp.ReferencedConfigMaps()).To(BeEmpty())
+	})
+
+	It("should run the pods with the managed service account", func() {
+		Expect(wp.WebPodTemplateSpec().Spec.ServiceAccountName).To(BeEmpty())
+
+		wp.Spec.ServiceAccount = &wordpressv1alpha1.ServiceAccountSpec{Create: true}
+		Expect(wp.CreatesServiceAccount()).To(BeTrue())
+		Expect(wp.WebPodTemplateSpec().Spec.ServiceAccountName).To(Equal(wp.Name))
+		Expect(wp.JobPodTemplateSpec().Spec.ServiceAccountName).To(Equal(wp.Name))
+
+		wp.Spec.ServiceAccountName = "custom"
+		Expect(wp.CreatesServiceAccount()).To(BeFalse())
+		Expect(wp.WebPodTemplateSpec().Spec.ServiceAccountName).To(Equal("custom"))
+	})
+
+	It("should verify the signatures of the site's images with the site's policy", func() {
+		Expect(wp.VerifiesImages()).To(BeFalse())
+
+		wp.Spec.ImageVerification = &wordpressv1alpha1.ImageVerificationSpec{}
+		Expect(wp.VerifiesImages()).To(BeTrue())
+		Expect(wp.CanVerifyImages()).To(HaveOccurred())
+
+		wp.Spec.ImageVerification.CertificateIdentity = "^https://github.com/example/"
+		Expect(wp.CanVerifyImages()).To(HaveOccurred())
+
+		wp.Spec.ImageVerification.CertificateOIDCIssuer = "https://token.actions.githubusercontent.com"
+		wp.Spec.Image = "docker.io/bitpoke/wordpress-runtime:5.8.2"
+		Expect(wp.CanVerifyImages()).To(MatchError(ContainSubstring("pinned by digest")))
+
+		wp.Spec.Image = "docker.io/bitpoke/wordpress-runtime@sha256:" + strings.Repeat("a", 64)
+		Expect(wp.CanVerifyImages()).To(Succeed())
+
+		version := wp.ImageVerificationVersion()
+		Expect(wp.JobName(WordpressImageVerification)).To(Equal(wp.Name + "-image-verification-for-" + version))
+
+		wp.Spec.ImageVerification.PublicKeySecretRef = &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "cosign"},
+			Key:                  "cosign.pub",
+		}
+		Expect(wp.ImageVerificationVersion()).NotTo(Equal(version))
+
+		// the images are verified again when the key changes
+		version = wp.ImageVerificationVersion()
+		wp.SetImageVerificationKeyVersion("2")
+		Expect(wp.ImageVerificationVersion()).NotTo(Equal(version))
+
+		wp.Spec.CodeVolumeSpec = &wordpressv1alpha1.CodeVolumeSpec{
+			GitDir: &wordpressv1alpha1.GitVolumeSource{},
+		}
+		containers := wp.ImageVerificationPodTemplateSpec().Spec.Containers
+		Expect(containers).To(HaveLen(2))
+		Expect(containers[0].Name).To(Equal("verify-runtime"))
+		Expect(containers[0].Image).To(Equal(options.CosignImage))
+		Expect(containers[0].Args).To(Equal([]string{"verify", "--key", "env://COSIGN_PUBLIC_KEY", wp.Spec.Image}))
+		Expect(containers[0].Env[0].ValueFrom.SecretKeyRef.Name).To(Equal("cosign"))
+		Expect(containers[1].Name).To(Equal("verify-git"))
+		Expect(wp.VerifiedImage("verify-git")).To(Equal(options.GitCloneImage))
+	})
+
+	It("should proxy the requests through the WAF sidecar", func() {
+		wp.Spec.WAF = &wordpressv1alpha1.WAFSpec{
+			Mode:  wordpressv1alpha1.WAFDetectionOnly,
+			Rules: &wordpressv1alpha1.WAFRulesSpec{ConfigMapName: "waf", Files: []string{"REQUEST-900-EXCLUSION-RULES-BEFORE-CRS.conf"}},
+		}
+
+		spec := wp.WebPodTemplateSpec().Spec
+		waf := spec.Containers[len(spec.Containers)-1]
+		Expect(waf.Name).To(Equal("waf"))
+		Expect(waf.Image).To(Equal(options.WAFImage))
+		Expect(waf.Env).To(ContainElement(corev1.EnvVar{Name: "MODSEC_RULE_ENGINE", Value: "DetectionOnly"}))
+		Expect(waf.Env).To(ContainElement(corev1.EnvVar{Name: "BACKEND", Value: "http://127.0.0.1:8080"}))
+		Expect(waf.VolumeMounts[0].MountPath).To(Equal("/etc/modsecurity.d/owasp-crs/rules/REQUEST-900-EXCLUSION-RULES-BEFORE-CRS.conf"))
+		Expect(spec.Volumes[len(spec.Volumes)-1].ConfigMap.Name).To(Equal("waf"))
+		Expect(wp.ReferencedConfigMaps()).To(ContainElement("waf"))
+
+		for _, c := range wp.JobPodTemplateSpec().Spec.Containers {
+			Expect(c.Name).NotTo(Equal("waf"))
+		}
+	})
+
+	It("should export the runtime's metrics from a sidecar", func() {
+		wp.Spec.Monitoring = &wordpressv1alpha1.MonitoringSpec{Exporter: &wordpressv1alpha1.ExporterSpec{}}
+
+		spec := wp.WebPodTemplateSpec().Spec
+		exporter := spec.Containers[len(spec.Containers)-1]
+		Expect(exporter.Name).To(Equal("exporter"))
+		Expect(exporter.Image).To(Equal(options.PHPFPMExporterImage))
+		Expect(exporter.Args).To(ContainElement("--phpfpm.scrape-uri=tcp://127.0.0.1:9000/status"))
+		Expect(exporter.Ports).To(Equal([]corev1.ContainerPort{{Name: "metrics", ContainerPort: ExporterMetricsPort}}))
+
+		wp.Spec.Monitoring.Exporter = &wordpressv1alpha1.ExporterSpec{
+			Type:      wordpressv1alpha1.NginxExporter,
+			StatusURI: "http://127.0.0.1:8080/nginx_status",
+			Image:     "nginx/nginx-prometheus-exporter:latest",
+		}
+
+		spec = wp.WebPodTemplateSpec().Spec
+		exporter = spec.Containers[len(spec.Containers)-1]
+		Expect(exporter.Image).To(Equal("nginx/nginx-prometheus-exporter:latest"))
+		Expect(exporter.Args).To(Equal([]string{
+			"-nginx.scrape-uri=http://127.0.0.1:8080/nginx_status",
+			"-web.listen-address=:9253",
+		}))
+
+		for _, c := range wp.JobPodTemplateSpec().Spec.Containers {
+			Expect(c.Name).NotTo(Equal("exporter"))
+		}
+	})
+
+	It("should probe the site's routes and aliases once a prober is set", func() {
+		wp.Spec.Monitoring = &wordpressv1alpha1.MonitoringSpec{}
+		Expect(wp.ProbesDomains()).To(BeFalse())
+
+		wp.Spec.Monitoring.Probe = &wordpressv1alpha1.ProbeSpec{ProberURL: "blackbox-exporter.monitoring:9115"}
+		wp.Spec.Routes = append(wp.Spec.Routes, wordpressv1alpha1.RouteSpec{Domain: "test.com", Path: "shop"})
+		wp.Spec.Aliases = []string{"www.test.com"}
+		wp.Spec.TLSSecretRef = "test-tls"
+
+		Expect(wp.ProbesDomains()).To(BeTrue())
+		Expect(wp.ProbeModule()).To(Equal(options.ProbeModule))
+		Expect(wp.ProbeTargets()).To(Equal([]string{"https://test.com/", "https://test.com/shop", "https://www.test.com/"}))
+	})
+
+	It("should ship the runtime's logs from a sidecar", func() {
+		wp.Spec.Logging = &wordpressv1alpha1.LoggingSpec{Format: wordpressv1alpha1.JSONLogFormat}
+
+		spec := wp.WebPodTemplateSpec().Spec
+		Expect(spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "STACK_LOG_FORMAT", Value: "json"}))
+		Expect(spec.Containers[len(spec.Containers)-1].Name).NotTo(Equal("log-shipper"))
+
+		wp.Spec.Logging.Shipper = &wordpressv1alpha1.LogShipperSpec{OutputConfigMapName: "logs-output"}
+
+		spec = wp.WebPodTemplateSpec().Spec
+		Expect(spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "STACK_ACCESS_LOG", Value: "/var/log/stack/access.log"}))
+		Expect(spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "logs", MountPath: "/var/log/stack"}))
+
+		shipper := spec.Containers[len(spec.Containers)-1]
+		Expect(shipper.Name).To(Equal("log-shipper"))
+		Expect(shipper.Image).To(Equal(options.LogShipperImage))
+		Expect(shipper.Args).To(ContainElement("parser=json"))
+		Expect(shipper.Args).To(ContainElement("Record=site " + wp.Name))
+		Expect(shipper.Args[len(shipper.Args)-2:]).To(Equal([]string{"-c", "/fluent-bit/etc/output/output.conf"}))
+		Expect(wp.ReferencedConfigMaps()).To(ContainElement("logs-output"))
+
+		job := wp.JobPodTemplateSpec().Spec
+		Expect(job.Containers[0].Env).NotTo(ContainElement(corev1.EnvVar{Name: "STACK_ACCESS_LOG", Value: "/var/log/stack/access.log"}))
+
+		for _, c := range job.Containers {
+			Expect(c.Name).NotTo(Equal("log-shipper"))
+		}
+	})
+
+	It("should send the emails through the SMTP server and test it in a job", func() {
+		wp.Spec.SMTP = &wordpressv1alpha1.SMTPSpec{
+			Host:                 "smtp.example.com",
+			Encryption:           wordpressv1alpha1.SMTPEncryptionTLS,
+			CredentialsSecretRef: "smtp",
+			FromAddress:          "noreply@example.com",
+		}
+		Expect(wp.TestsSMTP()).To(BeFalse())
+
+		env := wp.WebPodTemplateSpec().Spec.Containers[0].Env
+		for name, value := range map[string]string{
+			"SMTP_HOST":   "smtp.example.com",
+			"SMTP_PORT":   "465",
+			"SMTP_SECURE": "ssl",
+			"SMTP_AUTH":   "true",
+			"SMTP_FROM":   "noreply@example.com",
+		} {
+			e, found := lookupEnvVar(name, env)
+			Expect(found).To(BeTrue())
+			Expect(e.Value).To(Equal(value))
+		}
+
+		e, found := lookupEnvVar("SMTP_PASSWORD", env)
+		Expect(found).To(BeTrue())
+		Expect(e.ValueFrom.SecretKeyRef.Name).To(Equal("smtp"))
+		Expect(wp.ReferencedSecrets()).To(ContainElement("smtp"))
+
+		wp.Spec.SMTP.TestRecipient = "admin@example.com"
+		Expect(wp.TestsSMTP()).To(BeTrue())
+
+		name := wp.JobName(WordpressSMTPTest)
+		spec := wp.SMTPTestPodTemplateSpec()
+		Expect(spec.Spec.Containers[0].Args).To(ContainElement(smtpTestScript))
+
+		e, found = lookupEnvVar("SMTP_TEST_RECIPIENT", spec.Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("admin@example.com"))
+
+		wp.Spec.SMTP.Encryption = ""
+		Expect(wp.SMTPPort()).To(Equal(int32(587)))
+		Expect(wp.JobName(WordpressSMTPTest)).ToNot(Equal(name))
+	})
+
+	It("should install the managed plugins in the web pods and sync them in a job", func() {
+		for _, c := range wp.WebPodTemplateSpec().Spec.InitContainers {
+			Expect(c.Name).NotTo(Equal("install-plugins"))
+		}
+
+		wp.Spec.Plugins = []wordpressv1alpha1.PluginSpec{
+			{Name: "akismet", Version: "5.1"},
+			{Name: "custom", Source: "https://example.com/custom.zip", State: wordpressv1alpha1.PluginInactive},
+		}
+
+		version := wp.PluginsVersion()
+		Expect(wp.JobName(WordpressPlugins)).To(Equal(wp.Name + "-plugins-for-" + version))
+
+		plugins := "akismet|5.1||active\ncustom||https://example.com/custom.zip|inactive"
+
+		initContainers := wp.WebPodTemplateSpec().Spec.InitContainers
+		install := initContainers[len(initContainers)-1]
+		Expect(install.Name).To(Equal("install-plugins"))
+		Expect(install.Env).To(ContainElement(corev1.EnvVar{Name: "EXTENSIONS", Value: plugins}))
+		Expect(install.Env).To(ContainElement(corev1.EnvVar{Name: "INSTALL_ONLY", Value: "true"}))
+
+		job := wp.PluginsPodTemplateSpec()
+		for _, c := range job.Spec.InitContainers {
+			Expect(c.Name).NotTo(Equal("install-plugins"))
+		}
+		Expect(job.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "EXTENSIONS", Value: plugins}))
+
+		wp.Spec.Plugins[0].Version = "5.2"
+		Expect(wp.PluginsVersion()).NotTo(Equal(version))
+	})
+
+	It("should install the managed themes in the web pods and activate the active one in a job", func() {
+		wp.Spec.Themes = []wordpressv1alpha1.ThemeSpec{
+			{Name: "twentytwentyfour", Version: "1.0"},
+			{Name: "child", Active: true},
+			{Name: "other", Active: true},
+		}
+
+		version := wp.ThemesVersion()
+		Expect(wp.JobName(WordpressThemes)).To(Equal(wp.Name + "-themes-for-" + version))
+
+		themes := "twentytwentyfour|1.0||installed\nchild|||active\nother|||installed"
+
+		initContainers := wp.WebPodTemplateSpec().Spec.InitContainers
+		install := initContainers[len(initContainers)-1]
+		Expect(install.Name).To(Equal("install-themes"))
+		Expect(install.Env).To(ContainElement(corev1.EnvVar{Name: "EXTENSION", Value: "theme"}))
+		Expect(install.Env).To(ContainElement(corev1.EnvVar{Name: "EXTENSIONS", Value: themes}))
+
+		job := wp.ThemesPodTemplateSpec()
+		Expect(job.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "EXTENSIONS", Value: themes}))
+
+		wp.Spec.Themes[1].Active = false
+		Expect(wp.ThemesVersion()).NotTo(Equal(version))
+	})
+
+	It("should update the managed options in a job, reading the sensitive ones from secrets", func() {
+		apiKey := &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "mysite-options"},
+			Key:                  "API_KEY",
+		}
+		wp.Spec.Options = map[string]wordpressv1alpha1.OptionValue{
+			"timezone_string": {Value: "Europe/Bucharest"},
+			"blogname":        {Value: "My Site"},
+			"my_api_key":      {SecretKeyRef: apiKey},
+		}
+
+		version := wp.OptionsVersion()
+		Expect(wp.JobName(WordpressOptions)).To(Equal(wp.Name + "-options-for-" + version))
+
+		env := wp.OptionsPodTemplateSpec().Spec.Containers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{
+			Name:  "OPTIONS",
+			Value: "blogname|WP_OPTION_0\nmy_api_key|WP_OPTION_1\ntimezone_string|WP_OPTION_2",
+		}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "WP_OPTION_0", Value: "My Site"}))
+		Expect(env).To(ContainElement(corev1.EnvVar{
+			Name:      "WP_OPTION_1",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: apiKey},
+		}))
+
+		wp.Spec.Options["blogname"] = wordpressv1alpha1.OptionValue{Value: "My Other Site"}
+		Expect(wp.OptionsVersion()).NotTo(Equal(version))
+	})
+
+	It("should sync the managed users in a job, reading their passwords from secrets", func() {
+		password := &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "mysite-users"},
+			Key:                  "ADMIN_PASSWORD",
+		}
+		wp.Spec.Users = []wordpressv1alpha1.UserSpec{
+			{Username: "admin", Email: "admin@example.com", Role: "administrator", PasswordSecretRef: password},
+			{Username: "reader", Email: "reader@example.com"},
+		}
+
+		version := wp.UsersVersion()
+		Expect(wp.JobName(WordpressUsers)).To(Equal(wp.Name + "-users-for-" + version))
+
+		env := wp.UsersPodTemplateSpec().Spec.Containers[0].Env
+		Expect(env).To(ContainElement(corev1.EnvVar{
+			Name:  "USERS",
+			Value: "admin|admin@example.com|administrator|WP_USER_PASSWORD_0\nreader|reader@example.com|subscriber|",
+		}))
+		Expect(env).To(ContainElement(corev1.EnvVar{
+			Name:      "WP_USER_PASSWORD_0",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: password},
+		}))
+		Expect(env).To(ContainElement(corev1.EnvVar{Name: "DELETE_UNMANAGED_ADMINISTRATORS", Value: "false"}))
+
+		wp.Spec.DeleteUnmanagedAdministrators = true
+		Expect(wp.UsersVersion()).NotTo(Equal(version))
+	})
+
+	It("should check for core updates and apply the allowed ones within the maintenance window", func() {
+		wp.Spec.Updates = &wordpressv1alpha1.UpdatesSpec{
+			Core: &wordpressv1alpha1.CoreUpdatesSpec{
+				Policy: wordpressv1alpha1.CoreUpdateMinor,
+				Window: &wordpressv1alpha1.MaintenanceWindow{
+					Days:  []wordpressv1alpha1.Weekday{"Saturday"},
+					Start: "22:00",
+					End:   "02:00",
+				},
+			},
+		}
+
+		check := wp.CoreUpdateCheckPodTemplateSpec().Spec.Containers[0]
+		Expect(check.Env).To(ContainElement(corev1.EnvVar{Name: "CORE_UPDATE_POLICY", Value: "minor"}))
+		Expect(wp.CoreUpdateCheckInterval()).To(Equal(12 * time.Hour))
+
+		wp.Status.CoreUpdate = &wordpressv1alpha1.CoreUpdateStatus{Version: "6.4.1", AvailableVersion: "6.4.2"}
+		Expect(wp.JobName(WordpressCoreUpdate)).To(Equal(wp.Name + "-core-update-to-" + hash("6.4.2")))
+
+		update := wp.CoreUpdatePodTemplateSpec().Spec.Containers[0]
+		Expect(update.Env).To(ContainElement(corev1.EnvVar{Name: "VERSION", Value: "6.4.2"}))
+
+		// 2024-06-01 is a Saturday
+		for at, expected := range map[string]bool{
+			"2024-06-01T21:59:00Z": false,
+			"2024-06-01T22:00:00Z": true,
+			"2024-06-02T01:59:00Z": true,
+			"2024-06-02T02:00:00Z": false,
+			"2024-06-02T23:00:00Z": false,
+		} {
+			now, err := time.Parse(time.RFC3339, at)
+			Expect(err).NotTo(HaveOccurred())
+
+			inWindow, err := wp.InCoreUpdateWindow(now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inWindow).To(Equal(expected), at)
+		}
+
+		wp.Spec.Updates.Core.Window.Timezone = "Nowhere/Invalid"
+		_, err := wp.InCoreUpdateWindow(time.Now())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should install the network and map the domains of its sub-sites", func() {
+		wp.Spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{}
+		wp.Spec.Multisite = &wordpressv1alpha1.MultisiteSpec{
+			Sites: []wordpressv1alpha1.SubsiteSpec{
+				{Slug: "shop", Domains: []string{"Shop.Example.com"}},
+				{Slug: "docs"},
+			},
+		}
+
+		domain := wp.MainDomain()
+		Expect(wp.Domains()).To(ContainElement("Shop.Example.com"))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Annotations).To(HaveKey("wordpress.presslabs.org/sunriseVersion"))
+		Expect(spec.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "SUBDOMAIN_INSTALL", Value: "false"}))
+		Expect(spec.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "DOMAIN_CURRENT_SITE", Value: domain}))
+		Expect(spec.Spec.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "SUNRISE", Value: "true"}))
+		Expect(spec.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "sunrise",
+			MountPath: "/app/web/wp-content/sunrise.php",
+			SubPath:   SunriseKey,
+			ReadOnly:  true,
+		}))
+
+		var install corev1.Container
+		for _, c := range spec.Spec.InitContainers {
+			if c.Name == "install-wp" {
+				install = c
+			}
+		}
+		Expect(install.Command).To(Equal([]string{"/bin/sh", "-c", multisiteInstallScript, "--"}))
+		Expect(install.Env).To(ContainElement(corev1.EnvVar{
+			Name:  "MULTISITE_SITES",
+			Value: fmt.Sprintf("shop|http://%s/shop/\ndocs|http://%s/docs/", domain, domain),
+		}))
+
+		Expect(wp.Sunrise()).To(ContainSubstring(fmt.Sprintf("'shop.example.com' => array( '%s', '/shop/' ),", domain)))
+	})
+
+	It("should run wp-cli commands reporting their output tail", func() {
+		spec := wp.WPCliCommandPodTemplateSpec([]string{"plugin", "list"})
+
+		Expect(spec.Spec.RestartPolicy).To(Equal(corev1.RestartPolicyNever))
+		Expect(spec.Spec.Containers[0].Name).To(Equal(WPCliContainerName))
+		Expect(spec.Spec.Containers[0].Args).To(Equal([]string{"/bin/sh", "-c", wpCliCommandScript, "--", "plugin", "list"}))
+		Expect(wpCliCommandScript).To(ContainSubstring("/dev/termination-log"))
+	})
+
+	It("should disable the pseudo-cron when the events are run from a CronJob", func() {
+		_, found := lookupEnvVar("DISABLE_WP_CRON", wp.WebPodTemplateSpec().Spec.Containers[0].Env)
+		Expect(found).To(BeFalse())
+
+		wp.Spec.Cron = &wordpressv1alpha1.CronSpec{}
+		Expect(wp.RunsCronJob()).To(BeTrue())
+		Expect(wp.CronSchedule()).To(Equal("*/5 * * * *"))
+
+		e, found := lookupEnvVar("DISABLE_WP_CRON", wp.WebPodTemplateSpec().Spec.Containers[0].Env)
+		Expect(found).To(BeTrue())
+		Expect(e.Value).To(Equal("true"))
+
+		Expect(wp.CronPodTemplateSpec().Spec.Containers[0].Args).To(Equal([]string{"wp", "cron", "event", "run", "--due-now"}))
+
+		enabled := false
+		wp.Spec.Cron.Enabled = &enabled
+		Expect(wp.RunsCronJob()).To(BeFalse())
+	})
+
+	It("should project the rendered compression config along the web server config", func() {
+		wp.Spec.WebServerConfig = &wordpressv1alpha1.WebServerConfigSpec{ConfigMapName: "nginx"}
+		wp.Spec.Compression = &wordpressv1alpha1.CompressionSpec{Brotli: true, GzipLevel: 6, Types: []string{"text/html", "text/css"}}
+		wp.SetDefaults()
+
+		Expect(wp.CompressionConfig()).To(Equal("gzip on;\ngzip_comp_level 6;\ngzip_min_length 256;\ngzip_proxied any;\ngzip_vary on;\n" +
+			"gzip_types text/css;\nbrotli on;\nbrotli_comp_level 5;\nbrotli_min_length 256;\nbrotli_types text/css;\n"))
+
+		spec := wp.WebPodTemplateSpec()
+		Expect(spec.Annotations).To(HaveKey("wordpress.presslabs.org/compressionConfigVersion"))
+		Expect(spec.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "web-server-config",
+			VolumeSource: corev1.VolumeSource{
+				Projected: &corev1.ProjectedVolumeSource{
+					Sources: []corev1.VolumeProjection{
+						{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: wp.ComponentName(WordpressWebServerConfig)}}},
+						{ConfigMap: &corev1.ConfigMapProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "nginx"}}},
+					},
+				},
+			},
+		}))
+	})
+
 	It("should give me the default readiness probe", func() {
 		spec := wp.WebPodTemplateSpec()
 
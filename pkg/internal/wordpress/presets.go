@@ -0,0 +1,118 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+type sizePreset struct {
+	replicas       int32
+	requests       corev1.ResourceList
+	limits         corev1.ResourceList
+	maxChildren    int32
+	phpMemoryLimit resource.Quantity
+}
+
+// the web pods' memory fits the PHP-FPM workers, while their CPU isn't limited
+var sizePresets = map[wordpressv1alpha1.SizePreset]sizePreset{
+	wordpressv1alpha1.SizePresetSmall: {
+		replicas: 1,
+		requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+		limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("768Mi"),
+		},
+		maxChildren:    5,
+		phpMemoryLimit: resource.MustParse("128Mi"),
+	},
+	wordpressv1alpha1.SizePresetMedium: {
+		replicas: 2,
+		requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+		limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("1536Mi"),
+		},
+		maxChildren:    10,
+		phpMemoryLimit: resource.MustParse("128Mi"),
+	},
+	wordpressv1alpha1.SizePresetLarge: {
+		replicas: 3,
+		requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		},
+		limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("3Gi"),
+		},
+		maxChildren:    20,
+		phpMemoryLimit: resource.MustParse("256Mi"),
+	},
+}
+
+// mergeResourceList sets the resources missing from the list.
+func mergeResourceList(list corev1.ResourceList, defaults corev1.ResourceList) corev1.ResourceList {
+	if list == nil {
+		list = corev1.ResourceList{}
+	}
+
+	for name, q := range defaults {
+		if _, ok := list[name]; !ok {
+			list[name] = q.DeepCopy()
+		}
+	}
+
+	return list
+}
+
+// setSizePresetDefaults sets the replicas, resources and PHP-FPM pool of the
+// size preset, keeping the fields set explicitly.
+func (wp *Wordpress) setSizePresetDefaults() {
+	preset, ok := sizePresets[wp.Spec.SizePreset]
+	if !ok {
+		return
+	}
+
+	if wp.Spec.Replicas == nil {
+		replicas := preset.replicas
+		wp.Spec.Replicas = &replicas
+	}
+
+	wp.Spec.Resources.Requests = mergeResourceList(wp.Spec.Resources.Requests, preset.requests)
+	wp.Spec.Resources.Limits = mergeResourceList(wp.Spec.Resources.Limits, preset.limits)
+
+	if wp.Spec.PHP == nil {
+		wp.Spec.PHP = &wordpressv1alpha1.PHPSpec{}
+	}
+
+	if wp.Spec.PHP.MaxChildren == nil {
+		maxChildren := preset.maxChildren
+		wp.Spec.PHP.MaxChildren = &maxChildren
+	}
+
+	if wp.Spec.PHP.MemoryLimit == nil {
+		memoryLimit := preset.phpMemoryLimit.DeepCopy()
+		wp.Spec.PHP.MemoryLimit = &memoryLimit
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+var (
+	// the media env vars, as understood by rclone's on the fly backends
+	s3RcloneEnvVars = map[string]string{
+		"AWS_ACCESS_KEY_ID":     "RCLONE_S3_ACCESS_KEY_ID",
+		"AWS_SECRET_ACCESS_KEY": "RCLONE_S3_SECRET_ACCESS_KEY",
+		"ENDPOINT":              "RCLONE_S3_ENDPOINT",
+	}
+	gcsRcloneEnvVars = map[string]string{
+		"GOOGLE_CREDENTIALS": "RCLONE_GCS_SERVICE_ACCOUNT_CREDENTIALS",
+	}
+)
+
+// hasMediaBucket returns true if the media files are stored in a S3 or GCS bucket.
+func (wp *Wordpress) hasMediaBucket() bool {
+	media := wp.Spec.MediaVolumeSpec
+
+	return media != nil && (media.S3VolumeSource != nil || media.GCSVolumeSource != nil)
+}
+
+// mediaRclone returns the rclone remote of the media bucket's prefix, along
+// with the env vars configuring its backend and credentials.
+func (wp *Wordpress) mediaRclone() (string, []corev1.EnvVar) {
+	var (
+		remote, bucket, prefix string
+		env, out               []corev1.EnvVar
+		names                  map[string]string
+	)
+
+	media := wp.Spec.MediaVolumeSpec
+
+	if media.S3VolumeSource != nil {
+		remote, bucket, prefix = ":s3:", media.S3VolumeSource.Bucket, media.S3VolumeSource.PathPrefix
+		env, names = media.S3VolumeSource.Env, s3RcloneEnvVars
+
+		provider := "AWS"
+		for _, e := range env {
+			if e.Name == "ENDPOINT" {
+				provider = "Other"
+			}
+		}
+
+		out = append(out,
+			corev1.EnvVar{Name: "RCLONE_S3_PROVIDER", Value: provider},
+			corev1.EnvVar{Name: "RCLONE_S3_ENV_AUTH", Value: "true"},
+		)
+	} else {
+		remote, bucket, prefix = ":gcs:", media.GCSVolumeSource.Bucket, media.GCSVolumeSource.PathPrefix
+		env, names = media.GCSVolumeSource.Env, gcsRcloneEnvVars
+
+		out = append(out,
+			corev1.EnvVar{Name: "RCLONE_GCS_ENV_AUTH", Value: "true"},
+			corev1.EnvVar{Name: "RCLONE_GCS_BUCKET_POLICY_ONLY", Value: "true"},
+		)
+	}
+
+	for _, e := range env {
+		if name, ok := names[e.Name]; ok {
+			_env := e.DeepCopy()
+			_env.Name = name
+			out = append(out, *_env)
+		}
+	}
+
+	return remote + path.Join(bucket, prefix), out
+}
+
+// bucketRclone returns the named rclone remote of a S3 or GCS bucket's prefix,
+// along with the env vars configuring it. Unlike the on the fly backends, named
+// remotes allow a pod to use several buckets, each with its own credentials.
+func bucketRclone(name string, s3 *wordpressv1alpha1.S3VolumeSource,
+	gcs *wordpressv1alpha1.GCSVolumeSource) (string, []corev1.EnvVar) {
+	var (
+		bucket, prefix, backend string
+		env, out                []corev1.EnvVar
+		names                   map[string]string
+	)
+
+	config := "RCLONE_CONFIG_" + strings.ToUpper(name) + "_"
+
+	if s3 != nil {
+		bucket, prefix, backend = s3.Bucket, s3.PathPrefix, "RCLONE_S3_"
+		env, names = s3.Env, s3RcloneEnvVars
+
+		provider := "AWS"
+		for _, e := range env {
+			if e.Name == "ENDPOINT" {
+				provider = "Other"
+			}
+		}
+
+		out = append(out,
+			corev1.EnvVar{Name: config + "TYPE", Value: "s3"},
+			corev1.EnvVar{Name: config + "PROVIDER", Value: provider},
+			corev1.EnvVar{Name: config + "ENV_AUTH", Value: "true"},
+		)
+	} else {
+		bucket, prefix, backend = gcs.Bucket, gcs.PathPrefix, "RCLONE_GCS_"
+		env, names = gcs.Env, gcsRcloneEnvVars
+
+		out = append(out,
+			corev1.EnvVar{Name: config + "TYPE", Value: "google cloud storage"},
+			corev1.EnvVar{Name: config + "ENV_AUTH", Value: "true"},
+			corev1.EnvVar{Name: config + "BUCKET_POLICY_ONLY", Value: "true"},
+		)
+	}
+
+	for _, e := range env {
+		if option, ok := names[e.Name]; ok {
+			_env := e.DeepCopy()
+			_env.Name = config + strings.TrimPrefix(option, backend)
+			out = append(out, *_env)
+		}
+	}
+
+	return name + ":" + path.Join(bucket, prefix), out
+}
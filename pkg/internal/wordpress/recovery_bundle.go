@@ -0,0 +1,154 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"errors"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const (
+	// RecoveryBundleManifestKey is the key of the recovery bundle's ConfigMap
+	// holding the Wordpress manifest.
+	RecoveryBundleManifestKey = "wordpress.yaml"
+	// RecoveryBundleArtifactsKey is the key of the recovery bundle's
+	// ConfigMap holding the backup's artifacts.
+	RecoveryBundleArtifactsKey = "artifacts.yaml"
+
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+var (
+	errRecoveryDatabaseArtifact = errors.New("the backup has no database artifact")
+	errRecoveryDecryptionKey    = errors.New(
+		"the backup is encrypted to a public key, so the bundle's decryptionKeySecretRef must be set")
+)
+
+// recoveryImportSource returns the import source downloading the backup's
+// database artifact.
+func recoveryImportSource(bundle *wordpressv1alpha1.WordpressRecoveryBundle,
+	backup *wordpressv1alpha1.WordpressBackup) (*wordpressv1alpha1.BackupImportSource, error) {
+	artifact := DatabaseArtifact(backup)
+	if artifact == nil {
+		return nil, errRecoveryDatabaseArtifact
+	}
+
+	method, err := BackupEncryptionMethod(backup)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &wordpressv1alpha1.BackupImportSource{
+		Destination: *backup.Spec.Destination.DeepCopy(),
+		Path:        path.Join(BackupPath(backup), path.Base(artifact.Location)),
+		Encryption:  method,
+	}
+
+	switch method {
+	case "":
+	case wordpressv1alpha1.BackupEncryptionPassphrase:
+		source.DecryptionKeySecretRef = backup.Spec.Encryption.PassphraseSecretRef.DeepCopy()
+	default:
+		if bundle.Spec.DecryptionKeySecretRef == nil {
+			return nil, errRecoveryDecryptionKey
+		}
+
+		source.DecryptionKeySecretRef = bundle.Spec.DecryptionKeySecretRef.DeepCopy()
+	}
+
+	return source, nil
+}
+
+// RecoveryManifest returns the Wordpress recovered, in another cluster, from
+// the bundle's backup: a copy of the site, without a namespace, which is
+// bootstrapped only once by importing the backup's database artifact. The
+// content imports are dropped, as the content comes along with the database.
+func (wp *Wordpress) RecoveryManifest(bundle *wordpressv1alpha1.WordpressRecoveryBundle,
+	backup *wordpressv1alpha1.WordpressBackup) (*wordpressv1alpha1.Wordpress, error) {
+	source, err := recoveryImportSource(bundle, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := wp.Spec.DeepCopy()
+
+	if spec.WordpressBootstrapSpec == nil {
+		spec.WordpressBootstrapSpec = &wordpressv1alpha1.WordpressBootstrapSpec{}
+	}
+
+	spec.WordpressBootstrapSpec.RunOnce = true
+	spec.WordpressBootstrapSpec.ContentImport = nil
+	spec.WordpressBootstrapSpec.ImportFrom = &wordpressv1alpha1.DatabaseImportSource{Backup: source}
+
+	objLabels := map[string]string{}
+	for k, v := range wp.ObjectMeta.Labels {
+		objLabels[k] = v
+	}
+
+	annotations := map[string]string{}
+	for k, v := range wp.ObjectMeta.Annotations {
+		if k != lastAppliedAnnotation {
+			annotations[k] = v
+		}
+	}
+
+	annotations[wordpressv1alpha1.RecoveredFromAnnotation] = path.Join(backup.Namespace, backup.Name)
+
+	return &wordpressv1alpha1.Wordpress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: wordpressv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "Wordpress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        wp.Name,
+			Labels:      objLabels,
+			Annotations: annotations,
+		},
+		Spec: *spec,
+	}, nil
+}
+
+// RecoveryBundleData returns the data of the recovery bundle's ConfigMap: the
+// recovered Wordpress manifest and the backup's artifacts, e.g. for restoring
+// the media which is not stored in a bucket.
+func (wp *Wordpress) RecoveryBundleData(bundle *wordpressv1alpha1.WordpressRecoveryBundle,
+	backup *wordpressv1alpha1.WordpressBackup) (map[string]string, error) {
+	manifest, err := wp.RecoveryManifest(bundle, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestYAML, err := yaml.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	artifactsYAML, err := yaml.Marshal(backup.Status.Artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		RecoveryBundleManifestKey:  string(manifestYAML),
+		RecoveryBundleArtifactsKey: string(artifactsYAML),
+	}, nil
+}
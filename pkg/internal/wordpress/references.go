@@ -0,0 +1,141 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RollsOnConfigChange returns true if the web pods are rolled when the
+// Secrets and ConfigMaps referenced by their environment change.
+func (wp *Wordpress) RollsOnConfigChange() bool {
+	return wp.Spec.RollOnConfigChange == nil || *wp.Spec.RollOnConfigChange
+}
+
+// ReferencedSecrets returns the names of the Secrets referenced by the
+// environment of the web pods' containers, except for the site's secret,
+// whose changes always roll them, and the excluded ones.
+func (wp *Wordpress) ReferencedSecrets() []string {
+	secrets, _ := wp.envReferences()
+
+	return secrets
+}
+
+// ReferencedConfigMaps returns the names of the ConfigMaps referenced by the
+// environment of the web pods' containers, along with the WAF's rules and
+// the log shipper's outputs.
+func (wp *Wordpress) ReferencedConfigMaps() []string {
+	_, configMaps := wp.envReferences()
+
+	return configMaps
+}
+
+func (wp *Wordpress) envReferences() ([]string, []string) {
+	secrets := map[string]bool{}
+	configMaps := map[string]bool{}
+
+	spec := wp.WebPodTemplateSpec().Spec
+
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		for _, env := range c.Env {
+			switch {
+			case env.ValueFrom == nil:
+			case env.ValueFrom.SecretKeyRef != nil:
+				secrets[env.ValueFrom.SecretKeyRef.Name] = true
+			case env.ValueFrom.ConfigMapKeyRef != nil:
+				configMaps[env.ValueFrom.ConfigMapKeyRef.Name] = true
+			}
+		}
+
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.SecretRef != nil {
+				secrets[envFrom.SecretRef.Name] = true
+			}
+
+			if envFrom.ConfigMapRef != nil {
+				configMaps[envFrom.ConfigMapRef.Name] = true
+			}
+		}
+	}
+
+	// the WAF's rules are mounted by their sub-paths, so they're not updated in place
+	if wp.UsesWAF() && wp.Spec.WAF.Rules != nil {
+		configMaps[wp.Spec.WAF.Rules.ConfigMapName] = true
+	}
+
+	// fluent-bit doesn't reload its outputs
+	if wp.ShipsLogs() {
+		configMaps[wp.Spec.Logging.Shipper.OutputConfigMapName] = true
+	}
+
+	delete(secrets, wp.ComponentName(WordpressSecret))
+
+	for _, name := range wp.Spec.RollOnConfigChangeExclusions {
+		delete(secrets, name)
+		delete(configMaps, name)
+	}
+
+	return sortedKeys(secrets), sortedKeys(configMaps)
+}
+
+// DefersRestarts returns true if the web pods' restarts caused by changed
+// Secrets and ConfigMaps are deferred, as the maintenance window is closed.
+// An invalid window doesn't defer them.
+func (wp *Wordpress) DefersRestarts(now time.Time) bool {
+	if wp.Spec.MaintenanceWindow == nil {
+		return false
+	}
+
+	open, err := inMaintenanceWindow(wp.Spec.MaintenanceWindow, now)
+
+	return err == nil && !open
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := []string{}
+	for k := range m {
+		out = append(out, k)
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+// ReferencesVersion returns the hash of the data of the referenced Secrets
+// and ConfigMaps, which rolls the web pods when it changes. The missing ones
+// are hashed as empty.
+func ReferencesVersion(secrets []corev1.Secret, configMaps []corev1.ConfigMap) string {
+	if len(secrets) == 0 && len(configMaps) == 0 {
+		return ""
+	}
+
+	values := []interface{}{}
+
+	for _, s := range secrets {
+		values = append(values, "secret", s.Name, s.Data)
+	}
+
+	for _, c := range configMaps {
+		values = append(values, "configmap", c.Name, c.Data, c.BinaryData)
+	}
+
+	return hash(values...)
+}
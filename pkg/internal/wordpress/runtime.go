@@ -0,0 +1,72 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"errors"
+	"fmt"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+// ErrUnsupportedRuntime is returned for the PHP versions and variants without a runtime image.
+var ErrUnsupportedRuntime = errors.New("unsupported runtime")
+
+// runtimeTagSuffixes maps the supported variants and PHP versions to the
+// suffixes of the runtime image's tags.
+var runtimeTagSuffixes = map[wordpressv1alpha1.RuntimeVariant]map[string]string{
+	wordpressv1alpha1.RuntimeVariantNginx: {
+		"7.4": "-php-7.4",
+		"8.0": "-php-8.0",
+		"8.1": "-php-8.1",
+	},
+	wordpressv1alpha1.RuntimeVariantApache: {
+		"7.4": "-php-7.4-apache",
+		"8.0": "-php-8.0-apache",
+	},
+}
+
+// RuntimeImage returns the runtime image for the site's PHP version and
+// variant, as a tag of the operator's --wordpress-runtime-image.
+func (wp *Wordpress) RuntimeImage() (string, error) {
+	runtime := wp.Spec.Runtime
+
+	variant := runtime.Variant
+	if variant == "" {
+		variant = wordpressv1alpha1.RuntimeVariantNginx
+	}
+
+	suffix, ok := runtimeTagSuffixes[variant][runtime.PHPVersion]
+	if !ok {
+		return "", fmt.Errorf("%w: PHP %s is not available for the %s variant", ErrUnsupportedRuntime, runtime.PHPVersion, variant)
+	}
+
+	return options.WordpressRuntimeImage + suffix, nil
+}
+
+// defaultImage returns the runtime image selected by spec.runtime, falling
+// back to the operator's --wordpress-runtime-image.
+func (wp *Wordpress) defaultImage() string {
+	if wp.Spec.Runtime != nil {
+		if image, err := wp.RuntimeImage(); err == nil {
+			return image
+		}
+	}
+
+	return options.WordpressRuntimeImage
+}
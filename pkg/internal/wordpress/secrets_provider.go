@@ -0,0 +1,130 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	secretsProviderVolumeName       = "secrets-store"
+	defaultSecretsProviderMountPath = "/var/run/secrets/wordpress"
+	secretsStoreCSIDriver           = "secrets-store.csi.k8s.io"
+
+	defaultExternalSecretRefreshInterval = time.Hour
+	defaultExternalSecretStoreKind       = "SecretStore"
+)
+
+// mountsSecretsProvider returns true if the site's pods mount a
+// SecretProviderClass of the Secrets Store CSI driver.
+func (wp *Wordpress) mountsSecretsProvider() bool {
+	return wp.Spec.SecretsProvider != nil && wp.Spec.SecretsProvider.CSI != nil
+}
+
+// UsesExternalSecret returns true if an ExternalSecret is created for the site.
+func (wp *Wordpress) UsesExternalSecret() bool {
+	return wp.Spec.SecretsProvider != nil && wp.Spec.SecretsProvider.ExternalSecret != nil
+}
+
+// ExternalSecretRefreshInterval returns how often the ExternalSecret is
+// refreshed from its store.
+func (wp *Wordpress) ExternalSecretRefreshInterval() time.Duration {
+	if interval := wp.Spec.SecretsProvider.ExternalSecret.RefreshInterval; interval != nil {
+		return interval.Duration
+	}
+
+	return defaultExternalSecretRefreshInterval
+}
+
+// ExternalSecretStoreKind returns the kind of the ExternalSecret's store.
+func (wp *Wordpress) ExternalSecretStoreKind() string {
+	if kind := wp.Spec.SecretsProvider.ExternalSecret.SecretStoreRef.Kind; kind != "" {
+		return kind
+	}
+
+	return defaultExternalSecretStoreKind
+}
+
+// secretsProviderEnvFrom returns the secrets synced from the secrets store.
+// They're added after the site's secret, so they take precedence over it.
+func (wp *Wordpress) secretsProviderEnvFrom() []corev1.EnvFromSource {
+	out := []corev1.EnvFromSource{}
+
+	if wp.mountsSecretsProvider() && wp.Spec.SecretsProvider.CSI.SecretName != "" {
+		out = append(out, secretEnvFrom(wp.Spec.SecretsProvider.CSI.SecretName))
+	}
+
+	if wp.UsesExternalSecret() {
+		out = append(out, secretEnvFrom(wp.ComponentName(WordpressExternalSecret)))
+	}
+
+	return out
+}
+
+func secretEnvFrom(name string) corev1.EnvFromSource {
+	return corev1.EnvFromSource{
+		SecretRef: &corev1.SecretEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+		},
+	}
+}
+
+// secretsProviderVolumes returns the CSI volume of the SecretProviderClass.
+// The driver syncs its secret objects only while the volume is mounted.
+func (wp *Wordpress) secretsProviderVolumes() []corev1.Volume {
+	if !wp.mountsSecretsProvider() {
+		return nil
+	}
+
+	readOnly := true
+
+	return []corev1.Volume{
+		{
+			Name: secretsProviderVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				CSI: &corev1.CSIVolumeSource{
+					Driver:   secretsStoreCSIDriver,
+					ReadOnly: &readOnly,
+					VolumeAttributes: map[string]string{
+						"secretProviderClass": wp.Spec.SecretsProvider.CSI.SecretProviderClass,
+					},
+				},
+			},
+		},
+	}
+}
+
+func (wp *Wordpress) secretsProviderVolumeMounts() []corev1.VolumeMount {
+	if !wp.mountsSecretsProvider() {
+		return nil
+	}
+
+	mountPath := wp.Spec.SecretsProvider.CSI.MountPath
+	if mountPath == "" {
+		mountPath = defaultSecretsProviderMountPath
+	}
+
+	return []corev1.VolumeMount{
+		{
+			Name:      secretsProviderVolumeName,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		},
+	}
+}
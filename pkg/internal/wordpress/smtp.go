@@ -0,0 +1,160 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+const (
+	defaultSMTPPort    = int32(587)
+	defaultSMTPTLSPort = int32(465)
+)
+
+// the error reported by PHPMailer is printed, as wp_mail only returns false
+const smtpTestScript = `
+add_action('wp_mail_failed', function ($error) {
+	fwrite(STDERR, $error->get_error_message() . PHP_EOL);
+});
+$sent = wp_mail(
+	getenv('SMTP_TEST_RECIPIENT'),
+	sprintf('SMTP test from %s', get_bloginfo('name')),
+	sprintf('This email was sent by the operator to verify the SMTP configuration of %s.', home_url())
+);
+exit($sent ? 0 : 1);
+`
+
+// ConfiguresSMTP returns true if the site sends its emails through an SMTP server.
+func (wp *Wordpress) ConfiguresSMTP() bool {
+	return wp.Spec.SMTP != nil
+}
+
+// TestsSMTP returns true if a test email verifies the SMTP configuration.
+func (wp *Wordpress) TestsSMTP() bool {
+	return wp.ConfiguresSMTP() && wp.Spec.SMTP.TestRecipient != ""
+}
+
+// SMTPEncryption returns the encryption of the connection to the SMTP server.
+func (wp *Wordpress) SMTPEncryption() wordpressv1alpha1.SMTPEncryption {
+	if wp.Spec.SMTP.Encryption == "" {
+		return wordpressv1alpha1.SMTPEncryptionSTARTTLS
+	}
+
+	return wp.Spec.SMTP.Encryption
+}
+
+// SMTPPort returns the port of the SMTP server, depending on its encryption
+// if not set.
+func (wp *Wordpress) SMTPPort() int32 {
+	switch {
+	case wp.Spec.SMTP.Port > 0:
+		return wp.Spec.SMTP.Port
+	case wp.SMTPEncryption() == wordpressv1alpha1.SMTPEncryptionTLS:
+		return defaultSMTPTLSPort
+	}
+
+	return defaultSMTPPort
+}
+
+// smtpEnv returns the env vars configuring the runtime's PHPMailer to send
+// the site's emails through the SMTP server. SMTP_SECURE follows PHPMailer's
+// SMTPSecure values.
+func (wp *Wordpress) smtpEnv() []corev1.EnvVar {
+	if !wp.ConfiguresSMTP() {
+		return nil
+	}
+
+	spec := wp.Spec.SMTP
+
+	secure := ""
+
+	switch wp.SMTPEncryption() {
+	case wordpressv1alpha1.SMTPEncryptionSTARTTLS:
+		secure = "tls"
+	case wordpressv1alpha1.SMTPEncryptionTLS:
+		secure = "ssl"
+	case wordpressv1alpha1.SMTPEncryptionNone:
+	}
+
+	out := []corev1.EnvVar{
+		{
+			Name:  "SMTP_HOST",
+			Value: spec.Host,
+		},
+		{
+			Name:  "SMTP_PORT",
+			Value: strconv.Itoa(int(wp.SMTPPort())),
+		},
+		{
+			Name:  "SMTP_SECURE",
+			Value: secure,
+		},
+		{
+			Name:  "SMTP_AUTH",
+			Value: strconv.FormatBool(len(spec.CredentialsSecretRef) > 0),
+		},
+	}
+
+	if spec.FromAddress != "" {
+		out = append(out, corev1.EnvVar{
+			Name:  "SMTP_FROM",
+			Value: spec.FromAddress,
+		})
+	}
+
+	if spec.FromName != "" {
+		out = append(out, corev1.EnvVar{
+			Name:  "SMTP_FROM_NAME",
+			Value: spec.FromName,
+		})
+	}
+
+	if len(spec.CredentialsSecretRef) > 0 {
+		credentials := string(spec.CredentialsSecretRef)
+
+		out = append(out,
+			secretKeyEnvVar("SMTP_USER", credentials, "USER"),
+			secretKeyEnvVar("SMTP_PASSWORD", credentials, "PASSWORD"),
+		)
+	}
+
+	return out
+}
+
+// SMTPVersion identifies the SMTP configuration and the test recipient the
+// test email is sent with.
+func (wp *Wordpress) SMTPVersion() string {
+	spec := wp.Spec.SMTP
+
+	return hash(spec.Host, wp.SMTPPort(), string(wp.SMTPEncryption()), string(spec.CredentialsSecretRef),
+		spec.FromAddress, spec.FromName, spec.TestRecipient)
+}
+
+// SMTPTestPodTemplateSpec generates the pod template spec of the job which
+// sends a test email to the test recipient, through wp_mail.
+func (wp *Wordpress) SMTPTestPodTemplateSpec() corev1.PodTemplateSpec {
+	out := wp.WPCliCommandPodTemplateSpec([]string{"eval", smtpTestScript})
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "SMTP_TEST_RECIPIENT", Value: wp.Spec.SMTP.TestRecipient},
+	)
+
+	return out
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	staticAssetsVolumeName = "static-assets"
+	staticAssetsMountPath  = "/mnt/static-assets"
+	staticAssetsPrefix     = "static"
+)
+
+var (
+	defaultStaticAssetsPaths      = []string{"themes", "plugins", "mu-plugins"}
+	defaultStaticAssetsExtensions = []string{
+		"css", "js", "map",
+		"png", "jpg", "jpeg", "gif", "svg", "webp", "ico",
+		"woff", "woff2", "ttf", "otf", "eot",
+	}
+)
+
+// the assets are copied, keeping their paths within wp-content, to a volume
+// shared with the upload container. Globbing is disabled for the -iname patterns.
+const collectStaticAssetsScript = `
+set -ef
+
+cd "$WP_CONTENT_DIR"
+
+paths=""
+for p in $STATIC_ASSETS_PATHS; do
+    if [ -d "$p" ]; then
+        paths="$paths $p"
+    fi
+done
+
+names=""
+for ext in $STATIC_ASSETS_EXTENSIONS; do
+    names="${names:+$names -o }-iname *.$ext"
+done
+
+if [ -n "$paths" ]; then
+    find $paths -type f \( $names \) | tar -cf - -T - | tar -xf - -C "$STATIC_ASSETS_DIR"
+fi
+`
+
+// the manifest is uploaded last, so that it marks a complete upload
+const uploadStaticAssetsScript = `
+set -e
+
+rclone lsjson -R --files-only --hash "$STATIC_ASSETS_DIR" > /tmp/manifest.json
+rclone copy --checksum "$STATIC_ASSETS_DIR" "$STATIC_ASSETS_REMOTE"
+rclone copyto /tmp/manifest.json "$STATIC_ASSETS_REMOTE/manifest.json"
+`
+
+// OffloadsStaticAssets returns true if the static assets are offloaded to
+// the media bucket.
+func (wp *Wordpress) OffloadsStaticAssets() bool {
+	return wp.hasMediaBucket() && wp.Spec.MediaVolumeSpec.StaticAssets != nil
+}
+
+// StaticAssetsVersion returns the name of the directory the static assets of
+// the current code version are offloaded to.
+func (wp *Wordpress) StaticAssetsVersion() string {
+	return hash(wp.CodeVersion())
+}
+
+// staticAssetsEnv points the runtime to the offloaded assets, once they are
+// uploaded for the current code version. Until then, the assets are served by
+// the web pods.
+func (wp *Wordpress) staticAssetsEnv() []corev1.EnvVar {
+	if !wp.OffloadsStaticAssets() || wp.Status.StaticAssetsSyncedFor != wp.CodeVersion() {
+		return nil
+	}
+
+	url := strings.TrimSuffix(wp.Spec.MediaVolumeSpec.StaticAssets.URL, "/")
+
+	return []corev1.EnvVar{
+		{
+			Name:  "STACK_STATIC_ASSETS_URL",
+			Value: url + "/" + wp.StaticAssetsVersion(),
+		},
+	}
+}
+
+// StaticAssetsPodTemplateSpec generates the pod template spec of the job
+// which offloads the static assets. The assets are collected from the site's
+// code by an init container and uploaded by rclone.
+func (wp *Wordpress) StaticAssetsPodTemplateSpec() corev1.PodTemplateSpec {
+	spec := wp.Spec.MediaVolumeSpec.StaticAssets
+
+	paths := spec.Paths
+	if len(paths) == 0 {
+		paths = defaultStaticAssetsPaths
+	}
+
+	extensions := spec.Extensions
+	if len(extensions) == 0 {
+		extensions = defaultStaticAssetsExtensions
+	}
+
+	wpContentDir := defaultCodeMountPath
+	if wp.Spec.CodeVolumeSpec != nil {
+		wpContentDir = wp.Spec.CodeVolumeSpec.MountPath
+	}
+
+	mount := corev1.VolumeMount{
+		Name:      staticAssetsVolumeName,
+		MountPath: staticAssetsMountPath,
+	}
+
+	remote, env := wp.mediaRclone()
+
+	out := wp.JobPodTemplateSpec()
+
+	// the wp-cli container has the site's code and gets the assets out of it
+	collect := out.Spec.Containers[0]
+	collect.Name = "collect-static-assets"
+	collect.Command = []string{"/bin/sh", "-c", collectStaticAssetsScript}
+	collect.Args = nil
+	collect.VolumeMounts = append(collect.VolumeMounts, mount)
+	collect.Env = append(collect.Env,
+		corev1.EnvVar{Name: "WP_CONTENT_DIR", Value: wpContentDir},
+		corev1.EnvVar{Name: "STATIC_ASSETS_DIR", Value: staticAssetsMountPath},
+		corev1.EnvVar{Name: "STATIC_ASSETS_PATHS", Value: strings.Join(paths, " ")},
+		corev1.EnvVar{Name: "STATIC_ASSETS_EXTENSIONS", Value: strings.Join(extensions, " ")},
+	)
+
+	out.Spec.InitContainers = append(out.Spec.InitContainers, collect)
+	out.Spec.Containers = []corev1.Container{
+		{
+			Name:         "upload-static-assets",
+			Image:        options.RcloneImage,
+			Command:      []string{"/bin/sh", "-c", uploadStaticAssetsScript},
+			VolumeMounts: []corev1.VolumeMount{mount},
+			Env: append(env,
+				corev1.EnvVar{Name: "STATIC_ASSETS_DIR", Value: staticAssetsMountPath},
+				corev1.EnvVar{Name: "STATIC_ASSETS_REMOTE", Value: remote + "/" + path.Join(staticAssetsPrefix, wp.StaticAssetsVersion())},
+			),
+		},
+	}
+
+	out.Spec.Volumes = append(out.Spec.Volumes, corev1.Volume{
+		Name: staticAssetsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	return out
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ManagesThemes returns true if the site's themes are managed by the operator.
+func (wp *Wordpress) ManagesThemes() bool {
+	return len(wp.Spec.Themes) > 0
+}
+
+// ThemesVersion identifies the themes spec and the code version the themes
+// are synced for.
+func (wp *Wordpress) ThemesVersion() string {
+	return hash(wp.CodeVersion(), wp.themesList())
+}
+
+// themesList returns the themes in the format read by the extensions script.
+// Only the first active theme is activated, the others are just installed.
+func (wp *Wordpress) themesList() string {
+	lines := make([]string, len(wp.Spec.Themes))
+	activated := false
+
+	for i, t := range wp.Spec.Themes {
+		state := "installed"
+		if t.Active && !activated {
+			state = "active"
+			activated = true
+		}
+
+		lines[i] = fmt.Sprintf("%s|%s|%s|%s", t.Name, t.Version, t.Source, state)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// installThemesContainer installs the missing themes into the web pods.
+func (wp *Wordpress) installThemesContainer() []corev1.Container {
+	if !wp.ManagesThemes() {
+		return nil
+	}
+
+	return []corev1.Container{wp.installExtensionsContainer("theme", wp.themesList())}
+}
+
+// ThemesPodTemplateSpec generates the pod template spec of the job which
+// syncs the themes with the spec.
+func (wp *Wordpress) ThemesPodTemplateSpec() corev1.PodTemplateSpec {
+	return wp.extensionsPodTemplateSpec("theme", wp.themesList())
+}
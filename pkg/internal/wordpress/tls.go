@@ -0,0 +1,57 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var errTLSCertificateMissing = errors.New("the secret holds no PEM encoded certificate under " + corev1.TLSCertKey)
+
+// ValidatesTLSSecret returns true if the site is served with the certificate
+// of TLSSecretRef, which is then checked against the site's domains.
+func (wp *Wordpress) ValidatesTLSSecret() bool {
+	return wp.Spec.TLSSecretRef != "" && wp.TLSSecretName() == string(wp.Spec.TLSSecretRef)
+}
+
+// ParseTLSCertificate parses the leaf certificate of a TLS secret.
+func ParseTLSCertificate(secret *corev1.Secret) (*x509.Certificate, error) {
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errTLSCertificateMissing
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// UncoveredDomains returns the site's domains served with the certificate of
+// TLSSecretRef which the certificate doesn't cover.
+func (wp *Wordpress) UncoveredDomains(cert *x509.Certificate) []string {
+	domains := []string{}
+
+	for _, domain := range wp.CertificateDomains() {
+		if cert.VerifyHostname(domain) != nil {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+)
+
+var errUpgradeBackupDestination = errors.New(
+	"the backups taken before upgrades need a destination, in spec.updates.backupBeforeUpgrade or spec.backups")
+
+// BacksUpBeforeUpgrade returns true if the site is backed up before its
+// upgrades.
+func (wp *Wordpress) BacksUpBeforeUpgrade() bool {
+	return wp.Spec.Updates != nil && wp.Spec.Updates.BackupBeforeUpgrade != nil
+}
+
+// GitRef returns the git reference the code is cloned from, if any.
+func (wp *Wordpress) GitRef() string {
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.GitDir != nil {
+		return wp.Spec.CodeVolumeSpec.GitDir.GitRef
+	}
+
+	return ""
+}
+
+// UpgradePending returns true if the site's image or code reference differs
+// from the ones the web pods are rolled out with.
+func (wp *Wordpress) UpgradePending() bool {
+	status := wp.Status.Upgrade
+
+	return status != nil && (status.Image != wp.Spec.Image || status.GitRef != wp.GitRef())
+}
+
+// HoldUpgrade sets the site's image and code reference back to the ones the
+// web pods are rolled out with. The spec is only changed in memory, so that
+// the workload isn't rolled out until the upgrade is backed up.
+func (wp *Wordpress) HoldUpgrade() {
+	wp.Spec.Image = wp.Status.Upgrade.Image
+
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.GitDir != nil {
+		wp.Spec.CodeVolumeSpec.GitDir.GitRef = wp.Status.Upgrade.GitRef
+	}
+}
+
+// UpgradeBackup returns the WordpressBackup taken before upgrading the site
+// to the given version. It's named after the version, so it's taken once.
+func (wp *Wordpress) UpgradeBackup(version string) (*wordpressv1alpha1.WordpressBackup, error) {
+	spec := wp.Spec.Updates.BackupBeforeUpgrade
+
+	destination, encryption := spec.Destination, spec.Encryption
+	if wp.Spec.Backups != nil {
+		if destination == nil {
+			destination = &wp.Spec.Backups.Destination
+		}
+
+		if encryption == nil {
+			encryption = wp.Spec.Backups.Encryption
+		}
+	}
+
+	if destination == nil {
+		return nil, errUpgradeBackupDestination
+	}
+
+	return &wordpressv1alpha1.WordpressBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", wp.ComponentName(WordpressUpgradeBackups), hash(version)),
+			Namespace: wp.Namespace,
+			Labels:    wp.ComponentLabels(WordpressUpgradeBackups),
+		},
+		Spec: wordpressv1alpha1.WordpressBackupSpec{
+			SiteRef:     corev1.LocalObjectReference{Name: wp.Name},
+			Destination: *destination.DeepCopy(),
+			Encryption:  encryption.DeepCopy(),
+		},
+	}, nil
+}
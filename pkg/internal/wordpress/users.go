@@ -0,0 +1,156 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const defaultUserRole = "subscriber"
+
+// usersScript creates the missing users and corrects the email and the role
+// of the existing ones. They are passed one per line, as
+// username|email|role|variable, the variable holding the user's password, on
+// a separate file descriptor, as wp-cli may read its standard input. If
+// DELETE_UNMANAGED_ADMINISTRATORS is set, the administrators not listed are
+// deleted, their content being reassigned to the first listed one. The drift
+// found is reported through the termination message, one difference per line.
+const usersScript = `
+set -e
+
+drift=$(mktemp)
+managed=$(mktemp)
+reassign=""
+
+while IFS='|' read -r username email role variable <&3; do
+    [ -n "$username" ] || continue
+
+    echo "$username" >> "$managed"
+
+    password=""
+    if [ -n "$variable" ]; then
+        password=$(printenv "$variable" || true)
+    fi
+
+    if ! wp user get "$username" --field=ID > /dev/null 2>&1; then
+        echo "$username: not found" >> "$drift"
+        wp user create "$username" "$email" --role="$role" ${password:+--user_pass="$password"}
+    else
+        current=$(wp user get "$username" --field=user_email)
+        if [ "$current" != "$email" ]; then
+            echo "$username: email $current, expected $email" >> "$drift"
+            wp user update "$username" --user_email="$email" --skip-email
+        fi
+
+        current=$(wp user get "$username" --field=roles)
+        if [ "$current" != "$role" ]; then
+            echo "$username: role $current, expected $role" >> "$drift"
+            wp user set-role "$username" "$role"
+        fi
+
+        if [ -n "$password" ]; then
+            wp user update "$username" --user_pass="$password" --skip-email
+        fi
+    fi
+
+    if [ "$role" = "administrator" ] && [ -z "$reassign" ]; then
+        reassign=$(wp user get "$username" --field=ID)
+    fi
+done 3<<EOF
+$USERS
+EOF
+
+if [ "$DELETE_UNMANAGED_ADMINISTRATORS" = "true" ] && [ -n "$reassign" ]; then
+    wp user list --role=administrator --field=user_login > "$managed.admins"
+
+    while read -r login <&3; do
+        if ! grep -qxF "$login" "$managed"; then
+            echo "$login: unmanaged administrator, deleted" >> "$drift"
+            wp user delete "$login" --reassign="$reassign" --yes
+        fi
+    done 3< "$managed.admins"
+fi
+
+cat "$drift"
+tail -c 4096 "$drift" > /dev/termination-log
+`
+
+// ManagesUsers returns true if the site's users are managed by the operator.
+func (wp *Wordpress) ManagesUsers() bool {
+	return len(wp.Spec.Users) > 0
+}
+
+// UsersVersion identifies the users spec and the code version the users are
+// synced for.
+func (wp *Wordpress) UsersVersion() string {
+	users := make([]string, len(wp.Spec.Users))
+
+	for i, u := range wp.Spec.Users {
+		users[i] = fmt.Sprintf("%s|%s|%s", u.Username, u.Email, u.Role)
+		if u.PasswordSecretRef != nil {
+			users[i] += fmt.Sprintf("|%s/%s", u.PasswordSecretRef.Name, u.PasswordSecretRef.Key)
+		}
+	}
+
+	return hash(wp.CodeVersion(), strings.Join(users, "\n"), wp.Spec.DeleteUnmanagedAdministrators)
+}
+
+// usersEnv returns the list of the users, in the format read by the users
+// script, and the variables holding their passwords.
+func (wp *Wordpress) usersEnv() (string, []corev1.EnvVar) {
+	lines := make([]string, len(wp.Spec.Users))
+	env := []corev1.EnvVar{}
+
+	for i, u := range wp.Spec.Users {
+		role := u.Role
+		if role == "" {
+			role = defaultUserRole
+		}
+
+		variable := ""
+
+		if u.PasswordSecretRef != nil {
+			variable = fmt.Sprintf("WP_USER_PASSWORD_%d", i)
+			env = append(env, corev1.EnvVar{
+				Name:      variable,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: u.PasswordSecretRef},
+			})
+		}
+
+		lines[i] = fmt.Sprintf("%s|%s|%s|%s", u.Username, u.Email, role, variable)
+	}
+
+	return strings.Join(lines, "\n"), env
+}
+
+// UsersPodTemplateSpec generates the pod template spec of the job which
+// syncs the users with the spec.
+func (wp *Wordpress) UsersPodTemplateSpec() corev1.PodTemplateSpec {
+	list, env := wp.usersEnv()
+
+	out := wp.JobPodTemplateSpec("/bin/sh", "-c", usersScript)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env, env...)
+	out.Spec.Containers[0].Env = append(out.Spec.Containers[0].Env,
+		corev1.EnvVar{Name: "USERS", Value: list},
+		corev1.EnvVar{Name: "DELETE_UNMANAGED_ADMINISTRATORS", Value: fmt.Sprintf("%t", wp.Spec.DeleteUnmanagedAdministrators)},
+	)
+
+	return out
+}
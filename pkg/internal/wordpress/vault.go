@@ -0,0 +1,90 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	vaultAnnotationPrefix = "vault.hashicorp.com/"
+	vaultEnvFileName      = "wordpress.env"
+
+	// VaultEnvFile is the file the Vault Agent renders the site's secrets
+	// into, as export statements.
+	VaultEnvFile = "/vault/secrets/" + vaultEnvFileName
+)
+
+// vaultJobScript loads the secrets rendered by the Vault Agent before running
+// the wp-cli Jobs' command.
+const vaultJobScript = `. ` + VaultEnvFile + ` && exec "$@"`
+
+// UsesVault returns true if the site's pods get their secrets injected by the
+// Vault Agent Injector.
+func (wp *Wordpress) UsesVault() bool {
+	return wp.Spec.Vault != nil
+}
+
+// VaultEnvTemplate returns the template of the Vault Agent rendering the
+// site's secrets as export statements, with their values single quoted.
+func (wp *Wordpress) VaultEnvTemplate() string {
+	data := ".Data.data"
+	if wp.Spec.Vault.KVVersion == 1 {
+		data = ".Data"
+	}
+
+	lines := []string{}
+
+	for _, env := range wp.Spec.Vault.Env {
+		lines = append(lines, fmt.Sprintf(
+			`{{ with secret %q }}export %s='{{ index %s %q | replaceAll "'" "'\\''" }}'{{ end }}`,
+			env.Path, env.Name, data, env.Key))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// vaultAnnotations sets the annotations of the Vault Agent Injector. The
+// agent of the Jobs' pods only renders the secrets, without running a sidecar
+// which would keep them from completing.
+func (wp *Wordpress) vaultAnnotations(annotations map[string]string, job bool) map[string]string {
+	if !wp.UsesVault() {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	for k, v := range wp.Spec.Vault.Annotations {
+		annotations[k] = v
+	}
+
+	annotations[vaultAnnotationPrefix+"agent-inject"] = "true"
+	annotations[vaultAnnotationPrefix+"role"] = wp.Spec.Vault.Role
+	annotations[vaultAnnotationPrefix+"agent-inject-secret-"+vaultEnvFileName] = wp.Spec.Vault.Env[0].Path
+	annotations[vaultAnnotationPrefix+"agent-inject-template-"+vaultEnvFileName] = wp.VaultEnvTemplate()
+
+	if job {
+		annotations[vaultAnnotationPrefix+"agent-pre-populate-only"] = "true"
+	} else {
+		delete(annotations, vaultAnnotationPrefix+"agent-pre-populate-only")
+	}
+
+	return annotations
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	veleroPreHookPrefix  = "pre.hook.backup.velero.io/"
+	veleroPostHookPrefix = "post.hook.backup.velero.io/"
+	veleroBackupVolumes  = "backup.velero.io/backup-volumes"
+
+	veleroLockFile = "/tmp/velero-db-lock"
+
+	defaultVeleroLockTimeout = time.Hour
+)
+
+// veleroFlushScript flushes the object cache before the backup, so that the
+// restored site doesn't serve stale objects.
+const veleroFlushScript = `
+set -e
+
+wp cache flush
+`
+
+// veleroLockScript holds the global read lock in a background session, which
+// reports its connection id, so that the post-backup hook can kill it. The
+// hook returns once the lock is acquired.
+const veleroLockScript = `
+rm -f %[1]s
+nohup wp db query "SELECT CONNECTION_ID(); FLUSH TABLES WITH READ LOCK; SELECT 'locked'; DO SLEEP(%[2]d)" \
+	--skip-column-names --unbuffered > %[1]s 2>&1 &
+
+until grep -q '^locked$' %[1]s; do
+	if ! kill -0 $! 2>/dev/null; then
+		cat %[1]s
+		exit 1
+	fi
+	sleep 1
+done
+`
+
+// veleroUnlockScript kills the session holding the global read lock.
+const veleroUnlockScript = `
+set -e
+
+if [ -f %[1]s ]; then
+	wp db query "KILL $(head -n 1 %[1]s)" || true
+	rm -f %[1]s
+fi
+`
+
+// UsesVelero returns true if the web pods are annotated with the Velero
+// backup hooks.
+func (wp *Wordpress) UsesVelero() bool {
+	return wp.Spec.Velero != nil
+}
+
+func (wp *Wordpress) veleroLockTimeout() time.Duration {
+	if wp.Spec.Velero.LockTimeout != nil {
+		return wp.Spec.Velero.LockTimeout.Duration
+	}
+
+	return defaultVeleroLockTimeout
+}
+
+// veleroBackupVolumes returns the web pods' volumes which are backed up by
+// Velero's file system backup. Only the persistent volumes are backed up; the
+// media stored in a bucket isn't.
+func (wp *Wordpress) veleroBackupVolumes() []string {
+	volumes := []string{}
+
+	code := wp.Spec.CodeVolumeSpec
+	if code != nil && code.GitDir == nil && code.PersistentVolumeClaim != nil {
+		volumes = append(volumes, codeVolumeName)
+	}
+
+	media := wp.Spec.MediaVolumeSpec
+	if media != nil && !wp.hasMediaBucket() && media.PersistentVolumeClaim != nil {
+		volumes = append(volumes, mediaVolumeName)
+	}
+
+	return volumes
+}
+
+// veleroAnnotations sets the annotations of the Velero backup hooks, run in
+// the wordpress container of the web pods.
+func (wp *Wordpress) veleroAnnotations(annotations map[string]string) map[string]string {
+	if !wp.UsesVelero() {
+		return annotations
+	}
+
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	preScript := veleroFlushScript
+
+	if wp.Spec.Velero.LockDatabase {
+		preScript += fmt.Sprintf(veleroLockScript, veleroLockFile, int64(wp.veleroLockTimeout().Seconds()))
+
+		annotations[veleroPostHookPrefix+"container"] = "wordpress"
+		annotations[veleroPostHookPrefix+"command"] = veleroHookCommand(fmt.Sprintf(veleroUnlockScript, veleroLockFile))
+	}
+
+	annotations[veleroPreHookPrefix+"container"] = "wordpress"
+	annotations[veleroPreHookPrefix+"command"] = veleroHookCommand(preScript)
+
+	if volumes := wp.veleroBackupVolumes(); wp.Spec.Velero.BackupVolumes && len(volumes) > 0 {
+		annotations[veleroBackupVolumes] = strings.Join(volumes, ",")
+	}
+
+	return annotations
+}
+
+// veleroHookCommand returns the hook command annotation running the script,
+// which Velero parses as a JSON array.
+func veleroHookCommand(script string) string {
+	command, _ := json.Marshal([]string{"/bin/sh", "-c", script})
+
+	return string(command)
+}
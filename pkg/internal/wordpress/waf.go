@@ -0,0 +1,150 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	wordpressv1alpha1 "github.com/bitpoke/wordpress-operator/pkg/apis/wordpress/v1alpha1"
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const (
+	// WAFHTTPPort is the port the WAF sidecar listens on, in front of the runtime.
+	WAFHTTPPort = 8081
+
+	wafRulesVolumeName = "waf-rules"
+	wafRulesMountPath  = "/etc/modsecurity.d/owasp-crs/rules"
+
+	defaultWAFParanoiaLevel    = int32(1)
+	defaultWAFAnomalyThreshold = int32(5)
+)
+
+// UsesWAF returns true if the site's requests are proxied through the WAF
+// sidecar.
+func (wp *Wordpress) UsesWAF() bool {
+	return wp.Spec.WAF != nil
+}
+
+// HTTPPort returns the port of the web pods the site's requests are sent to,
+// the WAF's one if the site uses it.
+func (wp *Wordpress) HTTPPort() int {
+	if wp.UsesWAF() {
+		return WAFHTTPPort
+	}
+
+	return InternalHTTPPort
+}
+
+func (wp *Wordpress) wafContainers() []corev1.Container {
+	if !wp.UsesWAF() {
+		return nil
+	}
+
+	spec := wp.Spec.WAF
+
+	image := spec.Image
+	if image == "" {
+		image = options.WAFImage
+	}
+
+	ruleEngine := "On"
+	if spec.Mode == wordpressv1alpha1.WAFDetectionOnly {
+		ruleEngine = "DetectionOnly"
+	}
+
+	paranoiaLevel := defaultWAFParanoiaLevel
+	if spec.ParanoiaLevel > 0 {
+		paranoiaLevel = spec.ParanoiaLevel
+	}
+
+	anomalyThreshold := defaultWAFAnomalyThreshold
+	if spec.AnomalyThreshold > 0 {
+		anomalyThreshold = spec.AnomalyThreshold
+	}
+
+	container := corev1.Container{
+		Name:      "waf",
+		Image:     image,
+		Resources: spec.Resources,
+		Env: []corev1.EnvVar{
+			{
+				Name:  "PORT",
+				Value: strconv.Itoa(WAFHTTPPort),
+			},
+			{
+				Name:  "BACKEND",
+				Value: fmt.Sprintf("http://127.0.0.1:%d", InternalHTTPPort),
+			},
+			{
+				Name:  "MODSEC_RULE_ENGINE",
+				Value: ruleEngine,
+			},
+			{
+				Name:  "PARANOIA",
+				Value: strconv.Itoa(int(paranoiaLevel)),
+			},
+			{
+				Name:  "ANOMALY_INBOUND",
+				Value: strconv.Itoa(int(anomalyThreshold)),
+			},
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          "waf",
+				ContainerPort: WAFHTTPPort,
+			},
+		},
+	}
+
+	// the rules are mounted one by one, along the Core Rule Set's
+	if spec.Rules != nil {
+		for _, file := range spec.Rules.Files {
+			container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+				Name:      wafRulesVolumeName,
+				MountPath: path.Join(wafRulesMountPath, path.Base(file)),
+				SubPath:   file,
+				ReadOnly:  true,
+			})
+		}
+	}
+
+	return []corev1.Container{container}
+}
+
+func (wp *Wordpress) wafVolumes() []corev1.Volume {
+	if !wp.UsesWAF() || wp.Spec.WAF.Rules == nil {
+		return nil
+	}
+
+	return []corev1.Volume{
+		{
+			Name: wafRulesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: wp.Spec.WAF.Rules.ConfigMapName,
+					},
+				},
+			},
+		},
+	}
+}
@@ -18,7 +18,9 @@ package wordpress
 
 import (
 	"fmt"
+	"hash/fnv"
 	"path"
+	"strings"
 
 	"github.com/cooleo/slugify"
 	"k8s.io/apimachinery/pkg/labels"
@@ -29,12 +31,20 @@ import (
 // Wordpress embeds wordpressv1alpha1.Wordpress and adds utility functions.
 type Wordpress struct {
 	*wordpressv1alpha1.Wordpress
+
+	// imageVerificationKeyVersion is the resource version of the secret
+	// holding the public key the images' signatures are verified with
+	imageVerificationKeyVersion string
 }
 
 type component struct {
 	name       string // eg. web, database, cache
 	objNameFmt string
 	objName    string
+	// the Jobs run once per version of their inputs are named after the
+	// object name and the version, eg. %s-for-%s
+	jobNameFmt string
+	version    func(*Wordpress) string
 }
 
 var (
@@ -45,20 +55,143 @@ var (
 	// WordpressCron component.
 	WordpressCron = component{name: "cron", objNameFmt: "%s-wp-cron"}
 	// WordpressDBUpgrade component.
-	WordpressDBUpgrade = component{name: "upgrade", objNameFmt: "%s-upgrade"}
+	WordpressDBUpgrade = component{name: "upgrade", objNameFmt: "%s-upgrade",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).codeVersionHash}
 	// WordpressService component.
 	WordpressService = component{name: "web", objNameFmt: "%s"}
 	// WordpressIngress component.
 	WordpressIngress = component{name: "web", objNameFmt: "%s"}
+	// WordpressStatefulSet component.
+	WordpressStatefulSet = component{name: "web", objNameFmt: "%s"}
+	// WordpressHeadlessService component.
+	WordpressHeadlessService = component{name: "web", objNameFmt: "%s-headless"}
+	// WordpressCertificate component.
+	WordpressCertificate = component{name: "web", objNameFmt: "%s-tls"}
+	// WordpressExternalSecret component.
+	WordpressExternalSecret = component{name: "web", objNameFmt: "%s-external-secret"}
+	// WordpressServiceAccount component.
+	WordpressServiceAccount = component{name: "web", objNameFmt: "%s"}
+	// WordpressAdminIngress component.
+	WordpressAdminIngress = component{name: "web", objNameFmt: "%s-admin"}
+	// WordpressLoginIngress component.
+	WordpressLoginIngress = component{name: "web", objNameFmt: "%s-login"}
+	// WordpressAliasesIngress component.
+	WordpressAliasesIngress = component{name: "web", objNameFmt: "%s-aliases"}
+	// WordpressDNSEndpoint component.
+	WordpressDNSEndpoint = component{name: "web", objNameFmt: "%s"}
+	// WordpressProbe component.
+	WordpressProbe = component{name: "web", objNameFmt: "%s"}
+	// WordpressVirtualService component.
+	WordpressVirtualService = component{name: "web", objNameFmt: "%s"}
+	// WordpressDestinationRule component.
+	WordpressDestinationRule = component{name: "web", objNameFmt: "%s"}
+	// WordpressDBBootstrap component.
+	WordpressDBBootstrap = component{name: "db-bootstrap", objNameFmt: "%s-db-bootstrap"}
+	// WordpressDBUsage component.
+	WordpressDBUsage = component{name: "db-usage", objNameFmt: "%s-db-usage"}
+	// WordpressMemcachedDeployment component.
+	WordpressMemcachedDeployment = component{name: "memcached", objNameFmt: "%s-memcached"}
+	// WordpressMemcachedService component.
+	WordpressMemcachedService = component{name: "memcached", objNameFmt: "%s-memcached"}
+	// WordpressPageCacheDeployment component.
+	WordpressPageCacheDeployment = component{name: "page-cache", objNameFmt: "%s-page-cache"}
+	// WordpressPageCacheService component.
+	WordpressPageCacheService = component{name: "page-cache", objNameFmt: "%s-page-cache"}
+	// WordpressMysqlCluster component.
+	WordpressMysqlCluster = component{name: "db", objNameFmt: "%s"}
+	// WordpressMysqlSecret component.
+	WordpressMysqlSecret = component{name: "db", objNameFmt: "%s-db"}
+	// WordpressMysqlDatabase component.
+	WordpressMysqlDatabase = component{name: "db", objNameFmt: "%s"}
+	// WordpressMysqlUser component.
+	WordpressMysqlUser = component{name: "db", objNameFmt: "%s"}
+	// WordpressImageOptimization component.
+	WordpressImageOptimization = component{name: "image-optimization", objNameFmt: "%s-image-optimization"}
+	// WordpressImageVerification component.
+	WordpressImageVerification = component{name: "image-verification", objNameFmt: "%s-image-verification",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).ImageVerificationVersion}
+	// WordpressStaticAssets component.
+	WordpressStaticAssets = component{name: "static-assets", objNameFmt: "%s-static-assets",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).codeVersionHash}
+	// WordpressCacheWarmup component.
+	WordpressCacheWarmup = component{name: "cache-warmup", objNameFmt: "%s-cache-warmup"}
+	// WordpressCacheFlush component.
+	WordpressCacheFlush = component{name: "cache-flush", objNameFmt: "%s-cache-flush",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).codeVersionHash}
+	// WordpressCDNPurge component.
+	WordpressCDNPurge = component{name: "cdn-purge", objNameFmt: "%s-cdn-purge",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).codeVersionHash}
+	// WordpressSearchReplace component.
+	WordpressSearchReplace = component{name: "search-replace", objNameFmt: "%s-search-replace",
+		jobNameFmt: "%s-%s", version: (*Wordpress).homeURLChangeHash}
 	// WordpressCodePVC component.
 	WordpressCodePVC = component{name: "code", objNameFmt: "%s-code"}
 	// WordpressMediaPVC component.
 	WordpressMediaPVC = component{name: "media", objNameFmt: "%s-media"}
+	// WordpressPDB component.
+	WordpressPDB = component{name: "web", objNameFmt: "%s"}
+	// WordpressHPA component.
+	WordpressHPA = component{name: "web", objNameFmt: "%s"}
+	// WordpressScaledObject component.
+	WordpressScaledObject = component{name: "web", objNameFmt: "%s"}
+	// WordpressHTTPScaledObject component.
+	WordpressHTTPScaledObject = component{name: "web", objNameFmt: "%s"}
+	// WordpressInterceptorService component.
+	WordpressInterceptorService = component{name: "web", objNameFmt: "%s-interceptor"}
+	// WordpressCanaryDeployment component.
+	WordpressCanaryDeployment = component{name: "web-canary", objNameFmt: "%s-canary"}
+	// WordpressCanaryService component.
+	WordpressCanaryService = component{name: "web-canary", objNameFmt: "%s-canary"}
+	// WordpressCanaryIngress component.
+	WordpressCanaryIngress = component{name: "web-canary", objNameFmt: "%s-canary"}
+	// WordpressCanarySmokeTest component.
+	WordpressCanarySmokeTest = component{name: "smoke-test", objNameFmt: "%s-smoke-test",
+		jobNameFmt: "%s-%s", version: (*Wordpress).CanaryRevision}
+	// WordpressWebServerConfig component.
+	WordpressWebServerConfig = component{name: "web", objNameFmt: "%s-web-server-config"}
+	// WordpressPlugins component.
+	WordpressPlugins = component{name: "plugins", objNameFmt: "%s-plugins",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).PluginsVersion}
+	// WordpressThemes component.
+	WordpressThemes = component{name: "themes", objNameFmt: "%s-themes",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).ThemesVersion}
+	// WordpressOptions component.
+	WordpressOptions = component{name: "options", objNameFmt: "%s-options",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).OptionsVersion}
+	// WordpressUsers component.
+	WordpressUsers = component{name: "users", objNameFmt: "%s-users",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).UsersVersion}
+	// WordpressSMTPTest component.
+	WordpressSMTPTest = component{name: "smtp-test", objNameFmt: "%s-smtp-test",
+		jobNameFmt: "%s-for-%s", version: (*Wordpress).SMTPVersion}
+	// WordpressCoreUpdateCheck component.
+	WordpressCoreUpdateCheck = component{name: "core-update-check", objNameFmt: "%s-core-update-check"}
+	// WordpressCoreUpdate component.
+	WordpressCoreUpdate = component{name: "core-update", objNameFmt: "%s-core-update",
+		jobNameFmt: "%s-to-%s", version: (*Wordpress).availableCoreVersionHash}
+	// WordpressSunrise component.
+	WordpressSunrise = component{name: "web", objNameFmt: "%s-sunrise"}
+	// WordpressCleanup component.
+	WordpressCleanup = component{name: "cleanup", objNameFmt: "%s-cleanup"}
+	// WordpressDiagnostics component.
+	WordpressDiagnostics = component{name: "diagnostics", objNameFmt: "%s-diagnostics"}
+	// WordpressIntegrityCheck component.
+	WordpressIntegrityCheck = component{name: "integrity-check", objNameFmt: "%s-integrity-check"}
+	// WordpressInventory component.
+	WordpressInventory = component{name: "inventory", objNameFmt: "%s-inventory"}
+	// WordpressBackups component.
+	WordpressBackups = component{name: "backups", objNameFmt: "%s-backups"}
+	// WordpressUpgradeBackups component.
+	WordpressUpgradeBackups = component{name: "upgrade-backups", objNameFmt: "%s-upgrade-backup"}
+	// WordpressBackupVerification component.
+	WordpressBackupVerification = component{name: "backup-verification", objNameFmt: "%s-backup-verification"}
+	// WordpressRecoveryBundle component.
+	WordpressRecoveryBundle = component{name: "recovery-bundle", objNameFmt: "%s-recovery-bundle"}
 )
 
 // New wraps a wordpressv1alpha1.Wordpress into a Wordpress object.
 func New(obj *wordpressv1alpha1.Wordpress) *Wordpress {
-	return &Wordpress{obj}
+	return &Wordpress{Wordpress: obj}
 }
 
 // Unwrap returns the wrapped wordpressv1alpha1.Wordpress object.
@@ -87,10 +220,6 @@ func (wp *Wordpress) ComponentLabels(component component) labels.Set {
 	l := wp.Labels()
 	l["app.kubernetes.io/component"] = component.name
 
-	if component == WordpressDBUpgrade {
-		l["wordpress.presslabs.org/upgrade-for"] = wp.ImageVersion()
-	}
-
 	return l
 }
 
@@ -101,11 +230,29 @@ func (wp *Wordpress) ComponentName(component component) string {
 		name = fmt.Sprintf(component.objNameFmt, wp.ObjectMeta.Name)
 	}
 
-	if component == WordpressDBUpgrade {
-		name = fmt.Sprintf("%s-for-%s", name, wp.ImageVersion())
+	return name
+}
+
+// JobName returns the name of a component's Job, which is run once per
+// version of its inputs, eg. once per code version for the database upgrade.
+func (wp *Wordpress) JobName(component component) string {
+	if component.version == nil {
+		return wp.ComponentName(component)
 	}
 
-	return name
+	return fmt.Sprintf(component.jobNameFmt, wp.ComponentName(component), component.version(wp))
+}
+
+func (wp *Wordpress) codeVersionHash() string {
+	return hash(wp.CodeVersion())
+}
+
+func (wp *Wordpress) availableCoreVersionHash() string {
+	return hash(wp.AvailableCoreVersion())
+}
+
+func (wp *Wordpress) homeURLChangeHash() string {
+	return hash(wp.Status.HomeURL, wp.HomeURL())
 }
 
 // ImageVersion returns the version from the image in a format suitable
@@ -114,6 +261,16 @@ func (wp *Wordpress) ImageVersion() string {
 	return slugify.Slugify(wp.Spec.Image)
 }
 
+// CodeVersion returns the site's image and, if the code is cloned from git,
+// its git reference.
+func (wp *Wordpress) CodeVersion() string {
+	if wp.Spec.CodeVolumeSpec != nil && wp.Spec.CodeVolumeSpec.GitDir != nil {
+		return fmt.Sprintf("%s@%s", wp.Spec.Image, wp.Spec.CodeVolumeSpec.GitDir.GitRef)
+	}
+
+	return wp.Spec.Image
+}
+
 // WebPodLabels return labels to apply to web pods.
 func (wp *Wordpress) WebPodLabels() labels.Set {
 	l := wp.Labels()
@@ -122,6 +279,14 @@ func (wp *Wordpress) WebPodLabels() labels.Set {
 	return l
 }
 
+// CanaryPodLabels return labels to apply to canary web pods.
+func (wp *Wordpress) CanaryPodLabels() labels.Set {
+	l := wp.Labels()
+	l["app.kubernetes.io/component"] = "web-canary"
+
+	return l
+}
+
 // JobPodLabels return labels to apply to cli job pods.
 func (wp *Wordpress) JobPodLabels() labels.Set {
 	l := wp.Labels()
@@ -130,6 +295,269 @@ func (wp *Wordpress) JobPodLabels() labels.Set {
 	return l
 }
 
+// MinReplicas returns the minimum number of web pods the site is expected to run.
+func (wp *Wordpress) MinReplicas() int32 {
+	if wp.Spec.ScaleToZero != nil {
+		return 0
+	}
+
+	if wp.Spec.Autoscaling != nil {
+		if wp.Spec.Autoscaling.MinReplicas != nil {
+			return *wp.Spec.Autoscaling.MinReplicas
+		}
+
+		return 1
+	}
+
+	if wp.Spec.Replicas != nil {
+		return *wp.Spec.Replicas
+	}
+
+	return 1
+}
+
+// IsAutoscaled returns true if the number of web pods is managed by an autoscaler.
+func (wp *Wordpress) IsAutoscaled() bool {
+	return wp.Spec.Autoscaling != nil || wp.Spec.ScaleToZero != nil
+}
+
+// Canary returns a copy of the site running the canary image and code.
+func (wp *Wordpress) Canary() *Wordpress {
+	canary := New(wp.Unwrap().DeepCopy())
+	if canary.Spec.Canary == nil {
+		return canary
+	}
+
+	if len(canary.Spec.Canary.Image) > 0 {
+		canary.Spec.Image = canary.Spec.Canary.Image
+	}
+
+	if len(canary.Spec.Canary.GitRef) > 0 && canary.Spec.CodeVolumeSpec != nil && canary.Spec.CodeVolumeSpec.GitDir != nil {
+		canary.Spec.CodeVolumeSpec.GitDir.GitRef = canary.Spec.Canary.GitRef
+	}
+
+	// the preset would spread the canary pods away from the stable ones
+	canary.Spec.PodAntiAffinityPreset = wordpressv1alpha1.PodAntiAffinityPresetNone
+
+	return canary
+}
+
+// CanaryRevision returns a short hash of the canary image, code and smoke test.
+func (wp *Wordpress) CanaryRevision() string {
+	if wp.Spec.Canary == nil {
+		return ""
+	}
+
+	canary := wp.Canary()
+
+	values := []interface{}{canary.Spec.Image}
+
+	if canary.Spec.CodeVolumeSpec != nil && canary.Spec.CodeVolumeSpec.GitDir != nil {
+		values = append(values, canary.Spec.CodeVolumeSpec.GitDir.GitRef)
+	}
+
+	if smokeTest := wp.Spec.Canary.SmokeTest; smokeTest != nil {
+		values = append(values, smokeTest.Image, smokeTest.Command, smokeTest.Args)
+	}
+
+	return hash(values...)
+}
+
+// hash returns a short hash of the given values, suitable for object names.
+func hash(values ...interface{}) string {
+	h := fnv.New32a()
+	for _, v := range values {
+		fmt.Fprintln(h, v)
+	}
+
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// SaltsGeneration returns the generation of the site's authentication keys
+// and salts requested through spec.rotateSalts. The initial salts have the
+// empty generation.
+func (wp *Wordpress) SaltsGeneration() string {
+	if wp.Spec.RotateSalts == "" {
+		return ""
+	}
+
+	return hash(wp.Spec.RotateSalts)
+}
+
+// CreatesServiceAccount returns true if the operator provisions a dedicated
+// ServiceAccount for the site's pods.
+func (wp *Wordpress) CreatesServiceAccount() bool {
+	return wp.Spec.ServiceAccount != nil && wp.Spec.ServiceAccount.Create && wp.Spec.ServiceAccountName == ""
+}
+
+// ServiceAccountName returns the name of the ServiceAccount running the
+// site's pods, or an empty string for the namespace's default one.
+func (wp *Wordpress) ServiceAccountName() string {
+	if wp.CreatesServiceAccount() {
+		return wp.ComponentName(WordpressServiceAccount)
+	}
+
+	return wp.Spec.ServiceAccountName
+}
+
+// IsStatefulSet returns true if the web pods are run by a StatefulSet.
+func (wp *Wordpress) IsStatefulSet() bool {
+	return wp.Spec.WorkloadType == wordpressv1alpha1.WorkloadTypeStatefulSet
+}
+
+// HasHeadlessService returns true if a headless service selects the web pods.
+func (wp *Wordpress) HasHeadlessService() bool {
+	return wp.IsStatefulSet() || (wp.Spec.Service != nil && wp.Spec.Service.Headless)
+}
+
+// RuntimeProxyProtocol returns true if the runtime accepts the PROXY protocol.
+func (wp *Wordpress) RuntimeProxyProtocol() bool {
+	return wp.Spec.ProxyProtocol != nil && wp.Spec.ProxyProtocol.Runtime
+}
+
+// IngressProxyProtocol returns true if the ingress uses the client address
+// from the PROXY protocol header.
+func (wp *Wordpress) IngressProxyProtocol() bool {
+	return wp.Spec.ProxyProtocol != nil && wp.Spec.ProxyProtocol.Ingress
+}
+
+// ProvisionsDatabase returns true if the operator provisions the site's database.
+func (wp *Wordpress) ProvisionsDatabase() bool {
+	return wp.Spec.Database != nil && wp.Spec.Database.Provision && !wp.UsesPostgres()
+}
+
+// HasExternalDatabase returns true if the site uses an external database.
+func (wp *Wordpress) HasExternalDatabase() bool {
+	return wp.Spec.Database != nil && !wp.Spec.Database.Provision && (wp.Spec.Database.Host != "" || wp.Spec.Database.CloudSQL != nil)
+}
+
+// DatabaseHost returns the address of the external database or the host of
+// the provisioned MysqlCluster's master node.
+func (wp *Wordpress) DatabaseHost() string {
+	if wp.HasExternalDatabase() {
+		return wp.DatabaseAddress()
+	}
+
+	return fmt.Sprintf("%s-mysql-master.%s", wp.ComponentName(WordpressMysqlCluster), wp.Namespace)
+}
+
+// TLSSecretName returns the name of the secret holding the site's TLS
+// certificate or an empty string if TLS is not configured. The secret of the
+// cert-manager Certificate is used once the certificate is issued.
+func (wp *Wordpress) TLSSecretName() string {
+	if wp.Spec.TLS != nil && wp.Spec.TLS.IssuerRef != nil && wp.IsConditionTrue(wordpressv1alpha1.CertificateReadyCondition) {
+		return wp.ComponentName(WordpressCertificate)
+	}
+
+	return string(wp.Spec.TLSSecretRef)
+}
+
+// DomainTLSSecretName returns the name of the secret holding the TLS
+// certificate of a domain, either its own or the site's one, or an empty
+// string if the domain is not served over TLS.
+func (wp *Wordpress) DomainTLSSecretName(domain string) string {
+	if secretName, ok := wp.domainTLSSecrets()[domain]; ok {
+		return secretName
+	}
+
+	return wp.TLSSecretName()
+}
+
+// CertificateDomains returns the domains covered by the site's certificate,
+// which are the ones not having their own TLS secret.
+func (wp *Wordpress) CertificateDomains() []string {
+	secrets := wp.domainTLSSecrets()
+	domains := []string{}
+
+	for _, domain := range wp.Domains() {
+		if _, ok := secrets[domain]; !ok {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains
+}
+
+func (wp *Wordpress) domainTLSSecrets() map[string]string {
+	secrets := map[string]string{}
+
+	if wp.Spec.TLS == nil {
+		return secrets
+	}
+
+	for _, s := range wp.Spec.TLS.Secrets {
+		for _, domain := range s.Domains {
+			secrets[domain] = s.SecretName
+		}
+	}
+
+	return secrets
+}
+
+// RedirectToHTTPS returns true if plain HTTP requests should be redirected to HTTPS.
+func (wp *Wordpress) RedirectToHTTPS() bool {
+	if wp.Scheme() != "https" {
+		return false
+	}
+
+	return wp.Spec.TLS == nil || wp.Spec.TLS.Redirect == nil || *wp.Spec.TLS.Redirect
+}
+
+// HSTSHeader returns the value of the Strict-Transport-Security header or an
+// empty string if HSTS is not configured or the site is not served over HTTPS.
+func (wp *Wordpress) HSTSHeader() string {
+	if wp.Scheme() != "https" || wp.Spec.TLS == nil || wp.Spec.TLS.HSTS == nil {
+		return ""
+	}
+
+	hsts := wp.Spec.TLS.HSTS
+
+	maxAge := defaultHSTSMaxAge
+	if hsts.MaxAge != nil {
+		maxAge = *hsts.MaxAge
+	}
+
+	header := fmt.Sprintf("max-age=%d", maxAge)
+
+	if hsts.IncludeSubdomains {
+		header += "; includeSubDomains"
+	}
+
+	if hsts.Preload {
+		header += "; preload"
+	}
+
+	return header
+}
+
+// Domains returns the unique domains of the site's routes and aliases.
+func (wp *Wordpress) Domains() []string {
+	domains := []string{}
+	seen := map[string]bool{}
+
+	for _, route := range wp.Routes() {
+		if !seen[route.Domain] {
+			seen[route.Domain] = true
+			domains = append(domains, route.Domain)
+		}
+	}
+
+	for _, alias := range wp.Spec.Aliases {
+		if !seen[alias] {
+			seen[alias] = true
+			domains = append(domains, alias)
+		}
+	}
+
+	return domains
+}
+
+// Routes returns the site's routes, followed by the ones of the network's
+// sub-sites.
+func (wp *Wordpress) Routes() []wordpressv1alpha1.RouteSpec {
+	return append(append([]wordpressv1alpha1.RouteSpec{}, wp.Spec.Routes...), wp.multisiteRoutes()...)
+}
+
 // MainDomain returns the site main domain or a local domain <cluster-name>.<namespace>.svc.cluster.local.
 func (wp *Wordpress) MainDomain() string {
 	if len(wp.Spec.Routes) > 0 {
@@ -140,26 +568,65 @@ func (wp *Wordpress) MainDomain() string {
 	return fmt.Sprintf("%s.%s.svc", wp.ComponentName(WordpressService), wp.Namespace)
 }
 
-// HomeURL returns the WP_HOMEURL (e.g. http://example.com/)
-func (wp *Wordpress) HomeURL(subPaths ...string) string {
-	scheme := "http"
-	if len(wp.Spec.TLSSecretRef) > 0 {
-		scheme = "https"
+// Scheme returns the site's URL scheme, https if its main domain is served over TLS.
+func (wp *Wordpress) Scheme() string {
+	if len(wp.DomainTLSSecretName(wp.MainDomain())) > 0 {
+		return "https"
 	}
 
-	paths := []string{"/"}
+	return "http"
+}
+
+// RoutePath returns the path of a route, defaulting to the site's path.
+func (wp *Wordpress) RoutePath(route wordpressv1alpha1.RouteSpec) string {
+	if len(route.Path) > 0 {
+		return route.Path
+	}
+
+	if len(wp.Spec.Path) > 0 {
+		return wp.Spec.Path
+	}
+
+	return "/"
+}
+
+// mainPath returns the absolute path of the site's main route.
+func (wp *Wordpress) mainPath() string {
+	route := wordpressv1alpha1.RouteSpec{}
 	if len(wp.Spec.Routes) > 0 {
-		paths = append(paths, wp.Spec.Routes[0].Path)
+		route = wp.Spec.Routes[0]
 	}
 
-	paths = append(paths, subPaths...)
+	return path.Join("/", wp.RoutePath(route))
+}
+
+// HomeURL returns the WP_HOMEURL (e.g. http://example.com/)
+func (wp *Wordpress) HomeURL(subPaths ...string) string {
+	paths := append([]string{wp.mainPath()}, subPaths...)
 
 	p := path.Join(paths...)
 	if p == "/" {
 		p = ""
 	}
 
-	return fmt.Sprintf("%s://%s%s", scheme, wp.MainDomain(), p)
+	return fmt.Sprintf("%s://%s%s", wp.Scheme(), wp.MainDomain(), p)
+}
+
+// SearchReplacePending returns true if the site's main domain changed and the
+// content is not search-replaced yet to refer to the new home URL.
+func (wp *Wordpress) SearchReplacePending() bool {
+	return wp.Spec.SearchReplaceOnDomainChange && wp.Status.HomeURL != "" && wp.Status.HomeURL != wp.HomeURL()
+}
+
+// rolledOutURLs returns the home and the site URLs the pods are configured
+// with. While a search-replace is pending, they keep the home URL the content
+// refers to, so that the web pods are rolled out once it completes.
+func (wp *Wordpress) rolledOutURLs() (string, string) {
+	if !wp.SearchReplacePending() {
+		return wp.HomeURL(), wp.SiteURL()
+	}
+
+	return wp.Status.HomeURL, wp.Status.HomeURL + strings.TrimPrefix(wp.SiteURL(), wp.HomeURL())
 }
 
 // SiteURL returns the WP_SITEURL (e.g. http://example.com/wp)
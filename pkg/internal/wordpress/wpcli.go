@@ -0,0 +1,43 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package wordpress
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// WPCliContainerName is the name of the wp-cli container of the Jobs.
+const WPCliContainerName = "wp-cli"
+
+// the tail of the output is reported through the termination message, which
+// is limited to 4KiB
+const wpCliCommandScript = `
+out=$(mktemp)
+wp "$@" > "$out" 2>&1
+code=$?
+cat "$out"
+tail -c 4096 "$out" > /dev/termination-log
+exit $code
+`
+
+// WPCliCommandPodTemplateSpec generates the pod template spec of the job
+// which runs the given wp-cli command.
+func (wp *Wordpress) WPCliCommandPodTemplateSpec(args []string) corev1.PodTemplateSpec {
+	cmd := append([]string{"/bin/sh", "-c", wpCliCommandScript, "--"}, args...)
+
+	return wp.JobPodTemplateSpec(cmd...)
+}
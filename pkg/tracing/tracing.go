@@ -0,0 +1,175 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing exports the spans of the reconciles, of the child resources'
+// syncs and of the Jobs' launches to an OTLP collector.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/presslabs/controller-util/syncer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+const serviceName = "wordpress-operator"
+
+var errInvalidSampleRatio = errors.New("the tracing sample ratio must be between 0 and 1")
+
+// tracer creates the operator's spans. It's a no-op until Setup registers the
+// OTLP exporter.
+var tracer = otel.Tracer("github.com/bitpoke/wordpress-operator")
+
+// Setup registers the tracer provider exporting the spans to the OTLP
+// collector set by --tracing-otlp-endpoint, or does nothing without it. The
+// returned func flushes the pending spans and should be called on shutdown.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	if options.TracingSampleRatio < 0 || options.TracingSampleRatio > 1 {
+		return nil, fmt.Errorf("%w, got %v", errInvalidSampleRatio, options.TracingSampleRatio)
+	}
+
+	if options.TracingOTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(options.TracingOTLPEndpoint)}
+	if options.TracingOTLPInsecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// StartReconcile starts the span of an object's reconcile.
+func StartReconcile(ctx context.Context, kind string, key types.NamespacedName) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "Reconcile "+kind, trace.WithAttributes(
+		semconv.K8SNamespaceNameKey.String(key.Namespace),
+		attribute.String("k8s.object.kind", kind),
+		attribute.String("k8s.object.name", key.Name),
+	))
+}
+
+// End ends the span, marking it as failed if err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// Sync runs the syncer in a span of the child resource's sync, which records
+// the launch of the Jobs it creates.
+func Sync(ctx context.Context, s syncer.Interface, recorder record.EventRecorder) error {
+	kind := objectKind(s.Object())
+
+	ctx, span := tracer.Start(ctx, "Sync "+kind, trace.WithAttributes(attribute.String("k8s.object.kind", kind)))
+
+	err := syncer.Sync(ctx, &tracedSyncer{Interface: s, span: span}, recorder)
+
+	End(span, err)
+
+	return err
+}
+
+// tracedSyncer records the outcome of a syncer on its span.
+type tracedSyncer struct {
+	syncer.Interface
+	span trace.Span
+}
+
+// Sync implements syncer.Interface.
+func (s *tracedSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
+	result, err := s.Interface.Sync(ctx)
+
+	s.span.SetAttributes(attribute.String("wordpress.sync.operation", string(result.Operation)))
+
+	obj, ok := s.Object().(client.Object)
+	if !ok {
+		return result, err
+	}
+
+	s.span.SetAttributes(
+		semconv.K8SNamespaceNameKey.String(obj.GetNamespace()),
+		attribute.String("k8s.object.name", obj.GetName()),
+	)
+
+	if job, ok := obj.(*batchv1.Job); ok && result.Operation == controllerutil.OperationResultCreated {
+		s.span.AddEvent("Job launched", trace.WithAttributes(
+			semconv.K8SJobNameKey.String(job.Name),
+			attribute.String("wordpress.component", job.Labels["app.kubernetes.io/component"]),
+		))
+	}
+
+	return result, err
+}
+
+// objectKind returns the kind of a syncer's object, from its GVK if it's set,
+// as for the unstructured objects, or from its type otherwise.
+func objectKind(obj interface{}) string {
+	if o, ok := obj.(client.Object); ok {
+		if kind := o.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+			return kind
+		}
+	}
+
+	t := reflect.TypeOf(obj)
+	if t == nil {
+		return "Object"
+	}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
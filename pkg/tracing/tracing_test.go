@@ -0,0 +1,71 @@
+/*
+Copyright 2018 Pressinfra SRL.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"go.opentelemetry.io/otel"
+
+	"github.com/bitpoke/wordpress-operator/pkg/cmd/options"
+)
+
+var _ = Describe("The tracing setup", func() {
+	var (
+		endpoint string
+		ratio    float64
+	)
+
+	BeforeEach(func() {
+		endpoint = options.TracingOTLPEndpoint
+		ratio = options.TracingSampleRatio
+	})
+
+	AfterEach(func() {
+		options.TracingOTLPEndpoint = endpoint
+		options.TracingSampleRatio = ratio
+	})
+
+	It("should do nothing without an OTLP endpoint", func() {
+		options.TracingOTLPEndpoint = ""
+		provider := otel.GetTracerProvider()
+
+		shutdown, err := Setup(context.TODO())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(otel.GetTracerProvider()).To(BeIdenticalTo(provider))
+		Expect(shutdown(context.TODO())).To(Succeed())
+	})
+
+	DescribeTable("should validate the sample ratio", func(sampleRatio float64, valid bool) {
+		options.TracingOTLPEndpoint = ""
+		options.TracingSampleRatio = sampleRatio
+
+		_, err := Setup(context.TODO())
+		Expect(errors.Is(err, errInvalidSampleRatio)).To(Equal(!valid))
+	},
+		Entry("none", 0.0, true),
+		Entry("some", 0.25, true),
+		Entry("all", 1.0, true),
+		Entry("negative", -0.1, false),
+		Entry("over 1", 1.5, false),
+	)
+})